@@ -0,0 +1,115 @@
+// apierror.go - JSON error responses
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Stable error codes clients can switch on.
+const (
+	CodeInvalidBody         = "invalid_body"
+	CodeUnsupportedFormat   = "unsupported_format"
+	CodeUnauthorized        = "unauthorized"
+	CodeInvalidSignature    = "invalid_signature"
+	CodeOriginNotAllowed    = "origin_not_allowed"
+	CodeWorkflowMismatch    = "workflow_mismatch"
+	CodeWorkflowNotFound    = "workflow_not_found"
+	CodeExecutionNotFound   = "execution_not_found"
+	CodeNodeNotFound        = "node_not_found"
+	CodeVersionNotFound     = "version_not_found"
+	CodeApprovalNotFound    = "approval_not_found"
+	CodeHookNotFound        = "hook_not_found"
+	CodeSecretNotFound      = "secret_not_found"
+	CodeTemplateNotFound    = "template_not_found"
+	CodeVersionConflict     = "version_conflict"
+	CodeWorkflowExists      = "workflow_exists"
+	CodeExecutionNotRunning = "execution_not_running"
+	CodeNotResumable        = "execution_not_resumable"
+	CodeWorkflowBusy        = "workflow_busy"
+	CodeQueueFull           = "queue_full"
+	CodeValidationFailed    = "validation_failed"
+	CodeUnmappableNodes     = "unmappable_nodes"
+	CodeMissingDependencies = "missing_dependencies"
+	CodeMaskedValues        = "masked_values"
+	CodeShuttingDown        = "shutting_down"
+	CodeInternal            = "internal_error"
+)
+
+// ============================================
+// Error Envelope
+// ============================================
+
+// APIError is the body of every API error response:
+// {"error": {"code": "...", "message": "..."}}.
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// writeError writes a JSON error envelope with status.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIError(w, status, APIError{Code: code, Message: message})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, apiErr APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]APIError{"error": apiErr})
+}
+
+// writeEngineError reports an engine error with the status and code its
+// kind maps to; unrecognised errors are internal.
+func writeEngineError(w http.ResponseWriter, err error) {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		writeAPIError(w, http.StatusBadRequest, APIError{
+			Code:    CodeValidationFailed,
+			Message: err.Error(),
+			Details: map[string]interface{}{"fields": invalid.Fields},
+		})
+		return
+	}
+	status, code := errorCode(err)
+	writeError(w, status, code, err.Error())
+}
+
+// errorCode maps engine errors to HTTP status codes and error codes.
+func errorCode(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrWorkflowNotFound):
+		return http.StatusNotFound, CodeWorkflowNotFound
+	case errors.Is(err, ErrExecutionNotFound):
+		return http.StatusNotFound, CodeExecutionNotFound
+	case errors.Is(err, ErrApprovalNotFound):
+		return http.StatusNotFound, CodeApprovalNotFound
+	case errors.Is(err, ErrSecretNotFound):
+		return http.StatusNotFound, CodeSecretNotFound
+	case errors.Is(err, ErrNodeNotFound):
+		return http.StatusNotFound, CodeNodeNotFound
+	case errors.Is(err, ErrVersionNotFound):
+		return http.StatusNotFound, CodeVersionNotFound
+	case errors.Is(err, ErrTemplateNotFound):
+		return http.StatusNotFound, CodeTemplateNotFound
+	case errors.Is(err, ErrInvalidSignature):
+		return http.StatusUnauthorized, CodeInvalidSignature
+	case errors.Is(err, ErrWorkflowExists):
+		return http.StatusConflict, CodeWorkflowExists
+	case errors.Is(err, ErrVersionConflict):
+		return http.StatusConflict, CodeVersionConflict
+	case errors.Is(err, ErrExecutionNotRunning):
+		return http.StatusConflict, CodeExecutionNotRunning
+	case errors.Is(err, ErrExecutionNotResumable):
+		return http.StatusConflict, CodeNotResumable
+	case errors.Is(err, ErrWorkflowBusy):
+		return http.StatusConflict, CodeWorkflowBusy
+	case errors.Is(err, ErrQueueFull):
+		return http.StatusTooManyRequests, CodeQueueFull
+	case errors.Is(err, ErrShuttingDown):
+		return http.StatusServiceUnavailable, CodeShuttingDown
+	}
+	return http.StatusInternalServerError, CodeInternal
+}
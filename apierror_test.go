@@ -0,0 +1,106 @@
+// apierror_test.go - JSON error envelope tests
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorResponsesUseEnvelope(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{APIKeys: map[string]string{"secret": "alice"}})
+	auth := []string{"Authorization", "Bearer secret"}
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": "a"}, auth...)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, data)
+	}
+	var wf Workflow
+	decode(t, data, &wf)
+
+	tests := []struct {
+		name, method, path string
+		body               interface{}
+		header             []string
+		status             int
+		code               string
+	}{
+		{"no key", "GET", "/api/workflows", nil, nil, http.StatusUnauthorized, CodeUnauthorized},
+		{"unknown workflow", "GET", "/api/workflows/missing", nil, auth, http.StatusNotFound, CodeWorkflowNotFound},
+		{"execute unknown", "POST", "/api/workflows/missing/execute", nil, auth, http.StatusNotFound, CodeWorkflowNotFound},
+		{"unknown execution", "GET", "/api/executions/missing", nil, auth, http.StatusNotFound, CodeExecutionNotFound},
+		{"unknown approval", "POST", "/api/approvals/missing", map[string]interface{}{"approved": true}, auth, http.StatusNotFound, CodeApprovalNotFound},
+		{"bad body", "POST", "/api/workflows", "{", auth, http.StatusBadRequest, CodeInvalidBody},
+		{"export format", "GET", "/api/workflows/" + wf.ID + "/export?format=docx", nil, auth, http.StatusBadRequest, CodeUnsupportedFormat},
+		{"taken id", "POST", "/api/workflows", map[string]interface{}{"id": wf.ID, "name": "b"}, auth, http.StatusConflict, CodeWorkflowExists},
+		{"invalid workflow", "POST", "/api/workflows", map[string]interface{}{"name": "c", "nodes": []interface{}{map[string]interface{}{"type": "http"}}}, auth, http.StatusBadRequest, CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, tt.method, tt.path, tt.body, tt.header...)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, resp.StatusCode, tt.status, data)
+			continue
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type = %q", tt.name, ct)
+		}
+
+		// exactly {"error": {"code", "message", optional "details"}}
+		var envelope map[string]map[string]interface{}
+		if err := json.Unmarshal(data, &envelope); err != nil || len(envelope) != 1 || envelope["error"] == nil {
+			t.Errorf("%s: body %s is not an error envelope", tt.name, data)
+			continue
+		}
+		body := envelope["error"]
+		if body["code"] != tt.code {
+			t.Errorf("%s: code = %v, want %s", tt.name, body["code"], tt.code)
+		}
+		if msg, _ := body["message"].(string); msg == "" {
+			t.Errorf("%s: empty message", tt.name)
+		}
+		for k := range body {
+			if k != "code" && k != "message" && k != "details" {
+				t.Errorf("%s: unexpected key %q", tt.name, k)
+			}
+		}
+	}
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{
+		"name":  "dupes",
+		"nodes": []interface{}{map[string]interface{}{"id": "a", "type": "transform"}, map[string]interface{}{"id": "a", "type": "transform"}},
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d (%s)", resp.StatusCode, data)
+	}
+	details, _ := apiError(t, data).Details.(map[string]interface{})
+	fields, _ := details["fields"].([]interface{})
+	if len(fields) != 1 || fields[0].(map[string]interface{})["field"] != "nodes[1].id" {
+		t.Fatalf("details = %v", details)
+	}
+}
+
+func TestErrorCodeMapsWrappedErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{ErrWorkflowNotFound, http.StatusNotFound, CodeWorkflowNotFound},
+		{fmt.Errorf("loading: %w", ErrWorkflowNotFound), http.StatusNotFound, CodeWorkflowNotFound},
+		{fmt.Errorf("run: %w", ErrExecutionNotFound), http.StatusNotFound, CodeExecutionNotFound},
+		{ErrVersionConflict, http.StatusConflict, CodeVersionConflict},
+		{ErrWorkflowBusy, http.StatusConflict, CodeWorkflowBusy},
+		{ErrQueueFull, http.StatusTooManyRequests, CodeQueueFull},
+		{ErrShuttingDown, http.StatusServiceUnavailable, CodeShuttingDown},
+		{fmt.Errorf("disk on fire"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		status, code := errorCode(tt.err)
+		if status != tt.status || code != tt.code {
+			t.Errorf("errorCode(%v) = %d %s, want %d %s", tt.err, status, code, tt.status, tt.code)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// approval.go - Human-in-the-loop approval gates
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var ErrApprovalNotFound = errors.New("approval not found")
+
+// Approval output ports: a decided approval routes its output to one of
+// these, so a workflow branches on the decision.
+const (
+	PortApproved = "approved"
+	PortRejected = "rejected"
+)
+
+// ============================================
+// Approval Registry
+// ============================================
+
+// ApprovalDecision is posted by an approver to resume a suspended run.
+// Data carries whatever the approver sent to the resume endpoint.
+type ApprovalDecision struct {
+	Approved bool        `json:"approved"`
+	Approver string      `json:"approver,omitempty"`
+	Comment  string      `json:"comment,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// PendingApproval is an execution suspended at an approval node. Its ID is
+// the resume token: POSTing to ResumeURL resumes the execution.
+type PendingApproval struct {
+	ID          string     `json:"id"`
+	WorkflowID  string     `json:"workflow_id"`
+	ExecutionID string     `json:"execution_id"`
+	NodeID      string     `json:"node_id"`
+	OwnerID     string     `json:"owner_id,omitempty"`
+	Message     string     `json:"message"`
+	ResumeURL   string     `json:"resume_url"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+
+	decision chan ApprovalDecision
+}
+
+// ApprovalRegistry holds the approvals runs are waiting on. With a store
+// it also persists them, so a run resumed after a restart waits on the
+// approval it already announced rather than requesting a new one.
+type ApprovalRegistry struct {
+	mu      sync.Mutex
+	pending map[string]*PendingApproval
+	store   Store
+}
+
+func NewApprovalRegistry() *ApprovalRegistry {
+	return &ApprovalRegistry{
+		pending: make(map[string]*PendingApproval),
+	}
+}
+
+// add registers p, persisting it when it is new.
+func (ar *ApprovalRegistry) add(p *PendingApproval, persist bool) error {
+	if persist && ar.store != nil {
+		if err := ar.store.SaveApproval(p); err != nil {
+			return err
+		}
+	}
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.pending[p.ID] = p
+	return nil
+}
+
+// remove unregisters an approval; with forget set it is also dropped from
+// the store, as once decided or abandoned.
+func (ar *ApprovalRegistry) remove(id string, forget bool) {
+	ar.mu.Lock()
+	delete(ar.pending, id)
+	ar.mu.Unlock()
+	if forget && ar.store != nil {
+		ar.store.DeleteApproval(id)
+	}
+}
+
+// saved returns the persisted approval an earlier attempt at nodeID in
+// executionID announced, if any.
+func (ar *ApprovalRegistry) saved(executionID, nodeID string) *PendingApproval {
+	if ar.store == nil {
+		return nil
+	}
+	list, err := ar.store.Approvals()
+	if err != nil {
+		return nil
+	}
+	for _, p := range list {
+		if p.ExecutionID == executionID && p.NodeID == nodeID {
+			return p
+		}
+	}
+	return nil
+}
+
+// List returns the approvals visible to ownerID ("" sees all).
+func (ar *ApprovalRegistry) List(ownerID string) []*PendingApproval {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	list := []*PendingApproval{}
+	for _, p := range ar.pending {
+		if ownerID == "" || p.OwnerID == ownerID {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Decide delivers a decision to a waiting approval node. Each approval
+// accepts exactly one decision.
+func (ar *ApprovalRegistry) Decide(ownerID, id string, d ApprovalDecision) error {
+	return ar.Resume(ownerID, "", id, d)
+}
+
+// Resume delivers a decision to the approval with the given token, which
+// must belong to executionID unless that is blank.
+func (ar *ApprovalRegistry) Resume(ownerID, executionID, token string, d ApprovalDecision) error {
+	ar.mu.Lock()
+	p, ok := ar.pending[token]
+	if ok && (ownerID == "" || p.OwnerID == ownerID) && (executionID == "" || p.ExecutionID == executionID) {
+		delete(ar.pending, token)
+	} else {
+		ok = false
+	}
+	ar.mu.Unlock()
+
+	if !ok {
+		return ErrApprovalNotFound
+	}
+	p.decision <- d
+	return nil
+}
+
+// ============================================
+// Approval Node
+// ============================================
+
+// ApprovalExecutor suspends the run until an approver decides. The request
+// is announced as an approval_request event and, when notifyUrl is set,
+// POSTed there as JSON, including the resume_url to call. Properties:
+// message, notifyUrl, timeout; a run still waiting after timeout fails the
+// node. The output carries the decision and any data sent on resume, and
+// goes out on the "approved" or "rejected" port.
+//
+// A run resumed after an interruption waits on the approval it had
+// already announced: same token and deadline, and no new notification.
+type ApprovalExecutor struct {
+	approvals *ApprovalRegistry
+	clock     Clock
+	client    *http.Client
+}
+
+func (e *ApprovalExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	message, _ := node.Properties["message"].(string)
+	notifyURL, _ := node.Properties["notifyUrl"].(string)
+
+	p := e.approvals.saved(executionIDFromContext(ctx), node.ID)
+	resumed := p != nil
+	if !resumed {
+		p = &PendingApproval{
+			ID:          uuid.New().String(),
+			WorkflowID:  workflowIDFromContext(ctx),
+			ExecutionID: executionIDFromContext(ctx),
+			NodeID:      node.ID,
+			OwnerID:     principalFromContext(ctx),
+			Message:     message,
+			RequestedAt: e.clock.Now(),
+		}
+		p.ResumeURL = "/api/executions/" + p.ExecutionID + "/resume/" + p.ID
+		if v, ok := node.Properties["timeout"]; ok && v != "" && v != nil {
+			d, err := parseDelay(v)
+			if err != nil {
+				return nil, err
+			}
+			if d < 0 {
+				return nil, fmt.Errorf("timeout must not be negative")
+			}
+			expires := p.RequestedAt.Add(d)
+			p.ExpiresAt = &expires
+		}
+	}
+	p.decision = make(chan ApprovalDecision, 1)
+
+	var timeout <-chan time.Time
+	if p.ExpiresAt != nil {
+		timeout = e.clock.After(p.ExpiresAt.Sub(e.clock.Now()))
+	}
+	if err := e.approvals.add(p, !resumed); err != nil {
+		return nil, fmt.Errorf("saving approval: %v", err)
+	}
+	// A run stopped by shutdown keeps its approval for when it resumes
+	defer func() {
+		e.approvals.remove(p.ID, !errors.Is(context.Cause(ctx), ErrShuttingDown))
+	}()
+
+	emit(ctx, ExecutionEvent{Type: EventApprovalRequest, Status: "pending", Message: p.ID})
+	if notifyURL != "" && !resumed {
+		if err := e.notify(ctx, notifyURL, p); err != nil {
+			logf(ctx, "warn", "approval notification failed: %v", err)
+		}
+	}
+
+	select {
+	case d := <-p.decision:
+		branch := PortRejected
+		if d.Approved {
+			branch = PortApproved
+		}
+		return &Routed{Port: branch, Output: map[string]interface{}{
+			"status":      branch,
+			"approved":    d.Approved,
+			"approver":    d.Approver,
+			"comment":     d.Comment,
+			"data":        d.Data,
+			"approval_id": p.ID,
+			"input":       input,
+		}}, nil
+	case <-timeout:
+		return nil, fmt.Errorf("approval %s timed out", p.ID)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (e *ApprovalExecutor) notify(ctx context.Context, url string, p *PendingApproval) error {
+	announced := *p
+	announced.OwnerID = ""
+	body, err := json.Marshal(&announced)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notify returned %s", resp.Status)
+	}
+	return nil
+}
+
+// ============================================
+// Approval Handlers
+// ============================================
+
+func (s *Server) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.engine.approvals.List(principalFromContext(r.Context())))
+}
+
+func (s *Server) handleDecideApproval(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var decision ApprovalDecision
+	if err := decodeJSON(r.Body, &decision); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+	if decision.Approver == "" {
+		decision.Approver = principalFromContext(r.Context())
+	}
+
+	if err := s.engine.approvals.Decide(principalFromContext(r.Context()), id, decision); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleResumeExecution resumes an execution waiting at an approval node.
+// The body, any JSON value, becomes the node's data; an object may also
+// set approved (default true), approver and comment.
+func (s *Server) handleResumeExecution(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	principal := principalFromContext(r.Context())
+
+	var data interface{}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r.Body, &data); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+			return
+		}
+	}
+	decision := ApprovalDecision{Approved: true, Approver: principal, Data: data}
+	if m, ok := data.(map[string]interface{}); ok {
+		if approved, ok := m["approved"].(bool); ok {
+			decision.Approved = approved
+		}
+		if approver, ok := m["approver"].(string); ok && approver != "" {
+			decision.Approver = approver
+		}
+		decision.Comment, _ = m["comment"].(string)
+	}
+
+	if err := s.engine.approvals.Resume(principal, vars["id"], vars["token"], decision); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,240 @@
+// approval_test.go - Approval gate tests
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nodeOnReject is a test-only node type recording the rejected branch.
+const nodeOnReject NodeType = "record_rejected"
+
+// approvalFlow is an approval node whose approved port leads to a record
+// node and whose rejected port leads to a record_rejected node.
+func approvalFlow(props map[string]interface{}) *Workflow {
+	return &Workflow{
+		Name: "gated",
+		Nodes: []Node{
+			{ID: "gate", Type: NodeApproval, Properties: props},
+			{ID: "yes", Type: nodeRecord},
+			{ID: "no", Type: nodeOnReject},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "gate", ToID: "yes", Port: PortApproved},
+			{ID: "c2", FromID: "gate", ToID: "no", Port: PortRejected},
+		},
+	}
+}
+
+// awaitApproval waits for the single pending approval of the engine.
+func awaitApproval(t *testing.T, we *WorkflowEngine) *PendingApproval {
+	t.Helper()
+	var pending []*PendingApproval
+	eventually(t, "pending approval", func() bool {
+		pending = we.approvals.List("")
+		return len(pending) == 1
+	})
+	return pending[0]
+}
+
+func TestApprovalDecisionPicksBranch(t *testing.T) {
+	for _, approved := range []bool{true, false} {
+		approvedRec, rejectedRec := &recorder{}, &recorder{}
+		s, ts := newTestServer(t, ServerConfig{
+			AuthDisabled:  true,
+			NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: approvedRec, nodeOnReject: rejectedRec},
+		})
+		wf := mustCreate(t, s.engine, context.Background(), approvalFlow(map[string]interface{}{"message": "ship it?"}))
+
+		done := make(chan *ExecutionResult, 1)
+		go func() {
+			result, _ := s.engine.ExecuteWorkflow(context.Background(), wf.ID)
+			done <- result
+		}()
+		p := awaitApproval(t, s.engine)
+		if p.Message != "ship it?" || p.NodeID != "gate" || p.WorkflowID != wf.ID {
+			t.Fatalf("pending approval = %+v", p)
+		}
+
+		resp, body := doRequest(t, ts, "POST", "/api/approvals/"+p.ID, ApprovalDecision{Approved: approved, Approver: "lead", Comment: "ok"})
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("decide: %d %s", resp.StatusCode, body)
+		}
+		result := <-done
+		if result.Status != StatusCompleted {
+			t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+		}
+
+		taken, skipped := approvedRec, rejectedRec
+		if !approved {
+			taken, skipped = rejectedRec, approvedRec
+		}
+		if len(taken.calls()) != 1 || len(skipped.calls()) != 0 {
+			t.Fatalf("approved=%v: taken branch ran %d times, other %d", approved, len(taken.calls()), len(skipped.calls()))
+		}
+		out := taken.calls()[0].(map[string]interface{})
+		if out["approved"] != approved || out["approver"] != "lead" || out["comment"] != "ok" {
+			t.Fatalf("approval output = %v", out)
+		}
+
+		// Each approval takes exactly one decision
+		if resp, _ := doRequest(t, ts, "POST", "/api/approvals/"+p.ID, ApprovalDecision{Approved: true}); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("second decision: status %d, want 404", resp.StatusCode)
+		}
+	}
+}
+
+func TestApprovalNotifiesAndResumesWithData(t *testing.T) {
+	announced := make(chan PendingApproval, 1)
+	notify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p PendingApproval
+		json.NewDecoder(r.Body).Decode(&p)
+		announced <- p
+	}))
+	defer notify.Close()
+
+	rec := &recorder{}
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: rec, nodeOnReject: &recorder{}},
+	})
+	wf := mustCreate(t, s.engine, context.Background(), approvalFlow(map[string]interface{}{"notifyUrl": notify.URL}))
+
+	done := make(chan *ExecutionResult, 1)
+	go func() {
+		result, _ := s.engine.ExecuteWorkflow(context.Background(), wf.ID)
+		done <- result
+	}()
+	p := <-announced
+	if p.ResumeURL == "" || p.OwnerID != "" {
+		t.Fatalf("announced approval = %+v", p)
+	}
+
+	// A token from another execution is refused
+	if resp, _ := doRequest(t, ts, "POST", "/api/executions/other/resume/"+p.ID, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("mismatched execution: status %d, want 404", resp.StatusCode)
+	}
+	resp, body := doRequest(t, ts, "POST", p.ResumeURL, map[string]interface{}{"ticket": "T-1"})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("resume: %d %s", resp.StatusCode, body)
+	}
+	if result := <-done; result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := rec.calls()[0].(map[string]interface{})
+	if out["approved"] != true || out["data"].(map[string]interface{})["ticket"] != "T-1" {
+		t.Fatalf("resumed output = %v", out)
+	}
+}
+
+// startGatedRun starts a run of approvalFlow(props) on an engine with a
+// fake clock and returns the approval it waits on. The run's result is
+// sent on the returned channel.
+func startGatedRun(t *testing.T, props map[string]interface{}) (*WorkflowEngine, *FakeClock, *recorder, *PendingApproval, <-chan *ExecutionResult) {
+	t.Helper()
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	rec := &recorder{}
+	we := newTestEngine(t, WithClock(clock),
+		WithNodeExecutor(nodeRecord, rec), WithNodeExecutor(nodeOnReject, &recorder{}))
+	wf := mustCreate(t, we, context.Background(), approvalFlow(props))
+
+	done := make(chan *ExecutionResult, 1)
+	go func() {
+		result, _ := we.ExecuteWorkflow(context.Background(), wf.ID)
+		done <- result
+	}()
+	p := awaitApproval(t, we)
+	awaitWaiters(t, clock, 1)
+	return we, clock, rec, p, done
+}
+
+// savedApprovals counts the approvals persisted in the engine's store.
+func savedApprovals(t *testing.T, we *WorkflowEngine) int {
+	t.Helper()
+	list, err := we.store.Approvals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(list)
+}
+
+func TestApprovalResumedBeforeTimeout(t *testing.T) {
+	we, clock, rec, p, done := startGatedRun(t, map[string]interface{}{"timeout": "1h"})
+	if p.ExpiresAt == nil || !p.ExpiresAt.Equal(p.RequestedAt.Add(time.Hour)) {
+		t.Fatalf("expires_at = %v, want an hour after %v", p.ExpiresAt, p.RequestedAt)
+	}
+	if n := savedApprovals(t, we); n != 1 {
+		t.Fatalf("%d approvals saved while waiting, want 1", n)
+	}
+
+	clock.Advance(59 * time.Minute)
+	if err := we.approvals.Resume("", p.ExecutionID, p.ID, ApprovalDecision{Approved: true, Data: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	result := <-done
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if out := rec.calls()[0].(map[string]interface{}); out["data"] != "go" {
+		t.Fatalf("resumed output = %v", out)
+	}
+
+	// A decided approval is forgotten, and the deadline passing is harmless
+	if n := savedApprovals(t, we); n != 0 {
+		t.Fatalf("%d approvals saved after the decision, want 0", n)
+	}
+	clock.Advance(time.Hour)
+	if err := we.approvals.Resume("", p.ExecutionID, p.ID, ApprovalDecision{Approved: true}); err != ErrApprovalNotFound {
+		t.Fatalf("second resume: err = %v, want ErrApprovalNotFound", err)
+	}
+}
+
+func TestApprovalTimeoutExpires(t *testing.T) {
+	we, clock, rec, p, done := startGatedRun(t, map[string]interface{}{"timeout": "1h"})
+
+	clock.Advance(time.Hour)
+	result := <-done
+	if result.Status != StatusFailed || len(result.Errors) == 0 || !strings.Contains(result.Errors[0], "timed out") {
+		t.Fatalf("status = %s, errors %v; want a timeout failure", result.Status, result.Errors)
+	}
+	if len(rec.calls()) != 0 {
+		t.Fatalf("approved branch ran %d times after the timeout", len(rec.calls()))
+	}
+
+	// The expired approval can no longer be resumed
+	if n := len(we.approvals.List("")); n != 0 {
+		t.Fatalf("%d approvals pending after the timeout, want 0", n)
+	}
+	if n := savedApprovals(t, we); n != 0 {
+		t.Fatalf("%d approvals saved after the timeout, want 0", n)
+	}
+	if err := we.approvals.Resume("", p.ExecutionID, p.ID, ApprovalDecision{Approved: true}); err != ErrApprovalNotFound {
+		t.Fatalf("late resume: err = %v, want ErrApprovalNotFound", err)
+	}
+}
+
+func TestApprovalRejectsBadTimeout(t *testing.T) {
+	tests := []struct {
+		timeout, want string
+	}{
+		{"soon", "invalid delay"},
+		{"-1h", "must not be negative"},
+	}
+	for _, tt := range tests {
+		we := newTestEngine(t, WithNodeExecutor(nodeRecord, &recorder{}), WithNodeExecutor(nodeOnReject, &recorder{}))
+		wf := mustCreate(t, we, context.Background(), approvalFlow(map[string]interface{}{"timeout": tt.timeout}))
+
+		result, _ := we.ExecuteWorkflow(context.Background(), wf.ID)
+		if result == nil || result.Status != StatusFailed || len(result.Errors) == 0 || !strings.Contains(result.Errors[0], tt.want) {
+			t.Fatalf("timeout %q: result = %+v, want a failed run with %q", tt.timeout, result, tt.want)
+		}
+		if n := len(we.approvals.List("")); n != 0 {
+			t.Fatalf("timeout %q: %d approvals pending, want 0", tt.timeout, n)
+		}
+	}
+}
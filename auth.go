@@ -0,0 +1,91 @@
+// auth.go - API key authentication
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// ============================================
+// Authentication
+// ============================================
+
+const principalKey contextKey = "principal"
+
+// principalFromContext returns who the request authenticated as, or "" when
+// auth is disabled.
+func principalFromContext(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey).(string)
+	return p
+}
+
+// requireAPIKey rejects requests without a valid key with 401. With
+// allowQuery set the key may also arrive as ?key=, which browsers need for
+// WebSocket upgrades since they cannot set headers.
+func (s *Server) requireAPIKey(next http.Handler, allowQuery bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AuthDisabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := bearerToken(r)
+		if key == "" && allowQuery {
+			key = r.URL.Query().Get("key")
+		}
+
+		principal, ok := s.lookupKey(key)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goflow"`)
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "missing or invalid API key")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalKey, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// lookupKey compares against every configured key in constant time so the
+// response time does not reveal partial matches.
+func (s *Server) lookupKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+
+	principal, found := "", false
+	for k, p := range s.config.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			principal, found = p, true
+		}
+	}
+	return principal, found
+}
+
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+// parseAPIKeys reads "key:principal" pairs separated by commas. A key
+// without a principal authenticates as itself.
+func parseAPIKeys(spec string) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, principal, found := strings.Cut(entry, ":")
+		if !found {
+			principal = key
+		}
+		keys[key] = principal
+	}
+	return keys
+}
@@ -0,0 +1,111 @@
+// auth_test.go - API key authentication tests
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{APIKeys: map[string]string{"k-alice": "alice"}})
+
+	tests := []struct {
+		name   string
+		header []string
+		want   int
+	}{
+		{"no header", nil, http.StatusUnauthorized},
+		{"wrong key", []string{"Authorization", "Bearer nope"}, http.StatusUnauthorized},
+		{"wrong scheme", []string{"Authorization", "Basic k-alice"}, http.StatusUnauthorized},
+		{"valid key", []string{"Authorization", "Bearer k-alice"}, http.StatusOK},
+		{"scheme is case-insensitive", []string{"Authorization", "bearer k-alice"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, body := doRequest(t, ts, "GET", "/api/workflows", nil, tt.header...)
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, resp.StatusCode, tt.want, body)
+		}
+		if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: 401 without WWW-Authenticate", tt.name)
+		}
+	}
+
+	// Only the WebSocket endpoint accepts the key as a query parameter
+	if resp, _ := doRequest(t, ts, "GET", "/api/workflows?key=k-alice", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("query key on /api: status %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestWebSocketRequiresKey(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{APIKeys: map[string]string{"k-alice": "alice"}})
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial without key: err %v, resp %v", err, resp)
+	}
+
+	for name, dial := range map[string]func() (*websocket.Conn, *http.Response, error){
+		"query": func() (*websocket.Conn, *http.Response, error) {
+			return websocket.DefaultDialer.Dial(url+"?key=k-alice", nil)
+		},
+		"header": func() (*websocket.Conn, *http.Response, error) {
+			return websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer k-alice"}})
+		},
+	} {
+		conn, _, err := dial()
+		if err != nil {
+			t.Errorf("dial with %s key: %v", name, err)
+			continue
+		}
+		conn.Close()
+	}
+}
+
+func TestAuthDisabledAllowsAnonymous(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	if resp, body := doRequest(t, ts, "GET", "/api/workflows", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestWorkflowsAreScopedToPrincipal(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{APIKeys: map[string]string{"k-alice": "alice", "k-bob": "bob"}})
+	alice := []string{"Authorization", "Bearer k-alice"}
+	bob := []string{"Authorization", "Bearer k-bob"}
+
+	resp, body := doRequest(t, ts, "POST", "/api/workflows", &Workflow{
+		Name:  "private",
+		Nodes: []Node{{ID: "t", Type: NodeTransform}},
+	}, alice...)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, body)
+	}
+	var wf Workflow
+	decode(t, body, &wf)
+	if wf.OwnerID != "alice" {
+		t.Fatalf("owner = %q, want alice", wf.OwnerID)
+	}
+
+	if resp, _ := doRequest(t, ts, "GET", "/api/workflows/"+wf.ID, nil, alice...); resp.StatusCode != http.StatusOK {
+		t.Errorf("owner read: status %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, ts, "GET", "/api/workflows/"+wf.ID, nil, bob...); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other principal read: status %d, want 404", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil, bob...); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other principal execute: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	got := parseAPIKeys(" k1:alice, k2 ,,k3:ops:team ")
+	want := map[string]string{"k1": "alice", "k2": "k2", "k3": "ops:team"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseAPIKeys = %v, want %v", got, want)
+	}
+}
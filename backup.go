@@ -0,0 +1,376 @@
+// backup.go - Whole-server export and import for backups
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveFormat is the backup archive format written by this server.
+const archiveFormat = 1
+
+// maxImportRecords bounds the records one import may hold, since the
+// archive as a whole escapes the request body limit.
+const maxImportRecords = 10000
+
+// Archive record types, one per line of an archive.
+const (
+	ArchiveHeader    = "header"
+	ArchiveWorkflow  = "workflow"
+	ArchiveVersion   = "version"
+	ArchiveExecution = "execution"
+)
+
+// Import modes decide what happens to records whose ID already exists.
+const (
+	ImportSkip      = "skip"
+	ImportOverwrite = "overwrite"
+)
+
+// ArchiveRecord is one line of a backup archive, which is NDJSON: a
+// header, then each workflow followed by its versions, oldest first, then
+// executions.
+type ArchiveRecord struct {
+	Type       string           `json:"type"`
+	Format     int              `json:"format,omitempty"`
+	ExportedAt *time.Time       `json:"exported_at,omitempty"`
+	Workflow   *Workflow        `json:"workflow,omitempty"`
+	Execution  *ExecutionResult `json:"execution,omitempty"`
+}
+
+// ImportCounts tallies one record type in an import.
+type ImportCounts struct {
+	Imported    int `json:"imported"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// ImportSummary reports an archive import. Records that could not be
+// imported are listed in Errors by line; the rest are still imported.
+// Masked lists the sensitive properties, as "workflow/node.property", that
+// kept their workflow or version out because the archive held only the
+// mask for them.
+type ImportSummary struct {
+	Mode       string       `json:"mode"`
+	Workflows  ImportCounts `json:"workflows"`
+	Versions   ImportCounts `json:"versions"`
+	Executions ImportCounts `json:"executions"`
+	Errors     []string     `json:"errors"`
+	Masked     []string     `json:"masked,omitempty"`
+}
+
+// ============================================
+// Importing Workflows
+// ============================================
+
+// ImportWorkflow stores w under its own ID for the caller, keeping its
+// status, version and timestamps; active workflows have their triggers
+// started. A workflow with the same ID is replaced when overwrite is set
+// and otherwise left alone, reported by imported being false. IDs held by
+// another owner are never replaced.
+func (we *WorkflowEngine) ImportWorkflow(ctx context.Context, w *Workflow, overwrite bool) (imported bool, err error) {
+	if strings.TrimSpace(w.ID) == "" {
+		return false, fmt.Errorf("workflow has no id")
+	}
+	if err := w.Validate(); err != nil {
+		return false, err
+	}
+
+	we.mu.Lock()
+	defer we.mu.Unlock()
+
+	owner := principalFromContext(ctx)
+	existing, err := we.store.Get("", w.ID)
+	if err == nil && !ownedBy(existing, owner) {
+		return false, fmt.Errorf("workflow id %s belongs to another owner", w.ID)
+	}
+	if err == nil && !overwrite {
+		return false, nil
+	}
+
+	w.OwnerID = owner
+	w.Tags = normalizeTags(w.Tags)
+	w.Warnings = ValidateConnections(w)
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = we.clock.Now()
+	}
+	if w.UpdatedAt.IsZero() {
+		w.UpdatedAt = w.CreatedAt
+	}
+	if w.Status == "" {
+		w.Status = StatusInactive
+	}
+	if w.Version == 0 {
+		w.Version = 1
+	}
+
+	if err == nil {
+		we.stopTriggers(w.ID)
+		err = we.store.Update(w)
+	} else {
+		err = we.store.Create(w)
+	}
+	if err != nil {
+		return false, err
+	}
+	if w.Status == StatusActive && w.DeletedAt == nil {
+		we.startTriggers(w)
+	}
+	return true, nil
+}
+
+// ============================================
+// Backup Handlers
+// ============================================
+
+// handleExport streams every workflow the caller owns, soft-deleted ones
+// included, as an NDJSON archive. ?include=versions,executions adds
+// version history and finished executions. Sensitive values are masked
+// unless revealed as for other workflow responses.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	var withVersions, withExecutions bool
+	for _, part := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "versions":
+			withVersions = true
+		case "executions":
+			withExecutions = true
+		default:
+			writeError(w, http.StatusBadRequest, CodeInvalidBody, fmt.Sprintf("cannot include %q; use versions or executions", part))
+			return
+		}
+	}
+	workflows, err := s.engine.ListWorkflows(r.Context(), ListOptions{IncludeDeleted: true})
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].ID < workflows[j].ID })
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="goflow-backup.ndjson"`)
+	enc := json.NewEncoder(w)
+	flush := func() {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	now := s.engine.clock.Now()
+	if err := enc.Encode(ArchiveRecord{Type: ArchiveHeader, Format: archiveFormat, ExportedAt: &now}); err != nil {
+		return
+	}
+
+	for _, wf := range workflows {
+		if err := enc.Encode(ArchiveRecord{Type: ArchiveWorkflow, Workflow: s.presentWorkflow(r, wf)}); err != nil {
+			return
+		}
+		if withVersions {
+			versions, _ := s.engine.store.Versions(wf.ID)
+			for i := len(versions) - 1; i >= 0; i-- {
+				if err := enc.Encode(ArchiveRecord{Type: ArchiveVersion, Workflow: s.presentWorkflow(r, versions[i])}); err != nil {
+					return
+				}
+			}
+		}
+		flush()
+	}
+
+	if withExecutions {
+		for _, result := range s.engine.executions.List(principalFromContext(r.Context()), "") {
+			if result.Status == StatusRunning {
+				continue
+			}
+			if err := enc.Encode(ArchiveRecord{Type: ArchiveExecution, Execution: result}); err != nil {
+				return
+			}
+		}
+		flush()
+	}
+}
+
+// handleImport restores an archive written by handleExport, reading it a
+// line at a time. ?mode=skip (default) leaves records whose ID exists
+// alone; ?mode=overwrite replaces them. Each line, rather than the whole
+// archive, is held to the request body limit, and the archive to
+// maxImportRecords records. The import is not atomic: bad records are
+// reported in the summary and the rest go ahead. Workflows with masked
+// values are refused unless they overwrite one holding the real values,
+// so archives meant for restoring should be exported with reveal.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = ImportSkip
+	case ImportSkip, ImportOverwrite:
+	default:
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, "mode must be skip or overwrite")
+		return
+	}
+	overwrite := mode == ImportOverwrite
+	owner := principalFromContext(r.Context())
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), int(s.maxBodyBytes()))
+	line := 0
+	// next reads the next non-blank line, returning a nil record at the
+	// end of the archive
+	next := func() (*ArchiveRecord, error) {
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+			var rec ArchiveRecord
+			if err := decodeJSON(bytes.NewReader(data), &rec); err != nil {
+				return nil, err
+			}
+			return &rec, nil
+		}
+		return nil, scanner.Err()
+	}
+
+	header, err := next()
+	switch {
+	case err != nil:
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, bodyError(err).Error())
+		return
+	case header == nil || header.Type != ArchiveHeader:
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, "archive must start with a header record")
+		return
+	case header.Format != archiveFormat:
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, fmt.Sprintf("unsupported archive format %d", header.Format))
+		return
+	}
+
+	summary := &ImportSummary{Mode: mode, Errors: []string{}}
+	fail := func(err error) {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("line %d: %v", line, err))
+	}
+	// refuseMasked reports, and refuses, a workflow still holding masks
+	refuseMasked := func(wf *Workflow) bool {
+		masked := s.maskedProperties(wf)
+		if len(masked) == 0 {
+			return false
+		}
+		summary.Masked = append(summary.Masked, masked...)
+		fail(fmt.Errorf("workflow %s has masked values: %s", wf.ID, strings.Join(masked, ", ")))
+		return true
+	}
+	records := 0
+	// Versions follow their workflow and are restored only with it;
+	// restored holds the version numbers each restored workflow already has
+	restored := make(map[string]map[int64]bool)
+	for {
+		rec, err := next()
+		if errors.Is(err, bufio.ErrTooLong) {
+			fail(fmt.Errorf("record exceeds the %d-byte limit", s.maxBodyBytes()))
+			break
+		}
+		if err != nil {
+			fail(bodyError(err))
+			if scanner.Err() != nil {
+				break
+			}
+			continue
+		}
+		if rec == nil {
+			break
+		}
+		if records++; records > maxImportRecords {
+			fail(fmt.Errorf("archive exceeds %d records; the rest were not imported", maxImportRecords))
+			break
+		}
+		switch rec.Type {
+		case ArchiveWorkflow:
+			if rec.Workflow == nil {
+				fail(fmt.Errorf("workflow record has no workflow"))
+				continue
+			}
+			wf := rec.Workflow
+			existing, getErr := s.engine.store.Get(owner, wf.ID)
+			if getErr == nil && overwrite {
+				s.restoreMasked(wf, existing)
+			}
+			// Ones a skip leaves alone need no values
+			if (getErr != nil || overwrite) && refuseMasked(wf) {
+				continue
+			}
+			imported, err := s.engine.ImportWorkflow(r.Context(), wf, overwrite)
+			switch {
+			case err != nil:
+				fail(fmt.Errorf("workflow %s: %v", wf.ID, err))
+			case !imported:
+				summary.Workflows.Skipped++
+			case getErr == nil:
+				summary.Workflows.Overwritten++
+				have := make(map[int64]bool)
+				versions, _ := s.engine.store.Versions(wf.ID)
+				for _, v := range versions {
+					have[v.Version] = true
+				}
+				restored[wf.ID] = have
+			default:
+				summary.Workflows.Imported++
+				restored[wf.ID] = make(map[int64]bool)
+			}
+		case ArchiveVersion:
+			if rec.Workflow == nil {
+				fail(fmt.Errorf("version record has no workflow"))
+				continue
+			}
+			have, ok := restored[rec.Workflow.ID]
+			if !ok || have[rec.Workflow.Version] {
+				summary.Versions.Skipped++
+				continue
+			}
+			if refuseMasked(rec.Workflow) {
+				continue
+			}
+			rec.Workflow.OwnerID = owner
+			if err := s.engine.store.SaveVersion(rec.Workflow); err != nil {
+				fail(fmt.Errorf("version of workflow %s: %v", rec.Workflow.ID, err))
+				continue
+			}
+			have[rec.Workflow.Version] = true
+			summary.Versions.Imported++
+		case ArchiveExecution:
+			result := rec.Execution
+			if result == nil || result.ID == "" {
+				fail(fmt.Errorf("execution record has no execution"))
+				continue
+			}
+			_, getErr := s.engine.executions.Get(owner, result.ID)
+			if _, err := s.engine.executions.Get("", result.ID); err == nil && getErr != nil {
+				fail(fmt.Errorf("execution id %s belongs to another owner", result.ID))
+				continue
+			}
+			switch {
+			case getErr == nil && !overwrite:
+				summary.Executions.Skipped++
+				continue
+			case getErr == nil:
+				summary.Executions.Overwritten++
+			default:
+				summary.Executions.Imported++
+			}
+			s.engine.executions.Record(owner, result)
+		case ArchiveHeader:
+			fail(fmt.Errorf("unexpected header record"))
+		default:
+			fail(fmt.Errorf("unknown record type %q", rec.Type))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
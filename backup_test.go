@@ -0,0 +1,209 @@
+// backup_test.go - Export and import tests
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// exportArchive fetches ts's archive with the given query.
+func exportArchive(t *testing.T, ts *httptest.Server, query string) string {
+	t.Helper()
+	resp, data := doRequest(t, ts, "GET", "/api/export"+query, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("export%s: %d %s", query, resp.StatusCode, data)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("export content type = %q", ct)
+	}
+	return string(data)
+}
+
+// importArchive posts archive to ts in mode and decodes the summary.
+func importArchive(t *testing.T, ts *httptest.Server, archive, mode string) ImportSummary {
+	t.Helper()
+	resp, data := doRequest(t, ts, "POST", "/api/import?mode="+mode, archive)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("import: %d %s", resp.StatusCode, data)
+	}
+	var summary ImportSummary
+	decode(t, data, &summary)
+	return summary
+}
+
+// archiveTypes lists the record type of each line of archive.
+func archiveTypes(t *testing.T, archive string) []string {
+	t.Helper()
+	var types []string
+	scanner := bufio.NewScanner(strings.NewReader(archive))
+	for scanner.Scan() {
+		var rec ArchiveRecord
+		decode(t, scanner.Bytes(), &rec)
+		types = append(types, rec.Type)
+	}
+	return types
+}
+
+// workflowSummaries describes every workflow of we by ID, name, version
+// and node count, sorted, so two servers' catalogs can be compared.
+func workflowSummaries(t *testing.T, we *WorkflowEngine) []string {
+	t.Helper()
+	list, err := we.ListWorkflows(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, wf := range list {
+		out = append(out, fmt.Sprintf("%s %s v%d %d nodes", wf.ID, wf.Name, wf.Version, len(wf.Nodes)))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	executors := map[NodeType]NodeExecutor{nodeRecord: &recorder{}}
+	src, srcTS := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: executors})
+	ctx := context.Background()
+
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		wf := mustCreate(t, src.engine, ctx, &Workflow{
+			Name:  fmt.Sprintf("flow %d", i),
+			Nodes: []Node{{ID: "a", Type: nodeRecord, Properties: map[string]interface{}{"step": float64(i)}}},
+		})
+		ids = append(ids, wf.ID)
+	}
+	// The first workflow gains a version history and an execution
+	if err := src.engine.UpdateWorkflow(ctx, &Workflow{ID: ids[0], Name: "flow 1 edited"}); err != nil {
+		t.Fatal(err)
+	}
+	run, err := src.engine.ExecuteWorkflow(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := exportArchive(t, srcTS, "?include=versions,executions")
+	want := []string{ArchiveHeader, ArchiveWorkflow, ArchiveWorkflow, ArchiveWorkflow, ArchiveVersion, ArchiveExecution}
+	got := archiveTypes(t, archive)
+	sort.Strings(got[1:])
+	sort.Strings(want[1:])
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("archive records = %v, want %v", got, want)
+	}
+
+	dst, dstTS := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: executors})
+	summary := importArchive(t, dstTS, archive, ImportSkip)
+	if len(summary.Errors) != 0 {
+		t.Fatalf("import errors: %v", summary.Errors)
+	}
+	if summary.Workflows.Imported != 3 || summary.Versions.Imported != 1 || summary.Executions.Imported != 1 {
+		t.Fatalf("import summary = %+v", summary)
+	}
+	if a, b := workflowSummaries(t, src.engine), workflowSummaries(t, dst.engine); !reflect.DeepEqual(a, b) {
+		t.Fatalf("restored catalog = %v, want %v", b, a)
+	}
+	restored, err := dst.engine.GetWorkflow(ctx, ids[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step := restored.Nodes[0].Properties["step"]; step != 3.0 {
+		t.Fatalf("restored step = %v, want 3", step)
+	}
+	if versions, _ := dst.engine.store.Versions(ids[0]); len(versions) != 1 || versions[0].Name != "flow 1" {
+		t.Fatalf("restored versions = %v", versions)
+	}
+	if result, err := dst.engine.executions.Get("", run.ID); err != nil || result.Status != run.Status {
+		t.Fatalf("restored execution = %+v, %v", result, err)
+	}
+
+	// A second import finds everything already there
+	again := importArchive(t, dstTS, archive, ImportSkip)
+	if again.Workflows.Skipped != 3 || again.Versions.Skipped != 1 || again.Executions.Skipped != 1 {
+		t.Fatalf("repeat skip summary = %+v", again)
+	}
+}
+
+func TestImportOverwriteReplacesExisting(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, &Workflow{Name: "original"})
+	archive := exportArchive(t, ts, "")
+
+	if err := s.engine.UpdateWorkflow(ctx, &Workflow{ID: wf.ID, Name: "changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if summary := importArchive(t, ts, archive, ImportSkip); summary.Workflows.Skipped != 1 {
+		t.Fatalf("skip summary = %+v", summary)
+	}
+	if got, _ := s.engine.GetWorkflow(ctx, wf.ID); got.Name != "changed" {
+		t.Fatalf("skip replaced the workflow: name %q", got.Name)
+	}
+
+	if summary := importArchive(t, ts, archive, ImportOverwrite); summary.Workflows.Overwritten != 1 {
+		t.Fatalf("overwrite summary = %+v", summary)
+	}
+	if got, _ := s.engine.GetWorkflow(ctx, wf.ID); got.Name != "original" {
+		t.Fatalf("overwrite left name %q, want original", got.Name)
+	}
+}
+
+func TestImportRefusesMaskedValues(t *testing.T) {
+	src, srcTS := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: &recorder{}}})
+	wf := mustCreate(t, src.engine, context.Background(), &Workflow{
+		Name:  "keyed",
+		Nodes: []Node{{ID: "call", Type: nodeRecord, Properties: map[string]interface{}{"apiKey": "sk-live-123"}}},
+	})
+
+	dst, dstTS := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: &recorder{}}})
+	summary := importArchive(t, dstTS, exportArchive(t, srcTS, ""), ImportSkip)
+	if summary.Workflows.Imported != 0 || len(summary.Masked) != 1 || summary.Masked[0] != wf.ID+"/call.apiKey" {
+		t.Fatalf("masked import summary = %+v", summary)
+	}
+
+	// Revealed, the archive restores the real value
+	summary = importArchive(t, dstTS, exportArchive(t, srcTS, "?reveal=true"), ImportSkip)
+	if summary.Workflows.Imported != 1 {
+		t.Fatalf("revealed import summary = %+v", summary)
+	}
+	got, err := dst.engine.GetWorkflow(context.Background(), wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := got.Nodes[0].Properties["apiKey"]; key != "sk-live-123" {
+		t.Fatalf("restored apiKey = %v", key)
+	}
+}
+
+func TestImportRejectsBadArchives(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	header := `{"type":"header","format":1}` + "\n"
+
+	for name, tc := range map[string]struct{ method, path, body string }{
+		"no header":     {"POST", "/api/import", `{"type":"workflow","workflow":{"id":"w1","name":"x"}}`},
+		"future format": {"POST", "/api/import", `{"type":"header","format":99}`},
+		"unknown mode":  {"POST", "/api/import?mode=merge", header},
+		"not json":      {"POST", "/api/import", "garbage\n"},
+		"bad include":   {"GET", "/api/export?include=secrets", ""},
+	} {
+		if resp, data := doRequest(t, ts, tc.method, tc.path, tc.body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d %s, want 400", name, resp.StatusCode, data)
+		}
+	}
+
+	// Bad records are reported by line and the rest still imported
+	archive := header +
+		`{"type":"gadget"}` + "\n" +
+		`{"type":"workflow"}` + "\n" +
+		`{"type":"workflow","workflow":{"id":"w1","name":"kept"}}` + "\n"
+	summary := importArchive(t, ts, archive, ImportSkip)
+	if summary.Workflows.Imported != 1 || len(summary.Errors) != 2 || !strings.HasPrefix(summary.Errors[0], "line 2:") {
+		t.Fatalf("summary = %+v, want w1 imported and lines 2 and 3 reported", summary)
+	}
+}
@@ -0,0 +1,74 @@
+// batch.go - Batch workflow execution
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	// maxBatchSize caps the entries accepted in one batch request.
+	maxBatchSize = 100
+	// batchConcurrency bounds how many batch entries run at once.
+	batchConcurrency = 8
+)
+
+// BatchEntry is one workflow run requested in a batch.
+type BatchEntry struct {
+	WorkflowID string      `json:"workflowId"`
+	Input      interface{} `json:"input,omitempty"`
+}
+
+// BatchResult reports one entry's outcome: the execution result, or the
+// error that prevented the run.
+type BatchResult struct {
+	WorkflowID string           `json:"workflowId"`
+	Result     *ExecutionResult `json:"result,omitempty"`
+	Error      *APIError        `json:"error,omitempty"`
+}
+
+// ============================================
+// Batch Handler
+// ============================================
+
+// handleExecuteBatch runs a list of {workflowId, input} entries with
+// bounded concurrency and returns one BatchResult per entry, in order. A
+// failing entry does not fail the batch.
+func (s *Server) handleExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	var entries []BatchEntry
+	if err := decodeJSON(r.Body, &entries); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+	if len(entries) > maxBatchSize {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody,
+			fmt.Sprintf("batch has %d entries; the limit is %d", len(entries), maxBatchSize))
+		return
+	}
+
+	results := make([]BatchResult, len(entries))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, entry BatchEntry) {
+			defer func() { <-sem; wg.Done() }()
+
+			results[i].WorkflowID = entry.WorkflowID
+			result, err := s.engine.ExecuteWorkflowWithInput(r.Context(), entry.WorkflowID, entry.Input)
+			if err != nil {
+				_, code := errorCode(err)
+				results[i].Error = &APIError{Code: code, Message: err.Error()}
+				return
+			}
+			results[i].Result = result
+		}(i, entry)
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
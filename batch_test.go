@@ -0,0 +1,112 @@
+// batch_test.go - Batch execution endpoint tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nodeBusy is a test-only node type run by a concurrencyProbe.
+const nodeBusy NodeType = "busy"
+
+// concurrencyProbe holds each run briefly and records the most runs it
+// saw at once.
+type concurrencyProbe struct {
+	mu           sync.Mutex
+	active, peak int
+}
+
+func (p *concurrencyProbe) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	p.mu.Lock()
+	p.active++
+	if p.active > p.peak {
+		p.peak = p.active
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.active--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(20 * time.Millisecond):
+		return input, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestBatchWithMissingWorkflow(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeFail: failing{}}})
+	ctx := context.Background()
+	echo := mustCreate(t, s.engine, ctx, &Workflow{
+		Name:  "echo",
+		Nodes: []Node{{ID: "set", Type: NodeSet, Properties: map[string]interface{}{"mode": "merge", "fields": map[string]interface{}{"seen": true}}}},
+	})
+	broken := mustCreate(t, s.engine, ctx, &Workflow{Name: "broken", Nodes: []Node{{ID: "f", Type: nodeFail}}})
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/execute-batch", []BatchEntry{
+		{WorkflowID: echo.ID, Input: map[string]interface{}{"n": 1}},
+		{WorkflowID: "missing"},
+		{WorkflowID: broken.ID},
+		{WorkflowID: echo.ID, Input: map[string]interface{}{"n": 2}},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch: %d %s", resp.StatusCode, data)
+	}
+	var results []BatchResult
+	decode(t, data, &results)
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+
+	for i, n := range map[int]float64{0: 1, 3: 2} {
+		r := results[i]
+		if r.Error != nil || r.Result == nil || r.Result.Status != StatusCompleted {
+			t.Fatalf("entry %d = %+v, want completed", i, r)
+		}
+		out := r.Result.Results["set"].(map[string]interface{})
+		if out["n"] != n || out["seen"] != true {
+			t.Fatalf("entry %d ran with %v; results out of order", i, out)
+		}
+	}
+	if r := results[1]; r.WorkflowID != "missing" || r.Result != nil || r.Error == nil || r.Error.Code != CodeWorkflowNotFound {
+		t.Fatalf("missing entry = %+v", r)
+	}
+	if r := results[2]; r.Error != nil || r.Result == nil || r.Result.Status != StatusFailed {
+		t.Fatalf("failing entry = %+v, want a failed result", r)
+	}
+}
+
+func TestBatchConcurrencyIsBounded(t *testing.T) {
+	probe := &concurrencyProbe{}
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeBusy: probe}})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{Name: "busy", Nodes: []Node{{ID: "b", Type: nodeBusy}}})
+
+	entries := make([]BatchEntry, 3*batchConcurrency)
+	for i := range entries {
+		entries[i].WorkflowID = wf.ID
+	}
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/execute-batch", entries)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch: %d %s", resp.StatusCode, data)
+	}
+	if probe.peak > batchConcurrency {
+		t.Fatalf("%d entries ran at once, want at most %d", probe.peak, batchConcurrency)
+	}
+}
+
+func TestBatchRejectsBadRequests(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	tooMany := make([]BatchEntry, maxBatchSize+1)
+	for _, body := range []interface{}{tooMany, `{"workflowId": "x"}`, `[{"workflow": "x"}]`} {
+		resp, data := doRequest(t, ts, "POST", "/api/workflows/execute-batch", body)
+		if resp.StatusCode != http.StatusBadRequest || apiError(t, data).Code != CodeInvalidBody {
+			t.Errorf("%v: %d %s, want 400 invalid_body", body, resp.StatusCode, data)
+		}
+	}
+}
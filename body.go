@@ -0,0 +1,84 @@
+// body.go - Request body limits and strict decoding
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxBodyBytes caps request bodies when the server sets no limit.
+const defaultMaxBodyBytes = 1 << 20
+
+// ============================================
+// Body Limits
+// ============================================
+
+// unlimitedBodyPaths are read a record at a time by their handlers, which
+// cap each record instead of the whole body.
+var unlimitedBodyPaths = map[string]bool{
+	"/api/import": true,
+}
+
+// maxBodyBytes is the configured request body cap.
+func (s *Server) maxBodyBytes() int64 {
+	if s.config.MaxBodyBytes <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return s.config.MaxBodyBytes
+}
+
+// limitBody caps every request body at the configured size. Reads past
+// the cap fail with *http.MaxBytesError.
+func (s *Server) limitBody(next http.Handler) http.Handler {
+	limit := s.maxBodyBytes()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !unlimitedBodyPaths[r.URL.Path] {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ============================================
+// Strict Decoding
+// ============================================
+
+// decodeJSON decodes exactly one JSON value from data, rejecting fields
+// that v does not declare.
+func decodeJSON(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return bodyError(err)
+	}
+	if dec.More() {
+		return fmt.Errorf("request body must contain a single JSON value")
+	}
+	return nil
+}
+
+// bodyError rewrites decoding errors as messages a client can act on.
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	var syntax *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		return fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit)
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("request body is truncated JSON")
+	case errors.As(err, &syntax):
+		return fmt.Errorf("malformed JSON at byte %d: %v", syntax.Offset, err)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("field %q must be %s, not %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Errorf("unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	return err
+}
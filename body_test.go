@@ -0,0 +1,52 @@
+// body_test.go - Request body limit and strict decoding tests
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitsAndUnknownFields(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true, MaxBodyBytes: 512})
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": "existing"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, data)
+	}
+	var wf Workflow
+	decode(t, data, &wf)
+
+	oversized := `{"name": "` + strings.Repeat("x", 1024) + `"}`
+	tests := []struct {
+		name, method, path string
+		body               interface{}
+		want               string
+	}{
+		{"create oversized", "POST", "/api/workflows", oversized, "request body exceeds 512 bytes"},
+		{"update oversized", "PUT", "/api/workflows/" + wf.ID, oversized, "request body exceeds 512 bytes"},
+		{"create unknown field", "POST", "/api/workflows", `{"name": "a", "nmae": "b"}`, `unknown field "nmae"`},
+		{"update unknown field", "PUT", "/api/workflows/" + wf.ID, `{"name": "a", "nodes": [{"id": "n", "typ": "http"}]}`, `unknown field "typ"`},
+		{"malformed", "POST", "/api/workflows", `{"name": }`, "malformed JSON"},
+		{"truncated", "POST", "/api/workflows", `{"name": "a"`, "truncated JSON"},
+		{"empty", "POST", "/api/workflows", "", "request body is empty"},
+		{"wrong type", "POST", "/api/workflows", `{"name": 7}`, `field "name" must be string`},
+		{"two values", "POST", "/api/workflows", `{"name": "a"} {"name": "b"}`, "single JSON value"},
+	}
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, tt.method, tt.path, tt.body)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400 (%s)", tt.name, resp.StatusCode, data)
+			continue
+		}
+		if msg := apiError(t, data).Message; !strings.Contains(msg, tt.want) {
+			t.Errorf("%s: message = %q, want %q", tt.name, msg, tt.want)
+		}
+	}
+}
+
+func TestDefaultBodyLimit(t *testing.T) {
+	s := &Server{}
+	if got := s.maxBodyBytes(); got != defaultMaxBodyBytes {
+		t.Fatalf("default limit = %d, want %d", got, defaultMaxBodyBytes)
+	}
+}
@@ -0,0 +1,241 @@
+// breaker.go - Circuit breakers for failing external services
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBreakerThreshold is how many consecutive failures open a
+	// circuit.
+	defaultBreakerThreshold = 5
+	// defaultBreakerCooldown is how long a circuit first stays open.
+	defaultBreakerCooldown = 30 * time.Second
+	// maxBreakerCooldown caps the cooldown as failed probes double it.
+	maxBreakerCooldown = 10 * time.Minute
+)
+
+// ErrCircuitOpen reports a call refused because its target's circuit is
+// open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// WithCircuitBreaker opens a target's circuit after threshold consecutive
+// failures, refusing calls to it for cooldown. Zero values use
+// defaultBreakerThreshold and defaultBreakerCooldown; a negative threshold
+// disables circuit breaking.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) EngineOption {
+	return func(we *WorkflowEngine) {
+		we.breakerThreshold, we.breakerCooldown = threshold, cooldown
+	}
+}
+
+// ============================================
+// Circuit Breakers
+// ============================================
+
+// CircuitBreakers tracks the health of each external target outbound
+// nodes call. A target's circuit opens after threshold consecutive
+// failures, as judged by breakerFailure, and refuses calls until its cooldown passes; then one probe
+// call is let through (half-open). A successful probe closes the circuit;
+// a failed one reopens it for twice as long, up to maxBreakerCooldown.
+type CircuitBreakers struct {
+	mu        sync.Mutex
+	clock     Clock
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	cooldown  time.Duration
+	probing   bool
+}
+
+func NewCircuitBreakers(clock Clock, threshold int, cooldown time.Duration) *CircuitBreakers {
+	if threshold == 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &CircuitBreakers{
+		clock:     clock,
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// Allow reports whether a call to target may go ahead, claiming the probe
+// of a half-open circuit.
+func (cb *CircuitBreakers) Allow(target string) error {
+	if cb.threshold < 0 {
+		return nil
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[target]
+	if !ok || c.openUntil.IsZero() {
+		return nil
+	}
+	if now := cb.clock.Now(); now.Before(c.openUntil) {
+		return fmt.Errorf("%w for %s until %s", ErrCircuitOpen, target, c.openUntil.Format(time.RFC3339))
+	}
+	if c.probing {
+		return fmt.Errorf("%w for %s: recovery probe in flight", ErrCircuitOpen, target)
+	}
+	c.probing = true
+	return nil
+}
+
+// Record notes the outcome of an allowed call to target.
+func (cb *CircuitBreakers) Record(target string, err error) {
+	if cb.threshold < 0 {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err == nil {
+		delete(cb.circuits, target)
+		return
+	}
+	c, ok := cb.circuits[target]
+	if !ok {
+		c = &circuit{}
+		cb.circuits[target] = c
+	}
+	switch {
+	case c.probing:
+		c.probing = false
+		c.cooldown *= 2
+		if c.cooldown > maxBreakerCooldown {
+			c.cooldown = maxBreakerCooldown
+		}
+		c.openUntil = cb.clock.Now().Add(c.cooldown)
+	case c.openUntil.IsZero():
+		c.failures++
+		if c.failures >= cb.threshold {
+			c.cooldown = cb.cooldown
+			c.openUntil = cb.clock.Now().Add(c.cooldown)
+		}
+	}
+}
+
+// Open counts the circuits currently refusing calls.
+func (cb *CircuitBreakers) Open() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	now := cb.clock.Now()
+	n := 0
+	for _, c := range cb.circuits {
+		if now.Before(c.openUntil) {
+			n++
+		}
+	}
+	return n
+}
+
+// breakerTarget names the service an outbound node calls, as seen by the
+// owner the run acts for: its type and the host of its url, webhook or
+// endpoint property, or the type alone. Circuits are per owner, so one
+// owner's failing credentials do not cut off the others. Other nodes have
+// no target.
+func breakerTarget(ctx context.Context, node *Node) string {
+	if !outboundNodeTypes[node.Type] {
+		return ""
+	}
+	target := string(node.Type)
+	for _, key := range []string{"url", "webhook", "endpoint"} {
+		raw, _ := node.Properties[key].(string)
+		if u, err := url.Parse(strings.TrimSpace(raw)); err == nil && u.Host != "" {
+			target += ":" + u.Host
+			break
+		}
+	}
+	if owner := principalFromContext(ctx); owner != "" {
+		target = owner + "/" + target
+	}
+	return target
+}
+
+// statusCoder is implemented by errors carrying the HTTP status a service
+// answered with.
+type statusCoder interface {
+	HTTPStatus() int
+}
+
+func (e *HTTPStatusError) HTTPStatus() int { return e.Status }
+
+// statusError tags an error with the HTTP status behind it.
+type statusError struct {
+	status int
+	err    error
+}
+
+// withStatus tags err with the HTTP status a service answered with, so
+// the breakers can tell a refused request from an unhealthy service.
+func withStatus(status int, err error) error {
+	return &statusError{status: status, err: err}
+}
+
+func (e *statusError) Error() string   { return e.err.Error() }
+func (e *statusError) Unwrap() error   { return e.err }
+func (e *statusError) HTTPStatus() int { return e.status }
+
+// breakerFailure reports whether err counts against a target's health:
+// transport errors, 5xx and 429 do; other statuses are answers from a
+// healthy service to a bad request.
+func breakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var sc statusCoder
+	if errors.As(err, &sc) {
+		status := sc.HTTPStatus()
+		return status >= 500 || status == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// call runs call unless target's circuit is open, recording the outcome.
+// A cancelled call says nothing about the target's health and only gives
+// back a probe it held.
+func (cb *CircuitBreakers) call(ctx context.Context, target string, call func() error) error {
+	if cb == nil || target == "" {
+		return call()
+	}
+	if err := cb.Allow(target); err != nil {
+		return err
+	}
+	err := call()
+	if ctx.Err() != nil {
+		cb.release(target)
+		return err
+	}
+	if breakerFailure(err) {
+		cb.Record(target, err)
+	} else {
+		cb.Record(target, nil)
+	}
+	return err
+}
+
+// release gives back a half-open circuit's probe without an outcome.
+func (cb *CircuitBreakers) release(target string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if c, ok := cb.circuits[target]; ok {
+		c.probing = false
+	}
+}
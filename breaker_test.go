@@ -0,0 +1,167 @@
+// breaker_test.go - Circuit breaker tests
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerTripsAndResets(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	cb := NewCircuitBreakers(clock, 3, 10*time.Second)
+	down := errors.New("connection refused")
+
+	fail := func() {
+		t.Helper()
+		if err := cb.Allow("api"); err != nil {
+			t.Fatalf("call refused before the threshold: %v", err)
+		}
+		cb.Record("api", down)
+	}
+	fail()
+	fail()
+	fail()
+	if err := cb.Allow("api"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after 3 failures: err = %v, want ErrCircuitOpen", err)
+	}
+	if cb.Open() != 1 {
+		t.Fatalf("%d circuits open, want 1", cb.Open())
+	}
+	if err := cb.Allow("other"); err != nil {
+		t.Fatalf("other target refused: %v", err)
+	}
+
+	// After the cooldown one probe goes through; it fails, doubling the wait
+	clock.Advance(10 * time.Second)
+	if err := cb.Allow("api"); err != nil {
+		t.Fatalf("half-open probe refused: %v", err)
+	}
+	if err := cb.Allow("api"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second call during the probe: err = %v, want ErrCircuitOpen", err)
+	}
+	cb.Record("api", down)
+	clock.Advance(10 * time.Second)
+	if err := cb.Allow("api"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("10s after a failed probe: err = %v, want still open", err)
+	}
+
+	// A successful probe closes the circuit
+	clock.Advance(10 * time.Second)
+	if err := cb.Allow("api"); err != nil {
+		t.Fatalf("second probe refused: %v", err)
+	}
+	cb.Record("api", nil)
+	if err := cb.Allow("api"); err != nil || cb.Open() != 0 {
+		t.Fatalf("after recovery: err = %v, %d open", err, cb.Open())
+	}
+}
+
+func TestBreakerCountsConsecutiveFailures(t *testing.T) {
+	cb := NewCircuitBreakers(NewFakeClock(time.Now()), 3, time.Second)
+	down := errors.New("down")
+	for _, err := range []error{down, down, nil, down, down} {
+		cb.Record("api", err)
+	}
+	if err := cb.Allow("api"); err != nil {
+		t.Fatalf("a success between failures should reset the count: %v", err)
+	}
+
+	disabled := NewCircuitBreakers(NewFakeClock(time.Now()), -1, time.Second)
+	for i := 0; i < 10; i++ {
+		disabled.Record("api", down)
+	}
+	if err := disabled.Allow("api"); err != nil {
+		t.Fatalf("disabled breaker refused: %v", err)
+	}
+}
+
+func TestBreakerFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"success", nil, false},
+		{"transport error", errors.New("dial tcp: refused"), true},
+		{"server error", withStatus(http.StatusBadGateway, errors.New("bad gateway")), true},
+		{"throttled", withStatus(http.StatusTooManyRequests, errors.New("slow down")), true},
+		{"bad request", withStatus(http.StatusNotFound, errors.New("no such thing")), false},
+		{"http node status", &HTTPStatusError{Status: http.StatusServiceUnavailable}, true},
+	}
+	for _, tt := range tests {
+		if got := breakerFailure(tt.err); got != tt.want {
+			t.Errorf("%s: breakerFailure = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerTarget(t *testing.T) {
+	tests := []struct {
+		ctx  context.Context
+		node *Node
+		want string
+	}{
+		{context.Background(), &Node{Type: NodeHTTP, Properties: map[string]interface{}{"url": "https://api.example.com/v1/x"}}, "http:api.example.com"},
+		{asPrincipal("alice"), &Node{Type: NodeHTTP, Properties: map[string]interface{}{"url": "https://api.example.com"}}, "alice/http:api.example.com"},
+		{context.Background(), &Node{Type: NodeHTTP}, "http"},
+		{context.Background(), &Node{Type: NodeTransform}, ""},
+	}
+	for _, tt := range tests {
+		if got := breakerTarget(tt.ctx, tt.node); got != tt.want {
+			t.Errorf("breakerTarget(%s %v) = %q, want %q", tt.node.Type, tt.node.Properties, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerShortCircuitsHTTPNode(t *testing.T) {
+	var hits, healthy atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if healthy.Load() == 0 {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer api.Close()
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	we := newTestEngine(t, WithClock(clock), WithCircuitBreaker(2, 10*time.Second))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "flaky", Nodes: []Node{
+		{ID: "call", Type: NodeHTTP, Properties: map[string]interface{}{"url": api.URL, "failOnStatus": true}},
+	}})
+	run := func() string {
+		t.Helper()
+		result, err := we.ExecuteWorkflow(ctx, wf.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Nodes["call"].Error
+	}
+
+	for i := 0; i < 4; i++ {
+		msg := run()
+		if open := strings.Contains(msg, ErrCircuitOpen.Error()); open != (i >= 2) {
+			t.Fatalf("run %d: error %q, want circuit open=%v", i, msg, i >= 2)
+		}
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("server hit %d times, want 2 before the circuit opened", hits.Load())
+	}
+
+	healthy.Store(1)
+	clock.Advance(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if msg := run(); msg != "" {
+			t.Fatalf("run after recovery: %q", msg)
+		}
+	}
+	if hits.Load() != 4 {
+		t.Fatalf("server hit %d times, want 4 once the circuit closed", hits.Load())
+	}
+}
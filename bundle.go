@@ -0,0 +1,258 @@
+// bundle.go - Self-contained workflow bundles for hand-off
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// bundleVersion is the bundle format written by this server.
+const bundleVersion = 1
+
+// workflowRefNodes are the node types whose workflowId property names
+// another workflow.
+var workflowRefNodes = map[NodeType]bool{
+	NodeSubWorkflow:      true,
+	NodeScheduleFollowUp: true,
+	NodeLoop:             true,
+}
+
+// WorkflowBundle is a workflow together with every workflow it references,
+// directly or indirectly. Secret values are never included: Secrets lists
+// the secret names the workflows reference, which the importer must set.
+type WorkflowBundle struct {
+	Version   int         `json:"version"`
+	Root      string      `json:"root"`
+	Workflows []*Workflow `json:"workflows"`
+	Secrets   []string    `json:"secrets"`
+	// Missing lists referenced workflows that could not be found
+	Missing []string `json:"missing,omitempty"`
+	// Masked lists sensitive properties exported as the mask, as
+	// "workflow/node.property"; they must be filled in before import
+	Masked []string `json:"masked,omitempty"`
+}
+
+// BundleImport reports an imported bundle: the new root workflow, the
+// created workflows, the old-to-new ID mapping and any referenced secrets
+// the importer has yet to set.
+type BundleImport struct {
+	Root           string            `json:"root"`
+	Workflows      []*Workflow       `json:"workflows"`
+	IDMap          map[string]string `json:"idMap"`
+	MissingSecrets []string          `json:"missingSecrets"`
+}
+
+// ============================================
+// Bundling
+// ============================================
+
+// workflowRefs lists the workflow IDs w's nodes reference.
+func workflowRefs(w *Workflow) []string {
+	var refs []string
+	for _, node := range w.Nodes {
+		if !workflowRefNodes[node.Type] {
+			continue
+		}
+		if id, _ := node.Properties["workflowId"].(string); strings.TrimSpace(id) != "" {
+			refs = append(refs, strings.TrimSpace(id))
+		}
+	}
+	return refs
+}
+
+// secretRefs lists the secret names referenced anywhere in workflows,
+// sorted.
+func secretRefs(workflows []*Workflow) []string {
+	seen := make(map[string]bool)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch x := v.(type) {
+		case string:
+			for _, m := range secretRefPattern.FindAllStringSubmatch(x, -1) {
+				seen[m[1]] = true
+			}
+		case map[string]interface{}:
+			for _, item := range x {
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range x {
+				walk(item)
+			}
+		}
+	}
+	for _, w := range workflows {
+		for _, node := range w.Nodes {
+			walk(node.Properties)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleBundleWorkflow returns the workflow and everything it references
+// as a WorkflowBundle, masked like other workflow responses.
+func (s *Server) handleBundleWorkflow(w http.ResponseWriter, r *http.Request) {
+	root, err := s.engine.GetWorkflow(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	bundle := &WorkflowBundle{Version: bundleVersion, Root: root.ID}
+	seen := map[string]bool{root.ID: true}
+	queue := []*Workflow{root}
+	for len(queue) > 0 {
+		wf := queue[0]
+		queue = queue[1:]
+		bundle.Workflows = append(bundle.Workflows, s.presentWorkflow(r, wf))
+
+		for _, id := range workflowRefs(wf) {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			dep, err := s.engine.GetWorkflow(r.Context(), id)
+			if err != nil {
+				bundle.Missing = append(bundle.Missing, id)
+				continue
+			}
+			queue = append(queue, dep)
+		}
+	}
+	bundle.Secrets = secretRefs(bundle.Workflows)
+	bundle.Masked = s.maskedProperties(bundle.Workflows...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// handleImportBundle creates every workflow in a bundle under fresh IDs,
+// rewriting workflow references to match. Bundles with references to
+// workflows they do not contain, or with masked sensitive values, are
+// rejected with 422. Either every workflow is created or none is.
+func (s *Server) handleImportBundle(w http.ResponseWriter, r *http.Request) {
+	var bundle WorkflowBundle
+	if err := decodeJSON(r.Body, &bundle); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+	if bundle.Version != bundleVersion {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, fmt.Sprintf("unsupported bundle version %d", bundle.Version))
+		return
+	}
+
+	ids := make(map[string]string, len(bundle.Workflows))
+	for _, wf := range bundle.Workflows {
+		ids[wf.ID] = uuid.New().String()
+	}
+	if _, ok := ids[bundle.Root]; !ok {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, "bundle root is not among its workflows")
+		return
+	}
+
+	var missing []string
+	for _, wf := range bundle.Workflows {
+		for _, id := range workflowRefs(wf) {
+			if _, ok := ids[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, APIError{
+			Code:    CodeMissingDependencies,
+			Message: "bundle references workflows it does not contain: " + strings.Join(missing, ", "),
+			Details: map[string]interface{}{"missing": missing},
+		})
+		return
+	}
+
+	if masked := s.maskedProperties(bundle.Workflows...); len(masked) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, APIError{
+			Code:    CodeMaskedValues,
+			Message: "bundle holds masked values; export it with reveal or replace them: " + strings.Join(masked, ", "),
+			Details: map[string]interface{}{"masked": masked},
+		})
+		return
+	}
+
+	created := make([]*Workflow, len(bundle.Workflows))
+	for i, wf := range bundle.Workflows {
+		created[i] = remapWorkflow(wf, ids)
+		if err := created[i].Validate(); err != nil {
+			writeEngineError(w, err)
+			return
+		}
+	}
+	for i, wf := range created {
+		if err := s.engine.CreateWorkflow(r.Context(), wf); err != nil {
+			// Leave nothing of a half-imported bundle behind
+			for _, done := range created[:i] {
+				s.engine.store.Delete(done.ID)
+			}
+			writeEngineError(w, err)
+			return
+		}
+	}
+	for i, wf := range created {
+		created[i] = s.presentWorkflow(r, wf)
+	}
+
+	have := make(map[string]bool)
+	for _, name := range s.engine.secrets.Names(principalFromContext(r.Context())) {
+		have[name] = true
+	}
+	result := &BundleImport{
+		Root:           ids[bundle.Root],
+		Workflows:      created,
+		IDMap:          ids,
+		MissingSecrets: []string{},
+	}
+	for _, name := range secretRefs(bundle.Workflows) {
+		if !have[name] {
+			result.MissingSecrets = append(result.MissingSecrets, name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// remapWorkflow copies the definition of wf under its new ID, pointing
+// workflow references at their new IDs.
+func remapWorkflow(wf *Workflow, ids map[string]string) *Workflow {
+	out := &Workflow{
+		ID:                ids[wf.ID],
+		Name:              wf.Name,
+		Description:       wf.Description,
+		Nodes:             make([]Node, len(wf.Nodes)),
+		Connections:       wf.Connections,
+		RateLimit:         wf.RateLimit,
+		Tags:              wf.Tags,
+		ConcurrencyPolicy: wf.ConcurrencyPolicy,
+	}
+	for i, node := range wf.Nodes {
+		props := make(map[string]interface{}, len(node.Properties))
+		for k, v := range node.Properties {
+			props[k] = v
+		}
+		if id, _ := props["workflowId"].(string); workflowRefNodes[node.Type] && ids[strings.TrimSpace(id)] != "" {
+			props["workflowId"] = ids[strings.TrimSpace(id)]
+		}
+		node.Properties = props
+		out.Nodes[i] = node
+	}
+	return out
+}
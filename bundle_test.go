@@ -0,0 +1,117 @@
+// bundle_test.go - Workflow bundle export and import tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBundleWithSubWorkflow(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	leaf := mustCreate(t, s.engine, ctx, &Workflow{Name: "leaf", Nodes: []Node{{
+		ID: "call", Type: NodeHTTP, Properties: map[string]interface{}{
+			"url":     "https://example.com",
+			"headers": map[string]interface{}{"Authorization": "Bearer {{secrets.TOKEN}}"},
+		},
+	}}})
+	root := mustCreate(t, s.engine, ctx, caller("root", leaf.ID))
+
+	resp, data := doRequest(t, ts, "GET", "/api/workflows/"+root.ID+"/bundle", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("bundle: %d %s", resp.StatusCode, data)
+	}
+	var bundle WorkflowBundle
+	decode(t, data, &bundle)
+	if bundle.Version != bundleVersion || bundle.Root != root.ID || len(bundle.Missing) != 0 {
+		t.Fatalf("bundle = %+v", bundle)
+	}
+	if len(bundle.Workflows) != 2 || bundle.Workflows[0].ID != root.ID || bundle.Workflows[1].ID != leaf.ID {
+		t.Fatalf("bundle workflows = %v, want root then leaf", bundle.Workflows)
+	}
+	if !reflect.DeepEqual(bundle.Secrets, []string{"TOKEN"}) {
+		t.Fatalf("secrets = %v, want [TOKEN]", bundle.Secrets)
+	}
+
+	resp, data = doRequest(t, ts, "POST", "/api/workflows/import-bundle", bundle)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("import: %d %s", resp.StatusCode, data)
+	}
+	var imported BundleImport
+	decode(t, data, &imported)
+	newRoot, newLeaf := imported.IDMap[root.ID], imported.IDMap[leaf.ID]
+	if imported.Root != newRoot || newRoot == "" || newRoot == root.ID || newLeaf == "" || newLeaf == leaf.ID {
+		t.Fatalf("import = %+v, want fresh IDs for both workflows", imported)
+	}
+	if !reflect.DeepEqual(imported.MissingSecrets, []string{"TOKEN"}) {
+		t.Errorf("missing secrets = %v, want [TOKEN]", imported.MissingSecrets)
+	}
+
+	stored, err := s.engine.GetWorkflow(ctx, newRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref := stored.Nodes[0].Properties["workflowId"]; ref != newLeaf {
+		t.Fatalf("imported root calls %v, want the imported leaf %s", ref, newLeaf)
+	}
+	if _, err := s.engine.GetWorkflow(ctx, newLeaf); err != nil {
+		t.Fatalf("imported leaf: %v", err)
+	}
+}
+
+func TestBundleMissingDependency(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	root := mustCreate(t, s.engine, context.Background(), caller("root", "ghost"))
+
+	_, data := doRequest(t, ts, "GET", "/api/workflows/"+root.ID+"/bundle", nil)
+	var bundle WorkflowBundle
+	decode(t, data, &bundle)
+	if !reflect.DeepEqual(bundle.Missing, []string{"ghost"}) || len(bundle.Workflows) != 1 {
+		t.Fatalf("bundle = %+v, want ghost reported missing", bundle)
+	}
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/import-bundle", bundle)
+	if resp.StatusCode != http.StatusUnprocessableEntity || apiError(t, data).Code != CodeMissingDependencies {
+		t.Fatalf("import: %d %s", resp.StatusCode, data)
+	}
+	if workflows, _ := s.engine.ListWorkflows(context.Background(), ListOptions{}); len(workflows) != 1 {
+		t.Fatalf("rejected import left %d workflows, want 1", len(workflows))
+	}
+}
+
+func TestBundleImportRejects(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", credentialFlow())
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, data)
+	}
+	var wf Workflow
+	decode(t, data, &wf)
+
+	_, data = doRequest(t, ts, "GET", "/api/workflows/"+wf.ID+"/bundle", nil)
+	var bundle WorkflowBundle
+	decode(t, data, &bundle)
+	if len(bundle.Masked) == 0 {
+		t.Fatal("bundle of a workflow with an API key lists nothing masked")
+	}
+	resp, data = doRequest(t, ts, "POST", "/api/workflows/import-bundle", bundle)
+	if resp.StatusCode != http.StatusUnprocessableEntity || apiError(t, data).Code != CodeMaskedValues {
+		t.Fatalf("masked import: %d %s", resp.StatusCode, data)
+	}
+
+	tests := map[string]WorkflowBundle{
+		"wrong version": {Version: bundleVersion + 1, Root: "a", Workflows: []*Workflow{{ID: "a", Name: "a"}}},
+		"missing root":  {Version: bundleVersion, Root: "b", Workflows: []*Workflow{{ID: "a", Name: "a"}}},
+	}
+	for name, bundle := range tests {
+		resp, data := doRequest(t, ts, "POST", "/api/workflows/import-bundle", bundle)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: %d %s", name, resp.StatusCode, data)
+		}
+	}
+	if workflows, _ := s.engine.ListWorkflows(context.Background(), ListOptions{}); len(workflows) != 1 {
+		t.Fatalf("rejected imports left %d workflows, want 1", len(workflows))
+	}
+}
@@ -0,0 +1,172 @@
+// businessdate.go - Business-day calendar arithmetic node
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ============================================
+// Business Date Node
+// ============================================
+
+// BusinessDateExecutor does working-day date math. Properties: operation
+// (addBusinessDays|subtractBusinessDays|isBusinessDay|nextBusinessDay), date
+// (YYYY-MM-DD or RFC3339; blank = today or a string input), days, holidays
+// (YYYY-MM-DD list), weekend (weekday names, default Saturday,Sunday).
+type BusinessDateExecutor struct {
+	clock Clock
+}
+
+// BusinessCalendar decides which days count as working days.
+type BusinessCalendar struct {
+	Weekend  map[time.Weekday]bool
+	Holidays map[string]bool // keyed by YYYY-MM-DD
+}
+
+func (c BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	return !c.Weekend[t.Weekday()] && !c.Holidays[t.Format(dateLayout)]
+}
+
+// AddBusinessDays moves n working days forward (or back for negative n),
+// skipping weekends and holidays.
+func (c BusinessCalendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// NextBusinessDay returns the first working day strictly after t.
+func (c BusinessCalendar) NextBusinessDay(t time.Time) time.Time {
+	return c.AddBusinessDays(t, 1)
+}
+
+func (e *BusinessDateExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	operation, _ := node.Properties["operation"].(string)
+
+	cal, err := businessCalendar(node)
+	if err != nil {
+		return nil, err
+	}
+
+	dateStr, _ := node.Properties["date"].(string)
+	if dateStr == "" {
+		dateStr, _ = input.(string)
+	}
+	date := e.clock.Now()
+	if dateStr != "" {
+		if date, err = parseDate(dateStr); err != nil {
+			return nil, err
+		}
+	}
+
+	days := 0
+	if v, ok := node.Properties["days"]; ok && v != "" && v != nil {
+		if days, err = toInt(v); err != nil {
+			return nil, fmt.Errorf("invalid days: %v", err)
+		}
+	}
+
+	var result time.Time
+	switch operation {
+	case "addBusinessDays":
+		result = cal.AddBusinessDays(date, days)
+	case "subtractBusinessDays":
+		result = cal.AddBusinessDays(date, -days)
+	case "nextBusinessDay":
+		result = cal.NextBusinessDay(date)
+	case "isBusinessDay":
+		return map[string]interface{}{
+			"date":            date.Format(dateLayout),
+			"isBusinessDay":   cal.IsBusinessDay(date),
+			"isHoliday":       cal.Holidays[date.Format(dateLayout)],
+			"isWeekend":       cal.Weekend[date.Weekday()],
+			"nextBusinessDay": cal.NextBusinessDay(date).Format(dateLayout),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported business date operation: %q", operation)
+	}
+
+	return map[string]interface{}{
+		"date":    result.Format(dateLayout),
+		"weekday": result.Weekday().String(),
+	}, nil
+}
+
+func businessCalendar(node *Node) (BusinessCalendar, error) {
+	cal := BusinessCalendar{
+		Weekend:  map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		Holidays: make(map[string]bool),
+	}
+
+	if names := stringList(node.Properties["weekend"]); len(names) > 0 {
+		cal.Weekend = make(map[time.Weekday]bool)
+		for _, name := range names {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return cal, fmt.Errorf("invalid weekend day: %q", name)
+			}
+			cal.Weekend[day] = true
+		}
+		if len(cal.Weekend) == 7 {
+			return cal, fmt.Errorf("weekend cannot cover every day")
+		}
+	}
+
+	for _, h := range stringList(node.Properties["holidays"]) {
+		d, err := time.Parse(dateLayout, h)
+		if err != nil {
+			return cal, fmt.Errorf("invalid holiday %q: expected YYYY-MM-DD", h)
+		}
+		cal.Holidays[d.Format(dateLayout)] = true
+	}
+	return cal, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q: expected YYYY-MM-DD or RFC3339", s)
+	}
+	return t, nil
+}
+
+// toInt accepts JSON numbers and numeric strings, as the UI sends both.
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), nil
+	case int:
+		return n, nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(n))
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
@@ -0,0 +1,68 @@
+// businessdate_test.go - Business-day arithmetic tests
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBusinessDateOperations(t *testing.T) {
+	e := &BusinessDateExecutor{clock: NewFakeClock(time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC))}
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input interface{}
+		want  string
+	}{
+		{"across a weekend", map[string]interface{}{"operation": "addBusinessDays", "date": "2024-03-01", "days": 1.0}, nil, "2024-03-04"},
+		{"across a weekend and holiday", map[string]interface{}{"operation": "addBusinessDays", "date": "2024-03-01", "days": 3.0, "holidays": []interface{}{"2024-03-04"}}, nil, "2024-03-07"},
+		{"subtract back over a weekend", map[string]interface{}{"operation": "subtractBusinessDays", "date": "2024-03-04", "days": "2"}, nil, "2024-02-29"},
+		{"next business day skips holiday", map[string]interface{}{"operation": "nextBusinessDay", "date": "2024-03-01", "holidays": "2024-03-04,2024-03-05"}, nil, "2024-03-06"},
+		{"Friday-Saturday weekend", map[string]interface{}{"operation": "nextBusinessDay", "date": "2024-02-29", "weekend": "fri,sat"}, nil, "2024-03-03"},
+		{"date from input", map[string]interface{}{"operation": "addBusinessDays", "days": 5.0}, "2024-03-04T09:00:00Z", "2024-03-11"},
+		{"date from clock", map[string]interface{}{"operation": "nextBusinessDay"}, nil, "2024-03-04"},
+	}
+	for _, tt := range tests {
+		out, err := e.Execute(context.Background(), &Node{Properties: tt.props}, tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := out.(map[string]interface{})["date"]; got != tt.want {
+			t.Errorf("%s: date = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	e := &BusinessDateExecutor{clock: RealClock{}}
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"operation": "isBusinessDay",
+		"date":      "2024-12-25",
+		"holidays":  []interface{}{"2024-12-25", "2024-12-26"},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.(map[string]interface{})
+	if got["isBusinessDay"] != false || got["isHoliday"] != true || got["isWeekend"] != false || got["nextBusinessDay"] != "2024-12-27" {
+		t.Fatalf("isBusinessDay result = %v", got)
+	}
+}
+
+func TestBusinessDateRejectsBadInput(t *testing.T) {
+	e := &BusinessDateExecutor{clock: RealClock{}}
+	for name, props := range map[string]map[string]interface{}{
+		"bad holiday":      {"operation": "nextBusinessDay", "holidays": "25/12/2024"},
+		"bad weekend":      {"operation": "nextBusinessDay", "weekend": "funday"},
+		"all-week weekend": {"operation": "nextBusinessDay", "weekend": "sun,mon,tue,wed,thu,fri,sat"},
+		"bad date":         {"operation": "nextBusinessDay", "date": "tomorrow"},
+		"bad days":         {"operation": "addBusinessDays", "days": "many"},
+		"unknown op":       {"operation": "addMonths"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+// checkpoint.go - Execution checkpoints and resuming interrupted runs
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrCheckpointNotFound    = errors.New("checkpoint not found")
+	ErrExecutionNotResumable = errors.New("execution cannot be resumed")
+)
+
+// replayUnsafeNodeTypes are node types, besides the outbound ones, whose
+// effects outlive the run, so running one twice is not safe.
+var replayUnsafeNodeTypes = map[NodeType]bool{
+	NodeDatabase:         true,
+	NodeKubernetes:       true,
+	NodeFileWrite:        true,
+	NodeExec:             true,
+	NodeSubWorkflow:      true,
+	NodeLoop:             true,
+	NodeScheduleFollowUp: true,
+}
+
+// sideEffecting reports whether nodes of type t act on the outside world.
+// One interrupted while running is failed on resume rather than run again,
+// since it may already have acted.
+func sideEffecting(t NodeType) bool {
+	return outboundNodeTypes[t] || replayUnsafeNodeTypes[t]
+}
+
+// ============================================
+// Checkpoints
+// ============================================
+
+// Checkpoint is an execution's progress: the workflow as it was when the
+// run started, the run's input and the outcome of every node that has
+// finished. Running names the node in flight when it was saved.
+//
+// The store never sees the owner's secret values: saved inputs and outputs
+// hold a reference, tagged with SecretTag, in place of each one, which is
+// resolved again on resume. The tag is random per run, so data cannot
+// smuggle in a reference of its own.
+type Checkpoint struct {
+	ExecutionID string                     `json:"execution_id"`
+	OwnerID     string                     `json:"owner_id,omitempty"`
+	Workflow    *Workflow                  `json:"workflow"`
+	Input       interface{}                `json:"input,omitempty"`
+	StartTime   time.Time                  `json:"start_time"`
+	Nodes       map[string]*NodeCheckpoint `json:"nodes"`
+	Running     string                     `json:"running,omitempty"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	SecretTag   string                     `json:"secret_tag,omitempty"`
+
+	// sealer swaps the run's secret values for references
+	sealer *strings.Replacer
+}
+
+// CheckpointUpdate is one step of a checkpointed run: Running names the
+// node now in flight, if any, and Node, when set, is the outcome of the
+// node NodeID that just finished.
+type CheckpointUpdate struct {
+	ExecutionID string
+	Running     string
+	NodeID      string
+	Node        *NodeCheckpoint
+	UpdatedAt   time.Time
+}
+
+// NodeCheckpoint is a finished node's outcome. Input and Output are the
+// real values handed downstream, secrets aside; Result is the node's
+// recorded, redacted result.
+type NodeCheckpoint struct {
+	Input     interface{} `json:"input,omitempty"`
+	Output    interface{} `json:"output,omitempty"`
+	Port      string      `json:"port,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Continued bool        `json:"continued,omitempty"`
+	Result    *NodeResult `json:"result"`
+}
+
+// clone copies cp so the copy's node map can change independently.
+func (cp *Checkpoint) clone() *Checkpoint {
+	c := *cp
+	c.Nodes = make(map[string]*NodeCheckpoint, len(cp.Nodes))
+	for id, n := range cp.Nodes {
+		c.Nodes[id] = n
+	}
+	return &c
+}
+
+// node returns the checkpointed outcome of nodeID, if it finished.
+func (cp *Checkpoint) node(nodeID string) *NodeCheckpoint {
+	if cp == nil {
+		return nil
+	}
+	return cp.Nodes[nodeID]
+}
+
+// outcome rebuilds what the node handed its connections.
+func (n *NodeCheckpoint) outcome() *nodeOutcome {
+	o := &nodeOutcome{input: n.Input, output: n.Output, port: n.Port, continued: n.Continued}
+	if n.Error != "" {
+		o.err = errors.New(n.Error)
+	}
+	return o
+}
+
+// secretRef is how a sealed checkpoint refers to the secret name.
+func secretRef(tag, name string) string {
+	return "{{" + tag + ":" + name + "}}"
+}
+
+// sealValue replaces every secret value inside v with its reference.
+func sealValue(sealer *strings.Replacer, v interface{}) interface{} {
+	if sealer == nil || v == nil {
+		return v
+	}
+	return mapStrings(genericJSON(v), sealer.Replace)
+}
+
+// mapStrings applies f to every string inside v, a generic JSON value.
+func mapStrings(v interface{}, f func(string) string) interface{} {
+	switch x := v.(type) {
+	case string:
+		return f(x)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			out[k] = mapStrings(item, f)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, item := range x {
+			out[i] = mapStrings(item, f)
+		}
+		return out
+	}
+	return v
+}
+
+// seal prepares cp to be saved with the secrets of ctx's execution,
+// longest first so a secret that contains another is replaced whole.
+func (cp *Checkpoint) seal(ctx context.Context) {
+	if cp.SecretTag == "" {
+		cp.SecretTag = uuid.New().String()
+	}
+	values := secretsFromContext(ctx)
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		cp.sealer = nil
+		return
+	}
+	sort.Slice(names, func(i, j int) bool { return len(values[names[i]]) > len(values[names[j]]) })
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, values[name], secretRef(cp.SecretTag, name))
+	}
+	cp.sealer = strings.NewReplacer(pairs...)
+}
+
+// sealedNode copies n with its secrets replaced.
+func (cp *Checkpoint) sealedNode(n *NodeCheckpoint) *NodeCheckpoint {
+	sealed := *n
+	sealed.Input = sealValue(cp.sealer, n.Input)
+	sealed.Output = sealValue(cp.sealer, n.Output)
+	return &sealed
+}
+
+// unsealed copies a saved checkpoint with its secret references resolved
+// from values, the owner's current secrets. References to secrets since
+// deleted are left as they are.
+func (cp *Checkpoint) unsealed(values map[string]string) *Checkpoint {
+	c := cp.clone()
+	if cp.SecretTag == "" || len(values) == 0 {
+		return c
+	}
+	pairs := make([]string, 0, 2*len(values))
+	for name, value := range values {
+		pairs = append(pairs, secretRef(cp.SecretTag, name), value)
+	}
+	r := strings.NewReplacer(pairs...)
+	unseal := func(v interface{}) interface{} {
+		if v == nil {
+			return nil
+		}
+		return mapStrings(genericJSON(v), r.Replace)
+	}
+	c.Input = unseal(cp.Input)
+	for id, n := range cp.Nodes {
+		node := *n
+		node.Input, node.Output = unseal(n.Input), unseal(n.Output)
+		c.Nodes[id] = &node
+	}
+	return c
+}
+
+// saveCheckpoint persists the whole of cp, as a run starts. A failure is
+// logged rather than failing the run, which then merely cannot resume
+// from this point.
+func (we *WorkflowExecutor) saveCheckpoint(ctx context.Context, cp *Checkpoint) {
+	if cp == nil || we.checkpoints == nil {
+		return
+	}
+	cp.seal(ctx)
+	cp.UpdatedAt = we.clock.Now()
+	sealed := cp.clone()
+	sealed.Input = sealValue(cp.sealer, cp.Input)
+	for id, n := range cp.Nodes {
+		sealed.Nodes[id] = cp.sealedNode(n)
+	}
+	if err := we.checkpoints.SaveCheckpoint(sealed); err != nil {
+		logf(ctx, "warn", "saving checkpoint failed: %v", err)
+	}
+}
+
+// advanceCheckpoint persists one step of cp's progress: the node now
+// running and, when nodeID is set, that node's outcome. Each step costs
+// the same however far the run has got.
+func (we *WorkflowExecutor) advanceCheckpoint(ctx context.Context, cp *Checkpoint, nodeID string) {
+	if cp == nil || we.checkpoints == nil {
+		return
+	}
+	cp.UpdatedAt = we.clock.Now()
+	u := CheckpointUpdate{ExecutionID: cp.ExecutionID, Running: cp.Running, UpdatedAt: cp.UpdatedAt}
+	if nodeID != "" {
+		u.NodeID, u.Node = nodeID, cp.sealedNode(cp.Nodes[nodeID])
+	}
+	if err := we.checkpoints.UpdateCheckpoint(u); err != nil {
+		logf(ctx, "warn", "saving checkpoint failed: %v", err)
+	}
+}
+
+// ============================================
+// Resuming
+// ============================================
+
+// ResumeExecution continues an interrupted execution in the background
+// from its checkpoint: finished nodes keep their outcomes and the rest
+// run. It keeps its execution ID. Executions that finished, or are still
+// running, report ErrExecutionNotResumable.
+func (we *WorkflowEngine) ResumeExecution(ctx context.Context, executionID string) error {
+	owner := principalFromContext(ctx)
+	cp, err := we.store.Checkpoint(owner, executionID)
+	if errors.Is(err, ErrCheckpointNotFound) {
+		if _, err := we.executions.Get(owner, executionID); err != nil {
+			return err
+		}
+		return ErrExecutionNotResumable
+	}
+	if err != nil {
+		return err
+	}
+	return we.resume(ctx, cp)
+}
+
+// ResumeInterrupted resumes every execution left with a checkpoint, as
+// after a crash or a shutdown that cancelled runs. It returns how many
+// were resumed. Only checkpoints the store kept are found: with the
+// default MemoryStore nothing survives a restart, so this resumes nothing
+// after a crash unless the engine runs with a persistent Store.
+func (we *WorkflowEngine) ResumeInterrupted(ctx context.Context) int {
+	checkpoints, err := we.store.Checkpoints()
+	if err != nil {
+		we.logger.Error("listing checkpoints failed", "error", err)
+		return 0
+	}
+	resumed := 0
+	for _, cp := range checkpoints {
+		if err := we.resume(ctx, cp); err != nil {
+			we.logger.Warn("resuming execution failed", "execution_id", cp.ExecutionID, "error", err)
+			continue
+		}
+		resumed++
+	}
+	return resumed
+}
+
+// resume starts running cp in the background, after a worker is free.
+// Resumed runs bypass the workflow's concurrency policy.
+func (we *WorkflowEngine) resume(ctx context.Context, cp *Checkpoint) error {
+	cp = cp.unsealed(we.secrets.Snapshot(cp.OwnerID))
+	we.runMu.Lock()
+	if _, ok := we.active[cp.ExecutionID]; ok {
+		we.runMu.Unlock()
+		return fmt.Errorf("%w: it is still running", ErrExecutionNotResumable)
+	}
+	// Held until run registers the real cancel function
+	we.active[cp.ExecutionID] = &activeRun{owner: cp.OwnerID, cancel: func(error) {}}
+	we.runMu.Unlock()
+	unmark := func() {
+		we.runMu.Lock()
+		delete(we.active, cp.ExecutionID)
+		we.runMu.Unlock()
+	}
+
+	start, abandon, err := we.enqueue(ctx)
+	if err == nil {
+		if err = we.beginRun(); err != nil {
+			abandon()
+		}
+	}
+	if err != nil {
+		unmark()
+		return err
+	}
+
+	we.executions.Record(cp.OwnerID, &ExecutionResult{
+		ID:         cp.ExecutionID,
+		WorkflowID: cp.Workflow.ID,
+		Status:     StatusRunning,
+		StartTime:  cp.StartTime,
+		Results:    map[string]interface{}{},
+		Errors:     []string{},
+	})
+	go func() {
+		defer we.running.Done()
+		ctx := context.WithoutCancel(ctx)
+		finish, err := start(ctx)
+		if err != nil {
+			unmark()
+			we.executions.Record(cp.OwnerID, &ExecutionResult{
+				ID:         cp.ExecutionID,
+				WorkflowID: cp.Workflow.ID,
+				Status:     StatusFailed,
+				StartTime:  cp.StartTime,
+				EndTime:    we.clock.Now(),
+				Results:    map[string]interface{}{},
+				Errors:     []string{err.Error()},
+			})
+			return
+		}
+		defer finish()
+		we.logger.Info("resuming execution", "execution_id", cp.ExecutionID, "completed_nodes", len(cp.Nodes))
+		we.runFrom(ctx, cp)
+	}()
+	return nil
+}
+
+// ============================================
+// Checkpoint Handlers
+// ============================================
+
+// handleResumeInterrupted resumes an interrupted execution, answering 202
+// with its Location like an asynchronous execute.
+func (s *Server) handleResumeInterrupted(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := s.engine.ResumeExecution(r.Context(), id); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	writeExecution(w, id, nil)
+}
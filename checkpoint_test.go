@@ -0,0 +1,165 @@
+// checkpoint_test.go - Checkpoint and resume tests
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// awaitExecution waits for executionID to finish and returns its result.
+func awaitExecution(t *testing.T, we *WorkflowEngine, owner, executionID string) *ExecutionResult {
+	t.Helper()
+	var result *ExecutionResult
+	eventually(t, "execution to finish", func() bool {
+		result, _ = we.executions.Get(owner, executionID)
+		return result != nil && result.Status != StatusRunning
+	})
+	return result
+}
+
+// onlyCheckpoint returns the single checkpoint in store.
+func onlyCheckpoint(t *testing.T, store Store) *Checkpoint {
+	t.Helper()
+	list, err := store.Checkpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("%d checkpoints saved, want 1", len(list))
+	}
+	return list[0]
+}
+
+// recorderByNode dispatches to a recorder per node ID.
+type recorderByNode map[string]*recorder
+
+func (r recorderByNode) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	return r[node.ID].Execute(ctx, node, input)
+}
+
+func TestResumeFromMidGraphCheckpoint(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := asPrincipal("alice")
+	flow := &Workflow{
+		Name: "three steps",
+		Nodes: []Node{
+			{ID: "a", Type: nodeRecord},
+			{ID: "b", Type: nodeGate},
+			{ID: "c", Type: nodeRecord},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "a", ToID: "b"},
+			{ID: "c2", FromID: "b", ToID: "c"},
+		},
+	}
+	input := map[string]interface{}{"order": 7.0, "token": testSecret}
+
+	// The first process stops while b is running
+	first := newTestEngine(t, WithStore(store), WithNodeExecutor(nodeRecord, &recorder{}), WithNodeExecutor(nodeGate, make(gate)))
+	if err := first.secrets.Set("alice", "TOKEN", testSecret); err != nil {
+		t.Fatal(err)
+	}
+	wf := mustCreate(t, first, ctx, flow)
+	go first.ExecuteWorkflowWithInput(ctx, wf.ID, input)
+	eventually(t, "b to start", func() bool {
+		list, _ := store.Checkpoints()
+		return len(list) == 1 && list[0].Running == "b"
+	})
+	stopCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	first.Shutdown(stopCtx)
+
+	cp := onlyCheckpoint(t, store)
+	if cp.Running != "b" || cp.Nodes["a"] == nil || cp.Nodes["b"] != nil || cp.Nodes["c"] != nil {
+		t.Fatalf("checkpoint running %q with nodes %v, want a done and b in flight", cp.Running, cp.Nodes)
+	}
+	saved, _ := json.Marshal(cp)
+	if strings.Contains(string(saved), testSecret) {
+		t.Fatalf("checkpoint holds a secret value: %s", saved)
+	}
+
+	// The next process picks up from b without running a again
+	a, c := &recorder{}, &recorder{}
+	release := make(gate)
+	close(release)
+	second := newTestEngine(t, WithStore(store), WithNodeExecutor(nodeRecord, recorderByNode{"a": a, "c": c}), WithNodeExecutor(nodeGate, release))
+	if err := second.secrets.Set("alice", "TOKEN", testSecret); err != nil {
+		t.Fatal(err)
+	}
+	if n := second.ResumeInterrupted(context.Background()); n != 1 {
+		t.Fatalf("resumed %d executions, want 1", n)
+	}
+
+	result := awaitExecution(t, second, "alice", cp.ExecutionID)
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if len(a.calls()) != 0 {
+		t.Fatalf("checkpointed node a ran %d more times", len(a.calls()))
+	}
+	got := c.calls()
+	if len(got) != 1 || got[0].(map[string]interface{})["token"] != testSecret {
+		t.Fatalf("c inputs = %v, want the run input with its secret restored", got)
+	}
+	if _, ok := result.Results["a"]; !ok {
+		t.Fatalf("results %v lack the checkpointed node a", result.Results)
+	}
+	if list, _ := store.Checkpoints(); len(list) != 0 {
+		t.Fatalf("%d checkpoints left after the run finished", len(list))
+	}
+}
+
+func TestResumeFailsInterruptedSideEffectingNode(t *testing.T) {
+	store := NewMemoryStore()
+	write, after := &recorder{}, &recorder{}
+	we := newTestEngine(t, WithStore(store), WithNodeExecutor(NodeFileWrite, write), WithNodeExecutor(nodeRecord, after))
+
+	// The process died while the write was in flight
+	err := store.SaveCheckpoint(&Checkpoint{
+		ExecutionID: "exec-1",
+		Workflow: &Workflow{
+			ID:    "wf-1",
+			Name:  "write then record",
+			Nodes: []Node{{ID: "w", Type: NodeFileWrite}, {ID: "r", Type: nodeRecord}},
+			Connections: []Connection{
+				{ID: "c1", FromID: "w", ToID: "r"},
+			},
+		},
+		Nodes:   map[string]*NodeCheckpoint{},
+		Running: "w",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := we.ResumeExecution(context.Background(), "exec-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := awaitExecution(t, we, "", "exec-1")
+	if result.Status != StatusFailed || len(result.Errors) == 0 || !strings.Contains(result.Errors[0], "not run again") {
+		t.Fatalf("status = %s, errors %v; want the write refused", result.Status, result.Errors)
+	}
+	if len(write.calls()) != 0 || len(after.calls()) != 0 {
+		t.Fatalf("write ran %d times and its successor %d, want neither", len(write.calls()), len(after.calls()))
+	}
+}
+
+func TestResumeExecutionRefusesFinishedRuns(t *testing.T) {
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, &recorder{}))
+	wf := mustCreate(t, we, context.Background(), &Workflow{Name: "one", Nodes: []Node{{ID: "a", Type: nodeRecord}}})
+	result, err := we.ExecuteWorkflow(context.Background(), wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := we.ResumeExecution(context.Background(), result.ID); !errors.Is(err, ErrExecutionNotResumable) {
+		t.Fatalf("resume finished run: err = %v, want ErrExecutionNotResumable", err)
+	}
+	if err := we.ResumeExecution(context.Background(), "missing"); !errors.Is(err, ErrExecutionNotFound) {
+		t.Fatalf("resume unknown run: err = %v, want ErrExecutionNotFound", err)
+	}
+}
@@ -0,0 +1,102 @@
+// clock_test.go - Fake clock and engine timestamp tests
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClockReleasesWaitersOnAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	soon := clock.After(time.Second)
+	later := clock.After(time.Minute)
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("After(0) did not fire immediately")
+	}
+	if n := clock.Waiters(); n != 2 {
+		t.Fatalf("waiters = %d, want 2", n)
+	}
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-soon:
+		t.Fatal("waiter fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	if got := <-soon; !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("fired at %v, want %v", got, start.Add(time.Second))
+	}
+	select {
+	case <-later:
+		t.Fatal("later waiter fired early")
+	default:
+	}
+	if n := clock.Waiters(); n != 1 {
+		t.Fatalf("waiters = %d, want 1", n)
+	}
+
+	clock.Advance(time.Hour)
+	<-later
+	if got, want := clock.Now(), start.Add(time.Hour+time.Second); !got.Equal(want) {
+		t.Fatalf("now = %v, want %v", got, want)
+	}
+}
+
+func TestFakeClockSleepBlocksUntilAdvanced(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	woke := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Hour)
+		close(woke)
+	}()
+
+	awaitWaiters(t, clock, 1)
+	select {
+	case <-woke:
+		t.Fatal("Sleep returned before the clock advanced")
+	default:
+	}
+	clock.Advance(time.Hour)
+	<-woke
+}
+
+func TestEngineTimestampsUseClock(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(created)
+	we := newTestEngine(t, WithClock(clock))
+	ctx := context.Background()
+
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name:  "timed",
+		Nodes: []Node{{ID: "timer", Type: NodeTimer, Properties: map[string]interface{}{"interval": 60.0}}},
+	})
+	if !wf.CreatedAt.Equal(created) || !wf.UpdatedAt.Equal(created) {
+		t.Fatalf("created %v updated %v, want both %v", wf.CreatedAt, wf.UpdatedAt, created)
+	}
+
+	clock.Advance(90 * time.Minute)
+	edit := *wf
+	edit.Name = "timed again"
+	if err := we.UpdateWorkflow(ctx, &edit); err != nil {
+		t.Fatal(err)
+	}
+	if want := created.Add(90 * time.Minute); !edit.UpdatedAt.Equal(want) || !edit.CreatedAt.Equal(created) {
+		t.Fatalf("after update created %v updated %v, want %v and %v", edit.CreatedAt, edit.UpdatedAt, created, want)
+	}
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := result.Results["timer"].(map[string]interface{})
+	if fired := out["fired_at"].(time.Time); !fired.Equal(clock.Now()) {
+		t.Fatalf("fired_at = %v, want %v", fired, clock.Now())
+	}
+}
@@ -0,0 +1,152 @@
+// compress.go - Gzip compression of responses
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultCompressMinBytes is the smallest response worth compressing.
+const defaultCompressMinBytes = 1024
+
+// ============================================
+// Compression
+// ============================================
+
+// compress gzips responses for clients that accept it. Responses smaller
+// than CompressMinBytes, already encoded responses and WebSocket upgrades
+// are left alone.
+func (s *Server) compress(next http.Handler) http.Handler {
+	if s.config.DisableCompression {
+		return next
+	}
+	minBytes := s.config.CompressMinBytes
+	if minBytes <= 0 {
+		minBytes = defaultCompressMinBytes
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsGzip(r) || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipResponseWriter{ResponseWriter: w, minBytes: minBytes, status: http.StatusOK}
+		defer gw.Close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether Accept-Encoding lists gzip with a non-zero
+// quality, as "gzip;q=0" refuses it.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(enc, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), "gzip") {
+			continue
+		}
+		accepted := true
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				accepted = err == nil && q > 0
+			}
+		}
+		return accepted
+	}
+	return false
+}
+
+// gzipResponseWriter holds the response back until minBytes have been
+// written, then commits to gzip; a response that ends sooner is written
+// as is.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minBytes int
+	status   int
+	buf      bytes.Buffer
+	gz       *gzip.Writer
+	decided  bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if !g.decided {
+		g.status = code
+	}
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.decided {
+		if g.gz != nil {
+			return g.gz.Write(p)
+		}
+		return g.ResponseWriter.Write(p)
+	}
+	g.buf.Write(p)
+	if g.buf.Len() >= g.minBytes {
+		if err := g.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start sends the header, gzipped or not, followed by the buffered body.
+func (g *gzipResponseWriter) start(gzipped bool) error {
+	g.decided = true
+	h := g.ResponseWriter.Header()
+	if h.Get("Content-Encoding") != "" || g.status == http.StatusNoContent || g.status == http.StatusNotModified {
+		gzipped = false
+	}
+	if gzipped {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+
+	if g.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(g.buf.Bytes())
+	} else {
+		_, err = g.ResponseWriter.Write(g.buf.Bytes())
+	}
+	g.buf.Reset()
+	return err
+}
+
+// Flush sends what has been written so far, committing to gzip if nothing
+// has been sent yet, so streamed responses reach the client as they go.
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		if err := g.start(true); err != nil {
+			return
+		}
+	}
+	if g.gz != nil {
+		if err := g.gz.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes out a response still under the threshold, or finishes the
+// gzip stream.
+func (g *gzipResponseWriter) Close() error {
+	if !g.decided {
+		return g.start(false)
+	}
+	if g.gz != nil {
+		return g.gz.Close()
+	}
+	return nil
+}
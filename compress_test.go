@@ -0,0 +1,150 @@
+// compress_test.go - Response compression tests
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// getRaw fetches path without the transport's transparent gzip handling,
+// so the body comes back exactly as the server sent it.
+func getRaw(t *testing.T, ts *httptest.Server, path, acceptEncoding string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest("GET", ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+// seedWorkflows creates enough workflows for the list to pass the
+// compression threshold.
+func seedWorkflows(t *testing.T, s *Server, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		mustCreate(t, s.engine, context.Background(), &Workflow{
+			Name:  "bulk workflow",
+			Nodes: []Node{{ID: "t", Type: NodeTransform, Name: "transform"}},
+		})
+	}
+}
+
+func TestCompressLargeList(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	seedWorkflows(t, s, 50)
+
+	resp, data := getRaw(t, ts, "/api/workflows", "deflate, gzip")
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+	if !strings.Contains(resp.Header.Get("Vary"), "Accept-Encoding") {
+		t.Errorf("Vary = %q, want Accept-Encoding", resp.Header.Get("Vary"))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unzipped, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unzipped) <= len(data) || !json.Valid(unzipped) {
+		t.Fatalf("gzip body of %d bytes unpacked to %d bytes of invalid or uncompressed JSON", len(data), len(unzipped))
+	}
+
+	for _, accept := range []string{"", "identity", "gzip;q=0"} {
+		resp, plain := getRaw(t, ts, "/api/workflows", accept)
+		if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want none", accept, enc)
+		}
+		// The list comes from a map, so only its size is stable
+		if len(plain) != len(unzipped) {
+			t.Errorf("Accept-Encoding %q: plain body is %d bytes, gzipped one unpacks to %d", accept, len(plain), len(unzipped))
+		}
+	}
+}
+
+func TestCompressSkipsSmallAndDisabled(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	resp, data := getRaw(t, ts, "/api/workflows/missing", "gzip")
+	if resp.Header.Get("Content-Encoding") != "" || !json.Valid(data) {
+		t.Fatalf("small response: Content-Encoding = %q, body %s", resp.Header.Get("Content-Encoding"), data)
+	}
+
+	// A raised threshold leaves a large list uncompressed
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, CompressMinBytes: 1 << 20})
+	seedWorkflows(t, s, 50)
+	if resp, _ := getRaw(t, ts, "/api/workflows", "gzip"); resp.Header.Get("Content-Encoding") != "" {
+		t.Error("response under CompressMinBytes was compressed")
+	}
+
+	s, ts = newTestServer(t, ServerConfig{AuthDisabled: true, DisableCompression: true})
+	seedWorkflows(t, s, 50)
+	if resp, _ := getRaw(t, ts, "/api/workflows", "gzip"); resp.Header.Get("Content-Encoding") != "" {
+		t.Error("DisableCompression still compressed the response")
+	}
+}
+
+func TestCompressLeavesWebSocketAlone(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	header := http.Header{"Accept-Encoding": {"gzip"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", header)
+	if err != nil {
+		t.Fatalf("upgrade with Accept-Encoding: gzip failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.WriteJSON(map[string]string{"type": "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	var pong map[string]interface{}
+	if err := conn.ReadJSON(&pong); err != nil || pong["type"] != "pong" {
+		t.Fatalf("ping over upgraded connection: %v %v", pong, err)
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"gzip;q=bogus", false},
+		{"br, deflate", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(r); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// concurrency.go - Overlapping runs of the same workflow
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Concurrency policies decide what happens when a workflow is triggered
+// while a run of it is in flight.
+const (
+	// ConcurrencyAllow runs every trigger at once (the default)
+	ConcurrencyAllow = "allow"
+	// ConcurrencySkip drops a trigger while a run is active
+	ConcurrencySkip = "skip"
+	// ConcurrencyQueue holds a trigger until the active run finishes
+	ConcurrencyQueue = "queue"
+)
+
+// ErrWorkflowBusy reports a run skipped under ConcurrencySkip.
+var ErrWorkflowBusy = errors.New("workflow is already running")
+
+// validConcurrencyPolicy reports whether policy is a known policy; empty
+// means allow.
+func validConcurrencyPolicy(policy string) bool {
+	switch policy {
+	case "", ConcurrencyAllow, ConcurrencySkip, ConcurrencyQueue:
+		return true
+	}
+	return false
+}
+
+// ============================================
+// Run Slots
+// ============================================
+
+// RunSlots tracks the active guarded run of each workflow: one slot per
+// workflow ID, held for the duration of a run under the skip or queue
+// policy.
+type RunSlots struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func NewRunSlots() *RunSlots {
+	return &RunSlots{slots: make(map[string]chan struct{})}
+}
+
+func (rs *RunSlots) slot(workflowID string) chan struct{} {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	slot, ok := rs.slots[workflowID]
+	if !ok {
+		slot = make(chan struct{}, 1)
+		rs.slots[workflowID] = slot
+	}
+	return slot
+}
+
+// TryAcquire takes the workflow's slot if it is free.
+func (rs *RunSlots) TryAcquire(workflowID string) (release func(), ok bool) {
+	slot := rs.slot(workflowID)
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, true
+	default:
+		return nil, false
+	}
+}
+
+// Acquire waits for the workflow's slot until ctx is done.
+func (rs *RunSlots) Acquire(ctx context.Context, workflowID string) (release func(), err error) {
+	slot := rs.slot(workflowID)
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Remove forgets a deleted workflow's slot.
+func (rs *RunSlots) Remove(workflowID string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.slots, workflowID)
+}
+
+// admit applies w's concurrency policy to a new run, returning the
+// function that ends it. Skipped runs report ErrWorkflowBusy; queued runs
+// wait until the active run finishes, ctx is done or the engine shuts
+// down. Sub-workflow calls are nested in their caller's run and always
+// admitted, so a workflow may call itself whatever its policy.
+func (we *WorkflowEngine) admit(ctx context.Context, w *Workflow) (release func(), err error) {
+	if len(callStackFromContext(ctx)) > 0 {
+		return func() {}, nil
+	}
+	switch w.ConcurrencyPolicy {
+	case ConcurrencySkip:
+		release, ok := we.slots.TryAcquire(w.ID)
+		if !ok {
+			return nil, ErrWorkflowBusy
+		}
+		return release, nil
+	case ConcurrencyQueue:
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(we.stopping, cancel)
+		defer stop()
+		release, err := we.slots.Acquire(ctx, w.ID)
+		if err != nil && we.stopping.Err() != nil {
+			return nil, ErrShuttingDown
+		}
+		return release, err
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,119 @@
+// concurrency_test.go - Concurrency policy tests for overlapping runs
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// activeRuns counts the engine's in-flight executions.
+func activeRuns(we *WorkflowEngine) int {
+	we.runMu.Lock()
+	defer we.runMu.Unlock()
+	return len(we.active)
+}
+
+// triggerAll runs workflow id n times at once and returns each run's error.
+func triggerAll(we *WorkflowEngine, id string, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = we.ExecuteWorkflow(context.Background(), id)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestConcurrencySkipDropsOverlappingRuns(t *testing.T) {
+	release := make(gate)
+	we := newTestEngine(t, WithNodeExecutor(nodeGate, release))
+	wf := mustCreate(t, we, context.Background(), &Workflow{
+		Name:              "nightly",
+		ConcurrencyPolicy: ConcurrencySkip,
+		Nodes:             []Node{{ID: "g", Type: nodeGate}},
+	})
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := we.ExecuteWorkflow(context.Background(), wf.ID)
+		first <- err
+	}()
+	eventually(t, "the first run to start", func() bool { return activeRuns(we) == 1 })
+
+	for i, err := range triggerAll(we, wf.ID, 5) {
+		if !errors.Is(err, ErrWorkflowBusy) {
+			t.Errorf("overlapping trigger %d: err = %v, want ErrWorkflowBusy", i, err)
+		}
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	// The slot is free again once the active run ends
+	if _, err := we.ExecuteWorkflow(context.Background(), wf.ID); err != nil {
+		t.Fatalf("run after the first finished: %v", err)
+	}
+}
+
+func TestConcurrencyQueueSerializesRuns(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		serial bool
+	}{
+		{ConcurrencyQueue, true},
+		{ConcurrencyAllow, false},
+	} {
+		probe := &concurrencyProbe{}
+		we := newTestEngine(t, WithNodeExecutor(nodeBusy, probe))
+		wf := mustCreate(t, we, context.Background(), &Workflow{
+			Name:              tt.policy,
+			ConcurrencyPolicy: tt.policy,
+			Nodes:             []Node{{ID: "b", Type: nodeBusy}},
+		})
+
+		for i, err := range triggerAll(we, wf.ID, 5) {
+			if err != nil {
+				t.Errorf("%s: trigger %d: %v", tt.policy, i, err)
+			}
+		}
+		if serial := probe.peak == 1; serial != tt.serial {
+			t.Errorf("%s: %d runs overlapped, want serial=%v", tt.policy, probe.peak, tt.serial)
+		}
+		if runs := len(we.executions.List("", wf.ID)); runs != 5 {
+			t.Errorf("%s: %d runs recorded, want 5", tt.policy, runs)
+		}
+	}
+}
+
+func TestConcurrencySkipOverAPI(t *testing.T) {
+	release := make(gate)
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeGate: release}})
+	defer close(release)
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name:              "guarded",
+		ConcurrencyPolicy: ConcurrencySkip,
+		Nodes:             []Node{{ID: "g", Type: nodeGate}},
+	})
+
+	path := "/api/workflows/" + wf.ID + "/execute?async=true"
+	if resp, data := doRequest(t, ts, "POST", path, nil); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("first run: %d %s", resp.StatusCode, data)
+	}
+	resp, data := doRequest(t, ts, "POST", path, nil)
+	if resp.StatusCode != http.StatusConflict || apiError(t, data).Code != CodeWorkflowBusy {
+		t.Fatalf("overlapping run: %d %s, want 409 %s", resp.StatusCode, data, CodeWorkflowBusy)
+	}
+
+	resp, data = doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": "bad", "concurrency_policy": "sometimes"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown policy: %d %s, want 400", resp.StatusCode, data)
+	}
+}
@@ -0,0 +1,207 @@
+// convert.go - JSON/XML conversion node
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ============================================
+// Convert Node
+// ============================================
+
+// ConvertExecutor converts the input (or the value at the field path)
+// between JSON and XML. Properties: from, to (json or xml), field, and
+// rootElement (JSON->XML, default "root").
+//
+// XML maps to JSON as follows: an element becomes an object keyed by its
+// child element names, attributes are stored under "@name" and text under
+// "#text"; a child that repeats becomes an array; an element with only
+// text becomes that string. The document is wrapped in an object keyed by
+// the root element name. JSON->XML applies the same rules in reverse.
+type ConvertExecutor struct{}
+
+func (e *ConvertExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	from, _ := node.Properties["from"].(string)
+	to, _ := node.Properties["to"].(string)
+	value := inputAt(node, "field", input)
+
+	switch {
+	case from == "xml" && to == "json":
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("xml input must be a string")
+		}
+		return xmlToJSON(text)
+
+	case from == "json" && to == "xml":
+		if text, ok := value.(string); ok {
+			if err := json.Unmarshal([]byte(text), &value); err != nil {
+				return nil, fmt.Errorf("invalid JSON input: %v", err)
+			}
+		}
+		root, _ := node.Properties["rootElement"].(string)
+		if root == "" {
+			root = "root"
+		}
+		return jsonToXML(root, genericJSON(value))
+
+	case from == to && (from == "json" || from == "xml"):
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported conversion: %q to %q", from, to)
+}
+
+// ============================================
+// XML -> JSON
+// ============================================
+
+func xmlToJSON(text string) (interface{}, error) {
+	dec := xml.NewDecoder(strings.NewReader(text))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("invalid XML: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %v", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			v, err := decodeElement(dec, start)
+			if err != nil {
+				return nil, fmt.Errorf("invalid XML: %v", err)
+			}
+			return map[string]interface{}{start.Name.Local: v}, nil
+		}
+	}
+}
+
+// decodeElement reads everything up to start's end tag.
+func decodeElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	obj := make(map[string]interface{})
+	for _, a := range start.Attr {
+		obj["@"+a.Name.Local] = a.Value
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := decodeElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch existing := obj[name].(type) {
+			case nil:
+				obj[name] = child
+			case []interface{}:
+				obj[name] = append(existing, child)
+			default:
+				obj[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(obj) == 0 {
+				return s, nil
+			}
+			if s != "" {
+				obj["#text"] = s
+			}
+			return obj, nil
+		}
+	}
+}
+
+// ============================================
+// JSON -> XML
+// ============================================
+
+func jsonToXML(root string, value interface{}) (string, error) {
+	// A single-key object names its own root, as xmlToJSON produces
+	if m, ok := value.(map[string]interface{}); ok && len(m) == 1 {
+		for k, v := range m {
+			if !strings.HasPrefix(k, "@") && k != "#text" {
+				root, value = k, v
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := encodeElement(enc, root, value); err != nil {
+		return "", err
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func encodeElement(enc *xml.Encoder, name string, value interface{}) error {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if err := encodeElement(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	obj, isObj := value.(map[string]interface{})
+	if !isObj {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if value != nil {
+			if err := enc.EncodeToken(xml.CharData(exprString(value))); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	// Sorted keys keep the output stable
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if strings.HasPrefix(k, "@") {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: k[1:]}, Value: exprString(obj[k])})
+		}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if text, ok := obj["#text"]; ok {
+		if err := enc.EncodeToken(xml.CharData(exprString(text))); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if strings.HasPrefix(k, "@") || k == "#text" {
+			continue
+		}
+		if err := encodeElement(enc, k, obj[k]); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
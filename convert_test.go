@@ -0,0 +1,117 @@
+// convert_test.go - JSON/XML conversion tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const orderXML = `<?xml version="1.0"?>
+<order id="42" status="open">
+  <customer>
+    <name>Ada</name>
+    <email type="work">ada@example.com</email>
+  </customer>
+  <item sku="A1">Widget</item>
+  <item sku="B2">Gadget</item>
+  <note/>
+</order>`
+
+var orderJSON = map[string]interface{}{
+	"order": map[string]interface{}{
+		"@id":     "42",
+		"@status": "open",
+		"customer": map[string]interface{}{
+			"name":  "Ada",
+			"email": map[string]interface{}{"@type": "work", "#text": "ada@example.com"},
+		},
+		"item": []interface{}{
+			map[string]interface{}{"@sku": "A1", "#text": "Widget"},
+			map[string]interface{}{"@sku": "B2", "#text": "Gadget"},
+		},
+		"note": "",
+	},
+}
+
+// convert runs the convert node from one format to another.
+func convert(t *testing.T, from, to string, props map[string]interface{}, input interface{}) (interface{}, error) {
+	t.Helper()
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	props["from"], props["to"] = from, to
+	return (&ConvertExecutor{}).Execute(context.Background(), &Node{Properties: props}, input)
+}
+
+func TestConvertXMLToJSON(t *testing.T) {
+	out, err := convert(t, "xml", "json", nil, orderXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, orderJSON) {
+		t.Fatalf("got %#v\nwant %#v", out, orderJSON)
+	}
+}
+
+func TestConvertJSONToXML(t *testing.T) {
+	out, err := convert(t, "json", "xml", nil, orderJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<order id="42" status="open">
+  <customer>
+    <email type="work">ada@example.com</email>
+    <name>Ada</name>
+  </customer>
+  <item sku="A1">Widget</item>
+  <item sku="B2">Gadget</item>
+  <note></note>
+</order>`
+	if out != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+
+	// and back again
+	back, err := convert(t, "xml", "json", nil, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, orderJSON) {
+		t.Fatalf("round trip = %#v", back)
+	}
+}
+
+func TestConvertJSONToXMLRootElement(t *testing.T) {
+	out, err := convert(t, "json", "xml", map[string]interface{}{"rootElement": "user", "field": "body"},
+		map[string]interface{}{"body": `{"name": "Ada", "langs": ["go", "c"]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<user>
+  <langs>go</langs>
+  <langs>c</langs>
+  <name>Ada</name>
+</user>`
+	if out != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestConvertRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		from, to string
+		input    interface{}
+	}{
+		{"xml", "json", "<order><item></order>"},
+		{"xml", "json", "just text"},
+		{"xml", "json", 42.0},
+		{"json", "xml", `{"broken": `},
+		{"yaml", "xml", "a: b"},
+	}
+	for _, tt := range tests {
+		if _, err := convert(t, tt.from, tt.to, nil, tt.input); err == nil {
+			t.Errorf("%s->%s %v: expected an error", tt.from, tt.to, tt.input)
+		}
+	}
+}
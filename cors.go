@@ -0,0 +1,87 @@
+// cors.go - Cross-origin access to the API and WebSocket
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// defaultCORSMethods are allowed cross-origin unless configured.
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE"}
+	// defaultCORSHeaders are the request headers the API reads.
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "If-Match", "Idempotency-Key"}
+)
+
+// ============================================
+// CORS
+// ============================================
+
+// allowedOrigin reports whether a browser on origin may call the server.
+// The server's own origin always may; others must be listed in
+// CORSAllowedOrigins, where "*" allows any.
+func (s *Server) allowedOrigin(r *http.Request, origin string) bool {
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range s.config.CORSAllowedOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin is the WebSocket upgrader's origin check. Requests without an
+// Origin header come from non-browser clients and are allowed.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || s.allowedOrigin(r, origin)
+}
+
+// cors answers preflight requests and marks responses readable by allowed
+// origins. Requests from other origins get no CORS headers, so browsers
+// keep them same-origin only; preflights from them are refused with 403.
+func (s *Server) cors(next http.Handler) http.Handler {
+	methods := s.config.CORSAllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := s.config.CORSAllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !s.allowedOrigin(r, origin) {
+			if preflight {
+				writeError(w, http.StatusForbidden, CodeOriginNotAllowed, "origin not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location, Idempotent-Replayed")
+		next.ServeHTTP(w, r)
+	})
+}
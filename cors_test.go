@@ -0,0 +1,117 @@
+// cors_test.go - Cross-origin access tests
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{
+		APIKeys:            map[string]string{"k": "alice"},
+		CORSAllowedOrigins: []string{"https://app.example.com/"},
+	})
+
+	// Preflights carry no credentials, so they are answered before auth
+	resp, data := doRequest(t, ts, "OPTIONS", "/api/workflows", nil,
+		"Origin", "https://app.example.com",
+		"Access-Control-Request-Method", "PUT",
+		"Access-Control-Request-Headers", "Authorization, If-Match")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("preflight: %d %s", resp.StatusCode, data)
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE",
+		"Access-Control-Allow-Headers": "Authorization, Content-Type, If-Match, Idempotency-Key",
+		"Vary":                         "Origin",
+	} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	resp, data = doRequest(t, ts, "OPTIONS", "/api/workflows", nil,
+		"Origin", "https://evil.example.com",
+		"Access-Control-Request-Method", "DELETE")
+	if resp.StatusCode != http.StatusForbidden || apiError(t, data).Code != CodeOriginNotAllowed {
+		t.Fatalf("disallowed preflight: %d %s", resp.StatusCode, data)
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("disallowed origin got an Allow-Origin header")
+	}
+}
+
+func TestCORSActualRequests(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:       true,
+		CORSAllowedOrigins: []string{"https://app.example.com"},
+		CORSAllowedMethods: []string{"GET"},
+		CORSAllowedHeaders: []string{"X-Custom"},
+	})
+	tests := []struct {
+		origin string
+		allow  string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{ts.URL, ts.URL},
+		{"https://evil.example.com", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		resp, _ := doRequest(t, ts, "GET", "/api/workflows", nil, "Origin", tt.origin)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%q: status %d", tt.origin, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.allow {
+			t.Errorf("%q: Allow-Origin = %q, want %q", tt.origin, got, tt.allow)
+		}
+		if tt.allow != "" && !strings.Contains(resp.Header.Get("Access-Control-Expose-Headers"), "ETag") {
+			t.Errorf("%q: ETag not exposed", tt.origin)
+		}
+	}
+
+	resp, _ := doRequest(t, ts, "OPTIONS", "/api/workflows", nil, "Origin", "https://app.example.com", "Access-Control-Request-Method", "GET")
+	if resp.Header.Get("Access-Control-Allow-Methods") != "GET" || resp.Header.Get("Access-Control-Allow-Headers") != "X-Custom" {
+		t.Fatalf("configured preflight headers = %v", resp.Header)
+	}
+}
+
+func TestWebSocketChecksOrigin(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true, CORSAllowedOrigins: []string{"https://app.example.com"}})
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{"https://app.example.com", true},
+		{ts.URL, true},
+		{"", true},
+		{"https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("%q: dial failed: %v", tt.origin, err)
+				continue
+			}
+			conn.Close()
+			continue
+		}
+		if err == nil {
+			conn.Close()
+			t.Errorf("%q: cross-origin WebSocket accepted", tt.origin)
+		} else if resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%q: err = %v, want 403", tt.origin, err)
+		}
+	}
+}
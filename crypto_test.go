@@ -0,0 +1,92 @@
+// crypto_test.go - Hash and HMAC node tests against known vectors
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+const quickFox = "The quick brown fox jumps over the lazy dog"
+
+func TestCryptoKnownVectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input interface{}
+		want  string
+	}{
+		{"sha256 default", nil, "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha256 empty", nil, "", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"sha1", map[string]interface{}{"algorithm": "sha1"}, "abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"md5", map[string]interface{}{"algorithm": "MD5"}, "abc", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha512", map[string]interface{}{"algorithm": "sha512"}, "abc", "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{"base64", map[string]interface{}{"encoding": "base64"}, "abc", "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0="},
+		{"templated value", map[string]interface{}{"algorithm": "md5", "value": "{{ a }}{{ b }}"}, map[string]interface{}{"a": "a", "b": "bc"}, "900150983cd24fb0d6963f7d28e17f72"},
+		{"number", map[string]interface{}{"algorithm": "md5", "value": "{{ n }}"}, map[string]interface{}{"n": 1.0}, "c4ca4238a0b923820dcc509a6f75849b"},
+		{"hmac sha256", map[string]interface{}{"operation": "hmac", "secret": "key"}, quickFox, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"hmac sha1", map[string]interface{}{"operation": "hmac", "secret": "key", "algorithm": "sha1"}, quickFox, "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"},
+		{"hmac md5", map[string]interface{}{"operation": "hmac", "secret": "key", "algorithm": "md5"}, quickFox, "80070713463e7749b90c2dc24911e275"},
+	}
+	e := &CryptoExecutor{}
+	for _, tt := range tests {
+		props := tt.props
+		if props == nil {
+			props = map[string]interface{}{}
+		}
+		out, err := e.Execute(context.Background(), &Node{Properties: props}, tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := out.(map[string]interface{})["digest"]; got != tt.want {
+			t.Errorf("%s: digest = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCryptoSignsWebhookBody(t *testing.T) {
+	// An object is hashed as its JSON, keys sorted, so a flow can check a
+	// sender's signature over the body it received.
+	body := map[string]interface{}{"event": "push", "id": 42.0}
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write([]byte(`{"event":"push","id":42}`))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "verify webhook",
+		Nodes: []Node{{ID: "sig", Type: NodeCrypto, Properties: map[string]interface{}{
+			"operation": "hmac", "secret": "whsec",
+		}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := result.Results["sig"].(map[string]interface{})
+	if out["digest"] != want || out["algorithm"] != "sha256" {
+		t.Fatalf("output = %v, want digest %s", out, want)
+	}
+}
+
+func TestCryptoRejectsBadSettings(t *testing.T) {
+	e := &CryptoExecutor{}
+	for name, props := range map[string]map[string]interface{}{
+		"unknown algorithm": {"algorithm": "crc32"},
+		"hmac without key":  {"operation": "hmac"},
+		"unknown operation": {"operation": "encrypt"},
+		"unknown encoding":  {"encoding": "base32"},
+		"bad template":      {"value": "{{ unclosed"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, "abc"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// csvnodes.go - CSV parse and build nodes
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================
+// CSV Helpers
+// ============================================
+
+// csvDelimiter reads the delimiter property; "\t" and "tab" select tabs.
+func csvDelimiter(node *Node) (rune, error) {
+	d, _ := node.Properties["delimiter"].(string)
+	switch d {
+	case "":
+		return ',', nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(d) != 1 {
+		return 0, fmt.Errorf("delimiter must be a single character")
+	}
+	r, _ := utf8.DecodeRuneInString(d)
+	return r, nil
+}
+
+// csvHeader reports whether the first row is a header (the default).
+func csvHeader(node *Node) bool {
+	if _, set := node.Properties["header"]; !set {
+		return true
+	}
+	return boolProperty(node, "header")
+}
+
+// inputAt returns the value at the path in the node's key property, or the
+// whole input when the property is blank.
+func inputAt(node *Node, key string, input interface{}) interface{} {
+	path, _ := node.Properties[key].(string)
+	if strings.TrimSpace(path) == "" {
+		return input
+	}
+	v, _ := lookupPath(genericJSON(input), path)
+	return v
+}
+
+// ============================================
+// CSV Parse Node
+// ============================================
+
+// CSVParseExecutor parses CSV text (the input, or the string at the field
+// path) into rows. With a header row (the default) each row is an object
+// keyed by column name; otherwise each row is an array of fields. With
+// output set to "table" the result is {"columns": [...], "rows": [...]},
+// keeping the header order for csvBuild. Properties: field, delimiter,
+// header, output.
+type CSVParseExecutor struct{}
+
+func (e *CSVParseExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	text, ok := inputAt(node, "field", input).(string)
+	if !ok {
+		return nil, fmt.Errorf("csvParse needs a string input")
+	}
+	delim, err := csvDelimiter(node)
+	if err != nil {
+		return nil, err
+	}
+	output, _ := node.Properties["output"].(string)
+	switch output {
+	case "", "rows", "table":
+	default:
+		return nil, fmt.Errorf("output must be rows or table")
+	}
+
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+
+	rows := []interface{}{}
+	if !csvHeader(node) {
+		for _, rec := range records {
+			row := make([]interface{}, len(rec))
+			for i, f := range rec {
+				row[i] = f
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	header := []string{}
+	if len(records) > 0 {
+		header, records = records[0], records[1:]
+	}
+	for _, rec := range records {
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i < len(rec) {
+				row[name] = rec[i]
+			} else {
+				row[name] = ""
+			}
+		}
+		rows = append(rows, row)
+	}
+	if output == "table" {
+		columns := make([]interface{}, len(header))
+		for i, name := range header {
+			columns[i] = name
+		}
+		return map[string]interface{}{"columns": columns, "rows": rows}, nil
+	}
+	return rows, nil
+}
+
+// ============================================
+// CSV Build Node
+// ============================================
+
+// CSVBuildExecutor renders an array (the input, or the array at the field
+// path) as CSV text. It also takes a csvParse table, {"columns", "rows"}.
+// Object rows are written under columns, which defaults to the table's
+// columns, then to every key found, sorted; array rows are written as they
+// are. Properties: field, delimiter, header, columns.
+type CSVBuildExecutor struct{}
+
+func (e *CSVBuildExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	data := genericJSON(inputAt(node, "field", input))
+	var tableColumns []string
+	if table, ok := data.(map[string]interface{}); ok {
+		if _, ok := table["rows"].([]interface{}); ok {
+			data = table["rows"]
+			tableColumns = stringList(table["columns"])
+		}
+	}
+	rows, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("csvBuild needs an array input")
+	}
+	delim, err := csvDelimiter(node)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := stringList(node.Properties["columns"])
+	if len(columns) == 0 {
+		columns = tableColumns
+	}
+	if len(columns) == 0 {
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			if m, ok := row.(map[string]interface{}); ok {
+				for k := range m {
+					if !seen[k] {
+						seen[k] = true
+						columns = append(columns, k)
+					}
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Comma = delim
+	if csvHeader(node) && len(columns) > 0 {
+		w.Write(columns)
+	}
+	for i, row := range rows {
+		var rec []string
+		switch r := row.(type) {
+		case map[string]interface{}:
+			rec = make([]string, len(columns))
+			for j, col := range columns {
+				rec[j] = exprString(r[col])
+			}
+		case []interface{}:
+			rec = make([]string, len(r))
+			for j, f := range r {
+				rec[j] = exprString(f)
+			}
+		default:
+			return nil, fmt.Errorf("row %d is not an object or array", i)
+		}
+		w.Write(rec)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,109 @@
+// csvnodes_test.go - CSV parse and build tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const quotedCSV = `name,note,city
+"Smith, Jane","said ""hi""",Oslo
+Bob,"two
+lines",
+`
+
+func TestCSVParseQuotedFields(t *testing.T) {
+	out, err := (&CSVParseExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{}}, quotedCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"name": "Smith, Jane", "note": `said "hi"`, "city": "Oslo"},
+		map[string]interface{}{"name": "Bob", "note": "two\nlines", "city": ""},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("rows = %#v, want %#v", out, want)
+	}
+}
+
+func TestCSVRoundTripIsLossless(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		text  string
+	}{
+		{"header table", map[string]interface{}{"output": "table"}, quotedCSV},
+		{"no header", map[string]interface{}{"header": false}, "a,\"b,c\"\n\"d\ne\",f\n"},
+		{"semicolons", map[string]interface{}{"output": "table", "delimiter": ";"}, "id;label\n1;\"x;y\"\n2;a,b\n"},
+		{"tabs", map[string]interface{}{"output": "table", "delimiter": "tab"}, "k\tv\nx\t\"1\t2\"\n"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		parsed, err := (&CSVParseExecutor{}).Execute(ctx, &Node{Properties: tt.props}, tt.text)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", tt.name, err)
+		}
+		built, err := (&CSVBuildExecutor{}).Execute(ctx, &Node{Properties: tt.props}, parsed)
+		if err != nil {
+			t.Fatalf("%s: build: %v", tt.name, err)
+		}
+		if built != tt.text {
+			t.Errorf("%s: rebuilt\n%q\nwant\n%q", tt.name, built, tt.text)
+		}
+	}
+}
+
+func TestCSVBuildFromObjects(t *testing.T) {
+	rows := map[string]interface{}{"body": []interface{}{
+		map[string]interface{}{"b": 2.0, "a": "x"},
+		map[string]interface{}{"a": "y", "c": true},
+	}}
+	ctx := context.Background()
+
+	out, err := (&CSVBuildExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{"field": "body"}}, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,b,c\nx,2,\ny,,true\n"; out != want {
+		t.Fatalf("all keys sorted: got %q, want %q", out, want)
+	}
+
+	out, err = (&CSVBuildExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{"field": "body", "columns": "c,a", "header": false}}, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ",x\ntrue,y\n"; out != want {
+		t.Fatalf("chosen columns: got %q, want %q", out, want)
+	}
+}
+
+func TestCSVRejectsBadInput(t *testing.T) {
+	ctx := context.Background()
+	for name, run := range map[string]func() error{
+		"parse non-string": func() error {
+			_, err := (&CSVParseExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{}}, 42.0)
+			return err
+		},
+		"parse bad quotes": func() error {
+			_, err := (&CSVParseExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{}}, "a,\"b\nc")
+			return err
+		},
+		"long delimiter": func() error {
+			_, err := (&CSVParseExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{"delimiter": "::"}}, "a")
+			return err
+		},
+		"build non-array": func() error {
+			_, err := (&CSVBuildExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{}}, "a,b")
+			return err
+		},
+		"build scalar row": func() error {
+			_, err := (&CSVBuildExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{}}, []interface{}{"a"})
+			return err
+		},
+	} {
+		if run() == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
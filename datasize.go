@@ -0,0 +1,40 @@
+// datasize.go - Size limits on data passed between nodes
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// defaultMaxNodeDataBytes caps a node's encoded input and output.
+const defaultMaxNodeDataBytes = 10 << 20
+
+// ErrDataTooLarge reports a node input or output over the size limit.
+var ErrDataTooLarge = errors.New("node data exceeds size limit")
+
+// WithNodeDataLimit caps the encoded size of each node's input and output
+// at maxBytes; zero uses defaultMaxNodeDataBytes.
+func WithNodeDataLimit(maxBytes int64) EngineOption {
+	return func(we *WorkflowEngine) { we.maxNodeData = maxBytes }
+}
+
+// ============================================
+// Size Checks
+// ============================================
+
+// checkDataSize fails when v's JSON encoding is over limit bytes. Values
+// that cannot be encoded are left to the node that uses them.
+func checkDataSize(kind string, v interface{}, limit int64) error {
+	if limit <= 0 || v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	if size := int64(len(data)); size > limit {
+		return fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrDataTooLarge, kind, size, limit)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// datasize_test.go - Node data size limit tests
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// bloat is a node executor that outputs a string of its "size" property
+// in bytes.
+type bloat struct{}
+
+func (bloat) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	size, _ := node.Properties["size"].(float64)
+	return strings.Repeat("x", int(size)), nil
+}
+
+const nodeBloat NodeType = "bloat"
+
+func TestOversizedOutputFailsNode(t *testing.T) {
+	rec := &recorder{}
+	we := newTestEngine(t, WithNodeDataLimit(1000), WithNodeExecutor(nodeBloat, bloat{}), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "runaway",
+		Nodes: []Node{
+			{ID: "small", Type: nodeBloat, Properties: map[string]interface{}{"size": 100.0}},
+			{ID: "huge", Type: nodeBloat, Properties: map[string]interface{}{"size": 5000.0}},
+			{ID: "after", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c1", FromID: "huge", ToID: "after"}},
+	})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed || len(result.Errors) != 1 {
+		t.Fatalf("status %s, errors %v", result.Status, result.Errors)
+	}
+	huge := result.Nodes["huge"]
+	if huge.Status != NodeStatusFailed || !strings.Contains(huge.Error, ErrDataTooLarge.Error()) || !strings.Contains(huge.Error, "output is 5002 bytes") {
+		t.Fatalf("huge node = %+v, want a size limit failure", huge)
+	}
+	if _, stored := result.Results["huge"]; stored {
+		t.Error("oversized output stored in results")
+	}
+	if len(result.Results["small"].(string)) != 100 {
+		t.Error("output under the limit not stored")
+	}
+	if calls := rec.calls(); len(calls) != 0 {
+		t.Errorf("downstream ran with %d inputs", len(calls))
+	}
+}
+
+func TestOversizedInputFailsNode(t *testing.T) {
+	rec := &recorder{}
+	we := newTestEngine(t, WithNodeDataLimit(1000), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "fed too much", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, strings.Repeat("x", 2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := result.Nodes["r"]; r.Status != NodeStatusFailed || !strings.Contains(r.Error, "input is 2002 bytes") {
+		t.Fatalf("node = %+v, want an input size failure", r)
+	}
+	if calls := rec.calls(); len(calls) != 0 {
+		t.Fatal("node ran on oversized input")
+	}
+}
+
+func TestCheckDataSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     interface{}
+		limit int64
+		fails bool
+	}{
+		{"nil", nil, 1, false},
+		{"under", "abc", 10, false},
+		{"at limit", "abc", 5, false},
+		{"over", "abcd", 5, true},
+		{"no limit", strings.Repeat("x", 100), 0, false},
+		{"unencodable", make(chan int), 1, false},
+	}
+	for _, tt := range tests {
+		err := checkDataSize("output", tt.v, tt.limit)
+		if fails := errors.Is(err, ErrDataTooLarge); fails != tt.fails {
+			t.Errorf("%s: err = %v, want failure=%v", tt.name, err, tt.fails)
+		}
+	}
+}
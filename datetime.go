@@ -0,0 +1,211 @@
+// datetime.go - Date and time manipulation node
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datetimeLayouts are the named formats the datetime node accepts besides
+// Go layouts; "unix" and "unixMilli" are handled separately.
+var datetimeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"date":        dateLayout,
+	"datetime":    "2006-01-02 15:04:05",
+	"kitchen":     time.Kitchen,
+}
+
+// datetimeAutoLayouts are tried in order when no input format is set.
+var datetimeAutoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	dateLayout,
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// ============================================
+// Date/Time Node
+// ============================================
+
+// DateTimeExecutor reads, shifts, converts and formats timestamps.
+// Properties:
+//   - operation: now, parse, format, add, subtract or convert
+//   - field: path to the value in the input (blank = the input itself)
+//   - inputFormat: RFC3339, date, datetime, unix, unixMilli, another name
+//     in datetimeLayouts or a Go layout; blank tries common formats and
+//     takes numbers as Unix seconds
+//   - fromTimezone: zone for values that carry no offset (default UTC)
+//   - duration: for add and subtract, e.g. "24h" or "90m"
+//   - timezone: zone the result is converted to (default: as parsed)
+//   - outputFormat: as inputFormat, default RFC3339
+//   - outputField: when set and the input is an object, the input is
+//     output with this field set to the formatted result
+//
+// Without outputField the output is {value, unix, unixMilli, timezone,
+// year, month, day, hour, minute, second, weekday}.
+type DateTimeExecutor struct {
+	clock Clock
+}
+
+func (e *DateTimeExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	str := func(name string) string {
+		v, _ := node.Properties[name].(string)
+		return strings.TrimSpace(v)
+	}
+
+	from := time.UTC
+	if name := str("fromTimezone"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fromTimezone: %v", err)
+		}
+		from = loc
+	}
+
+	var t time.Time
+	operation := str("operation")
+	switch operation {
+	case "now":
+		t = e.clock.Now().In(from)
+	case "", "parse", "format", "add", "subtract", "convert":
+		var err error
+		if t, err = parseDateTime(inputAt(node, "field", input), str("inputFormat"), from); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported datetime operation: %q", operation)
+	}
+
+	if operation == "add" || operation == "subtract" {
+		d, err := parseDelay(node.Properties["duration"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %v", err)
+		}
+		if operation == "subtract" {
+			d = -d
+		}
+		t = t.Add(d)
+	}
+	if name := str("timezone"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %v", err)
+		}
+		t = t.In(loc)
+	} else if operation == "convert" {
+		return nil, fmt.Errorf("timezone is required to convert")
+	}
+
+	formatted := formatDateTime(t, str("outputFormat"))
+	if field := str("outputField"); field != "" {
+		if m, ok := genericJSON(input).(map[string]interface{}); ok {
+			out := make(map[string]interface{}, len(m)+1)
+			for k, v := range m {
+				out[k] = v
+			}
+			out[field] = formatted
+			return out, nil
+		}
+	}
+	return map[string]interface{}{
+		"value":     formatted,
+		"unix":      t.Unix(),
+		"unixMilli": t.UnixMilli(),
+		"timezone":  t.Location().String(),
+		"year":      t.Year(),
+		"month":     int(t.Month()),
+		"day":       t.Day(),
+		"hour":      t.Hour(),
+		"minute":    t.Minute(),
+		"second":    t.Second(),
+		"weekday":   t.Weekday().String(),
+	}, nil
+}
+
+// parseDateTime reads a timestamp in format, or in any common format when
+// format is blank. Values without an offset are taken to be in loc.
+func parseDateTime(v interface{}, format string, loc *time.Location) (time.Time, error) {
+	switch format {
+	case "unix", "unixMilli":
+		var n float64
+		switch x := v.(type) {
+		case float64:
+			n = x
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid %s timestamp %q", format, x)
+			}
+			n = f
+		default:
+			return time.Time{}, fmt.Errorf("invalid %s timestamp: %v", format, v)
+		}
+		if format == "unixMilli" {
+			return time.UnixMilli(int64(n)).In(loc), nil
+		}
+		return time.Unix(0, int64(n*float64(time.Second))).In(loc), nil
+	}
+
+	var s string
+	switch x := genericJSON(v).(type) {
+	case string:
+		s = strings.TrimSpace(x)
+	case float64:
+		if format == "" {
+			return time.Unix(0, int64(x*float64(time.Second))).In(loc), nil
+		}
+		s = exprString(x)
+	case nil:
+		return time.Time{}, fmt.Errorf("no date/time value to read")
+	default:
+		return time.Time{}, fmt.Errorf("date/time value must be a string or number, got %T", v)
+	}
+
+	if format != "" {
+		layout := format
+		if named, ok := datetimeLayouts[format]; ok {
+			layout = named
+		}
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q does not match format %q", s, format)
+		}
+		return t, nil
+	}
+	for _, layout := range datetimeAutoLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(n*float64(time.Second))).In(loc), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date/time %q; set inputFormat", s)
+}
+
+// formatDateTime writes t in format, RFC3339 when blank. Unix formats
+// yield numbers.
+func formatDateTime(t time.Time, format string) interface{} {
+	switch format {
+	case "":
+		return t.Format(time.RFC3339)
+	case "unix":
+		return t.Unix()
+	case "unixMilli":
+		return t.UnixMilli()
+	}
+	if named, ok := datetimeLayouts[format]; ok {
+		return t.Format(named)
+	}
+	return t.Format(format)
+}
@@ -0,0 +1,133 @@
+// datetime_test.go - Date/time node tests for parsing, shifting and time zones
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// runDateTime executes a datetime node with props against input.
+func runDateTime(t *testing.T, props map[string]interface{}, input interface{}) map[string]interface{} {
+	t.Helper()
+	e := &DateTimeExecutor{clock: NewFakeClock(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC))}
+	out, err := e.Execute(context.Background(), &Node{Properties: props}, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.(map[string]interface{})
+}
+
+func TestDateTimeParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input interface{}
+		unix  int64
+	}{
+		{"RFC3339 with offset", nil, "2024-03-04T09:30:00+02:00", 1709537400},
+		{"date in a zone", map[string]interface{}{"fromTimezone": "America/New_York"}, "2024-03-04", 1709528400},
+		{"datetime without offset", nil, "2024-03-04 07:30:00", 1709537400},
+		{"custom layout", map[string]interface{}{"inputFormat": "02/01/2006 15:04"}, "04/03/2024 07:30", 1709537400},
+		{"named layout", map[string]interface{}{"inputFormat": "RFC1123"}, "Mon, 04 Mar 2024 07:30:00 UTC", 1709537400},
+		{"unix number", nil, 1709537400.0, 1709537400},
+		{"unixMilli string", map[string]interface{}{"inputFormat": "unixMilli"}, "1709537400000", 1709537400},
+		{"field path", map[string]interface{}{"field": "event.at"}, map[string]interface{}{"event": map[string]interface{}{"at": "2024-03-04T07:30:00Z"}}, 1709537400},
+	}
+	for _, tt := range tests {
+		props := map[string]interface{}{"operation": "parse"}
+		for k, v := range tt.props {
+			props[k] = v
+		}
+		if got := runDateTime(t, props, tt.input); got["unix"] != tt.unix {
+			t.Errorf("%s: unix = %v, want %d (%v)", tt.name, got["unix"], tt.unix, got["value"])
+		}
+	}
+
+	out := runDateTime(t, map[string]interface{}{"operation": "parse"}, "2024-03-04T09:30:00+02:00")
+	if out["year"] != 2024 || out["month"] != 3 || out["day"] != 4 || out["hour"] != 9 || out["weekday"] != "Monday" {
+		t.Fatalf("parts = %v", out)
+	}
+}
+
+func TestDateTimeAdd24Hours(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input string
+		want  string
+	}{
+		{"add", map[string]interface{}{"operation": "add", "duration": "24h"}, "2024-02-28T10:00:00Z", "2024-02-29T10:00:00Z"},
+		// 24 hours is not a calendar day when the clocks change
+		{"across DST", map[string]interface{}{"operation": "add", "duration": "24h", "fromTimezone": "America/New_York"}, "2024-03-09 12:00:00", "2024-03-10T13:00:00-04:00"},
+		{"subtract", map[string]interface{}{"operation": "subtract", "duration": "90m"}, "2024-01-01T00:30:00Z", "2023-12-31T23:00:00Z"},
+		{"seconds", map[string]interface{}{"operation": "add", "duration": 3600.0, "outputFormat": "datetime"}, "2024-01-01T00:00:00Z", "2024-01-01 01:00:00"},
+	}
+	for _, tt := range tests {
+		if got := runDateTime(t, tt.props, tt.input)["value"]; got != tt.want {
+			t.Errorf("%s: %s = %v, want %s", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDateTimeConvertTimezones(t *testing.T) {
+	tests := []struct {
+		from, zone, input, want string
+	}{
+		{"", "Asia/Tokyo", "2024-07-01T09:00:00Z", "2024-07-01T18:00:00+09:00"},
+		{"", "America/Los_Angeles", "2024-07-01T09:00:00Z", "2024-07-01T02:00:00-07:00"},
+		{"", "America/Los_Angeles", "2024-01-01T09:00:00Z", "2024-01-01T01:00:00-08:00"},
+		{"Europe/London", "UTC", "2024-07-01 09:00:00", "2024-07-01T08:00:00Z"},
+		{"Asia/Kolkata", "Europe/Berlin", "2024-07-01 12:00:00", "2024-07-01T08:30:00+02:00"},
+	}
+	for _, tt := range tests {
+		out := runDateTime(t, map[string]interface{}{"operation": "convert", "fromTimezone": tt.from, "timezone": tt.zone}, tt.input)
+		if out["value"] != tt.want || out["timezone"] != tt.zone {
+			t.Errorf("%s from %q to %s = %v (%v), want %s", tt.input, tt.from, tt.zone, out["value"], out["timezone"], tt.want)
+		}
+	}
+}
+
+func TestDateTimeNowIntoField(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC))
+	we := newTestEngine(t, WithClock(clock))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "stamp",
+		Nodes: []Node{{ID: "now", Type: NodeDateTime, Properties: map[string]interface{}{
+			"operation": "now", "timezone": "Asia/Tokyo", "outputFormat": "2006-01-02 15:04 MST", "outputField": "receivedAt",
+		}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"id": "e1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := result.Results["now"].(map[string]interface{})
+	if out["id"] != "e1" || out["receivedAt"] != "2024-03-04 21:00 JST" {
+		t.Fatalf("output = %v", out)
+	}
+}
+
+func TestDateTimeRejectsBadInput(t *testing.T) {
+	e := &DateTimeExecutor{clock: RealClock{}}
+	for name, tt := range map[string]struct {
+		props map[string]interface{}
+		input interface{}
+	}{
+		"unknown operation":   {map[string]interface{}{"operation": "round"}, "2024-03-04"},
+		"unrecognised value":  {map[string]interface{}{"operation": "parse"}, "next tuesday"},
+		"wrong format":        {map[string]interface{}{"inputFormat": "date"}, "04/03/2024"},
+		"missing field":       {map[string]interface{}{"field": "at"}, map[string]interface{}{}},
+		"bad duration":        {map[string]interface{}{"operation": "add", "duration": "a while"}, "2024-03-04"},
+		"convert without tz":  {map[string]interface{}{"operation": "convert"}, "2024-03-04"},
+		"unknown timezone":    {map[string]interface{}{"timezone": "Mars/Olympus"}, "2024-03-04"},
+		"unknown source zone": {map[string]interface{}{"fromTimezone": "Nowhere"}, "2024-03-04"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: tt.props}, tt.input); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// dedupe.go - Remove duplicate array items
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ============================================
+// Dedupe Node
+// ============================================
+
+// DedupeExecutor removes duplicates from the input array (or the array at
+// the optional items path), keeping the first of each in its original
+// position. Items are compared by the value at field, e.g. "email" or
+// "user.id", or whole when field is blank; values are equal when their
+// JSON is, so numbers, strings and nested objects compare by content.
+// Items missing the field are always kept.
+type DedupeExecutor struct{}
+
+func (e *DedupeExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	value := genericJSON(input)
+	if path, _ := node.Properties["items"].(string); strings.TrimSpace(path) != "" {
+		value, _ = lookupPath(value, path)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dedupe needs an array input, got %T", value)
+	}
+	field, _ := node.Properties["field"].(string)
+	field = strings.TrimSpace(field)
+
+	seen := make(map[string]bool, len(items))
+	kept := []interface{}{}
+	for i, item := range items {
+		key := item
+		if field != "" {
+			var found bool
+			if key, found = lookupPath(item, field); !found {
+				kept = append(kept, item)
+				continue
+			}
+		}
+		data, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", i, err)
+		}
+		if seen[string(data)] {
+			continue
+		}
+		seen[string(data)] = true
+		kept = append(kept, item)
+	}
+	logf(ctx, "info", "kept %d of %d items", len(kept), len(items))
+	return kept, nil
+}
@@ -0,0 +1,95 @@
+// dedupe_test.go - Dedupe node tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeByKeyField(t *testing.T) {
+	// Contacts merged from two sources, the CRM listed first
+	contacts := []interface{}{
+		map[string]interface{}{"email": "ada@example.com", "source": "crm"},
+		map[string]interface{}{"email": "grace@example.com", "source": "crm"},
+		map[string]interface{}{"email": "ada@example.com", "source": "newsletter"},
+		map[string]interface{}{"name": "no email", "source": "newsletter"},
+		map[string]interface{}{"email": "linus@example.com", "source": "newsletter"},
+		map[string]interface{}{"email": "grace@example.com", "source": "newsletter"},
+		map[string]interface{}{"name": "no email", "source": "newsletter"},
+	}
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name:  "unique contacts",
+		Nodes: []Node{{ID: "d", Type: NodeDedupe, Properties: map[string]interface{}{"field": "email"}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, contacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	// First seen wins and keeps its position; items without the field stay
+	want := []interface{}{contacts[0], contacts[1], contacts[3], contacts[4], contacts[6]}
+	if got := result.Results["d"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("kept %v, want %v", got, want)
+	}
+}
+
+func TestDedupeByNestedKey(t *testing.T) {
+	input := map[string]interface{}{"orders": []interface{}{
+		map[string]interface{}{"id": "o1", "user": map[string]interface{}{"id": 7.0}},
+		map[string]interface{}{"id": "o2", "user": map[string]interface{}{"id": 8.0}},
+		map[string]interface{}{"id": "o3", "user": map[string]interface{}{"id": 7.0}},
+		// A string key is not the same value as a number
+		map[string]interface{}{"id": "o4", "user": map[string]interface{}{"id": "7"}},
+	}}
+	got, err := (&DedupeExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"items": "orders", "field": "user.id",
+	}}, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []interface{}
+	for _, o := range got.([]interface{}) {
+		ids = append(ids, o.(map[string]interface{})["id"])
+	}
+	if want := []interface{}{"o1", "o2", "o4"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("kept %v, want %v", ids, want)
+	}
+}
+
+func TestDedupeWholeItems(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"a": 1.0, "b": []interface{}{"x", "y"}},
+		"text",
+		map[string]interface{}{"b": []interface{}{"x", "y"}, "a": 1.0},
+		map[string]interface{}{"a": 1.0, "b": []interface{}{"y", "x"}},
+		1.0,
+		"text",
+		"1",
+		1.0,
+		nil,
+		nil,
+	}
+	got, err := (&DedupeExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{}}, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{items[0], "text", items[3], 1.0, "1", nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("kept %v, want %v", got, want)
+	}
+}
+
+func TestDedupeRejectsNonArrays(t *testing.T) {
+	e := &DedupeExecutor{}
+	if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{}}, "abc"); err == nil {
+		t.Error("string input: expected an error")
+	}
+	if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"items": "missing"}}, map[string]interface{}{}); err == nil {
+		t.Error("missing items path: expected an error")
+	}
+}
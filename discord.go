@@ -0,0 +1,183 @@
+// discord.go - Discord webhook node
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxDiscordRetries bounds how often a rate-limited post is retried.
+	maxDiscordRetries = 3
+	// maxDiscordWait is the longest rate-limit wait honoured; longer ones
+	// fail the node instead.
+	maxDiscordWait = time.Minute
+)
+
+// ============================================
+// Discord Node
+// ============================================
+
+// DiscordExecutor posts a message to a Discord webhook. Properties:
+// webhook (URL), content, embeds (JSON array of embed objects), username
+// and avatarUrl. String values in content and embeds are templates
+// rendered against the input. A 429 response is retried after the wait
+// Discord asks for; other non-2xx responses fail the node.
+type DiscordExecutor struct {
+	client HTTPDoer
+	clock  Clock
+}
+
+func (e *DiscordExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	webhook, _ := node.Properties["webhook"].(string)
+	u, err := url.Parse(strings.TrimSpace(webhook))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("webhook must be a Discord webhook URL")
+	}
+	q := u.Query()
+	q.Set("wait", "true")
+	u.RawQuery = q.Encode()
+
+	payload := make(map[string]interface{})
+	if content, _ := node.Properties["content"].(string); content != "" {
+		rendered, err := renderTemplate(content, input)
+		if err != nil {
+			return nil, fmt.Errorf("content: %v", err)
+		}
+		payload["content"] = exprString(rendered)
+	}
+	if embeds, err := discordEmbeds(node, input); err != nil {
+		return nil, err
+	} else if embeds != nil {
+		payload["embeds"] = embeds
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("content or embeds is required")
+	}
+	if username, _ := node.Properties["username"].(string); username != "" {
+		payload["username"] = username
+	}
+	if avatar, _ := node.Properties["avatarUrl"].(string); avatar != "" {
+		payload["avatar_url"] = avatar
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode message: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		status, data, wait, err := e.post(ctx, u.String(), body)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusTooManyRequests {
+			if attempt >= maxDiscordRetries {
+				return nil, fmt.Errorf("discord rate limit: still limited after %d retries", maxDiscordRetries)
+			}
+			if wait > maxDiscordWait {
+				return nil, fmt.Errorf("discord rate limit: asked to wait %s", wait)
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-e.clock.After(wait):
+			}
+			continue
+		}
+		if status < 200 || status > 299 {
+			msg := string(data)
+			if len(msg) > 200 {
+				msg = msg[:200] + "..."
+			}
+			return nil, withStatus(status, fmt.Errorf("discord returned %d: %s", status, msg))
+		}
+
+		var message struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(data, &message)
+		return map[string]interface{}{
+			"status":     "delivered",
+			"statusCode": status,
+			"messageId":  message.ID,
+			"attempts":   attempt + 1,
+		}, nil
+	}
+}
+
+// post sends one request, returning the response status and body and, for
+// a 429, how long Discord asks to wait.
+func (e *DiscordExecutor) post(ctx context.Context, endpoint string, body []byte) (int, []byte, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("discord: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("discord: %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return resp.StatusCode, data, 0, nil
+	}
+	return resp.StatusCode, data, discordRetryAfter(resp.Header, data), nil
+}
+
+// discordRetryAfter reads the wait a 429 asks for: the Retry-After or
+// X-RateLimit-Reset-After header, or the body's retry_after, all in
+// (possibly fractional) seconds. It defaults to one second.
+func discordRetryAfter(h http.Header, body []byte) time.Duration {
+	for _, name := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		if secs, err := strconv.ParseFloat(h.Get(name), 64); err == nil && secs >= 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	var limited struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &limited) == nil && limited.RetryAfter > 0 {
+		return time.Duration(limited.RetryAfter * float64(time.Second))
+	}
+	return time.Second
+}
+
+// discordEmbeds reads the embeds property, rendering its strings.
+func discordEmbeds(node *Node, input interface{}) ([]interface{}, error) {
+	var embeds []interface{}
+	switch v := node.Properties["embeds"].(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		embeds = v
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		if err := json.Unmarshal([]byte(v), &embeds); err != nil {
+			return nil, fmt.Errorf("invalid embeds JSON: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("embeds must be an array")
+	}
+	if len(embeds) == 0 {
+		return nil, nil
+	}
+	rendered, err := renderTemplates(embeds, input)
+	if err != nil {
+		return nil, fmt.Errorf("embeds: %v", err)
+	}
+	return rendered.([]interface{}), nil
+}
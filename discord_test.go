@@ -0,0 +1,202 @@
+// discord_test.go - Discord webhook node tests against a fake webhook
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// discordHook is a fake webhook. It answers the first limited posts with
+// a 429 asking for a two second wait, then accepts, keeping every payload
+// and query it received.
+type discordHook struct {
+	limited int
+
+	mu       sync.Mutex
+	payloads []map[string]interface{}
+	queries  []string
+}
+
+func (h *discordHook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&payload)
+	h.mu.Lock()
+	h.payloads = append(h.payloads, payload)
+	h.queries = append(h.queries, r.URL.RawQuery)
+	n := len(h.payloads)
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if n <= h.limited {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 2}`))
+		return
+	}
+	w.Write([]byte(`{"id": "1100", "channel_id": "42"}`))
+}
+
+func (h *discordHook) posts() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.payloads)
+}
+
+func TestDiscordPostsMessage(t *testing.T) {
+	hook := &discordHook{}
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "announce",
+		Nodes: []Node{{ID: "post", Type: NodeDiscord, Properties: map[string]interface{}{
+			"webhook":   srv.URL + "/api/webhooks/1/token",
+			"content":   "Deploy of {{ service }} finished",
+			"embeds":    `[{"title": "{{ service }}", "fields": [{"name": "version", "value": "{{ version }}"}]}]`,
+			"username":  "Release Bot",
+			"avatarUrl": "https://example.test/bot.png",
+		}}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"service": "api", "version": "1.4.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	want := map[string]interface{}{"status": "delivered", "statusCode": 200, "messageId": "1100", "attempts": 1}
+	if got := result.Results["post"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+
+	payload := map[string]interface{}{
+		"content": "Deploy of api finished",
+		"embeds": []interface{}{map[string]interface{}{
+			"title":  "api",
+			"fields": []interface{}{map[string]interface{}{"name": "version", "value": "1.4.2"}},
+		}},
+		"username":   "Release Bot",
+		"avatar_url": "https://example.test/bot.png",
+	}
+	if !reflect.DeepEqual(hook.payloads[0], payload) {
+		t.Fatalf("payload = %v, want %v", hook.payloads[0], payload)
+	}
+	// wait=true makes Discord answer with the created message
+	if hook.queries[0] != "wait=true" {
+		t.Fatalf("query = %q, want wait=true", hook.queries[0])
+	}
+}
+
+func TestDiscordWaitsOutRateLimit(t *testing.T) {
+	hook := &discordHook{limited: 1}
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := &DiscordExecutor{client: srv.Client(), clock: clock}
+	node := &Node{Properties: map[string]interface{}{"webhook": srv.URL, "content": "hi"}}
+
+	type outcome struct {
+		out interface{}
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		out, err := e.Execute(context.Background(), node, nil)
+		done <- outcome{out, err}
+	}()
+
+	awaitWaiters(t, clock, 1)
+	if n := hook.posts(); n != 1 {
+		t.Fatalf("%d posts before the wait, want 1", n)
+	}
+	clock.Advance(2 * time.Second)
+
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if got := res.out.(map[string]interface{}); got["attempts"] != 2 || got["messageId"] != "1100" {
+		t.Fatalf("output = %v", got)
+	}
+}
+
+func TestDiscordGivesUpWhenStillLimited(t *testing.T) {
+	hook := &discordHook{limited: maxDiscordRetries + 1}
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := &DiscordExecutor{client: srv.Client(), clock: clock}
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"webhook": srv.URL, "content": "hi"}}, nil)
+		done <- err
+	}()
+	for i := 0; i < maxDiscordRetries; i++ {
+		awaitWaiters(t, clock, 1)
+		clock.Advance(2 * time.Second)
+	}
+
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "still limited") {
+		t.Fatalf("err = %v, want a rate limit error", err)
+	}
+	if n := hook.posts(); n != maxDiscordRetries+1 {
+		t.Fatalf("%d posts, want %d", n, maxDiscordRetries+1)
+	}
+}
+
+func TestDiscordFailsOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Unknown Webhook", "code": 10015}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	e := &DiscordExecutor{client: srv.Client(), clock: RealClock{}}
+	_, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"webhook": srv.URL, "content": "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "discord returned 404") || !strings.Contains(err.Error(), "Unknown Webhook") {
+		t.Fatalf("err = %v, want the 404 and Discord's message", err)
+	}
+
+	for name, props := range map[string]map[string]interface{}{
+		"no webhook":  {"content": "hi"},
+		"no content":  {"webhook": srv.URL},
+		"bad embeds":  {"webhook": srv.URL, "embeds": "{not json"},
+		"embeds type": {"webhook": srv.URL, "embeds": 3.0},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDiscordRetryAfter(t *testing.T) {
+	tests := []struct {
+		header, value, body string
+		want                time.Duration
+	}{
+		{"Retry-After", "3", "", 3 * time.Second},
+		{"X-RateLimit-Reset-After", "0.25", "", 250 * time.Millisecond},
+		{"", "", `{"retry_after": 1.5}`, 1500 * time.Millisecond},
+		{"", "", `{}`, time.Second},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.header != "" {
+			h.Set(tt.header, tt.value)
+		}
+		if got := discordRetryAfter(h, []byte(tt.body)); got != tt.want {
+			t.Errorf("discordRetryAfter(%s=%q, %s) = %s, want %s", tt.header, tt.value, tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// events.go - Execution events and live log streaming
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ============================================
+// Execution Events
+// ============================================
+
+const (
+	EventExecutionUpdate = "execution_update"
+	EventNodeUpdate      = "node_update"
+	EventLog             = "log"
+	EventApprovalRequest = "approval_request"
+)
+
+// ExecutionEvent is emitted while a workflow runs. Field names follow the
+// camelCase the front-end already reads.
+type ExecutionEvent struct {
+	Type        string    `json:"type"`
+	WorkflowID  string    `json:"workflowId"`
+	ExecutionID string    `json:"executionId"`
+	NodeID      string    `json:"nodeId,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Level       string    `json:"level,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Time        time.Time `json:"time"`
+	// OwnerID is the principal the run acts for; only its clients see
+	// the event
+	OwnerID string `json:"-"`
+}
+
+// EventPublisher receives execution events. Publish is called inline by the
+// executor and should return promptly.
+type EventPublisher interface {
+	Publish(ev ExecutionEvent)
+}
+
+const (
+	executionIDKey contextKey = "executionID"
+	nodeIDKey      contextKey = "nodeID"
+	publisherKey   contextKey = "publisher"
+	clockKey       contextKey = "clock"
+)
+
+func executionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(executionIDKey).(string)
+	return id
+}
+
+func nodeIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(nodeIDKey).(string)
+	return id
+}
+
+// clockFromContext returns the engine clock of the running execution,
+// or the real clock outside one.
+func clockFromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey).(Clock); ok {
+		return c
+	}
+	return RealClock{}
+}
+
+// emit publishes an event stamped with the execution identity in ctx.
+func emit(ctx context.Context, ev ExecutionEvent) {
+	p, _ := ctx.Value(publisherKey).(EventPublisher)
+	if p == nil {
+		return
+	}
+	ev.WorkflowID = workflowIDFromContext(ctx)
+	ev.ExecutionID = executionIDFromContext(ctx)
+	ev.OwnerID = principalFromContext(ctx)
+	if ev.NodeID == "" {
+		ev.NodeID = nodeIDFromContext(ctx)
+	}
+	ev.Time = clockFromContext(ctx).Now()
+	p.Publish(ev)
+}
+
+// logf records a log line for the running node. Executors call it to
+// surface progress to subscribed clients; the line is also written to the
+// structured log.
+func logf(ctx context.Context, level, format string, args ...interface{}) {
+	msg := redactString(ctx, fmt.Sprintf(format, args...))
+	loggerFromContext(ctx).Log(ctx, slogLevel(level), msg)
+	emit(ctx, ExecutionEvent{
+		Type:    EventLog,
+		Level:   level,
+		Message: msg,
+	})
+}
+
+func slogLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// ============================================
+// WebSocket Hub
+// ============================================
+
+const (
+	// wsWriteWait bounds each write to a client.
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageBytes caps messages read from a client.
+	wsMaxMessageBytes = 64 << 10
+	// wsSendBuffer is how many messages may queue for a client before it
+	// is considered too slow and disconnected.
+	wsSendBuffer = 256
+)
+
+// Keepalive timing; variables so tests can shorten them.
+var (
+	// wsPongWait is how long a client may stay silent, pongs included.
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod is how often clients are pinged; shorter than wsPongWait
+	// so a live client's pong arrives in time.
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+var errClientClosed = errors.New("websocket client closed")
+
+// Hub fans execution events out to WebSocket clients. Clients subscribe to
+// individual workflows, or to everything with an empty workflow ID; either
+// way they only see events of runs acting for their own principal.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*hubClient]bool
+}
+
+// hubClient is one WebSocket connection. Only its writer goroutine writes
+// to conn; everything else queues messages on out.
+type hubClient struct {
+	// principal is who the connection authenticated as; "" when auth is
+	// disabled, which sees every event
+	principal string
+	conn      *websocket.Conn
+	out       chan interface{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu   sync.Mutex
+	subs map[string]bool
+	all  bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*hubClient]bool),
+	}
+}
+
+func (h *Hub) add(conn *websocket.Conn, principal string) *hubClient {
+	c := &hubClient{
+		principal: principal,
+		conn:      conn,
+		out:       make(chan interface{}, wsSendBuffer),
+		done:      make(chan struct{}),
+		subs:      make(map[string]bool),
+	}
+	h.mu.Lock()
+	h.clients[c] = true
+	h.mu.Unlock()
+	return c
+}
+
+func (h *Hub) remove(c *hubClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+func (h *Hub) Publish(ev ExecutionEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.clients {
+		if c.wants(ev) {
+			if err := c.send(ev); err != nil {
+				slog.Debug("websocket write failed", "error", err)
+			}
+		}
+	}
+}
+
+func (c *hubClient) subscribe(workflowID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if workflowID == "" {
+		c.all = true
+		return
+	}
+	c.subs[workflowID] = true
+}
+
+func (c *hubClient) unsubscribe(workflowID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if workflowID == "" {
+		c.all = false
+		c.subs = make(map[string]bool)
+		return
+	}
+	delete(c.subs, workflowID)
+}
+
+func (c *hubClient) wants(ev ExecutionEvent) bool {
+	if c.principal != "" && ev.OwnerID != c.principal {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.all || c.subs[ev.WorkflowID]
+}
+
+// send queues v for the writer without blocking. A client whose queue is
+// full is closed rather than allowed to stall the executor.
+func (c *hubClient) send(v interface{}) error {
+	select {
+	case <-c.done:
+		return errClientClosed
+	default:
+	}
+	select {
+	case c.out <- v:
+		return nil
+	default:
+		c.close()
+		return fmt.Errorf("websocket client too slow; disconnected")
+	}
+}
+
+// close stops the writer; the reader notices when the connection closes.
+func (c *hubClient) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// writePump is the connection's only writer: it sends queued messages and
+// periodic pings, each under a write deadline, and closes the connection
+// when the client is closed or a write fails.
+func (c *hubClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case v := <-c.out:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(v); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(wsWriteWait))
+			return
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// events_test.go - Live execution event streaming and WebSocket keepalive tests
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// nodeGate is a test-only node type that blocks until its gate opens.
+const nodeGate NodeType = "gate"
+
+type gate chan struct{}
+
+func (g gate) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	select {
+	case <-g:
+		return input, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dialHub opens a WebSocket to ts subscribed to workflowID. A ping/pong
+// round trip confirms the subscription was handled before it returns.
+func dialHub(t *testing.T, url, workflowID string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(map[string]string{"type": "subscribe", "workflowId": workflowID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteJSON(map[string]string{"type": "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	var pong map[string]interface{}
+	if err := conn.ReadJSON(&pong); err != nil || pong["type"] != "pong" {
+		t.Fatalf("subscribe handshake: %v %v", pong, err)
+	}
+	return conn
+}
+
+func TestTransformLogsStreamDuringExecution(t *testing.T) {
+	release := make(gate)
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		NodeExecutors: map[NodeType]NodeExecutor{nodeGate: release},
+	})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "logging",
+		Nodes: []Node{
+			{ID: "t", Type: NodeTransform, Properties: map[string]interface{}{"script": "return input"}},
+			{ID: "g", Type: nodeGate},
+		},
+		Connections: []Connection{{ID: "c", FromID: "t", ToID: "g"}},
+	})
+	conn := dialHub(t, ts.URL, wf.ID)
+
+	done := make(chan *ExecutionResult, 1)
+	go func() {
+		result, _ := s.engine.ExecuteWorkflow(context.Background(), wf.ID)
+		done <- result
+	}()
+
+	// The gate holds the run open, so the log line must arrive live
+	var logEvent ExecutionEvent
+	for logEvent.Type != EventLog {
+		if err := conn.ReadJSON(&logEvent); err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+	}
+	select {
+	case <-done:
+		t.Fatal("log line arrived only after the execution finished")
+	default:
+	}
+	if logEvent.WorkflowID != wf.ID || logEvent.NodeID != "t" || logEvent.Level != "info" {
+		t.Fatalf("log event = %+v", logEvent)
+	}
+	if !strings.Contains(logEvent.Message, "12-byte script") {
+		t.Fatalf("log message = %q", logEvent.Message)
+	}
+	if logEvent.ExecutionID == "" {
+		t.Fatal("log event has no execution ID")
+	}
+
+	close(release)
+	if result := <-done; result == nil || result.Status != StatusCompleted {
+		t.Fatalf("execution did not complete: %+v", result)
+	}
+}
+
+func TestLogsOnlyReachSubscribers(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	transform := func(name string) *Workflow {
+		return mustCreate(t, s.engine, ctx, &Workflow{
+			Name:  name,
+			Nodes: []Node{{ID: "t", Type: NodeTransform, Properties: map[string]interface{}{"script": name}}},
+		})
+	}
+	watched, other := transform("watched"), transform("other")
+	conn := dialHub(t, ts.URL, watched.ID)
+
+	if _, err := s.engine.ExecuteWorkflow(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.engine.ExecuteWorkflow(ctx, watched.ID); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var ev ExecutionEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		if ev.WorkflowID != watched.ID {
+			t.Fatalf("received event for unsubscribed workflow: %+v", ev)
+		}
+		if ev.Type == EventLog {
+			return
+		}
+	}
+}
+
+// shortKeepalive shortens WebSocket ping timing for a test. The returned
+// function waits for s's clients to go, then restores the timing.
+func shortKeepalive(t *testing.T, s *Server, pongWait, pingPeriod time.Duration) func() {
+	t.Helper()
+	oldWait, oldPeriod := wsPongWait, wsPingPeriod
+	wsPongWait, wsPingPeriod = pongWait, pingPeriod
+	return func() {
+		eventually(t, "websocket clients to disconnect", func() bool {
+			s.hub.mu.RLock()
+			defer s.hub.mu.RUnlock()
+			return len(s.hub.clients) == 0
+		})
+		wsPongWait, wsPingPeriod = oldWait, oldPeriod
+	}
+}
+
+func TestIdleClientIsPinged(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	restore := shortKeepalive(t, s, 300*time.Millisecond, 50*time.Millisecond)
+	defer restore()
+
+	conn := dialHub(t, ts.URL, "")
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var pings atomic.Int32
+	conn.SetPingHandler(func(data string) error {
+		pings.Add(1)
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// Stay idle well past the pong wait. The reader only processes control
+	// frames meanwhile; no data message arrives until the client asks
+	msgs := make(chan map[string]interface{})
+	go func() {
+		for {
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				close(msgs)
+				return
+			}
+			msgs <- msg
+		}
+	}()
+	select {
+	case msg, ok := <-msgs:
+		t.Fatalf("idle client received %v (open %v)", msg, ok)
+	case <-time.After(time.Second):
+	}
+	if n := pings.Load(); n < 5 {
+		t.Fatalf("idle client got %d pings in a second, want several", n)
+	}
+
+	// Answered pings kept the connection alive
+	if err := conn.WriteJSON(map[string]string{"type": "ping"}); err != nil {
+		t.Fatalf("connection dropped: %v", err)
+	}
+	if pong := <-msgs; pong["type"] != "pong" {
+		t.Fatalf("after idling: %v", pong)
+	}
+}
+
+func TestSilentClientIsDropped(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	restore := shortKeepalive(t, s, 200*time.Millisecond, time.Hour)
+	defer restore()
+
+	conn := dialHub(t, ts.URL, "")
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	start := time.Now()
+	_, _, err := conn.ReadMessage()
+	if err == nil || isTimeout(err) {
+		t.Fatalf("silent client still connected: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("dropped after %v", elapsed)
+	}
+}
+
+func TestConcurrentPublishesShareOneWriter(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	conn := dialHub(t, ts.URL, "")
+
+	const publishers, each = 8, 25
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				s.hub.Publish(ExecutionEvent{Type: EventLog, WorkflowID: "wf", Message: "x"})
+			}
+		}()
+	}
+	// The client pings at the same time, so replies race the events
+	for i := 0; i < 10; i++ {
+		conn.WriteJSON(map[string]string{"type": "ping"})
+	}
+	wg.Wait()
+
+	events, pongs := 0, 0
+	for events < publishers*each || pongs < 10 {
+		var msg map[string]interface{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("after %d events and %d pongs: %v", events, pongs, err)
+		}
+		switch msg["type"] {
+		case EventLog:
+			events++
+		case "pong":
+			pongs++
+		}
+	}
+}
+
+func TestSlowClientIsDisconnected(t *testing.T) {
+	h := NewHub()
+	c := h.add(nil, "")
+	for i := 0; i < wsSendBuffer; i++ {
+		if err := c.send(i); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+	}
+	if err := c.send("one too many"); err == nil {
+		t.Fatal("full queue accepted a message")
+	}
+	if err := c.send("after close"); err != errClientClosed {
+		t.Fatalf("send after disconnect: err = %v", err)
+	}
+}
+
+// isTimeout reports whether err is a read deadline expiring.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// eventLog is an EventPublisher keeping every event it receives.
+type eventLog struct {
+	mu     sync.Mutex
+	events []ExecutionEvent
+}
+
+func (l *eventLog) Publish(ev ExecutionEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, ev)
+}
+
+func TestEventsAreStampedWithEngineClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	we := newTestEngine(t, WithClock(NewFakeClock(now)), WithNodeExecutor(nodeRecord, &recorder{}))
+	events := &eventLog{}
+	we.executor.events = events
+	wf := mustCreate(t, we, context.Background(), &Workflow{Name: "stamped", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+
+	if _, err := we.ExecuteWorkflow(context.Background(), wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(events.events) == 0 {
+		t.Fatal("no events published")
+	}
+	for _, ev := range events.events {
+		if !ev.Time.Equal(now) {
+			t.Fatalf("%s event at %v, want %v", ev.Type, ev.Time, now)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// exec.go - Shell command node
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultExecTimeout bounds commands that set no timeout of their own.
+const defaultExecTimeout = 30 * time.Second
+
+// WithExecNodes allows exec nodes to run commands. They are refused
+// unless this is set.
+func WithExecNodes(allow bool) EngineOption {
+	return func(we *WorkflowEngine) { we.allowExec = allow }
+}
+
+// ============================================
+// Exec Node
+// ============================================
+
+// ExecExecutor runs a command without a shell, writing the node input to
+// its stdin (strings as-is, anything else as JSON). A non-zero exit is
+// reported in the output rather than as an error. Properties: command,
+// args (one per line), timeout.
+type ExecExecutor struct {
+	enabled bool
+}
+
+func (e *ExecExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	if !e.enabled {
+		return nil, fmt.Errorf("exec nodes are disabled; set ALLOW_EXEC=true to allow them")
+	}
+	command, _ := node.Properties["command"].(string)
+	if command = strings.TrimSpace(command); command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	timeout := defaultExecTimeout
+	if v, ok := node.Properties["timeout"]; ok && v != "" {
+		d, err := parseDelay(v)
+		if err != nil {
+			return nil, err
+		}
+		if d > 0 {
+			timeout = d
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdin []byte
+	switch in := input.(type) {
+	case nil:
+	case string:
+		stdin = []byte(in)
+	default:
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("encode input: %v", err)
+		}
+		stdin = data
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, execArgs(node.Properties["args"])...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("command timed out after %v", timeout)
+	}
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+	case err != nil:
+		return nil, fmt.Errorf("run %s: %v", command, err)
+	}
+
+	return map[string]interface{}{
+		"command":  command,
+		"stdout":   stdout.String(),
+		"stderr":   stderr.String(),
+		"exitCode": exitCode,
+	}, nil
+}
+
+// execArgs reads args as a list or as one argument per line, so arguments
+// may contain commas and spaces.
+func execArgs(v interface{}) []string {
+	var args []string
+	switch list := v.(type) {
+	case []interface{}:
+		for _, item := range list {
+			args = append(args, exprString(item))
+		}
+	case string:
+		for _, line := range strings.Split(list, "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				args = append(args, line)
+			}
+		}
+	}
+	return args
+}
@@ -0,0 +1,116 @@
+// exec_test.go - Exec node tests
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// needCommand skips the test when name is not on PATH.
+func needCommand(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not available", name)
+	}
+}
+
+func TestExecEcho(t *testing.T) {
+	needCommand(t, "echo")
+	we := newTestEngine(t, WithExecNodes(true))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name:  "echo",
+		Nodes: []Node{{ID: "run", Type: NodeExec, Properties: map[string]interface{}{"command": "echo", "args": "hello, world\nagain"}}},
+	})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := result.Results["run"].(map[string]interface{})
+	if out["stdout"] != "hello, world again\n" || out["exitCode"] != 0 {
+		t.Fatalf("output = %v", out)
+	}
+}
+
+func TestExecPassesInputOnStdin(t *testing.T) {
+	needCommand(t, "cat")
+	e := &ExecExecutor{enabled: true}
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"command": "cat"}}, map[string]interface{}{"a": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{})["stdout"]; got != `{"a":1}` {
+		t.Fatalf("stdout = %q", got)
+	}
+}
+
+func TestExecNonZeroExit(t *testing.T) {
+	needCommand(t, "sh")
+	e := &ExecExecutor{enabled: true}
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"command": "sh",
+		"args":    []interface{}{"-c", "echo oops >&2; exit 3"},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("a non-zero exit is output, not an error: %v", err)
+	}
+	got := out.(map[string]interface{})
+	if got["exitCode"] != 3 || got["stderr"] != "oops\n" || got["stdout"] != "" {
+		t.Fatalf("output = %v", got)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	needCommand(t, "sleep")
+	e := &ExecExecutor{enabled: true}
+	start := time.Now()
+	_, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"command": "sleep",
+		"args":    "10",
+		"timeout": "100ms",
+	}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout took %v", elapsed)
+	}
+}
+
+func TestExecRefusedUnlessAllowed(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *ExecExecutor
+		prop map[string]interface{}
+		want string
+	}{
+		{"disabled", &ExecExecutor{}, map[string]interface{}{"command": "echo"}, "disabled"},
+		{"no command", &ExecExecutor{enabled: true}, map[string]interface{}{"command": " "}, "command is required"},
+		{"not found", &ExecExecutor{enabled: true}, map[string]interface{}{"command": "no-such-command-xyz"}, "run no-such-command-xyz"},
+	}
+	for _, tt := range tests {
+		_, err := tt.e.Execute(context.Background(), &Node{Properties: tt.prop}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	// The engine leaves exec nodes disabled by default
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "blocked", Nodes: []Node{{ID: "run", Type: NodeExec, Properties: map[string]interface{}{"command": "echo"}}}})
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed {
+		t.Fatalf("status = %s, want failed", result.Status)
+	}
+}
@@ -0,0 +1,192 @@
+// execlogs.go - Per-execution log capture
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxExecutionLogEntries bounds how many lines one execution keeps; later
+// lines still reach the server log.
+const maxExecutionLogEntries = 1000
+
+// ============================================
+// Execution Logs
+// ============================================
+
+// LogEntry is one line logged during an execution. NodeID is blank for
+// lines logged by the run itself rather than one of its nodes.
+type LogEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	NodeID  string                 `json:"node_id,omitempty"`
+	Message string                 `json:"message"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// ExecutionLog collects an execution's log lines. It is safe for
+// concurrent use.
+type ExecutionLog struct {
+	mu        sync.Mutex
+	entries   []LogEntry
+	truncated bool
+}
+
+func (l *ExecutionLog) add(e LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) >= maxExecutionLogEntries {
+		if !l.truncated {
+			l.truncated = true
+			l.entries = append(l.entries, LogEntry{Time: e.Time, Level: "warn", Message: "log limit reached; later lines were dropped"})
+		}
+		return
+	}
+	l.entries = append(l.entries, e)
+}
+
+// Entries returns the lines at or above minLevel, narrowed to one node
+// when nodeID is set.
+func (l *ExecutionLog) Entries(minLevel slog.Level, nodeID string) []LogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := []LogEntry{}
+	for _, e := range l.entries {
+		if slogLevel(e.Level) < minLevel || (nodeID != "" && e.NodeID != nodeID) {
+			continue
+		}
+		list = append(list, e)
+	}
+	return list
+}
+
+// Size is the approximate encoded size of the log in bytes.
+func (l *ExecutionLog) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data, err := json.Marshal(l.entries)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
+
+// logIdentityKeys are attributes every line of a run carries; they are
+// kept out of LogEntry.Attrs.
+var logIdentityKeys = map[string]bool{
+	"workflow_id":  true,
+	"execution_id": true,
+	"request_id":   true,
+	"node_id":      true,
+	"node_type":    true,
+}
+
+// captureHandler copies every record logged during an execution into its
+// ExecutionLog, then passes it on to the server's handler. Messages and
+// string attributes are redacted with the run's secrets.
+type captureHandler struct {
+	next   slog.Handler
+	log    *ExecutionLog
+	ctx    context.Context
+	nodeID string
+	attrs  []slog.Attr
+}
+
+func (h *captureHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *captureHandler) Handle(ctx context.Context, r slog.Record) error {
+	entry := LogEntry{
+		Time:    r.Time,
+		Level:   strings.ToLower(r.Level.String()),
+		NodeID:  h.nodeID,
+		Message: redactString(h.ctx, r.Message),
+	}
+	add := func(a slog.Attr) bool {
+		if a.Key == "node_id" {
+			entry.NodeID = a.Value.String()
+		}
+		if logIdentityKeys[a.Key] {
+			return true
+		}
+		if entry.Attrs == nil {
+			entry.Attrs = make(map[string]interface{})
+		}
+		v := a.Value.Resolve().Any()
+		if s, ok := v.(string); ok {
+			v = redactString(h.ctx, s)
+		} else if err, ok := v.(error); ok {
+			v = redactString(h.ctx, err.Error())
+		}
+		entry.Attrs[a.Key] = v
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+	h.log.add(entry)
+
+	if h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	c.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	for _, a := range attrs {
+		if a.Key == "node_id" {
+			c.nodeID = a.Value.String()
+		}
+	}
+	return &c
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
+
+// ============================================
+// Execution Log Handlers
+// ============================================
+
+// handleExecutionLogs lists an execution's log lines in order. ?level=
+// keeps lines at or above a level (debug, info, warn, error) and ?node=
+// keeps one node's lines.
+func (s *Server) handleExecutionLogs(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	result, err := s.engine.executions.Get(principalFromContext(r.Context()), id)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	minLevel := slog.LevelDebug
+	if level := strings.ToLower(r.URL.Query().Get("level")); level != "" {
+		switch level {
+		case "debug", "info", "warn", "warning", "error":
+			minLevel = slogLevel(level)
+		default:
+			writeError(w, http.StatusBadRequest, CodeInvalidBody, "level must be debug, info, warn or error")
+			return
+		}
+	}
+	entries := []LogEntry{}
+	if result.Logs != nil {
+		entries = result.Logs.Entries(minLevel, r.URL.Query().Get("node"))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,117 @@
+// execlogs_test.go - Per-execution log tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// nodeChatty is a test-only node type that logs as it runs.
+const nodeChatty NodeType = "chatty"
+
+// chatty logs one line per level through the context logger, then one
+// through logf, naming its node in each message.
+type chatty struct{}
+
+func (chatty) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	logger := loggerFromContext(ctx)
+	logger.Debug(node.ID+" starting", "rows", 3)
+	logger.Info(node.ID + " working")
+	logf(ctx, "warn", "%s nearly done", node.ID)
+	return input, nil
+}
+
+func TestExecutionLogsCapturedAndFiltered(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		NodeExecutors: map[NodeType]NodeExecutor{nodeChatty: chatty{}},
+	})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name:        "two chatty nodes",
+		Nodes:       []Node{{ID: "first", Type: nodeChatty}, {ID: "second", Type: nodeChatty}},
+		Connections: []Connection{{ID: "c1", FromID: "first", ToID: "second"}},
+	})
+	result, err := s.engine.ExecuteWorkflow(context.Background(), wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := func(query string) []LogEntry {
+		t.Helper()
+		resp, data := doRequest(t, ts, "GET", "/api/executions/"+result.ID+"/logs"+query, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("logs%s: %d %s", query, resp.StatusCode, data)
+		}
+		var entries []LogEntry
+		decode(t, data, &entries)
+		return entries
+	}
+	// messages lists the lines nodeChatty logged, in order
+	messages := func(entries []LogEntry) []string {
+		var list []string
+		for _, e := range entries {
+			switch e.Message {
+			case e.NodeID + " starting", e.NodeID + " working", e.NodeID + " nearly done":
+				list = append(list, e.Level+" "+e.Message)
+			}
+		}
+		return list
+	}
+
+	all := logs("")
+	want := []string{
+		"debug first starting", "info first working", "warn first nearly done",
+		"debug second starting", "info second working", "warn second nearly done",
+	}
+	if got := messages(all); !reflect.DeepEqual(got, want) {
+		t.Fatalf("captured lines = %v, want %v", got, want)
+	}
+	for _, e := range all {
+		if e.Message == "first starting" && e.Attrs["rows"] != 3.0 {
+			t.Fatalf("attrs = %v, want rows 3", e.Attrs)
+		}
+	}
+
+	byNode := logs("?node=second")
+	for _, e := range byNode {
+		if e.NodeID != "second" {
+			t.Fatalf("?node=second returned a line of node %q: %+v", e.NodeID, e)
+		}
+	}
+	if got := messages(byNode); !reflect.DeepEqual(got, want[3:]) {
+		t.Fatalf("?node=second lines = %v, want %v", got, want[3:])
+	}
+
+	warnings := logs("?level=warn&node=first")
+	if got := messages(warnings); !reflect.DeepEqual(got, []string{"warn first nearly done"}) {
+		t.Fatalf("?level=warn&node=first lines = %v", got)
+	}
+	for _, e := range warnings {
+		if e.Level != "warn" && e.Level != "error" {
+			t.Fatalf("?level=warn returned a %s line", e.Level)
+		}
+	}
+
+	if resp, _ := doRequest(t, ts, "GET", "/api/executions/"+result.ID+"/logs?level=loud", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad level: status %d, want 400", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, ts, "GET", "/api/executions/missing/logs", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown execution: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestExecutionLogTruncates(t *testing.T) {
+	l := &ExecutionLog{}
+	for i := 0; i < maxExecutionLogEntries+5; i++ {
+		l.add(LogEntry{Level: "info", Message: "line"})
+	}
+	entries := l.Entries(slogLevel("debug"), "")
+	if len(entries) != maxExecutionLogEntries+1 {
+		t.Fatalf("%d entries kept, want %d plus a notice", len(entries), maxExecutionLogEntries)
+	}
+	if last := entries[len(entries)-1]; last.Level != "warn" {
+		t.Fatalf("last entry = %+v, want the truncation notice", last)
+	}
+}
@@ -0,0 +1,393 @@
+// executions.go - Execution history
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrExecutionNotFound   = errors.New("execution not found")
+	ErrExecutionNotRunning = errors.New("execution is not running")
+	// ErrExecutionCancelled is the cause of a cancelled run's context
+	ErrExecutionCancelled = errors.New("execution cancelled")
+)
+
+// ============================================
+// Execution Store
+// ============================================
+
+// defaultExecutionRetention is how many finished executions of each
+// workflow ExecutionStore keeps.
+const defaultExecutionRetention = 100
+
+// executionSweepInterval is how often ExecutionStore looks for executions
+// past their maximum age.
+const executionSweepInterval = time.Minute
+
+// WithExecutionRetention keeps the newest perWorkflow finished executions
+// of each workflow, and none that ended more than maxAge ago. Zero
+// perWorkflow uses defaultExecutionRetention, a negative one keeps them
+// all; zero maxAge keeps them regardless of age.
+func WithExecutionRetention(perWorkflow int, maxAge time.Duration) EngineOption {
+	return func(we *WorkflowEngine) {
+		we.executionRetention, we.executionMaxAge = perWorkflow, maxAge
+	}
+}
+
+// ExecutionStore keeps execution results in memory, tracking the
+// approximate encoded size of everything it holds. The oldest finished
+// executions of a workflow are evicted beyond its retention limit, and
+// any that outlive the maximum age; running ones are kept.
+type ExecutionStore struct {
+	mu      sync.RWMutex
+	records map[string]*executionRecord
+	bytes   int64
+	// byWorkflow holds the IDs recorded for each owner's workflow, oldest
+	// first
+	byWorkflow     map[string][]string
+	maxPerWorkflow int
+	maxAge         time.Duration
+	clock          Clock
+	lastSweep      time.Time
+}
+
+type executionRecord struct {
+	result *ExecutionResult
+	owner  string
+	size   int64
+}
+
+// NewExecutionStore returns a store keeping maxPerWorkflow executions of
+// each workflow (none beyond, when negative) for up to maxAge (forever,
+// when zero).
+func NewExecutionStore(clock Clock, maxPerWorkflow int, maxAge time.Duration) *ExecutionStore {
+	if maxPerWorkflow == 0 {
+		maxPerWorkflow = defaultExecutionRetention
+	}
+	return &ExecutionStore{
+		records:        make(map[string]*executionRecord),
+		byWorkflow:     make(map[string][]string),
+		maxPerWorkflow: maxPerWorkflow,
+		maxAge:         maxAge,
+		clock:          clock,
+	}
+}
+
+// Record stores a result, replacing any earlier record with the same ID,
+// then evicts what the retention limits no longer allow.
+func (es *ExecutionStore) Record(owner string, result *ExecutionResult) {
+	size := int64(0)
+	if data, err := json.Marshal(result); err == nil {
+		size = int64(len(data))
+	}
+	if result.Logs != nil {
+		size += result.Logs.Size()
+	}
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	key := owner + "/" + result.WorkflowID
+	if prev, ok := es.records[result.ID]; ok {
+		es.bytes -= prev.size
+		if prevKey := prev.owner + "/" + prev.result.WorkflowID; prevKey != key {
+			es.unlist(prevKey, result.ID)
+			es.byWorkflow[key] = append(es.byWorkflow[key], result.ID)
+		}
+	} else {
+		es.byWorkflow[key] = append(es.byWorkflow[key], result.ID)
+	}
+	es.records[result.ID] = &executionRecord{result: result, owner: owner, size: size}
+	es.bytes += size
+
+	es.trim(key)
+	if es.maxAge > 0 {
+		if now := es.clock.Now(); now.Sub(es.lastSweep) >= executionSweepInterval {
+			es.lastSweep = now
+			es.sweep(now.Add(-es.maxAge))
+		}
+	}
+}
+
+// trim evicts the oldest finished executions of a workflow beyond the
+// retention limit.
+func (es *ExecutionStore) trim(key string) {
+	if es.maxPerWorkflow < 0 {
+		return
+	}
+	ids := es.byWorkflow[key]
+	finished := 0
+	for _, id := range ids {
+		if es.records[id].result.Status != StatusRunning {
+			finished++
+		}
+	}
+	kept := ids[:0]
+	for _, id := range ids {
+		if finished > es.maxPerWorkflow && es.records[id].result.Status != StatusRunning {
+			finished--
+			es.evict(id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	es.setList(key, kept)
+}
+
+// sweep evicts finished executions that ended before cutoff.
+func (es *ExecutionStore) sweep(cutoff time.Time) {
+	for key, ids := range es.byWorkflow {
+		kept := ids[:0]
+		for _, id := range ids {
+			result := es.records[id].result
+			if result.Status != StatusRunning && result.EndTime.Before(cutoff) {
+				es.evict(id)
+				continue
+			}
+			kept = append(kept, id)
+		}
+		es.setList(key, kept)
+	}
+}
+
+func (es *ExecutionStore) evict(id string) {
+	es.bytes -= es.records[id].size
+	delete(es.records, id)
+}
+
+func (es *ExecutionStore) unlist(key, id string) {
+	ids := es.byWorkflow[key]
+	for i, listed := range ids {
+		if listed == id {
+			es.setList(key, append(ids[:i], ids[i+1:]...))
+			return
+		}
+	}
+}
+
+func (es *ExecutionStore) setList(key string, ids []string) {
+	if len(ids) == 0 {
+		delete(es.byWorkflow, key)
+		return
+	}
+	es.byWorkflow[key] = ids
+}
+
+func (es *ExecutionStore) Get(owner, id string) (*ExecutionResult, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	rec, ok := es.records[id]
+	if !ok || (owner != "" && rec.owner != owner) {
+		return nil, ErrExecutionNotFound
+	}
+	return rec.result, nil
+}
+
+// List returns the executions visible to owner, newest first, optionally
+// narrowed to one workflow.
+func (es *ExecutionStore) List(owner, workflowID string) []*ExecutionResult {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	list := []*ExecutionResult{}
+	for _, rec := range es.records {
+		if owner != "" && rec.owner != owner {
+			continue
+		}
+		if workflowID != "" && rec.result.WorkflowID != workflowID {
+			continue
+		}
+		list = append(list, rec.result)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartTime.After(list[j].StartTime) })
+	return list
+}
+
+// Stats reports how many executions are held and their approximate size
+// in bytes.
+func (es *ExecutionStore) Stats() (count int, bytes int64) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return len(es.records), es.bytes
+}
+
+// ============================================
+// Execution Handlers
+// ============================================
+
+// handleListExecutions lists the caller's executions, newest first;
+// ?workflowId= narrows the list to one workflow.
+func (s *Server) handleListExecutions(w http.ResponseWriter, r *http.Request) {
+	list := s.engine.executions.List(principalFromContext(r.Context()), r.URL.Query().Get("workflowId"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *Server) handleGetExecution(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	result, err := s.engine.executions.Get(principalFromContext(r.Context()), id)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleCancelExecution cancels a running execution. The run stops
+// asynchronously; its recorded status becomes cancelled once it has
+// unwound.
+func (s *Server) handleCancelExecution(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := s.engine.CancelExecution(r.Context(), id); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/executions/"+id)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"executionId": id,
+		"status":      "cancelling",
+	})
+}
+
+// ============================================
+// Execution Diff
+// ============================================
+
+// Node change kinds in an ExecutionDiff.
+const (
+	ChangeUnchanged = "unchanged"
+	ChangeChanged   = "changed"
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+)
+
+// ExecutionDiff compares two executions of one workflow node by node.
+type ExecutionDiff struct {
+	WorkflowID string     `json:"workflowId"`
+	A          string     `json:"a"`
+	B          string     `json:"b"`
+	StatusA    string     `json:"statusA"`
+	StatusB    string     `json:"statusB"`
+	Nodes      []NodeDiff `json:"nodes"`
+}
+
+// NodeDiff is one node's outcome in both runs. Outputs are included only
+// for nodes that differ. A node is added when only run B produced output
+// for it and removed when only run A did.
+type NodeDiff struct {
+	NodeID  string      `json:"nodeId"`
+	Change  string      `json:"change"`
+	StatusA string      `json:"statusA"`
+	StatusB string      `json:"statusB"`
+	OutputA interface{} `json:"outputA,omitempty"`
+	OutputB interface{} `json:"outputB,omitempty"`
+}
+
+// nodeStatus reports how a node fared in a run: "completed", "failed", or
+// "not_run" for nodes that were skipped or never reached.
+func nodeStatus(result *ExecutionResult, nodeID string) string {
+	if node, ok := result.Nodes[nodeID]; ok {
+		if node.Status == NodeStatusSkipped {
+			return "not_run"
+		}
+		return node.Status
+	}
+	prefix := "node " + nodeID + " "
+	for _, e := range result.Errors {
+		if strings.HasPrefix(e, prefix) {
+			return "failed"
+		}
+	}
+	if _, ok := result.Results[nodeID]; ok {
+		return StatusCompleted
+	}
+	return "not_run"
+}
+
+// diffExecutions compares every node that appears in either run.
+func diffExecutions(a, b *ExecutionResult) *ExecutionDiff {
+	diff := &ExecutionDiff{
+		WorkflowID: a.WorkflowID,
+		A:          a.ID,
+		B:          b.ID,
+		StatusA:    a.Status,
+		StatusB:    b.Status,
+		Nodes:      []NodeDiff{},
+	}
+
+	ids := make(map[string]bool)
+	for _, result := range []*ExecutionResult{a, b} {
+		for id := range result.Results {
+			ids[id] = true
+		}
+		for _, e := range result.Errors {
+			if rest, ok := strings.CutPrefix(e, "node "); ok {
+				if i := strings.Index(rest, " error:"); i > 0 {
+					ids[rest[:i]] = true
+				}
+			}
+		}
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	for _, id := range sorted {
+		nd := NodeDiff{NodeID: id, StatusA: nodeStatus(a, id), StatusB: nodeStatus(b, id)}
+		outA, inA := a.Results[id]
+		outB, inB := b.Results[id]
+		switch {
+		case inA && !inB:
+			nd.Change = ChangeRemoved
+		case inB && !inA:
+			nd.Change = ChangeAdded
+		case nd.StatusA != nd.StatusB || !reflect.DeepEqual(genericJSON(outA), genericJSON(outB)):
+			nd.Change = ChangeChanged
+		default:
+			nd.Change = ChangeUnchanged
+		}
+		if nd.Change != ChangeUnchanged {
+			nd.OutputA, nd.OutputB = outA, outB
+		}
+		diff.Nodes = append(diff.Nodes, nd)
+	}
+	return diff
+}
+
+// handleDiffExecutions compares two executions of the same workflow.
+func (s *Server) handleDiffExecutions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner := principalFromContext(r.Context())
+	a, err := s.engine.executions.Get(owner, vars["a"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	b, err := s.engine.executions.Get(owner, vars["b"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	if a.WorkflowID != b.WorkflowID {
+		writeError(w, http.StatusBadRequest, CodeWorkflowMismatch, "executions belong to different workflows")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diffExecutions(a, b))
+}
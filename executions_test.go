@@ -0,0 +1,302 @@
+// executions_test.go - Execution store retention, sync/async run, cancel and diff tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func finishedRun(id, workflowID string, ended time.Time) *ExecutionResult {
+	return &ExecutionResult{ID: id, WorkflowID: workflowID, Status: StatusCompleted, StartTime: ended, EndTime: ended}
+}
+
+func TestExecutionStoreKeepsNewestPerWorkflow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	es := NewExecutionStore(NewFakeClock(start), 2, 0)
+
+	running := &ExecutionResult{ID: "live", WorkflowID: "wf", Status: StatusRunning, StartTime: start}
+	es.Record("alice", running)
+	for i, id := range []string{"a", "b", "c"} {
+		es.Record("alice", finishedRun(id, "wf", start.Add(time.Duration(i+1)*time.Minute)))
+	}
+	es.Record("alice", finishedRun("other", "wf2", start))
+	es.Record("bob", finishedRun("bobs", "wf", start))
+
+	if _, err := es.Get("", "a"); err == nil {
+		t.Error("oldest finished execution was kept beyond the limit")
+	}
+	for _, id := range []string{"live", "b", "c", "other", "bobs"} {
+		if _, err := es.Get("", id); err != nil {
+			t.Errorf("execution %s evicted: %v", id, err)
+		}
+	}
+	if got := len(es.List("alice", "wf")); got != 3 {
+		t.Errorf("alice's wf executions = %d, want 3", got)
+	}
+
+	// Replacing a record keeps the size accounting in step
+	_, before := es.Stats()
+	es.Record("alice", finishedRun("c", "wf", start.Add(3*time.Minute)))
+	if n, after := es.Stats(); n != 5 || after != before {
+		t.Errorf("after re-recording: %d executions, %d bytes; want 5, %d", n, after, before)
+	}
+}
+
+func TestExecutionStoreEvictsByAge(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	es := NewExecutionStore(clock, -1, time.Hour)
+
+	es.Record("", finishedRun("old", "wf", start))
+	es.Record("", &ExecutionResult{ID: "live", WorkflowID: "wf", Status: StatusRunning, StartTime: start})
+
+	clock.Advance(2 * time.Hour)
+	es.Record("", finishedRun("new", "wf", clock.Now()))
+
+	if _, err := es.Get("", "old"); err == nil {
+		t.Error("execution older than maxAge was kept")
+	}
+	for _, id := range []string{"live", "new"} {
+		if _, err := es.Get("", id); err != nil {
+			t.Errorf("execution %s evicted: %v", id, err)
+		}
+	}
+	if n, _ := es.Stats(); n != 2 {
+		t.Errorf("stored = %d, want 2", n)
+	}
+}
+
+func TestExecutionStoreEmptiesAccounting(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	es := NewExecutionStore(clock, 1, 0)
+	es.Record("", finishedRun("a", "wf", clock.Now()))
+	es.Record("", finishedRun("b", "wf", clock.Now()))
+	_, one := es.Stats()
+
+	es.Record("", finishedRun("c", "wf", clock.Now()))
+	if n, bytes := es.Stats(); n != 1 || bytes != one {
+		t.Fatalf("stats = %d executions, %d bytes; want 1, %d", n, bytes, one)
+	}
+}
+
+func TestExecuteSynchronouslyByDefault(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name:  "sync",
+		Nodes: []Node{{ID: "set", Type: NodeSet, Properties: map[string]interface{}{"fields": map[string]interface{}{"done": true}}}},
+	})
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("execute: %d %s", resp.StatusCode, data)
+	}
+	var result ExecutionResult
+	decode(t, data, &result)
+	if result.Status != StatusCompleted || result.Results["set"] == nil {
+		t.Fatalf("result = %+v, want the finished run", result)
+	}
+}
+
+func TestExecuteAsyncReturnsImmediately(t *testing.T) {
+	release := make(gate)
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeGate: release}})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{Name: "async", Nodes: []Node{{ID: "g", Type: nodeGate}}})
+	conn := dialHub(t, ts.URL, wf.ID)
+
+	// The gate is still shut, so only an async response can come back
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute?async=true", nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("execute: %d %s, want 202", resp.StatusCode, data)
+	}
+	var accepted struct {
+		ExecutionID string `json:"executionId"`
+		Status      string `json:"status"`
+	}
+	decode(t, data, &accepted)
+	if accepted.ExecutionID == "" || accepted.Status != StatusRunning {
+		t.Fatalf("accepted = %+v", accepted)
+	}
+	location := resp.Header.Get("Location")
+	if location != "/api/executions/"+accepted.ExecutionID {
+		t.Fatalf("Location = %q", location)
+	}
+
+	var result ExecutionResult
+	resp, data = doRequest(t, ts, "GET", location, nil)
+	decode(t, data, &result)
+	if resp.StatusCode != http.StatusOK || result.Status != StatusRunning {
+		t.Fatalf("while running: %d %s", resp.StatusCode, data)
+	}
+
+	close(release)
+
+	// Progress arrives over the WebSocket, the result from the history
+	for {
+		var ev ExecutionEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		if ev.Type == EventExecutionUpdate && ev.Status == StatusCompleted {
+			if ev.ExecutionID != accepted.ExecutionID {
+				t.Fatalf("event for execution %q, want %q", ev.ExecutionID, accepted.ExecutionID)
+			}
+			break
+		}
+	}
+	eventually(t, "the stored result", func() bool {
+		_, data := doRequest(t, ts, "GET", location, nil)
+		decode(t, data, &result)
+		return result.Status == StatusCompleted
+	})
+	if result.ID != accepted.ExecutionID || result.WorkflowID != wf.ID {
+		t.Fatalf("stored result = %+v", result)
+	}
+}
+
+func TestCancelSlowExecutionMidRun(t *testing.T) {
+	release, rec := make(gate), &recorder{}
+	s, ts := newTestServer(t, ServerConfig{
+		APIKeys:       map[string]string{"alice-key": "alice", "bob-key": "bob"},
+		NodeExecutors: map[NodeType]NodeExecutor{nodeGate: release, nodeRecord: rec},
+	})
+	defer close(release)
+	alice, bob := []string{"Authorization", "Bearer alice-key"}, []string{"Authorization", "Bearer bob-key"}
+	wf := mustCreate(t, s.engine, asPrincipal("alice"), &Workflow{
+		Name:        "slow",
+		Nodes:       []Node{{ID: "slow", Type: nodeGate}, {ID: "after", Type: nodeRecord}},
+		Connections: []Connection{{ID: "c1", FromID: "slow", ToID: "after"}},
+	})
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute?async=true", nil, alice...)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("execute: %d %s", resp.StatusCode, data)
+	}
+	var accepted struct {
+		ExecutionID string `json:"executionId"`
+	}
+	decode(t, data, &accepted)
+	cancelPath := "/api/executions/" + accepted.ExecutionID + "/cancel"
+
+	// Another tenant cannot see the run, let alone stop it
+	if resp, data := doRequest(t, ts, "POST", cancelPath, nil, bob...); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("cancel by bob: %d %s, want 404", resp.StatusCode, data)
+	}
+
+	resp, data = doRequest(t, ts, "POST", cancelPath, nil, alice...)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("cancel: %d %s", resp.StatusCode, data)
+	}
+
+	var result ExecutionResult
+	eventually(t, "the run to be cancelled", func() bool {
+		_, data := doRequest(t, ts, "GET", "/api/executions/"+accepted.ExecutionID, nil, alice...)
+		decode(t, data, &result)
+		return result.Status == StatusCancelled
+	})
+	if calls := rec.calls(); len(calls) != 0 {
+		t.Fatalf("node after the cancelled one ran with %v", calls)
+	}
+
+	resp, data = doRequest(t, ts, "POST", cancelPath, nil, alice...)
+	if resp.StatusCode != http.StatusConflict || apiError(t, data).Code != CodeExecutionNotRunning {
+		t.Fatalf("cancel finished run: %d %s", resp.StatusCode, data)
+	}
+	if resp, _ := doRequest(t, ts, "POST", "/api/executions/missing/cancel", nil, alice...); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("cancel unknown run: %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestDiffTwoRuns(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	s.engine.executor.RegisterExecutor(nodeRecord, &recorder{})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, &Workflow{Name: "diffed", Nodes: []Node{
+		{ID: "fixed", Type: NodeSet, Properties: map[string]interface{}{"fields": map[string]interface{}{"k": "v"}}},
+		{ID: "echo", Type: nodeRecord},
+	}})
+
+	a, err := s.engine.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"n": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.engine.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"n": 2.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, data := doRequest(t, ts, "GET", "/api/executions/"+a.ID+"/diff/"+b.ID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("diff: %d %s", resp.StatusCode, data)
+	}
+	var diff ExecutionDiff
+	decode(t, data, &diff)
+	if diff.WorkflowID != wf.ID || diff.A != a.ID || diff.B != b.ID || len(diff.Nodes) != 2 {
+		t.Fatalf("diff = %+v", diff)
+	}
+	echo, fixed := diff.Nodes[0], diff.Nodes[1]
+	if echo.NodeID != "echo" || echo.Change != ChangeChanged {
+		t.Fatalf("echo = %+v, want changed", echo)
+	}
+	if !reflect.DeepEqual(echo.OutputA, map[string]interface{}{"n": 1.0}) || !reflect.DeepEqual(echo.OutputB, map[string]interface{}{"n": 2.0}) {
+		t.Errorf("echo outputs = %v / %v", echo.OutputA, echo.OutputB)
+	}
+	if fixed.NodeID != "fixed" || fixed.Change != ChangeUnchanged || fixed.OutputA != nil || fixed.OutputB != nil {
+		t.Errorf("fixed = %+v, want unchanged without outputs", fixed)
+	}
+}
+
+func TestDiffAddedRemovedAndFailed(t *testing.T) {
+	a := &ExecutionResult{ID: "a", WorkflowID: "w", Status: StatusCompleted, Results: map[string]interface{}{
+		"old":  "gone next time",
+		"same": 1.0,
+	}}
+	b := &ExecutionResult{ID: "b", WorkflowID: "w", Status: StatusFailed, Results: map[string]interface{}{
+		"new":  "first seen",
+		"same": 1.0,
+	}, Errors: []string{"node same2 error: boom"}}
+
+	got := make(map[string]NodeDiff)
+	for _, nd := range diffExecutions(a, b).Nodes {
+		got[nd.NodeID] = nd
+	}
+	tests := map[string]struct{ change, statusA, statusB string }{
+		"old":   {ChangeRemoved, StatusCompleted, "not_run"},
+		"new":   {ChangeAdded, "not_run", StatusCompleted},
+		"same":  {ChangeUnchanged, StatusCompleted, StatusCompleted},
+		"same2": {ChangeChanged, "not_run", "failed"},
+	}
+	if len(got) != len(tests) {
+		t.Fatalf("diffed nodes = %v", got)
+	}
+	for id, want := range tests {
+		nd := got[id]
+		if nd.Change != want.change || nd.StatusA != want.statusA || nd.StatusB != want.statusB {
+			t.Errorf("%s = %+v, want %+v", id, nd, want)
+		}
+	}
+}
+
+func TestDiffRejectsMismatchedRuns(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	var ids []string
+	for _, name := range []string{"one", "two"} {
+		wf := mustCreate(t, s.engine, ctx, &Workflow{Name: name})
+		result, err := s.engine.ExecuteWorkflow(ctx, wf.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, result.ID)
+	}
+
+	resp, data := doRequest(t, ts, "GET", "/api/executions/"+ids[0]+"/diff/"+ids[1], nil)
+	if resp.StatusCode != http.StatusBadRequest || apiError(t, data).Code != CodeWorkflowMismatch {
+		t.Fatalf("different workflows: %d %s", resp.StatusCode, data)
+	}
+	if resp, _ := doRequest(t, ts, "GET", "/api/executions/"+ids[0]+"/diff/missing", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown execution: %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,179 @@
+// executor_test.go - Node executor registry and per-node result tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shout is a custom node type that upper-cases its text property.
+type shout struct{}
+
+func (shout) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	text, _ := node.Properties["text"].(string)
+	return strings.ToUpper(text) + "!", nil
+}
+
+const nodeShout NodeType = "shout"
+
+func TestCustomNodeTypeRuns(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine func(t *testing.T) *WorkflowEngine
+	}{
+		{"engine option", func(t *testing.T) *WorkflowEngine {
+			return newTestEngine(t, WithNodeExecutor(nodeShout, shout{}))
+		}},
+		{"registered later", func(t *testing.T) *WorkflowEngine {
+			we := newTestEngine(t)
+			we.executor.RegisterExecutor(nodeShout, shout{})
+			return we
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			we := tt.engine(t)
+			ctx := context.Background()
+			wf := mustCreate(t, we, ctx, &Workflow{
+				Name:  "custom",
+				Nodes: []Node{{ID: "s", Type: nodeShout, Properties: map[string]interface{}{"text": "hello"}}},
+			})
+			result, err := we.ExecuteWorkflow(ctx, wf.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Status != StatusCompleted || result.Results["s"] != "HELLO!" {
+				t.Fatalf("result = %s %v %v", result.Status, result.Results, result.Errors)
+			}
+		})
+	}
+}
+
+func TestCustomExecutorReplacesBuiltIn(t *testing.T) {
+	we := newTestEngine(t, WithNodeExecutor(NodeTransform, shout{}))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name:  "replaced",
+		Nodes: []Node{{ID: "t", Type: NodeTransform, Properties: map[string]interface{}{"text": "mine", "script": "return 1"}}},
+	})
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Results["t"] != "MINE!" {
+		t.Fatalf("transform ran the built-in executor: %v", result.Results["t"])
+	}
+}
+
+func TestCustomExecutorThroughServerConfig(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeShout: shout{}}})
+	_, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{
+		"name":  "over http",
+		"nodes": []interface{}{map[string]interface{}{"id": "s", "type": "shout", "properties": map[string]interface{}{"text": "hi"}}},
+	})
+	var wf Workflow
+	decode(t, data, &wf)
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("execute: %d %s", resp.StatusCode, data)
+	}
+	var result ExecutionResult
+	decode(t, data, &result)
+	if result.Results["s"] != "HI!" {
+		t.Fatalf("results = %v", result.Results)
+	}
+}
+
+func TestUnknownNodeTypeFails(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "unknown", Nodes: []Node{{ID: "m", Type: "mystery"}}})
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "no executor for node type: mystery"
+	if result.Status != StatusFailed || result.Nodes["m"].Error != want {
+		t.Fatalf("result = %s, node error %q; want %q", result.Status, result.Nodes["m"].Error, want)
+	}
+}
+
+// ticking is a node executor that takes its "ms" property of fake time.
+type ticking struct{ clock *FakeClock }
+
+func (k ticking) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	ms, _ := node.Properties["ms"].(float64)
+	k.clock.Advance(time.Duration(ms) * time.Millisecond)
+	return node.ID, nil
+}
+
+const nodeTick NodeType = "tick"
+
+func TestNodeResultsRecordTimings(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	we := newTestEngine(t, WithClock(clock), WithNodeExecutor(nodeTick, ticking{clock}), WithNodeExecutor(nodeFail, failing{}))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "timed",
+		Nodes: []Node{
+			{ID: "a", Type: nodeTick, Properties: map[string]interface{}{"ms": 30.0}},
+			{ID: "b", Type: nodeTick, Properties: map[string]interface{}{"ms": 70.0}},
+			{ID: "c", Type: nodeFail},
+			{ID: "d", Type: nodeTick},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "a", ToID: "b"},
+			{ID: "c2", FromID: "b", ToID: "c"},
+			{ID: "c3", FromID: "c", ToID: "d"},
+		},
+	})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	tests := []struct {
+		id         string
+		status     string
+		start, end time.Time
+		output     interface{}
+		attempts   int
+	}{
+		{"a", NodeStatusCompleted, at(0), at(30), "a", 1},
+		{"b", NodeStatusCompleted, at(30), at(100), "b", 1},
+		{"c", NodeStatusFailed, at(100), at(100), nil, 1},
+		{"d", NodeStatusSkipped, at(100), at(100), nil, 0},
+	}
+	for _, tt := range tests {
+		nr := result.Nodes[tt.id]
+		if nr == nil {
+			t.Errorf("%s: no node result", tt.id)
+			continue
+		}
+		if nr.Status != tt.status || !nr.StartTime.Equal(tt.start) || !nr.EndTime.Equal(tt.end) {
+			t.Errorf("%s: %s from %v to %v, want %s from %v to %v", tt.id, nr.Status, nr.StartTime, nr.EndTime, tt.status, tt.start, tt.end)
+		}
+		if want := tt.end.Sub(tt.start).Milliseconds(); nr.DurationMs != want {
+			t.Errorf("%s: duration %dms, want %dms", tt.id, nr.DurationMs, want)
+		}
+		if nr.Output != tt.output || nr.Attempts != tt.attempts {
+			t.Errorf("%s: output %v, attempts %d, want %v, %d", tt.id, nr.Output, nr.Attempts, tt.output, tt.attempts)
+		}
+	}
+	if result.Nodes["c"].Error != "boom" {
+		t.Errorf("failed node error = %q, want boom", result.Nodes["c"].Error)
+	}
+	// The flat results map is kept for existing clients
+	if result.Results["a"] != "a" || result.Results["b"] != "b" {
+		t.Errorf("results = %v", result.Results)
+	}
+	if !result.StartTime.Equal(start) || !result.EndTime.Equal(at(100)) {
+		t.Errorf("run from %v to %v, want %v to %v", result.StartTime, result.EndTime, start, at(100))
+	}
+}
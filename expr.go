@@ -0,0 +1,486 @@
+// expr.go - Expression language for conditions and filters
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ============================================
+// Expressions
+// ============================================
+//
+// Expressions are small boolean/arithmetic formulas evaluated against a
+// scope value, e.g.
+//
+//	body.status == "active" && body.age >= 18
+//	!(tags contains "spam") || $.score * 2 > 10
+//
+// Bare identifiers and paths (a.b[0].c) are looked up in the scope; "$" is
+// the scope itself. Literals are numbers, 'single' or "double" quoted
+// strings, true, false and null. Operators, loosest first: || (or),
+// && (and), ! (not), comparisons (== != < <= > >= contains), + -, * / %.
+
+// evalExpr evaluates src against scope.
+func evalExpr(src string, scope interface{}) (interface{}, error) {
+	p := &exprParser{src: src}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in expression", p.peek().text)
+	}
+	return node(genericJSON(scope))
+}
+
+// evalCondition evaluates src and reports whether the result is truthy.
+func evalCondition(src string, scope interface{}) (bool, error) {
+	v, err := evalExpr(src, scope)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// truthy treats null, false, 0 and "" as false and everything else as true.
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+type exprFunc func(scope interface{}) (interface{}, error)
+
+type tokenKind int
+
+const (
+	tokOp tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+}
+
+type exprParser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(s) && rune(s[j]) != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return fmt.Errorf("unterminated string in expression")
+			}
+			p.tokens = append(p.tokens, token{kind: tokString, text: sb.String()})
+			i = j + 1
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", s[i:j])
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: s[i:j], num: n})
+			i = j
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			j := i
+			for j < len(s) && isPathChar(rune(s[j])) {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("unexpected character %q in expression", c)
+			}
+			p.tokens = append(p.tokens, token{kind: tokOp, text: op})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func isPathChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("_$.[]", c)
+}
+
+func (p *exprParser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *exprParser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	if p.done() {
+		return "", false
+	}
+	t := p.tokens[p.pos]
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseOr() (exprFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(scope interface{}) (interface{}, error) {
+			a, err := l(scope)
+			if err != nil || truthy(a) {
+				return truthy(a), err
+			}
+			b, err := right(scope)
+			return truthy(b), err
+		}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprFunc, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(scope interface{}) (interface{}, error) {
+			a, err := l(scope)
+			if err != nil || !truthy(a) {
+				return false, err
+			}
+			b, err := right(scope)
+			return truthy(b), err
+		}
+	}
+}
+
+func (p *exprParser) parseNot() (exprFunc, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(scope interface{}) (interface{}, error) {
+			v, err := inner(scope)
+			return !truthy(v), err
+		}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprFunc, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "contains")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return func(scope interface{}) (interface{}, error) {
+		a, err := left(scope)
+		if err != nil {
+			return nil, err
+		}
+		b, err := right(scope)
+		if err != nil {
+			return nil, err
+		}
+		return compareValues(op, a, b)
+	}, nil
+}
+
+func (p *exprParser) parseAdditive() (exprFunc, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmetic(op, left, right)
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmetic(op, left, right)
+	}
+}
+
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	if _, ok := p.accept("-"); ok {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return arithmetic("-", func(interface{}) (interface{}, error) { return 0.0, nil }, inner), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case tokNumber:
+		return constant(t.num), nil
+	case tokString:
+		return constant(t.text), nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return constant(true), nil
+		case "false":
+			return constant(false), nil
+		case "null", "nil":
+			return constant(nil), nil
+		}
+		path := t.text
+		return func(scope interface{}) (interface{}, error) {
+			v, _ := lookupPath(scope, path)
+			return v, nil
+		}, nil
+	}
+
+	if t.text == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing ) in expression")
+		}
+		return inner, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", t.text)
+}
+
+func constant(v interface{}) exprFunc {
+	return func(interface{}) (interface{}, error) { return v, nil }
+}
+
+func arithmetic(op string, left, right exprFunc) exprFunc {
+	return func(scope interface{}) (interface{}, error) {
+		a, err := left(scope)
+		if err != nil {
+			return nil, err
+		}
+		b, err := right(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		// + joins strings when either side is one
+		if op == "+" {
+			_, as := a.(string)
+			_, bs := b.(string)
+			if as || bs {
+				return exprString(a) + exprString(b), nil
+			}
+		}
+
+		x, ok1 := exprNumber(a)
+		y, ok2 := exprNumber(b)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%s needs numbers, got %v and %v", op, a, b)
+		}
+		switch op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/":
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		default:
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return math.Mod(x, y), nil
+		}
+	}
+}
+
+// compareValues compares numbers numerically (numeric strings included)
+// and everything else by text. contains tests array membership or
+// substring.
+func compareValues(op string, a, b interface{}) (interface{}, error) {
+	if op == "contains" {
+		if list, ok := a.([]interface{}); ok {
+			for _, item := range list {
+				if eq, _ := compareValues("==", item, b); eq == true {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return strings.Contains(exprString(a), exprString(b)), nil
+	}
+
+	if a == nil || b == nil {
+		switch op {
+		case "==":
+			return a == nil && b == nil, nil
+		case "!=":
+			return !(a == nil && b == nil), nil
+		}
+		return false, nil
+	}
+
+	var cmp int
+	x, ok1 := exprNumber(a)
+	y, ok2 := exprNumber(b)
+	if ok1 && ok2 {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(exprString(a), exprString(b))
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func exprNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case bool:
+		return 0, false
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func exprString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
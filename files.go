@@ -0,0 +1,195 @@
+// files.go - Local file read/write nodes
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithFileBaseDir lets file nodes work inside dir. Without it file access
+// is disabled.
+func WithFileBaseDir(dir string) EngineOption {
+	return func(we *WorkflowEngine) { we.fileBaseDir = dir }
+}
+
+// ============================================
+// Sandboxed Paths
+// ============================================
+
+// resolveFilePath maps a node's path into base, rejecting anything that
+// escapes it, including through symlinks. Relative paths are taken from
+// base.
+func resolveFilePath(base, path string) (string, error) {
+	if base == "" {
+		return "", fmt.Errorf("file access is disabled; set FILES_DIR to allow it")
+	}
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	root, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	target := path
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	target = filepath.Clean(target)
+
+	// Check the real location of the deepest part that exists, so a
+	// symlink inside base cannot point out of it
+	real := target
+	for dir := target; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			rest, _ := filepath.Rel(dir, target)
+			real = filepath.Join(resolved, rest)
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	rel, err := filepath.Rel(root, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the allowed directory", path)
+	}
+	return target, nil
+}
+
+// fileError turns os errors into messages that name the node's path.
+func fileError(path string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("file not found: %s", path)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("permission denied: %s", path)
+	}
+	return err
+}
+
+// ============================================
+// File Read Node
+// ============================================
+
+// FileReadExecutor reads a file. Properties: path, encoding (text,
+// base64 for binary data, or json to parse the contents).
+type FileReadExecutor struct {
+	baseDir string
+}
+
+func (e *FileReadExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	path, _ := node.Properties["path"].(string)
+	target, err := resolveFilePath(e.baseDir, path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil, fileError(path, err)
+	}
+
+	encoding, _ := node.Properties["encoding"].(string)
+	var content interface{}
+	switch encoding {
+	case "", "text":
+		content = string(data)
+	case "base64":
+		content = base64.StdEncoding.EncodeToString(data)
+	case "json":
+		if err := json.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("%s is not valid JSON: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %q", encoding)
+	}
+
+	return map[string]interface{}{
+		"path":     path,
+		"size":     len(data),
+		"encoding": encoding,
+		"content":  content,
+	}, nil
+}
+
+// ============================================
+// File Write Node
+// ============================================
+
+// FileWriteExecutor writes a file, creating missing directories.
+// Properties: path, content (blank = the input: strings as-is, anything
+// else as JSON), encoding (text, or base64 to decode binary content),
+// append.
+type FileWriteExecutor struct {
+	baseDir string
+}
+
+func (e *FileWriteExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	path, _ := node.Properties["path"].(string)
+	target, err := resolveFilePath(e.baseDir, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	content, _ := node.Properties["content"].(string)
+	switch {
+	case content != "":
+		data = []byte(content)
+	case input == nil:
+	default:
+		if s, ok := input.(string); ok {
+			data = []byte(s)
+		} else if data, err = json.MarshalIndent(input, "", "  "); err != nil {
+			return nil, fmt.Errorf("encode input: %v", err)
+		}
+	}
+
+	switch encoding, _ := node.Properties["encoding"].(string); encoding {
+	case "", "text":
+	case "base64":
+		if data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err != nil {
+			return nil, fmt.Errorf("invalid base64 content: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %q", encoding)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return nil, fileError(path, err)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if boolProperty(node, "append") {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(target, flags, 0o644)
+	if err != nil {
+		return nil, fileError(path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fileError(path, err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fileError(path, err)
+	}
+
+	return map[string]interface{}{
+		"status": "file_written",
+		"path":   path,
+		"bytes":  len(data),
+	}, nil
+}
@@ -0,0 +1,137 @@
+// files_test.go - File read/write node tests
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileWriteThenRead(t *testing.T) {
+	dir := t.TempDir()
+	we := newTestEngine(t, WithFileBaseDir(dir))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "save and load",
+		Nodes: []Node{
+			{ID: "save", Type: NodeFileWrite, Properties: map[string]interface{}{"path": "out/report.json"}},
+			{ID: "load", Type: NodeFileRead, Properties: map[string]interface{}{"path": "out/report.json", "encoding": "json"}},
+		},
+		Connections: []Connection{{ID: "c1", FromID: "save", ToID: "load"}},
+	})
+
+	input := map[string]interface{}{"total": 3.0, "ok": true}
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "report.json")); err != nil {
+		t.Fatalf("file not written inside the base dir: %v", err)
+	}
+	loaded := result.Results["load"].(map[string]interface{})
+	if !reflect.DeepEqual(loaded["content"], input) {
+		t.Fatalf("read back %v, want %v", loaded["content"], input)
+	}
+}
+
+func TestFileEncodings(t *testing.T) {
+	dir := t.TempDir()
+	write := &FileWriteExecutor{baseDir: dir}
+	read := &FileReadExecutor{baseDir: dir}
+	ctx := context.Background()
+
+	// base64 content is decoded on write and re-encoded on read
+	binary := "AAEC/w=="
+	if _, err := write.Execute(ctx, &Node{Properties: map[string]interface{}{"path": "blob.bin", "encoding": "base64"}}, binary); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "blob.bin")); string(data) != "\x00\x01\x02\xff" {
+		t.Fatalf("binary file = %q", data)
+	}
+	out, err := read.Execute(ctx, &Node{Properties: map[string]interface{}{"path": "blob.bin", "encoding": "base64"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{}); got["content"] != binary || got["size"] != 4 {
+		t.Fatalf("read = %v", got)
+	}
+
+	// append adds to the file; text reads it back as-is
+	for _, line := range []string{"one\n", "two\n"} {
+		if _, err := write.Execute(ctx, &Node{Properties: map[string]interface{}{"path": "log.txt", "content": line, "append": true}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err = read.Execute(ctx, &Node{Properties: map[string]interface{}{"path": "log.txt"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{})["content"]; got != "one\ntwo\n" {
+		t.Fatalf("text = %q", got)
+	}
+}
+
+func TestFileAccessStaysInBaseDir(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	read := &FileReadExecutor{baseDir: dir}
+	write := &FileWriteExecutor{baseDir: dir}
+
+	for _, path := range []string{
+		"../secret",
+		"sub/../../secret",
+		filepath.Join(outside, "secret"),
+		"escape/secret",
+		"escape/new.txt",
+	} {
+		props := map[string]interface{}{"path": path}
+		if _, err := read.Execute(context.Background(), &Node{Properties: props}, nil); err == nil || !strings.Contains(err.Error(), "outside the allowed directory") {
+			t.Errorf("read %q: err = %v", path, err)
+		}
+		if _, err := write.Execute(context.Background(), &Node{Properties: props}, "data"); err == nil || !strings.Contains(err.Error(), "outside the allowed directory") {
+			t.Errorf("write %q: err = %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Fatal("write escaped through a symlink")
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	_, err := (&FileReadExecutor{baseDir: dir}).Execute(ctx, &Node{Properties: map[string]interface{}{"path": "missing.txt"}}, nil)
+	if err == nil || err.Error() != "file not found: missing.txt" {
+		t.Fatalf("missing file: err = %v", err)
+	}
+
+	_, err = (&FileReadExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{"path": "a.txt"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("no base dir: err = %v", err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks do not apply to root")
+	}
+	locked := filepath.Join(dir, "locked.txt")
+	if err := os.WriteFile(locked, []byte("x"), 0o000); err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&FileReadExecutor{baseDir: dir}).Execute(ctx, &Node{Properties: map[string]interface{}{"path": "locked.txt"}}, nil)
+	if err == nil || err.Error() != "permission denied: locked.txt" {
+		t.Fatalf("unreadable file: err = %v", err)
+	}
+}
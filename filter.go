@@ -0,0 +1,60 @@
+// filter.go - Drop items that fail a condition
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PortStop receives a single (non-array) input that fails a filter.
+const PortStop = "stop"
+
+// ============================================
+// Filter Node
+// ============================================
+
+// FilterExecutor keeps the input items for which its condition expression
+// holds, evaluating the condition with each item as the scope. An array
+// input (or the array at the optional items path, e.g. "body.orders")
+// yields the array of passing items. Any other input passes through when
+// it matches; otherwise the run stops here, following only connections
+// labelled "stop".
+type FilterExecutor struct{}
+
+func (e *FilterExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	condition, _ := node.Properties["condition"].(string)
+	if strings.TrimSpace(condition) == "" {
+		return nil, fmt.Errorf("condition is required")
+	}
+
+	value := genericJSON(input)
+	if path, _ := node.Properties["items"].(string); strings.TrimSpace(path) != "" {
+		value, _ = lookupPath(value, path)
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		pass, err := evalCondition(condition, input)
+		if err != nil {
+			return nil, err
+		}
+		if !pass {
+			return &Routed{Port: PortStop, Output: input}, nil
+		}
+		return input, nil
+	}
+
+	kept := []interface{}{}
+	for i, item := range items {
+		pass, err := evalCondition(condition, item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", i, err)
+		}
+		if pass {
+			kept = append(kept, item)
+		}
+	}
+	logf(ctx, "info", "kept %d of %d items", len(kept), len(items))
+	return kept, nil
+}
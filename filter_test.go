@@ -0,0 +1,93 @@
+// filter_test.go - Filter node tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFilterKeepsMatchingItems(t *testing.T) {
+	people := []interface{}{
+		map[string]interface{}{"name": "ann", "status": "active", "age": 20.0},
+		map[string]interface{}{"name": "bob", "status": "inactive", "age": 30.0},
+		map[string]interface{}{"name": "cy", "status": "active", "age": 15.0},
+		"not a person",
+		map[string]interface{}{"name": "dee", "age": 40.0},
+		map[string]interface{}{"name": "eve", "status": "active", "age": 18.0},
+	}
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input interface{}
+		want  []interface{}
+	}{
+		{
+			name:  "array input",
+			props: map[string]interface{}{"condition": `status == "active" && age >= 18`},
+			input: people,
+			want:  []interface{}{people[0], people[5]},
+		},
+		{
+			name:  "items path",
+			props: map[string]interface{}{"condition": `age > 25`, "items": "body.people"},
+			input: map[string]interface{}{"body": map[string]interface{}{"people": people}},
+			want:  []interface{}{people[1], people[4]},
+		},
+		{
+			name:  "mixed scalars",
+			props: map[string]interface{}{"condition": `$ > 2`},
+			input: []interface{}{1.0, 5.0, "7", 2.0, 3.0},
+			want:  []interface{}{5.0, "7", 3.0},
+		},
+		{
+			name:  "nothing passes",
+			props: map[string]interface{}{"condition": `false`},
+			input: people,
+			want:  []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		out, err := (&FilterExecutor{}).Execute(context.Background(), &Node{Properties: tt.props}, tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(out, tt.want) {
+			t.Errorf("%s: kept %v, want %v", tt.name, out, tt.want)
+		}
+	}
+}
+
+func TestFilterStopsFailingScalarInput(t *testing.T) {
+	kept := &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, kept))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "adults only",
+		Nodes: []Node{
+			{ID: "filter", Type: NodeFilter, Properties: map[string]interface{}{"condition": "age >= 18"}},
+			{ID: "next", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c1", FromID: "filter", ToID: "next"}},
+	})
+
+	for _, age := range []float64{12, 30} {
+		result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"age": age})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != StatusCompleted {
+			t.Fatalf("age %v: status = %s", age, result.Status)
+		}
+	}
+	calls := kept.calls()
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0], map[string]interface{}{"age": 30.0}) {
+		t.Fatalf("downstream saw %v, want only the adult", calls)
+	}
+}
+
+func TestFilterRequiresCondition(t *testing.T) {
+	if _, err := (&FilterExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{"condition": " "}}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
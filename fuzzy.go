@@ -0,0 +1,191 @@
+// fuzzy.go - Fuzzy string matching node
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================================
+// Fuzzy Match Node
+// ============================================
+
+// FuzzyExecutor scores string similarity between 0 and 1. With a
+// candidates list it ranks them against value and reports the best match;
+// otherwise it compares value with other. Properties: algorithm
+// (levenshtein|jarowinkler), value, other, candidates (array or
+// newline/comma separated), caseSensitive.
+type FuzzyExecutor struct{}
+
+func (e *FuzzyExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	algorithm, _ := node.Properties["algorithm"].(string)
+	if algorithm == "" {
+		algorithm = "levenshtein"
+	}
+
+	var score func(a, b string) float64
+	switch algorithm {
+	case "levenshtein":
+		score = levenshteinSimilarity
+	case "jarowinkler":
+		score = jaroWinkler
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %q", algorithm)
+	}
+
+	value, _ := node.Properties["value"].(string)
+	if value == "" {
+		value, _ = input.(string)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	caseSensitive, _ := node.Properties["caseSensitive"].(bool)
+	norm := func(s string) string {
+		if caseSensitive {
+			return s
+		}
+		return strings.ToLower(s)
+	}
+
+	candidates := stringList(node.Properties["candidates"])
+	if len(candidates) == 0 {
+		other, _ := node.Properties["other"].(string)
+		return map[string]interface{}{
+			"algorithm": algorithm,
+			"score":     score(norm(value), norm(other)),
+		}, nil
+	}
+
+	type scored struct {
+		Candidate string  `json:"candidate"`
+		Score     float64 `json:"score"`
+	}
+	scores := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		scores = append(scores, scored{Candidate: c, Score: score(norm(value), norm(c))})
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+
+	return map[string]interface{}{
+		"algorithm":  algorithm,
+		"best_match": scores[0].Candidate,
+		"score":      scores[0].Score,
+		"scores":     scores,
+	}, nil
+}
+
+// stringList accepts a JSON array or a newline/comma separated string.
+func stringList(v interface{}) []string {
+	var out []string
+	switch list := v.(type) {
+	case []interface{}:
+		for _, item := range list {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	case []string:
+		out = append(out, list...)
+	case string:
+		for _, s := range strings.FieldsFunc(list, func(r rune) bool { return r == '\n' || r == ',' }) {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// levenshteinSimilarity normalizes edit distance by the longer string.
+func levenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func jaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, k := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[k] {
+			k++
+		}
+		if ra[i] != rb[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	// Winkler boost for a shared prefix of up to four characters
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
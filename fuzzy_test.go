@@ -0,0 +1,75 @@
+// fuzzy_test.go - Fuzzy matching node tests
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestFuzzyMatchesMisspelledName(t *testing.T) {
+	for _, algorithm := range []string{"levenshtein", "jarowinkler"} {
+		out, err := (&FuzzyExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{
+			"algorithm":  algorithm,
+			"candidates": []interface{}{"Jonathan Smith", "Jane Smythe", "Joan Smit", "Nathan Jones"},
+		}}, "Jonathon Smtih")
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		result := out.(map[string]interface{})
+		if result["best_match"] != "Jonathan Smith" {
+			t.Errorf("%s: best_match = %v, scores %v", algorithm, result["best_match"], result["scores"])
+		}
+	}
+}
+
+func TestFuzzyCaseSensitivity(t *testing.T) {
+	run := func(caseSensitive bool) float64 {
+		out, err := (&FuzzyExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{
+			"value":         "ACME Corp",
+			"other":         "acme corp",
+			"caseSensitive": caseSensitive,
+		}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out.(map[string]interface{})["score"].(float64)
+	}
+	if got := run(false); got != 1 {
+		t.Errorf("case-insensitive score = %v, want 1", got)
+	}
+	if got := run(true); got >= 1 {
+		t.Errorf("case-sensitive score = %v, want below 1", got)
+	}
+}
+
+func TestSimilarityScores(t *testing.T) {
+	tests := []struct {
+		score func(a, b string) float64
+		a, b  string
+		want  float64
+	}{
+		{levenshteinSimilarity, "kitten", "sitting", 1 - 3.0/7},
+		{levenshteinSimilarity, "", "", 1},
+		{levenshteinSimilarity, "abc", "abc", 1},
+		{jaroWinkler, "MARTHA", "MARHTA", 0.9611},
+		{jaroWinkler, "DIXON", "DICKSONX", 0.8133},
+		{jaroWinkler, "abc", "", 0},
+	}
+	for _, tt := range tests {
+		if got := tt.score(tt.a, tt.b); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("score(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyRejectsBadInput(t *testing.T) {
+	for name, props := range map[string]map[string]interface{}{
+		"no value":          {"other": "x"},
+		"unknown algorithm": {"value": "x", "algorithm": "soundex"},
+	} {
+		if _, err := (&FuzzyExecutor{}).Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+// gcalendar.go - Google Calendar event node
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// calendarAPIBase is the Google Calendar API root.
+	calendarAPIBase = "https://www.googleapis.com/calendar/v3"
+	// calendarScope is the OAuth scope service accounts request.
+	calendarScope = "https://www.googleapis.com/auth/calendar"
+	// defaultCalendarListMax caps listed events unless maxResults is set.
+	defaultCalendarListMax = 50
+)
+
+// WithCalendarClient replaces the client the Google Calendar node reaches
+// the Calendar API through, e.g. with a fake in tests.
+func WithCalendarClient(client CalendarClient) EngineOption {
+	return func(we *WorkflowEngine) { we.calendar = client }
+}
+
+// ============================================
+// Calendar Client
+// ============================================
+
+// CalendarAuth is how a request authenticates: a service account key (JSON,
+// optionally impersonating Subject) or an OAuth access token.
+type CalendarAuth struct {
+	ServiceAccount string
+	Subject        string
+	AccessToken    string
+}
+
+// CalendarEvent is an event as the node sees it. Start and End are RFC
+// 3339 times, or YYYY-MM-DD dates for all-day events.
+type CalendarEvent struct {
+	ID          string   `json:"id,omitempty"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Location    string   `json:"location,omitempty"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	Attendees   []string `json:"attendees,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	Link        string   `json:"link,omitempty"`
+}
+
+// CalendarListOptions narrows a listing. Zero values are unset.
+type CalendarListOptions struct {
+	TimeMin    string
+	TimeMax    string
+	Query      string
+	MaxResults int
+}
+
+// CalendarClient connects the Google Calendar node to the Calendar API.
+type CalendarClient interface {
+	Insert(ctx context.Context, auth CalendarAuth, calendarID string, event CalendarEvent) (CalendarEvent, error)
+	List(ctx context.Context, auth CalendarAuth, calendarID string, opts CalendarListOptions) ([]CalendarEvent, error)
+}
+
+// googleCalendarClient is the default CalendarClient, calling the REST API
+// and caching a token source per service account and subject.
+type googleCalendarClient struct {
+	client *http.Client
+	mu     sync.Mutex
+	tokens map[[32]byte]oauth2.TokenSource
+}
+
+func NewCalendarClient() CalendarClient {
+	return &googleCalendarClient{
+		client: &http.Client{Timeout: 30 * time.Second},
+		tokens: make(map[[32]byte]oauth2.TokenSource),
+	}
+}
+
+// token returns the bearer token for auth.
+func (c *googleCalendarClient) token(ctx context.Context, auth CalendarAuth) (string, error) {
+	if auth.AccessToken != "" {
+		return auth.AccessToken, nil
+	}
+	key := sha256.Sum256([]byte(auth.ServiceAccount + "\x00" + auth.Subject))
+	c.mu.Lock()
+	source, ok := c.tokens[key]
+	if !ok {
+		var account struct {
+			ClientEmail  string `json:"client_email"`
+			PrivateKey   string `json:"private_key"`
+			PrivateKeyID string `json:"private_key_id"`
+			TokenURI     string `json:"token_uri"`
+		}
+		if err := json.Unmarshal([]byte(auth.ServiceAccount), &account); err != nil || account.ClientEmail == "" {
+			c.mu.Unlock()
+			return "", fmt.Errorf("credentials must be a service account key JSON")
+		}
+		if account.TokenURI == "" {
+			account.TokenURI = "https://oauth2.googleapis.com/token"
+		}
+		cfg := &jwt.Config{
+			Email:        account.ClientEmail,
+			PrivateKey:   []byte(account.PrivateKey),
+			PrivateKeyID: account.PrivateKeyID,
+			Subject:      auth.Subject,
+			Scopes:       []string{calendarScope},
+			TokenURL:     account.TokenURI,
+		}
+		source = cfg.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, c.client))
+		c.tokens[key] = source
+	}
+	c.mu.Unlock()
+
+	tok, err := source.Token()
+	if err != nil {
+		return "", fmt.Errorf("google auth: %v", err)
+	}
+	return tok.AccessToken, nil
+}
+
+// call sends one API request and decodes the response into out.
+func (c *googleCalendarClient) call(ctx context.Context, auth CalendarAuth, method, endpoint string, body, out interface{}) error {
+	token, err := c.token(ctx, auth)
+	if err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("google calendar: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes))
+	if err != nil {
+		return fmt.Errorf("google calendar: %v", err)
+	}
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return withStatus(resp.StatusCode, fmt.Errorf("google calendar returned %d: %s", resp.StatusCode, apiErr.Error.Message))
+		}
+		return withStatus(resp.StatusCode, fmt.Errorf("google calendar returned %s", resp.Status))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// googleEvent is an event in the API's format.
+type googleEvent struct {
+	ID          string          `json:"id,omitempty"`
+	Summary     string          `json:"summary"`
+	Description string          `json:"description,omitempty"`
+	Location    string          `json:"location,omitempty"`
+	Start       googleEventTime `json:"start"`
+	End         googleEventTime `json:"end"`
+	Attendees   []struct {
+		Email string `json:"email"`
+	} `json:"attendees,omitempty"`
+	Status   string `json:"status,omitempty"`
+	HTMLLink string `json:"htmlLink,omitempty"`
+}
+
+type googleEventTime struct {
+	Date     string `json:"date,omitempty"`
+	DateTime string `json:"dateTime,omitempty"`
+}
+
+func toGoogleEvent(ev CalendarEvent) googleEvent {
+	at := func(s string) googleEventTime {
+		if len(s) == len("2006-01-02") {
+			return googleEventTime{Date: s}
+		}
+		return googleEventTime{DateTime: s}
+	}
+	g := googleEvent{
+		Summary:     ev.Summary,
+		Description: ev.Description,
+		Location:    ev.Location,
+		Start:       at(ev.Start),
+		End:         at(ev.End),
+	}
+	for _, email := range ev.Attendees {
+		g.Attendees = append(g.Attendees, struct {
+			Email string `json:"email"`
+		}{email})
+	}
+	return g
+}
+
+func fromGoogleEvent(g googleEvent) CalendarEvent {
+	at := func(t googleEventTime) string {
+		if t.DateTime != "" {
+			return t.DateTime
+		}
+		return t.Date
+	}
+	ev := CalendarEvent{
+		ID:          g.ID,
+		Summary:     g.Summary,
+		Description: g.Description,
+		Location:    g.Location,
+		Start:       at(g.Start),
+		End:         at(g.End),
+		Status:      g.Status,
+		Link:        g.HTMLLink,
+	}
+	for _, a := range g.Attendees {
+		ev.Attendees = append(ev.Attendees, a.Email)
+	}
+	return ev
+}
+
+func (c *googleCalendarClient) Insert(ctx context.Context, auth CalendarAuth, calendarID string, event CalendarEvent) (CalendarEvent, error) {
+	endpoint := calendarAPIBase + "/calendars/" + url.PathEscape(calendarID) + "/events"
+	var created googleEvent
+	if err := c.call(ctx, auth, http.MethodPost, endpoint, toGoogleEvent(event), &created); err != nil {
+		return CalendarEvent{}, err
+	}
+	return fromGoogleEvent(created), nil
+}
+
+func (c *googleCalendarClient) List(ctx context.Context, auth CalendarAuth, calendarID string, opts CalendarListOptions) ([]CalendarEvent, error) {
+	q := url.Values{"singleEvents": {"true"}, "orderBy": {"startTime"}}
+	if opts.TimeMin != "" {
+		q.Set("timeMin", opts.TimeMin)
+	}
+	if opts.TimeMax != "" {
+		q.Set("timeMax", opts.TimeMax)
+	}
+	if opts.Query != "" {
+		q.Set("q", opts.Query)
+	}
+	if opts.MaxResults > 0 {
+		q.Set("maxResults", strconv.Itoa(opts.MaxResults))
+	}
+	endpoint := calendarAPIBase + "/calendars/" + url.PathEscape(calendarID) + "/events?" + q.Encode()
+	var resp struct {
+		Items []googleEvent `json:"items"`
+	}
+	if err := c.call(ctx, auth, http.MethodGet, endpoint, nil, &resp); err != nil {
+		return nil, err
+	}
+	events := make([]CalendarEvent, len(resp.Items))
+	for i, item := range resp.Items {
+		events[i] = fromGoogleEvent(item)
+	}
+	return events, nil
+}
+
+// ============================================
+// Google Calendar Node
+// ============================================
+
+// GCalendarExecutor creates or lists events on a Google calendar.
+// Properties: credentials (service account key JSON) and subject (user to
+// act as, with domain-wide delegation), or accessToken (OAuth); calendarId
+// (default "primary") and operation:
+//   - create: summary, description, location, start, end and attendees
+//     (emails, comma-separated or an array), all templates rendered
+//     against the input. A blank end is an hour after start, or the next
+//     day for an all-day event.
+//   - list: timeMin, timeMax, query and maxResults (default 50).
+type GCalendarExecutor struct {
+	client CalendarClient
+}
+
+func (e *GCalendarExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	str := func(name string) string {
+		v, _ := node.Properties[name].(string)
+		return strings.TrimSpace(v)
+	}
+	auth := CalendarAuth{
+		ServiceAccount: str("credentials"),
+		Subject:        str("subject"),
+		AccessToken:    str("accessToken"),
+	}
+	if auth.ServiceAccount == "" && auth.AccessToken == "" {
+		return nil, fmt.Errorf("credentials or accessToken is required")
+	}
+	calendarID := str("calendarId")
+	if calendarID == "" {
+		calendarID = "primary"
+	}
+
+	switch operation := str("operation"); operation {
+	case "", "create":
+		event, err := calendarEventFromNode(node, input)
+		if err != nil {
+			return nil, err
+		}
+		created, err := e.client.Insert(ctx, auth, calendarID, event)
+		if err != nil {
+			return nil, err
+		}
+		return genericJSON(created), nil
+
+	case "list":
+		opts := CalendarListOptions{
+			TimeMin:    str("timeMin"),
+			TimeMax:    str("timeMax"),
+			Query:      str("query"),
+			MaxResults: defaultCalendarListMax,
+		}
+		if v := node.Properties["maxResults"]; v != nil && v != "" {
+			n, err := toInt(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("maxResults must be a positive integer, got %v", v)
+			}
+			opts.MaxResults = n
+		}
+		events, err := e.client.List(ctx, auth, calendarID, opts)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"events": genericJSON(events),
+			"count":  len(events),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported gcalendar operation: %q", operation)
+	}
+}
+
+// calendarEventFromNode renders the event fields of a create.
+func calendarEventFromNode(node *Node, input interface{}) (CalendarEvent, error) {
+	var event CalendarEvent
+	fields := []struct {
+		name string
+		dst  *string
+	}{
+		{"summary", &event.Summary},
+		{"description", &event.Description},
+		{"location", &event.Location},
+		{"start", &event.Start},
+		{"end", &event.End},
+	}
+	for _, f := range fields {
+		tmpl, _ := node.Properties[f.name].(string)
+		v, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return event, fmt.Errorf("%s: %v", f.name, err)
+		}
+		*f.dst = strings.TrimSpace(exprString(v))
+	}
+	if event.Summary == "" || event.Start == "" {
+		return event, fmt.Errorf("summary and start are required")
+	}
+
+	if event.End == "" {
+		if t, err := time.Parse(time.RFC3339, event.Start); err == nil {
+			event.End = t.Add(time.Hour).Format(time.RFC3339)
+		} else if d, err := time.Parse("2006-01-02", event.Start); err == nil {
+			event.End = d.AddDate(0, 0, 1).Format("2006-01-02")
+		} else {
+			return event, fmt.Errorf("start must be an RFC 3339 time or YYYY-MM-DD date, got %q", event.Start)
+		}
+	}
+
+	var attendees interface{} = node.Properties["attendees"]
+	if tmpl, ok := attendees.(string); ok {
+		v, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return event, fmt.Errorf("attendees: %v", err)
+		}
+		attendees = v
+	}
+	switch a := attendees.(type) {
+	case string:
+		for _, email := range strings.Split(a, ",") {
+			if email = strings.TrimSpace(email); email != "" {
+				event.Attendees = append(event.Attendees, email)
+			}
+		}
+	case []interface{}:
+		for _, item := range a {
+			event.Attendees = append(event.Attendees, exprString(item))
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,185 @@
+// gcalendar_test.go - Google Calendar node tests against a fake client
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// calendarInsert is one event the fake calendar was asked to create.
+type calendarInsert struct {
+	auth       CalendarAuth
+	calendarID string
+	event      CalendarEvent
+}
+
+// fakeCalendar is a CalendarClient keeping what it was asked to insert and
+// listing a fixed set of events.
+type fakeCalendar struct {
+	inserts []calendarInsert
+	lists   []CalendarListOptions
+	events  []CalendarEvent
+}
+
+func (c *fakeCalendar) Insert(ctx context.Context, auth CalendarAuth, calendarID string, event CalendarEvent) (CalendarEvent, error) {
+	c.inserts = append(c.inserts, calendarInsert{auth, calendarID, event})
+	event.ID = "evt1"
+	event.Status = "confirmed"
+	event.Link = "https://calendar.google.com/event?eid=evt1"
+	return event, nil
+}
+
+func (c *fakeCalendar) List(ctx context.Context, auth CalendarAuth, calendarID string, opts CalendarListOptions) ([]CalendarEvent, error) {
+	c.lists = append(c.lists, opts)
+	return c.events, nil
+}
+
+func TestGCalendarCreateEvent(t *testing.T) {
+	cal := &fakeCalendar{}
+	we := newTestEngine(t, WithCalendarClient(cal))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "schedule review",
+		Nodes: []Node{{ID: "event", Type: NodeGCalendar, Properties: map[string]interface{}{
+			"accessToken": "ya29.token",
+			"calendarId":  "oncall@example.com",
+			"summary":     "Incident review: {{ incident.title }}",
+			"description": "Severity {{ incident.severity }}",
+			"location":    "Room 4",
+			"start":       "{{ review.start }}",
+			"attendees":   "{{ review.attendees }}",
+		}}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{
+		"incident": map[string]interface{}{"title": "API outage", "severity": 1.0},
+		"review": map[string]interface{}{
+			"start":     "2024-03-04T15:00:00Z",
+			"attendees": []interface{}{"ada@example.com", "grace@example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+
+	want := calendarInsert{
+		auth:       CalendarAuth{AccessToken: "ya29.token"},
+		calendarID: "oncall@example.com",
+		event: CalendarEvent{
+			Summary:     "Incident review: API outage",
+			Description: "Severity 1",
+			Location:    "Room 4",
+			Start:       "2024-03-04T15:00:00Z",
+			End:         "2024-03-04T16:00:00Z",
+			Attendees:   []string{"ada@example.com", "grace@example.com"},
+		},
+	}
+	if len(cal.inserts) != 1 || !reflect.DeepEqual(cal.inserts[0], want) {
+		t.Fatalf("inserted %+v, want %+v", cal.inserts, want)
+	}
+	out := result.Results["event"].(map[string]interface{})
+	if out["id"] != "evt1" || out["summary"] != "Incident review: API outage" {
+		t.Fatalf("output = %v", out)
+	}
+}
+
+func TestGCalendarAllDayEvent(t *testing.T) {
+	cal := &fakeCalendar{}
+	e := &GCalendarExecutor{client: cal}
+	_, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"credentials": `{"client_email": "bot@project.iam.gserviceaccount.com"}`,
+		"subject":     "ada@example.com",
+		"summary":     "Offsite",
+		"start":       "2024-05-10",
+		"attendees":   "ada@example.com, , grace@example.com",
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cal.inserts[0]
+	if got.calendarID != "primary" || got.auth.Subject != "ada@example.com" {
+		t.Fatalf("inserted into %q as %+v", got.calendarID, got.auth)
+	}
+	if got.event.End != "2024-05-11" || !reflect.DeepEqual(got.event.Attendees, []string{"ada@example.com", "grace@example.com"}) {
+		t.Fatalf("event = %+v", got.event)
+	}
+}
+
+func TestGoogleEventPayload(t *testing.T) {
+	data, err := json.Marshal(toGoogleEvent(CalendarEvent{
+		Summary:   "Standup",
+		Start:     "2024-03-04T09:00:00Z",
+		End:       "2024-03-04",
+		Attendees: []string{"ada@example.com"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+	want := map[string]interface{}{
+		"summary":   "Standup",
+		"start":     map[string]interface{}{"dateTime": "2024-03-04T09:00:00Z"},
+		"end":       map[string]interface{}{"date": "2024-03-04"},
+		"attendees": []interface{}{map[string]interface{}{"email": "ada@example.com"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("payload = %v, want %v", got, want)
+	}
+
+	back := fromGoogleEvent(googleEvent{ID: "e1", Start: googleEventTime{Date: "2024-03-04"}, HTMLLink: "https://cal/e1"})
+	if back.Start != "2024-03-04" || back.Link != "https://cal/e1" {
+		t.Fatalf("fromGoogleEvent = %+v", back)
+	}
+}
+
+func TestGCalendarListEvents(t *testing.T) {
+	cal := &fakeCalendar{events: []CalendarEvent{
+		{ID: "a", Summary: "Standup", Start: "2024-03-04T09:00:00Z", End: "2024-03-04T09:15:00Z"},
+		{ID: "b", Summary: "Retro", Start: "2024-03-04T16:00:00Z", End: "2024-03-04T17:00:00Z"},
+	}}
+	e := &GCalendarExecutor{client: cal}
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"accessToken": "ya29.token",
+		"operation":   "list",
+		"timeMin":     "2024-03-04T00:00:00Z",
+		"query":       "standup",
+		"maxResults":  10.0,
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.(map[string]interface{})
+	if got["count"] != 2 || got["events"].([]interface{})[1].(map[string]interface{})["id"] != "b" {
+		t.Fatalf("output = %v", got)
+	}
+	want := CalendarListOptions{TimeMin: "2024-03-04T00:00:00Z", Query: "standup", MaxResults: 10}
+	if cal.lists[0] != want {
+		t.Fatalf("list options = %+v, want %+v", cal.lists[0], want)
+	}
+}
+
+func TestGCalendarRejectsBadInput(t *testing.T) {
+	cal := &fakeCalendar{}
+	e := &GCalendarExecutor{client: cal}
+	for name, props := range map[string]map[string]interface{}{
+		"no credentials": {"summary": "x", "start": "2024-03-04"},
+		"no summary":     {"accessToken": "t", "start": "2024-03-04"},
+		"no start":       {"accessToken": "t", "summary": "x"},
+		"bad start":      {"accessToken": "t", "summary": "x", "start": "next tuesday"},
+		"bad maxResults": {"accessToken": "t", "operation": "list", "maxResults": 0.0},
+		"bad operation":  {"accessToken": "t", "operation": "delete"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if len(cal.inserts) != 0 {
+		t.Fatalf("inserted %d events for invalid nodes", len(cal.inserts))
+	}
+}
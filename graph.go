@@ -0,0 +1,191 @@
+// graph.go - Execution graph ordering and edge routing
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PortError tags a connection followed only when its source node fails.
+const PortError = "onError"
+
+// Routed is returned by executors that choose an output port, such as
+// switch: only connections labelled with Port fire.
+type Routed struct {
+	Port   string
+	Output interface{}
+}
+
+// ============================================
+// Execution Graph
+// ============================================
+
+// topoSort orders nodes so every node follows its upstream nodes, keeping
+// the workflow's own order among nodes that are otherwise unordered.
+// Connections to unknown nodes are ignored; cycles are an error.
+func topoSort(w *Workflow) ([]Node, error) {
+	index := make(map[string]int, len(w.Nodes))
+	for i, node := range w.Nodes {
+		index[node.ID] = i
+	}
+
+	indegree := make([]int, len(w.Nodes))
+	next := make([][]int, len(w.Nodes))
+	for _, c := range w.Connections {
+		from, ok := index[c.FromID]
+		if !ok {
+			continue
+		}
+		to, ok := index[c.ToID]
+		if !ok {
+			continue
+		}
+		next[from] = append(next[from], to)
+		indegree[to]++
+	}
+
+	ordered := make([]Node, 0, len(w.Nodes))
+	done := make([]bool, len(w.Nodes))
+	for len(ordered) < len(w.Nodes) {
+		progressed := false
+		for i := range w.Nodes {
+			if done[i] || indegree[i] > 0 {
+				continue
+			}
+			done[i] = true
+			progressed = true
+			ordered = append(ordered, w.Nodes[i])
+			for _, j := range next[i] {
+				indegree[j]--
+			}
+		}
+		if !progressed {
+			return nil, fmt.Errorf("workflow contains a cycle")
+		}
+	}
+	return ordered, nil
+}
+
+// nodeOutcome is what a finished node hands its outgoing connections.
+type nodeOutcome struct {
+	input  interface{}
+	output interface{}
+	port   string // output port chosen by a routing node
+	err    error
+	// continued marks a failure the node tolerates (continueOnError), so
+	// its normal connections still fire
+	continued bool
+}
+
+// follows reports whether a connection carries the outcome of its source:
+// normal connections fire on success (or a tolerated failure), onError
+// connections on failure, and when the source routed, only connections
+// labelled with the chosen port.
+func (o *nodeOutcome) follows(c Connection) bool {
+	if o.err != nil {
+		return c.Port == PortError || o.continued
+	}
+	if o.port != "" {
+		return c.Port == o.port
+	}
+	return c.Port != PortError
+}
+
+// value is what a followed connection delivers downstream: the source's
+// output (nil after a tolerated failure), or on an onError connection a
+// description of the error.
+func (o *nodeOutcome) value(node *Node, c Connection) interface{} {
+	if o.err == nil || c.Port != PortError {
+		return o.output
+	}
+	return map[string]interface{}{
+		"error":    o.err.Error(),
+		"nodeId":   node.ID,
+		"nodeType": node.Type,
+		"input":    o.input,
+	}
+}
+
+// Upstream describes what reached a node over its incoming connections.
+type Upstream struct {
+	Sources []string               // connected source node IDs, in connection order
+	Values  map[string]interface{} // values of the connections that fired, by source
+}
+
+const upstreamKey contextKey = "upstream"
+
+// upstreamFromContext returns what reached the running node, for nodes
+// such as merge that need each branch separately.
+func upstreamFromContext(ctx context.Context) *Upstream {
+	up, _ := ctx.Value(upstreamKey).(*Upstream)
+	if up == nil {
+		up = &Upstream{Values: map[string]interface{}{}}
+	}
+	return up
+}
+
+// nodeInput decides whether a node runs and with what input. Nodes without
+// incoming connections start the run with the trigger input. Others run
+// only when at least one incoming connection is followed; a single
+// followed connection delivers its value directly, several deliver a map
+// keyed by source node ID.
+func nodeInput(w *Workflow, node *Node, outcomes map[string]*nodeOutcome, trigger interface{}) (interface{}, *Upstream, bool) {
+	nodes := make(map[string]*Node, len(w.Nodes))
+	for i := range w.Nodes {
+		nodes[w.Nodes[i].ID] = &w.Nodes[i]
+	}
+
+	up := &Upstream{Values: make(map[string]interface{})}
+	seen := make(map[string]bool)
+	for _, c := range w.Connections {
+		if c.ToID != node.ID || nodes[c.FromID] == nil {
+			continue
+		}
+		if !seen[c.FromID] {
+			seen[c.FromID] = true
+			up.Sources = append(up.Sources, c.FromID)
+		}
+		if o, ok := outcomes[c.FromID]; ok && o.follows(c) {
+			up.Values[c.FromID] = o.value(nodes[c.FromID], c)
+		}
+	}
+
+	switch {
+	case len(up.Sources) == 0:
+		return trigger, up, true
+	case len(up.Values) == 0:
+		return nil, up, false
+	case len(up.Values) == 1:
+		for _, v := range up.Values {
+			return v, up, true
+		}
+	}
+	return up.Values, up, true
+}
+
+// boolProperty reads a flag that may arrive as a JSON bool or, from the
+// UI's select inputs, as the string "true".
+func boolProperty(node *Node, key string) bool {
+	switch v := node.Properties[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// shouldRun evaluates a node's runIf condition against its input; nodes
+// without one always run.
+func shouldRun(node *Node, input interface{}) (bool, error) {
+	cond, _ := node.Properties["runIf"].(string)
+	if strings.TrimSpace(cond) == "" {
+		return true, nil
+	}
+	run, err := evalCondition(cond, input)
+	if err != nil {
+		return false, fmt.Errorf("runIf: %v", err)
+	}
+	return run, nil
+}
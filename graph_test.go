@@ -0,0 +1,221 @@
+// graph_test.go - Execution ordering and edge routing tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFailingHTTPNodeRoutesToErrorHandler(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	}))
+	defer api.Close()
+
+	// The Slack node stands in as a recorder so the handler's input can
+	// be inspected
+	alerts, downstream := &recorder{}, &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(NodeSlack, alerts), WithNodeExecutor(nodeRecord, downstream))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "alert on failure",
+		Nodes: []Node{
+			{ID: "fetch", Type: NodeHTTP, Properties: map[string]interface{}{"url": api.URL, "method": "GET", "failOnStatus": true}},
+			{ID: "use", Type: nodeRecord},
+			{ID: "alert", Type: NodeSlack, Properties: map[string]interface{}{"channel": "#ops"}},
+		},
+		Connections: []Connection{
+			{ID: "ok", FromID: "fetch", ToID: "use"},
+			{ID: "failed", FromID: "fetch", ToID: "alert", Port: PortError},
+		},
+	})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed {
+		t.Fatalf("status = %s, want failed", result.Status)
+	}
+	if len(downstream.calls()) != 0 {
+		t.Fatalf("normal downstream ran with %v", downstream.calls())
+	}
+	if got := result.Nodes["use"].Status; got != NodeStatusSkipped {
+		t.Fatalf("downstream node status = %s, want skipped", got)
+	}
+
+	calls := alerts.calls()
+	if len(calls) != 1 {
+		t.Fatalf("error handler ran %d times, want 1", len(calls))
+	}
+	details := calls[0].(map[string]interface{})
+	if details["nodeId"] != "fetch" || details["nodeType"] != NodeHTTP {
+		t.Fatalf("error details = %v", details)
+	}
+	if msg, _ := details["error"].(string); !strings.Contains(msg, "502") {
+		t.Fatalf("error = %q, want the failing status", msg)
+	}
+}
+
+func TestErrorHandlerSkippedOnSuccess(t *testing.T) {
+	alerts, downstream := &recorder{}, &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(NodeSlack, alerts), WithNodeExecutor(nodeRecord, downstream))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "no alert",
+		Nodes: []Node{
+			{ID: "work", Type: NodeTransform},
+			{ID: "use", Type: nodeRecord},
+			{ID: "alert", Type: NodeSlack},
+		},
+		Connections: []Connection{
+			{ID: "ok", FromID: "work", ToID: "use"},
+			{ID: "failed", FromID: "work", ToID: "alert", Port: PortError},
+		},
+	})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted || len(alerts.calls()) != 0 || len(downstream.calls()) != 1 {
+		t.Fatalf("status %s, alerts %d, downstream %d", result.Status, len(alerts.calls()), len(downstream.calls()))
+	}
+}
+
+func TestContinueOnErrorOnThreeNodeChain(t *testing.T) {
+	for _, tolerate := range []bool{true, false} {
+		rec := &recorder{}
+		we := newTestEngine(t, WithNodeExecutor(nodeFail, failing{}), WithNodeExecutor(nodeRecord, rec))
+		ctx := context.Background()
+		wf := mustCreate(t, we, ctx, &Workflow{
+			Name: "chain",
+			Nodes: []Node{
+				{ID: "first", Type: nodeRecord},
+				{ID: "middle", Type: nodeFail, Properties: map[string]interface{}{"continueOnError": tolerate}},
+				{ID: "last", Type: nodeRecord},
+			},
+			Connections: []Connection{
+				{ID: "c1", FromID: "first", ToID: "middle"},
+				{ID: "c2", FromID: "middle", ToID: "last"},
+			},
+		})
+
+		result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, "start")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Errors) != 1 || result.Nodes["middle"].Status != NodeStatusFailed {
+			t.Fatalf("continueOnError=%v: errors %v, middle %s", tolerate, result.Errors, result.Nodes["middle"].Status)
+		}
+
+		calls := rec.calls()
+		if tolerate {
+			if result.Status != StatusCompletedWithErrors {
+				t.Errorf("tolerated: status = %s, want %s", result.Status, StatusCompletedWithErrors)
+			}
+			// The last node still runs, with nothing from the failed one
+			if want := []interface{}{"start", nil}; !reflect.DeepEqual(calls, want) {
+				t.Errorf("tolerated: recorded %v, want %v", calls, want)
+			}
+			continue
+		}
+		if result.Status != StatusFailed {
+			t.Errorf("untolerated: status = %s, want %s", result.Status, StatusFailed)
+		}
+		if want := []interface{}{"start"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("untolerated: recorded %v, want %v", calls, want)
+		}
+		if got := result.Nodes["last"].Status; got != NodeStatusSkipped {
+			t.Errorf("untolerated: last node %s, want skipped", got)
+		}
+	}
+}
+
+func TestRunIfSkipsNode(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		skipped  bool
+		received interface{}
+	}{
+		{5, true, map[string]interface{}{"amount": 5.0}},
+		{50, false, "BIG!"},
+	}
+	for _, tt := range tests {
+		rec := &recorder{}
+		we := newTestEngine(t, WithNodeExecutor(nodeShout, shout{}), WithNodeExecutor(nodeRecord, rec))
+		ctx := context.Background()
+		wf := mustCreate(t, we, ctx, &Workflow{
+			Name: "gated",
+			Nodes: []Node{
+				{ID: "big", Type: nodeShout, Properties: map[string]interface{}{"text": "big", "runIf": "amount > 10"}},
+				{ID: "after", Type: nodeRecord},
+			},
+			Connections: []Connection{{ID: "c1", FromID: "big", ToID: "after"}},
+		})
+
+		result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"amount": tt.amount})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != StatusCompleted {
+			t.Fatalf("amount %v: status %s, errors %v", tt.amount, result.Status, result.Errors)
+		}
+		if skipped := result.Nodes["big"].Status == NodeStatusSkipped; skipped != tt.skipped {
+			t.Errorf("amount %v: big node %s, want skipped=%v", tt.amount, result.Nodes["big"].Status, tt.skipped)
+		}
+		if tt.skipped {
+			want := map[string]interface{}{"status": "skipped", "runIf": "amount > 10"}
+			if !reflect.DeepEqual(result.Results["big"], want) {
+				t.Errorf("skipped result = %v, want %v", result.Results["big"], want)
+			}
+		}
+		// Downstream runs either way, with the upstream data passed through a skip
+		if calls := rec.calls(); len(calls) != 1 || !reflect.DeepEqual(calls[0], tt.received) {
+			t.Errorf("amount %v: downstream received %v, want %v", tt.amount, calls, tt.received)
+		}
+	}
+}
+
+func TestRunIfBadExpressionFailsNode(t *testing.T) {
+	we := newTestEngine(t, WithNodeExecutor(nodeShout, shout{}))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "broken", Nodes: []Node{
+		{ID: "big", Type: nodeShout, Properties: map[string]interface{}{"text": "big", "runIf": "amount >"}},
+	}})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"amount": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "runIf") {
+		t.Fatalf("status %s, errors %v, want a runIf failure", result.Status, result.Errors)
+	}
+}
+
+func TestTopoSortRejectsCycles(t *testing.T) {
+	w := &Workflow{
+		Nodes:       []Node{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		Connections: []Connection{{FromID: "a", ToID: "b"}, {FromID: "b", ToID: "c"}, {FromID: "c", ToID: "a"}},
+	}
+	if _, err := topoSort(w); err == nil {
+		t.Fatal("expected a cycle error")
+	}
+
+	w.Connections = []Connection{{FromID: "c", ToID: "a"}, {FromID: "x", ToID: "b"}}
+	ordered, err := topoSort(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range ordered {
+		ids = append(ids, n.ID)
+	}
+	if want := []string{"b", "c", "a"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("order = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,106 @@
+// graphql.go - GraphQL request node
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ============================================
+// GraphQL Node
+// ============================================
+
+// GraphQLExecutor posts a query or mutation to a GraphQL endpoint and
+// outputs the response's data object. Properties: url, query, variables
+// (object or JSON; string values are templates rendered against the
+// input), operationName and headers. A response carrying errors fails the
+// node with their messages, even when it has partial data.
+type GraphQLExecutor struct {
+	http *HTTPExecutor
+}
+
+// graphQLError is one entry of a response's errors array.
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+func (e *GraphQLExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	req := httpRequest{Method: http.MethodPost}
+	req.URL, _ = node.Properties["url"].(string)
+	if strings.TrimSpace(req.URL) == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	query, _ := node.Properties["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	var err error
+	if req.Headers, err = objectProperty(node, "headers"); err != nil {
+		return nil, err
+	}
+
+	variables, err := objectProperty(node, "variables")
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[string]interface{}, len(variables))
+	for name, v := range variables {
+		tmpl, ok := v.(string)
+		if !ok {
+			rendered[name] = v
+			continue
+		}
+		if rendered[name], err = renderTemplate(tmpl, input); err != nil {
+			return nil, fmt.Errorf("variable %q: %v", name, err)
+		}
+	}
+
+	payload := map[string]interface{}{"query": query, "variables": rendered}
+	if op, _ := node.Properties["operationName"].(string); op != "" {
+		payload["operationName"] = op
+	}
+	if req.Body, err = json.Marshal(payload); err != nil {
+		return nil, fmt.Errorf("encode request: %v", err)
+	}
+
+	resp, _, err := e.http.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := resp.Body.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("response (status %d) is not a GraphQL result", resp.Status)
+	}
+	if raw, ok := body["errors"].([]interface{}); ok && len(raw) > 0 {
+		return nil, graphQLErrors(raw)
+	}
+	if resp.Status >= 400 {
+		return nil, fmt.Errorf("graphql request returned status %d", resp.Status)
+	}
+	return body["data"], nil
+}
+
+// graphQLErrors joins a response's error messages, with their paths.
+func graphQLErrors(raw []interface{}) error {
+	var errs []graphQLError
+	data, _ := json.Marshal(raw)
+	json.Unmarshal(data, &errs)
+
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msg := e.Message
+		if len(e.Path) > 0 {
+			parts := make([]string, len(e.Path))
+			for i, p := range e.Path {
+				parts[i] = exprString(p)
+			}
+			msg = strings.Join(parts, ".") + ": " + msg
+		}
+		messages = append(messages, msg)
+	}
+	return fmt.Errorf("graphql: %s", strings.Join(messages, "; "))
+}
@@ -0,0 +1,114 @@
+// graphql_test.go - GraphQL node tests against a fake endpoint
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// graphQLServer answers every request with status and body, keeping the
+// last decoded request and its Authorization header.
+type graphQLServer struct {
+	status int
+	body   string
+	got    map[string]interface{}
+	auth   string
+}
+
+func (s *graphQLServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	json.NewDecoder(r.Body).Decode(&s.got)
+	s.auth = r.Header.Get("Authorization")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(s.status)
+	w.Write([]byte(s.body))
+}
+
+func TestGraphQLReturnsData(t *testing.T) {
+	api := &graphQLServer{status: http.StatusOK, body: `{"data": {"user": {"id": "7", "name": "Ada"}}}`}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	we := newTestEngine(t)
+	ctx := context.Background()
+	query := `query GetUser($id: ID!, $limit: Int) { user(id: $id) { id name } }`
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "lookup",
+		Nodes: []Node{{ID: "gql", Type: NodeGraphQL, Properties: map[string]interface{}{
+			"url":           srv.URL + "/graphql",
+			"query":         query,
+			"operationName": "GetUser",
+			"variables":     map[string]interface{}{"id": "{{ user.id }}", "limit": 5.0, "label": "user-{{ user.id }}"},
+			"headers":       map[string]interface{}{"Authorization": "Bearer t0ken"},
+		}}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"user": map[string]interface{}{"id": "7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	want := map[string]interface{}{"user": map[string]interface{}{"id": "7", "name": "Ada"}}
+	if got := result.Results["gql"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("output = %v, want the data object %v", got, want)
+	}
+
+	if api.got["query"] != query || api.got["operationName"] != "GetUser" {
+		t.Fatalf("request = %v", api.got)
+	}
+	vars := map[string]interface{}{"id": "7", "limit": 5.0, "label": "user-7"}
+	if !reflect.DeepEqual(api.got["variables"], vars) {
+		t.Fatalf("variables = %v, want %v", api.got["variables"], vars)
+	}
+	if api.auth != "Bearer t0ken" {
+		t.Fatalf("Authorization = %q", api.auth)
+	}
+}
+
+func TestGraphQLSurfacesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			"errors with partial data", http.StatusOK,
+			`{"data": {"user": null}, "errors": [{"message": "not authorized", "path": ["user", "email"]}, {"message": "rate limited"}]}`,
+			"graphql: user.email: not authorized; rate limited",
+		},
+		{"list index in path", http.StatusOK, `{"errors": [{"message": "bad", "path": ["users", 2, "id"]}]}`, "users.2.id: bad"},
+		{"status without errors", http.StatusBadGateway, `{"data": null}`, "status 502"},
+		{"not a GraphQL result", http.StatusOK, `[1, 2]`, "not a GraphQL result"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(&graphQLServer{status: tt.status, body: tt.body})
+		e := &GraphQLExecutor{http: newHTTPExecutor()}
+		_, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+			"url": srv.URL, "query": "{ user { email } }",
+		}}, nil)
+		srv.Close()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestGraphQLRejectsBadSettings(t *testing.T) {
+	e := &GraphQLExecutor{http: newHTTPExecutor()}
+	for name, props := range map[string]map[string]interface{}{
+		"no url":       {"query": "{ a }"},
+		"no query":     {"url": "http://example.test"},
+		"bad template": {"url": "http://example.test", "query": "{ a }", "variables": map[string]interface{}{"x": "{{ unclosed"}},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// grpc.go - gRPC unary call node
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// defaultGRPCTimeout is a gRPC call's deadline unless the node sets one.
+const defaultGRPCTimeout = 30 * time.Second
+
+// ============================================
+// gRPC Connections
+// ============================================
+
+// GRPCConns shares one client connection per target address and
+// transport security.
+type GRPCConns struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func NewGRPCConns() *GRPCConns {
+	return &GRPCConns{
+		conns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Conn returns the shared connection to address, dialing it first if
+// needed. Connections are established lazily by the first call.
+func (p *GRPCConns) Conn(address string, useTLS bool) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := fmt.Sprintf("%s|%t", address, useTLS)
+	if conn, ok := p.conns[key]; ok {
+		return conn, nil
+	}
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %v", address, err)
+	}
+	p.conns[key] = conn
+	return conn, nil
+}
+
+// Close closes every shared connection.
+func (p *GRPCConns) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, key)
+	}
+}
+
+// ============================================
+// Method Descriptors
+// ============================================
+
+// splitGRPCMethod splits "pkg.Service/Method" (or "pkg.Service.Method")
+// into its service and method names.
+func splitGRPCMethod(full string) (service, method string, err error) {
+	full = strings.TrimPrefix(strings.TrimSpace(full), "/")
+	i := strings.LastIndexAny(full, "/.")
+	if i <= 0 || i == len(full)-1 {
+		return "", "", fmt.Errorf("method must look like package.Service/Method, got %q", full)
+	}
+	return full[:i], full[i+1:], nil
+}
+
+// findMethod looks up a method in files.
+func findMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found", service)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found in %s", method, service)
+	}
+	return md, nil
+}
+
+// descriptorSetFiles decodes a base64 FileDescriptorSet, as written by
+// protoc --descriptor_set_out --include_imports.
+func descriptorSetFiles(encoded string) (*protoregistry.Files, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptorSet base64: %v", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid descriptorSet: %v", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptorSet: %v", err)
+	}
+	return files, nil
+}
+
+// reflectFiles asks the server, through gRPC server reflection, for the
+// file defining service and every file it imports.
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, grpcError("reflection", err)
+	}
+	defer stream.CloseSend()
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+	request := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return grpcError("reflection", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return grpcError("reflection", err)
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return fmt.Errorf("reflection: %s", e.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("reflection: invalid descriptor: %v", err)
+			}
+			protos[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	err = request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for missing := true; missing; {
+		missing = false
+		for _, fd := range protos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := protos[dep]; ok {
+					continue
+				}
+				missing = true
+				err := request(&rpb.ServerReflectionRequest{
+					MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				})
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := protos[dep]; !ok {
+					return nil, fmt.Errorf("reflection: server did not return %s", dep)
+				}
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range protos {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %v", err)
+	}
+	return files, nil
+}
+
+// grpcError describes a failed call by its status code and message.
+func grpcError(what string, err error) error {
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: connection closed", what)
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%s: %v", what, err)
+	}
+	return fmt.Errorf("%s failed with %s: %s", what, st.Code(), st.Message())
+}
+
+// ============================================
+// gRPC Node
+// ============================================
+
+// GRPCExecutor invokes a unary gRPC method with a JSON request and outputs
+// the JSON response. Properties:
+//   - address: host:port of the server; tls: "true" for a TLS connection
+//   - method: package.Service/Method
+//   - request: the request message as an object or JSON, string values
+//     being templates rendered against the input; blank sends the input
+//   - descriptorSet: base64 FileDescriptorSet describing the service;
+//     blank looks the method up through server reflection
+//   - metadata: JSON object of request metadata, e.g. authorization
+//   - timeout: call deadline (default 30s)
+//
+// A non-OK status fails the node with its code and message.
+type GRPCExecutor struct {
+	conns *GRPCConns
+}
+
+func (e *GRPCExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	address, _ := node.Properties["address"].(string)
+	if address = strings.TrimSpace(address); address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	fullMethod, _ := node.Properties["method"].(string)
+	service, method, err := splitGRPCMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	timeout := defaultGRPCTimeout
+	if v := node.Properties["timeout"]; v != nil && v != "" {
+		if timeout, err = parseDelay(v); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout: %v", v)
+		}
+	}
+	md, err := objectProperty(node, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	request, err := grpcRequest(node, input)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := e.conns.Conn(address, boolProperty(node, "tls"))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for k, v := range md {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, exprString(v))
+	}
+
+	var files *protoregistry.Files
+	if set, _ := node.Properties["descriptorSet"].(string); strings.TrimSpace(set) != "" {
+		files, err = descriptorSetFiles(set)
+	} else {
+		files, err = reflectFiles(ctx, conn, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+	desc, err := findMethod(files, service, method)
+	if err != nil {
+		return nil, err
+	}
+	if desc.IsStreamingClient() || desc.IsStreamingServer() {
+		return nil, fmt.Errorf("%s/%s is a streaming method; only unary methods are supported", service, method)
+	}
+
+	req := dynamicpb.NewMessage(desc.Input())
+	if err := protojson.Unmarshal(request, req); err != nil {
+		return nil, fmt.Errorf("request does not match %s: %v", desc.Input().FullName(), err)
+	}
+	resp := dynamicpb.NewMessage(desc.Output())
+	if err := conn.Invoke(ctx, "/"+service+"/"+method, req, resp); err != nil {
+		return nil, grpcError(service+"/"+method, err)
+	}
+
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("encode response: %v", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("encode response: %v", err)
+	}
+	return out, nil
+}
+
+// grpcRequest encodes the request message as JSON.
+func grpcRequest(node *Node, input interface{}) ([]byte, error) {
+	fields, err := objectProperty(node, "request")
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		if input == nil {
+			return []byte("{}"), nil
+		}
+		return json.Marshal(input)
+	}
+	rendered := make(map[string]interface{}, len(fields))
+	for name, v := range fields {
+		tmpl, ok := v.(string)
+		if !ok {
+			rendered[name] = v
+			continue
+		}
+		if rendered[name], err = renderTemplate(tmpl, input); err != nil {
+			return nil, fmt.Errorf("request field %q: %v", name, err)
+		}
+	}
+	return json.Marshal(rendered)
+}
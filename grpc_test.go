@@ -0,0 +1,182 @@
+// grpc_test.go - gRPC node tests against an in-process server
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// healthService answers Check by service name: "down" is not serving,
+// "slow" blocks until the deadline and anything unknown is NotFound. It
+// keeps the authorization metadata of the last call.
+type healthService struct {
+	healthpb.UnimplementedHealthServer
+	auth chan string
+}
+
+func (s *healthService) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	select {
+	case s.auth <- strings.Join(md.Get("authorization"), ","):
+	default:
+	}
+	switch req.GetService() {
+	case "", "orders":
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	case "down":
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	case "slow":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+}
+
+// startGRPC serves the health service on a local port, with server
+// reflection if withReflection is set, and returns its address.
+func startGRPC(t *testing.T, withReflection bool) (string, *healthService) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	svc := &healthService{auth: make(chan string, 1)}
+	healthpb.RegisterHealthServer(srv, svc)
+	if withReflection {
+		reflection.Register(srv)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), svc
+}
+
+// healthDescriptorSet is the health service's FileDescriptorSet, base64
+// encoded as the descriptorSet property expects.
+func healthDescriptorSet(t *testing.T) string {
+	t.Helper()
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto),
+	}}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestGRPCCallThroughReflection(t *testing.T) {
+	address, svc := startGRPC(t, true)
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "health",
+		Nodes: []Node{{ID: "check", Type: NodeGRPC, Properties: map[string]interface{}{
+			"address":  address,
+			"method":   "grpc.health.v1.Health/Check",
+			"request":  map[string]interface{}{"service": "{{ name }}"},
+			"metadata": map[string]interface{}{"authorization": "Bearer t0ken"},
+		}}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"name": "orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if got, want := result.Results["check"], map[string]interface{}{"status": "SERVING"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+	if auth := <-svc.auth; auth != "Bearer t0ken" {
+		t.Fatalf("authorization metadata = %q", auth)
+	}
+}
+
+func TestGRPCCallWithDescriptorSet(t *testing.T) {
+	// No reflection on the server: the descriptor set alone describes it
+	address, _ := startGRPC(t, false)
+	conns := NewGRPCConns()
+	defer conns.Close()
+	e := &GRPCExecutor{conns: conns}
+
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"address":       address,
+		"method":        "grpc.health.v1.Health.Check",
+		"descriptorSet": healthDescriptorSet(t),
+	}}, map[string]interface{}{"service": "down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{})["status"]; got != "NOT_SERVING" {
+		t.Fatalf("status = %v, want NOT_SERVING", got)
+	}
+
+	// Without the descriptor set the method cannot be resolved
+	if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"address": address, "method": "grpc.health.v1.Health/Check",
+	}}, nil); err == nil || !strings.Contains(err.Error(), "reflection") {
+		t.Fatalf("err = %v, want a reflection error", err)
+	}
+}
+
+func TestGRPCMapsFailures(t *testing.T) {
+	address, _ := startGRPC(t, true)
+	conns := NewGRPCConns()
+	defer conns.Close()
+	e := &GRPCExecutor{conns: conns}
+
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input interface{}
+		want  string
+	}{
+		{"status code", nil, map[string]interface{}{"service": "billing"}, `failed with NotFound: unknown service "billing"`},
+		{"deadline", map[string]interface{}{"timeout": "50ms"}, map[string]interface{}{"service": "slow"}, "DeadlineExceeded"},
+		{"bad request field", nil, map[string]interface{}{"colour": "blue"}, "request does not match grpc.health.v1.HealthCheckRequest"},
+		{"streaming method", map[string]interface{}{"method": "grpc.health.v1.Health/Watch"}, nil, "streaming method"},
+		{"unknown method", map[string]interface{}{"method": "grpc.health.v1.Health/Ping"}, nil, "method Ping not found"},
+		{"malformed method", map[string]interface{}{"method": "Check"}, nil, "package.Service/Method"},
+		{"bad timeout", map[string]interface{}{"timeout": "soon"}, nil, "invalid timeout"},
+		{"no address", map[string]interface{}{"address": ""}, nil, "address is required"},
+	}
+	for _, tt := range tests {
+		props := map[string]interface{}{"address": address, "method": "grpc.health.v1.Health/Check"}
+		for k, v := range tt.props {
+			props[k] = v
+		}
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, tt.input); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSplitGRPCMethod(t *testing.T) {
+	for _, full := range []string{"pkg.Svc/Call", "/pkg.Svc/Call", "pkg.Svc.Call"} {
+		service, method, err := splitGRPCMethod(full)
+		if err != nil || service != "pkg.Svc" || method != "Call" {
+			t.Errorf("splitGRPCMethod(%q) = %q, %q, %v", full, service, method, err)
+		}
+	}
+	for _, full := range []string{"", "Call", "pkg.Svc/"} {
+		if _, _, err := splitGRPCMethod(full); err == nil {
+			t.Errorf("splitGRPCMethod(%q): expected an error", full)
+		}
+	}
+}
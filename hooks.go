@@ -0,0 +1,233 @@
+// hooks.go - Live webhook trigger endpoints
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// ============================================
+// Hook Registry
+// ============================================
+
+// HookRegistry tracks the webhook trigger endpoints of active workflows.
+// A single router entry serves every hook, so registering and deregistering
+// only touches this table.
+type HookRegistry struct {
+	mu    sync.RWMutex
+	hooks map[string]map[string]*Hook // workflowID -> nodeID -> hook
+}
+
+// Hook is a live webhook endpoint: its node, as of activation, and the
+// owner whose secrets its properties may reference.
+type Hook struct {
+	Node  Node
+	Owner string
+}
+
+func NewHookRegistry() *HookRegistry {
+	return &HookRegistry{
+		hooks: make(map[string]map[string]*Hook),
+	}
+}
+
+// Register replaces the hooks of a workflow with one per webhook node and
+// returns their paths.
+func (hr *HookRegistry) Register(w *Workflow) []string {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	nodes := make(map[string]*Hook)
+	paths := []string{}
+	for _, node := range w.Nodes {
+		if node.Type == NodeWebhook {
+			nodes[node.ID] = &Hook{Node: node, Owner: w.OwnerID}
+			paths = append(paths, hookPath(w.ID, node.ID))
+		}
+	}
+
+	if len(nodes) == 0 {
+		delete(hr.hooks, w.ID)
+	} else {
+		hr.hooks[w.ID] = nodes
+	}
+	return paths
+}
+
+// Deregister removes every hook belonging to a workflow.
+func (hr *HookRegistry) Deregister(workflowID string) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	delete(hr.hooks, workflowID)
+}
+
+// Lookup returns a live hook, or nil.
+func (hr *HookRegistry) Lookup(workflowID, nodeID string) *Hook {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	return hr.hooks[workflowID][nodeID]
+}
+
+func hookPath(workflowID, nodeID string) string {
+	return fmt.Sprintf("/hooks/%s/%s", workflowID, nodeID)
+}
+
+// ============================================
+// Hook Handler
+// ============================================
+
+func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workflowID := vars["workflowID"]
+	nodeID := vars["nodeID"]
+
+	hook := s.engine.hooks.Lookup(workflowID, nodeID)
+	if hook == nil {
+		writeError(w, http.StatusNotFound, CodeHookNotFound, "hook not found")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, bodyError(err).Error())
+		return
+	}
+
+	// The caller is not authenticated, so secret names stay in the log
+	node, err := resolveSecrets(&hook.Node, s.engine.secrets.Snapshot(hook.Owner))
+	if err != nil {
+		loggerFromContext(r.Context()).Error("webhook secrets unresolved", "workflow_id", workflowID, "node_id", nodeID, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "webhook is misconfigured")
+		return
+	}
+	if err := verifySignature(node, r.Header, body, s.engine.clock.Now()); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	// Forms arrive as fields and files; other non-JSON payloads are passed
+	// through as a raw string
+	var input interface{}
+	if boundary, ok := isMultipart(r.Header.Get("Content-Type")); ok {
+		form, cleanup, err := parseMultipart(node, body, boundary, s.engine.fileBaseDir)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+			return
+		}
+		defer cleanup()
+		input = form
+	} else if len(body) > 0 {
+		if err := json.Unmarshal(body, &input); err != nil {
+			input = string(body)
+		}
+	}
+
+	result, err := s.engine.ExecuteWorkflowWithInput(r.Context(), workflowID, input)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	resp, err := hookResponse(node, result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "webhook response: "+err.Error())
+		return
+	}
+	if resp == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	for k, v := range resp.headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// webhookResponse is what a hook answers its caller with, as configured
+// on the webhook node.
+type webhookResponse struct {
+	status  int
+	headers map[string]string
+	body    []byte
+}
+
+// hookResponse builds the response a webhook node configures through
+// responseStatus, responseHeaders and responseBody, or nil when it
+// configures none and the caller gets the execution result. The body and
+// header values are templates over the run's results, keyed by node ID,
+// plus "execution" (id, status, errors). A body that renders to anything
+// but a string is sent as JSON.
+func hookResponse(node *Node, result *ExecutionResult) (*webhookResponse, error) {
+	tmpl, _ := node.Properties["responseBody"].(string)
+	headers, err := objectProperty(node, "responseHeaders")
+	if err != nil {
+		return nil, err
+	}
+	statusProp := node.Properties["responseStatus"]
+	if statusProp == nil || statusProp == "" {
+		if tmpl == "" && len(headers) == 0 {
+			return nil, nil
+		}
+		statusProp = float64(http.StatusOK)
+	}
+	status, err := toInt(statusProp)
+	if err != nil || status < 100 || status > 599 {
+		return nil, fmt.Errorf("invalid responseStatus: %v", statusProp)
+	}
+
+	scope := make(map[string]interface{}, len(result.Results)+1)
+	scope["execution"] = map[string]interface{}{
+		"id":     result.ID,
+		"status": result.Status,
+		"errors": result.Errors,
+	}
+	for id, out := range result.Results {
+		scope[id] = out
+	}
+
+	resp := &webhookResponse{status: status, headers: make(map[string]string, len(headers))}
+	for k, v := range headers {
+		rendered, err := renderTemplate(fmt.Sprint(v), scope)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %v", k, err)
+		}
+		resp.headers[k] = fmt.Sprint(rendered)
+	}
+
+	if tmpl == "" {
+		return resp, nil
+	}
+	rendered, err := renderTemplate(tmpl, scope)
+	if err != nil {
+		return nil, fmt.Errorf("body: %v", err)
+	}
+	contentType := "application/json"
+	if text, ok := rendered.(string); ok {
+		resp.body = []byte(text)
+		if !json.Valid(resp.body) {
+			contentType = "text/plain; charset=utf-8"
+		}
+	} else if resp.body, err = json.Marshal(rendered); err != nil {
+		return nil, fmt.Errorf("body: %v", err)
+	}
+	if _, ok := resp.headers["Content-Type"]; !ok {
+		resp.headers["Content-Type"] = contentType
+	}
+	return resp, nil
+}
+
+// hookURL builds an absolute hook URL using the host the caller reached us on.
+func hookURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, path)
+}
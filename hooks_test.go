@@ -0,0 +1,147 @@
+// hooks_test.go - Webhook trigger and response tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActivatedWebhookTriggersRun(t *testing.T) {
+	rec := &recorder{}
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: rec},
+	})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "hooked",
+		Nodes: []Node{
+			{ID: "hook", Type: NodeWebhook},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "hook", ToID: "r"}},
+	})
+	hook := "/hooks/" + wf.ID + "/hook"
+
+	if resp, _ := doRequest(t, ts, "POST", hook, map[string]interface{}{"event": "push"}); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("inactive hook answered %d, want 404", resp.StatusCode)
+	}
+
+	resp, body := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/activate", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("activate: %d %s", resp.StatusCode, body)
+	}
+	var activated struct {
+		Hooks []string `json:"hooks"`
+	}
+	decode(t, body, &activated)
+	if len(activated.Hooks) != 1 || !strings.HasSuffix(activated.Hooks[0], hook) {
+		t.Fatalf("hooks = %v, want one ending in %s", activated.Hooks, hook)
+	}
+
+	resp, body = doRequest(t, ts, "POST", hook, map[string]interface{}{"event": "push"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("hook: %d %s", resp.StatusCode, body)
+	}
+	var result ExecutionResult
+	decode(t, body, &result)
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	calls := rec.calls()
+	if len(calls) != 1 {
+		t.Fatalf("recorded %d runs, want 1", len(calls))
+	}
+	got := calls[0].(map[string]interface{})["body"]
+	if want := map[string]interface{}{"event": "push"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("webhook body = %v, want %v", got, want)
+	}
+
+	doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/deactivate", nil)
+	if resp, _ := doRequest(t, ts, "POST", hook, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deactivated hook answered %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestWebhookReturnsCustomBody(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "custom reply",
+		Nodes: []Node{
+			{ID: "hook", Type: NodeWebhook, Properties: map[string]interface{}{
+				"responseStatus":  201.0,
+				"responseHeaders": map[string]interface{}{"X-Execution": "{{ execution.id }}"},
+				"responseBody":    `{"order": "{{ hook.body.order }}", "result": "{{ shape.status }}", "run": "{{ execution.status }}"}`,
+			}},
+			{ID: "shape", Type: NodeTransform, Properties: map[string]interface{}{"script": "return input"}},
+		},
+		Connections: []Connection{{ID: "c", FromID: "hook", ToID: "shape"}},
+	})
+	if resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/activate", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("activate: %d %s", resp.StatusCode, data)
+	}
+
+	resp, data := doRequest(t, ts, "POST", "/hooks/"+wf.ID+"/hook", map[string]interface{}{"order": "A-17"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("hook: %d %s, want 201", resp.StatusCode, data)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var body map[string]interface{}
+	decode(t, data, &body)
+	want := map[string]interface{}{"order": "A-17", "result": "data_transformed", "run": StatusCompleted}
+	if !reflect.DeepEqual(body, want) {
+		t.Fatalf("body = %v, want %v", body, want)
+	}
+	runs := s.engine.executions.List("", wf.ID)
+	if len(runs) != 1 || resp.Header.Get("X-Execution") != runs[0].ID {
+		t.Fatalf("X-Execution = %q, want the run's ID", resp.Header.Get("X-Execution"))
+	}
+}
+
+func TestHookResponse(t *testing.T) {
+	result := &ExecutionResult{ID: "run-1", Status: StatusCompleted, Results: map[string]interface{}{
+		"shape": map[string]interface{}{"total": 3.0},
+	}}
+	tests := []struct {
+		name        string
+		props       map[string]interface{}
+		status      int
+		contentType string
+		body        string
+	}{
+		{"unconfigured", map[string]interface{}{}, 0, "", ""},
+		{"status only", map[string]interface{}{"responseStatus": 204.0}, 204, "", ""},
+		{"body defaults to 200", map[string]interface{}{"responseBody": "{{ shape }}"}, 200, "application/json", `{"total":3}`},
+		{"plain text", map[string]interface{}{"responseBody": "run {{ execution.id }} done"}, 200, "text/plain; charset=utf-8", "run run-1 done"},
+		{"explicit content type", map[string]interface{}{
+			"responseBody":    "<ok/>",
+			"responseHeaders": map[string]interface{}{"Content-Type": "application/xml"},
+		}, 200, "application/xml", "<ok/>"},
+	}
+	for _, tt := range tests {
+		resp, err := hookResponse(&Node{Properties: tt.props}, result)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.status == 0 {
+			if resp != nil {
+				t.Errorf("%s: got %+v, want the default response", tt.name, resp)
+			}
+			continue
+		}
+		if resp.status != tt.status || resp.headers["Content-Type"] != tt.contentType || string(resp.body) != tt.body {
+			t.Errorf("%s: %d %q %s, want %d %q %s", tt.name, resp.status, resp.headers["Content-Type"], resp.body, tt.status, tt.contentType, tt.body)
+		}
+	}
+
+	for _, status := range []interface{}{99.0, 600.0, "soon"} {
+		if _, err := hookResponse(&Node{Properties: map[string]interface{}{"responseStatus": status}}, result); err == nil {
+			t.Errorf("responseStatus %v: expected an error", status)
+		}
+	}
+}
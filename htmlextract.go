@@ -0,0 +1,125 @@
+// htmlextract.go - HTML extraction node using CSS selectors
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// ============================================
+// HTML Extract Node
+// ============================================
+
+// HTMLExtractExecutor selects elements from an HTML document with a CSS
+// selector and outputs an array with one value per element. Properties:
+// selector, extract (text, html or attribute; default text), attribute
+// (the attribute name for extract=attribute; elements without it are
+// skipped) and html, a template rendered against the input (blank = the
+// input string, or the body of an HTTP node's output).
+//
+// Malformed HTML is parsed the way browsers parse it, so unclosed or
+// misnested tags still yield elements rather than failing the node.
+type HTMLExtractExecutor struct{}
+
+func (e *HTMLExtractExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	selector, err := selectorProperty(node)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := htmlDocument(node, input)
+	if err != nil {
+		return nil, err
+	}
+	extract, _ := node.Properties["extract"].(string)
+	attribute, _ := node.Properties["attribute"].(string)
+	if extract == "attribute" && strings.TrimSpace(attribute) == "" {
+		return nil, fmt.Errorf("attribute is required to extract attributes")
+	}
+
+	results := []interface{}{}
+	var selErr error
+	doc.FindMatcher(selector).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		switch extract {
+		case "", "text":
+			results = append(results, strings.Join(strings.Fields(s.Text()), " "))
+		case "html":
+			h, err := goquery.OuterHtml(s)
+			if err != nil {
+				selErr = err
+				return false
+			}
+			results = append(results, h)
+		case "attribute":
+			if v, ok := s.Attr(strings.TrimSpace(attribute)); ok {
+				results = append(results, v)
+			}
+		default:
+			selErr = fmt.Errorf("unsupported extract: %q", extract)
+			return false
+		}
+		return true
+	})
+	if selErr != nil {
+		return nil, selErr
+	}
+	return results, nil
+}
+
+// htmlDocument parses the HTML to extract from.
+func htmlDocument(node *Node, input interface{}) (*goquery.Document, error) {
+	var source string
+	if tmpl, _ := node.Properties["html"].(string); tmpl != "" {
+		rendered, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return nil, fmt.Errorf("html: %v", err)
+		}
+		source = exprString(rendered)
+	} else {
+		switch v := genericJSON(input).(type) {
+		case string:
+			source = v
+		case map[string]interface{}:
+			body, ok := v["body"].(string)
+			if !ok {
+				return nil, fmt.Errorf("html is required when the input has no HTML body")
+			}
+			source = body
+		default:
+			return nil, fmt.Errorf("html is required when the input is not HTML")
+		}
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %v", err)
+	}
+	return doc, nil
+}
+
+// selectorProperty compiles the node's CSS selector.
+func selectorProperty(node *Node) (cascadia.Selector, error) {
+	selector, _ := node.Properties["selector"].(string)
+	if strings.TrimSpace(selector) == "" {
+		return nil, fmt.Errorf("selector is required")
+	}
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	return sel, nil
+}
+
+// checkHTMLExtractNode reports a selector that does not parse.
+func checkHTMLExtractNode(node *Node) []FieldError {
+	selector, _ := node.Properties["selector"].(string)
+	if strings.TrimSpace(selector) == "" || secretRefPattern.MatchString(selector) {
+		return nil
+	}
+	if _, err := cascadia.Compile(selector); err != nil {
+		return []FieldError{{NodeID: node.ID, Field: "selector", Message: err.Error()}}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// htmlextract_test.go - HTML extract node tests on a sample document
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const samplePage = `<!DOCTYPE html>
+<html>
+<head><title>Release notes</title></head>
+<body>
+  <h1>Release   notes</h1>
+  <nav>
+    <a href="/docs">Docs</a>
+    <a href="https://example.com/blog" class="external">Blog</a>
+    <a name="top">Top</a>
+  </nav>
+  <article>
+    <h2>v1.4 <small>(latest)</small></h2>
+    <p>Adds the <b>jsonQuery</b> node.</p>
+    <h2>v1.3</h2>
+  </article>
+</body>
+</html>`
+
+// extractHTML runs an htmlExtract node with props against input.
+func extractHTML(t *testing.T, props map[string]interface{}, input interface{}) interface{} {
+	t.Helper()
+	out, err := (&HTMLExtractExecutor{}).Execute(context.Background(), &Node{Properties: props}, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestHTMLExtractLinksAndHeadings(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		want  []interface{}
+	}{
+		{"link hrefs", map[string]interface{}{"selector": "a", "extract": "attribute", "attribute": "href"}, []interface{}{"/docs", "https://example.com/blog"}},
+		{"heading text", map[string]interface{}{"selector": "h1, h2"}, []interface{}{"Release notes", "v1.4 (latest)", "v1.3"}},
+		{"attribute selector", map[string]interface{}{"selector": "a.external"}, []interface{}{"Blog"}},
+		{"outer html", map[string]interface{}{"selector": "article p", "extract": "html"}, []interface{}{"<p>Adds the <b>jsonQuery</b> node.</p>"}},
+		{"nothing matched", map[string]interface{}{"selector": "table td"}, []interface{}{}},
+	}
+	for _, tt := range tests {
+		if got := extractHTML(t, tt.props, samplePage); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLExtractAfterHTTPGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(samplePage))
+	}))
+	defer srv.Close()
+
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "scrape links",
+		Nodes: []Node{
+			{ID: "page", Type: NodeHTTP, Properties: map[string]interface{}{"url": srv.URL, "method": "GET"}},
+			{ID: "links", Type: NodeHTMLExtract, Properties: map[string]interface{}{"selector": "nav a[href]", "extract": "attribute", "attribute": "href"}},
+		},
+		Connections: []Connection{{ID: "c", FromID: "page", ToID: "links"}},
+	})
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if got, want := result.Results["links"], []interface{}{"/docs", "https://example.com/blog"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("links = %v, want %v", got, want)
+	}
+}
+
+func TestHTMLExtractMalformedHTML(t *testing.T) {
+	// Unclosed and misnested tags are repaired the way a browser would
+	broken := `<ul><li>one<li>two <b>bold<i>both</b> italic</i><li>three</ul><p>tail`
+	got := extractHTML(t, map[string]interface{}{"selector": "li"}, broken)
+	if want := []interface{}{"one", "two boldboth italic", "three"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("items = %#v, want %#v", got, want)
+	}
+
+	got = extractHTML(t, map[string]interface{}{"selector": "p", "html": "{{ page }}"}, map[string]interface{}{"page": broken})
+	if want := []interface{}{"tail"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("templated html = %#v, want %#v", got, want)
+	}
+}
+
+func TestHTMLExtractRejectsBadSettings(t *testing.T) {
+	we := newTestEngine(t)
+	err := we.CreateWorkflow(context.Background(), &Workflow{
+		Name:  "broken",
+		Nodes: []Node{{ID: "h", Type: NodeHTMLExtract, Properties: map[string]interface{}{"selector": "a[href"}}},
+	})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || invalid.Fields[0].Field != "selector" {
+		t.Fatalf("err = %v, want a ValidationError on selector", err)
+	}
+
+	for name, tt := range map[string]struct {
+		props map[string]interface{}
+		input interface{}
+	}{
+		"no selector":       {map[string]interface{}{}, samplePage},
+		"no attribute name": {map[string]interface{}{"selector": "a", "extract": "attribute"}, samplePage},
+		"unknown extract":   {map[string]interface{}{"selector": "a", "extract": "links"}, samplePage},
+		"input not html":    {map[string]interface{}{"selector": "a"}, []interface{}{1.0}},
+		"object no body":    {map[string]interface{}{"selector": "a"}, map[string]interface{}{"status": 200.0}},
+	} {
+		if _, err := (&HTMLExtractExecutor{}).Execute(context.Background(), &Node{Properties: tt.props}, tt.input); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// httpcache.go - Response cache for the HTTP node
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHTTPCacheEntries sizes the engine's default response cache.
+const defaultHTTPCacheEntries = 256
+
+// CachedResponse is a stored HTTP node response and when it goes stale.
+type CachedResponse struct {
+	Status  int                    `json:"status"`
+	Headers map[string]interface{} `json:"headers"`
+	Body    interface{}            `json:"body"`
+	Expires time.Time              `json:"expires"`
+}
+
+// ResponseCache stores HTTP node responses by request key. Implementations
+// must be safe for concurrent use; expiry is checked by the caller.
+type ResponseCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+}
+
+// WithHTTPCache replaces the in-memory response cache used by HTTP nodes,
+// e.g. with one backed by persistent storage.
+func WithHTTPCache(cache ResponseCache) EngineOption {
+	return func(we *WorkflowEngine) { we.httpCache = cache }
+}
+
+// ============================================
+// LRU Cache
+// ============================================
+
+// LRUResponseCache keeps the most recently used responses in memory.
+type LRUResponseCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+func NewLRUResponseCache(size int) *LRUResponseCache {
+	return &LRUResponseCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUResponseCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry).resp, true
+}
+
+func (c *LRUResponseCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruEntry).resp = resp
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, resp: resp})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// ============================================
+// Cache Policy
+// ============================================
+
+// cacheKey identifies a request by the owner making it, URL and headers,
+// so owners never share responses. It is hashed so that credentials in
+// headers are not kept in the clear.
+func cacheKey(owner, rawURL string, headers map[string]interface{}) string {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte(owner))
+	h.Write([]byte{0})
+	h.Write([]byte(rawURL))
+	for _, k := range names {
+		h.Write([]byte{0})
+		h.Write([]byte(http.CanonicalHeaderKey(k) + ":" + exprString(headers[k])))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// copy returns the response for one run to use: the headers and body are
+// copied, since the cached ones are shared with every later hit.
+func (c *CachedResponse) copy() *httpResponse {
+	headers := make(map[string]interface{}, len(c.Headers))
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+	return &httpResponse{Status: c.Status, Headers: headers, Body: genericJSON(c.Body)}
+}
+
+// cacheLifetime returns how long a response may be cached: ttl, shortened
+// by a Cache-Control max-age, or zero when Cache-Control forbids storing.
+func cacheLifetime(ttl time.Duration, cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err != nil {
+				continue
+			}
+			if maxAge := time.Duration(secs) * time.Second; maxAge < ttl {
+				ttl = maxAge
+			}
+		}
+	}
+	return ttl
+}
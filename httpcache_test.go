@@ -0,0 +1,189 @@
+// httpcache_test.go - HTTP response cache tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingAPI answers every request with cacheControl and counts hits.
+func countingAPI(t *testing.T, cacheControl string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hit": ` + strconv.Itoa(int(n)) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// cachedGet is a workflow with one GET node cached for ttl.
+func cachedGet(url, ttl string, props ...string) *Workflow {
+	p := map[string]interface{}{"url": url, "method": "GET", "cacheTtl": ttl}
+	for i := 0; i+1 < len(props); i += 2 {
+		p[props[i]] = props[i+1]
+	}
+	return &Workflow{Name: "cached", Nodes: []Node{{ID: "get", Type: NodeHTTP, Properties: p}}}
+}
+
+// runBody runs wf as ctx's principal and returns the response body.
+func runBody(t *testing.T, we *WorkflowEngine, ctx context.Context, id string) map[string]interface{} {
+	t.Helper()
+	result, err := we.ExecuteWorkflow(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	return genericJSON(result.Results["get"]).(map[string]interface{})["body"].(map[string]interface{})
+}
+
+func TestHTTPCacheServesFreshResponses(t *testing.T) {
+	srv, hits := countingAPI(t, "")
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	we := newTestEngine(t, WithClock(clock))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, cachedGet(srv.URL, "5m"))
+
+	first := runBody(t, we, ctx, wf.ID)
+	clock.Advance(4 * time.Minute)
+	second := runBody(t, we, ctx, wf.ID)
+	if hits.Load() != 1 {
+		t.Fatalf("second call within the TTL hit the server (%d hits)", hits.Load())
+	}
+	if first["hit"] != second["hit"] {
+		t.Fatalf("cached body %v differs from %v", second, first)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if got := runBody(t, we, ctx, wf.ID); got["hit"] != 2.0 || hits.Load() != 2 {
+		t.Fatalf("stale entry served: body %v after %d hits", got, hits.Load())
+	}
+}
+
+func TestHTTPCacheHonoursCacheControl(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		advance      time.Duration
+		hits         int32
+	}{
+		{"max-age=60", 30 * time.Second, 1},
+		{"max-age=60", 90 * time.Second, 2},
+		{"public, max-age=3600", 4 * time.Minute, 1},
+		{"no-store", time.Second, 2},
+		{"no-cache", time.Second, 2},
+	}
+	for _, tt := range tests {
+		srv, hits := countingAPI(t, tt.cacheControl)
+		clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		we := newTestEngine(t, WithClock(clock))
+		ctx := context.Background()
+		wf := mustCreate(t, we, ctx, cachedGet(srv.URL, "5m"))
+
+		runBody(t, we, ctx, wf.ID)
+		clock.Advance(tt.advance)
+		runBody(t, we, ctx, wf.ID)
+		if hits.Load() != tt.hits {
+			t.Errorf("%q after %v: %d hits, want %d", tt.cacheControl, tt.advance, hits.Load(), tt.hits)
+		}
+	}
+}
+
+func TestHTTPCacheKeys(t *testing.T) {
+	srv, hits := countingAPI(t, "")
+	we := newTestEngine(t)
+	alice, bob := asPrincipal("alice"), asPrincipal("bob")
+
+	plain := mustCreate(t, we, alice, cachedGet(srv.URL, "5m"))
+	withHeader := mustCreate(t, we, alice, cachedGet(srv.URL, "5m", "headers", `{"Accept-Language": "fr"}`))
+	bobs := mustCreate(t, we, bob, cachedGet(srv.URL, "5m"))
+	uncached := mustCreate(t, we, alice, cachedGet(srv.URL, ""))
+	post := mustCreate(t, we, alice, cachedGet(srv.URL, "5m", "method", "POST"))
+
+	for i := 0; i < 2; i++ {
+		runBody(t, we, alice, plain.ID)
+		runBody(t, we, alice, withHeader.ID)
+		runBody(t, we, bob, bobs.ID)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("%d hits, want one per URL, header set and owner", hits.Load())
+	}
+
+	for i := 0; i < 2; i++ {
+		runBody(t, we, alice, uncached.ID)
+		runBody(t, we, alice, post.ID)
+	}
+	if hits.Load() != 7 {
+		t.Fatalf("%d hits; requests without a TTL or not GET were cached", hits.Load())
+	}
+}
+
+// mapCache is a ResponseCache standing in for persistent storage.
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string]*CachedResponse
+}
+
+func (c *mapCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.entries[key]
+	return r, ok
+}
+
+func (c *mapCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = resp
+}
+
+func TestHTTPCacheIsInjectable(t *testing.T) {
+	srv, hits := countingAPI(t, "")
+	cache := &mapCache{entries: make(map[string]*CachedResponse)}
+	we := newTestEngine(t, WithHTTPCache(cache))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, cachedGet(srv.URL, "1h"))
+
+	runBody(t, we, ctx, wf.ID)
+	if len(cache.entries) != 1 {
+		t.Fatalf("injected cache holds %d entries, want 1", len(cache.entries))
+	}
+	// A run that edits its output must not change the cached copy
+	runBody(t, we, ctx, wf.ID)["hit"] = "edited"
+	if got := runBody(t, we, ctx, wf.ID)["hit"]; got != 1.0 || hits.Load() != 1 {
+		t.Fatalf("cached hit = %v after %d hits", got, hits.Load())
+	}
+}
+
+func TestLRUResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRUResponseCache(2)
+	c.Set("a", &CachedResponse{Status: 1})
+	c.Set("b", &CachedResponse{Status: 2})
+	c.Get("a")
+	c.Set("c", &CachedResponse{Status: 3})
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("%s was evicted", key)
+		}
+	}
+	c.Set("a", &CachedResponse{Status: 10})
+	if r, _ := c.Get("a"); r.Status != 10 {
+		t.Fatalf("replaced entry = %+v", r)
+	}
+}
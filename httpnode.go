@@ -0,0 +1,365 @@
+// httpnode.go - HTTP request node with optional pagination
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// maxHTTPResponseBytes caps the response body read per request.
+	maxHTTPResponseBytes = 10 << 20
+	// defaultMaxPages bounds a paginated request unless maxPages is set.
+	defaultMaxPages = 10
+)
+
+// ============================================
+// HTTP Node
+// ============================================
+
+// HTTPExecutor sends one request (properties url, method, headers, body;
+// headers and body as JSON) and outputs {status, headers, body}, the body
+// decoded from JSON when it parses. Responses of 400 and above are output
+// like any other, for workflows to branch on status, unless failOnStatus
+// is set, which makes them fail the node with an *HTTPStatusError.
+//
+// A paginate object follows next-page cursors:
+//
+//	{"next": "meta.next_cursor", "param": "cursor", "items": "data",
+//	 "stop": "data == null", "maxPages": 10}
+//
+// next is the path to the cursor in each page's body; it is sent as the
+// query parameter param, or when param is blank is itself the next page's
+// URL. Paging stops when the cursor is empty, when the optional stop
+// condition holds for a page, or after maxPages pages. The output is then
+// {status, pages, items}: the arrays at items concatenated, or every
+// page's body when items is blank.
+//
+// GET responses are cached for cacheTtl (e.g. "5m") when it is set, keyed
+// by owner, URL and headers, and for no longer than a Cache-Control max-age;
+// no-store and no-cache responses are not cached.
+//
+// With signingSecret set, each request carries an HMAC of its body (see
+// signPayload) in signatureHeader, X-Signature by default, using
+// signatureAlgorithm: sha256 (default), sha1 or sha512.
+type HTTPExecutor struct {
+	client *http.Client
+	clock  Clock
+	cache  ResponseCache
+}
+
+// httpRequest is one request the node sends.
+type httpRequest struct {
+	Method   string
+	URL      string
+	Headers  map[string]interface{}
+	Body     []byte
+	CacheTTL time.Duration
+	// FailOnStatus turns responses of 400 and above into errors
+	FailOnStatus bool
+
+	SigningSecret      string
+	SignatureHeader    string
+	SignatureAlgorithm string
+}
+
+// paginateConfig is the HTTP node's paginate property.
+type paginateConfig struct {
+	Next     string `json:"next"`
+	Param    string `json:"param"`
+	Items    string `json:"items"`
+	Stop     string `json:"stop"`
+	MaxPages int    `json:"maxPages"`
+}
+
+// HTTPStatusError is a response of 400 or above from a request made with
+// failOnStatus.
+type HTTPStatusError struct {
+	Method string
+	URL    string
+	Status int
+	// Text is the status line, e.g. "404 Not Found"
+	Text string
+	Body string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s %s returned %s: %s", e.Method, e.URL, e.Text, e.Body)
+}
+
+// httpResponse is one response, decoded.
+type httpResponse struct {
+	Status  int
+	Headers map[string]interface{}
+	Body    interface{}
+}
+
+func (e *HTTPExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	req := httpRequest{Method: http.MethodGet}
+	req.URL, _ = node.Properties["url"].(string)
+	if strings.TrimSpace(req.URL) == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if method, _ := node.Properties["method"].(string); method != "" {
+		req.Method = strings.ToUpper(method)
+	}
+	var err error
+	if req.Headers, err = objectProperty(node, "headers"); err != nil {
+		return nil, err
+	}
+	if req.Body, err = requestBody(node, req.Method); err != nil {
+		return nil, err
+	}
+	if v := node.Properties["cacheTtl"]; v != nil && v != "" {
+		if req.CacheTTL, err = parseDelay(v); err != nil {
+			return nil, fmt.Errorf("invalid cacheTtl: %v", err)
+		}
+	}
+	req.SigningSecret, _ = node.Properties["signingSecret"].(string)
+	req.SignatureHeader, _ = node.Properties["signatureHeader"].(string)
+	req.SignatureAlgorithm, _ = node.Properties["signatureAlgorithm"].(string)
+	req.FailOnStatus = boolProperty(node, "failOnStatus")
+	paginate, err := paginateProperty(node)
+	if err != nil {
+		return nil, err
+	}
+
+	if paginate == nil {
+		resp, err := e.do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"status":  resp.Status,
+			"headers": resp.Headers,
+			"body":    resp.Body,
+		}, nil
+	}
+	return e.paginate(ctx, paginate, req)
+}
+
+// paginate requests pages until the cursor runs out, the stop condition
+// holds or maxPages is reached, accumulating their items.
+func (e *HTTPExecutor) paginate(ctx context.Context, cfg *paginateConfig, req httpRequest) (interface{}, error) {
+	items := []interface{}{}
+	status := 0
+	pages := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := e.do(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", pages+1, err)
+		}
+		pages++
+		status = resp.Status
+
+		if cfg.Items == "" {
+			items = append(items, resp.Body)
+		} else if v, _ := lookupPath(resp.Body, cfg.Items); v != nil {
+			if arr, ok := v.([]interface{}); ok {
+				items = append(items, arr...)
+			} else {
+				items = append(items, v)
+			}
+		}
+
+		if cfg.Stop != "" {
+			stop, err := evalCondition(cfg.Stop, resp.Body)
+			if err != nil {
+				return nil, fmt.Errorf("paginate stop: %v", err)
+			}
+			if stop {
+				break
+			}
+		}
+		cursor, _ := lookupPath(resp.Body, cfg.Next)
+		if cursor == nil || exprString(cursor) == "" {
+			break
+		}
+		if pages >= cfg.MaxPages {
+			logf(ctx, "warn", "stopped paginating after maxPages (%d)", cfg.MaxPages)
+			break
+		}
+		if req.URL, err = nextPageURL(req.URL, cfg.Param, exprString(cursor)); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"status": status,
+		"pages":  pages,
+		"items":  items,
+	}, nil
+}
+
+// do sends one request and decodes the response, going through the
+// cache when the request may be cached.
+func (e *HTTPExecutor) do(ctx context.Context, req httpRequest) (*httpResponse, error) {
+	cacheable := e.cache != nil && e.clock != nil && req.Method == http.MethodGet && req.CacheTTL > 0
+	var key string
+	if cacheable {
+		key = cacheKey(principalFromContext(ctx), req.URL, req.Headers)
+		if cached, ok := e.cache.Get(key); ok && e.clock.Now().Before(cached.Expires) {
+			logf(ctx, "info", "served %s from cache", req.URL)
+			return cached.copy(), nil
+		}
+	}
+
+	out, cacheControl, err := e.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable && out.Status < 400 {
+		if ttl := cacheLifetime(req.CacheTTL, cacheControl); ttl > 0 {
+			// The cache keeps its own copy; out goes on to this run
+			stored := (&CachedResponse{Status: out.Status, Headers: out.Headers, Body: out.Body}).copy()
+			e.cache.Set(key, &CachedResponse{
+				Status:  stored.Status,
+				Headers: stored.Headers,
+				Body:    stored.Body,
+				Expires: e.clock.Now().Add(ttl),
+			})
+		}
+	}
+	return out, nil
+}
+
+// send performs req over the network, returning the decoded response and
+// its Cache-Control header.
+func (e *HTTPExecutor) send(ctx context.Context, req httpRequest) (*httpResponse, string, error) {
+	var reader io.Reader
+	if req.Body != nil {
+		reader = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, reader)
+	if err != nil {
+		return nil, "", err
+	}
+	if req.Body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, exprString(v))
+	}
+	if req.SigningSecret != "" {
+		signature, err := signPayload(req.SignatureAlgorithm, req.SigningSecret, req.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		header := req.SignatureHeader
+		if header == "" {
+			header = defaultSignatureHeader
+		}
+		httpReq.Header.Set(header, signature)
+	}
+
+	client := e.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxHTTPResponseBytes {
+		return nil, "", fmt.Errorf("response body exceeds %d bytes", maxHTTPResponseBytes)
+	}
+	if req.FailOnStatus && resp.StatusCode >= 400 {
+		msg := string(data)
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return nil, "", &HTTPStatusError{Method: req.Method, URL: req.URL, Status: resp.StatusCode, Text: resp.Status, Body: msg}
+	}
+
+	out := &httpResponse{
+		Status:  resp.StatusCode,
+		Headers: make(map[string]interface{}, len(resp.Header)),
+		Body:    string(data),
+	}
+	for k := range resp.Header {
+		out.Headers[k] = resp.Header.Get(k)
+	}
+	var decoded interface{}
+	if json.Unmarshal(data, &decoded) == nil {
+		out.Body = decoded
+	}
+	return out, resp.Header.Get("Cache-Control"), nil
+}
+
+// requestBody returns the JSON body to send, or nil for GET requests and
+// blank bodies.
+func requestBody(node *Node, method string) ([]byte, error) {
+	if method == http.MethodGet {
+		return nil, nil
+	}
+	switch b := node.Properties["body"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.TrimSpace(b) == "" {
+			return nil, nil
+		}
+		if !json.Valid([]byte(b)) {
+			return nil, fmt.Errorf("invalid body JSON")
+		}
+		return []byte(b), nil
+	default:
+		return json.Marshal(b)
+	}
+}
+
+// paginateProperty reads the paginate property; nil means no pagination.
+func paginateProperty(node *Node) (*paginateConfig, error) {
+	raw, err := objectProperty(node, "paginate")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(raw)
+	var cfg paginateConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid paginate: %v", err)
+	}
+	if strings.TrimSpace(cfg.Next) == "" {
+		return nil, fmt.Errorf("paginate.next is required")
+	}
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = defaultMaxPages
+	}
+	return &cfg, nil
+}
+
+// nextPageURL sets the cursor as query parameter param on current, or
+// when param is blank resolves the cursor as a URL relative to current.
+func nextPageURL(current, param, cursor string) (string, error) {
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	if param == "" {
+		next, err := base.Parse(cursor)
+		if err != nil {
+			return "", fmt.Errorf("invalid next page URL %q: %v", cursor, err)
+		}
+		return next.String(), nil
+	}
+	q := base.Query()
+	q.Set(param, cursor)
+	base.RawQuery = q.Encode()
+	return base.String(), nil
+}
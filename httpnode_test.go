@@ -0,0 +1,176 @@
+// httpnode_test.go - HTTP node pagination tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pagedAPI serves three pages of items linked by a cursor, recording the
+// cursors it was asked for. The last page has no next cursor.
+type pagedAPI struct {
+	mu      sync.Mutex
+	cursors []string
+	// onPage runs before each page is served
+	onPage func(n int)
+}
+
+func (p *pagedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pages := map[string]string{
+		"":   `{"data": [1, 2], "meta": {"next_cursor": "p2"}}`,
+		"p2": `{"data": [3, 4], "meta": {"next_cursor": "p3"}}`,
+		"p3": `{"data": [5], "meta": {"next_cursor": ""}}`,
+	}
+	cursor := r.URL.Query().Get("cursor")
+	p.mu.Lock()
+	p.cursors = append(p.cursors, cursor)
+	n := len(p.cursors)
+	p.mu.Unlock()
+	if p.onPage != nil {
+		p.onPage(n)
+	}
+	page, ok := pages[cursor]
+	if !ok {
+		http.Error(w, "unknown cursor", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(page))
+}
+
+func (p *pagedAPI) requested() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.cursors...)
+}
+
+// paginatedNode is an HTTP node reading url with paginate settings.
+func paginatedNode(url string, paginate map[string]interface{}) *Node {
+	return &Node{ID: "list", Type: NodeHTTP, Properties: map[string]interface{}{"url": url, "method": "GET", "paginate": paginate}}
+}
+
+func newHTTPExecutor() *HTTPExecutor {
+	return &HTTPExecutor{client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func TestHTTPPaginationFollowsCursor(t *testing.T) {
+	api := &pagedAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name:  "paged",
+		Nodes: []Node{*paginatedNode(srv.URL+"/items?limit=2", map[string]interface{}{"next": "meta.next_cursor", "param": "cursor", "items": "data"})},
+	})
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := genericJSON(result.Results["list"]).(map[string]interface{})
+	if out["pages"] != 3.0 || out["status"] != 200.0 {
+		t.Fatalf("output = %v, want 3 pages", out)
+	}
+	if want := []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}; !reflect.DeepEqual(out["items"], want) {
+		t.Fatalf("items = %v, want %v", out["items"], want)
+	}
+	if got := api.requested(); !reflect.DeepEqual(got, []string{"", "p2", "p3"}) {
+		t.Fatalf("cursors requested = %q", got)
+	}
+}
+
+func TestHTTPPaginationLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		paginate map[string]interface{}
+		pages    float64
+		items    int
+	}{
+		{"maxPages", map[string]interface{}{"next": "meta.next_cursor", "param": "cursor", "items": "data", "maxPages": 2}, 2, 4},
+		{"stop condition", map[string]interface{}{"next": "meta.next_cursor", "param": "cursor", "items": "data", "stop": "data contains 3"}, 2, 4},
+		{"whole bodies", map[string]interface{}{"next": "meta.next_cursor", "param": "cursor"}, 3, 3},
+	}
+	for _, tt := range tests {
+		api := &pagedAPI{}
+		srv := httptest.NewServer(api)
+		out, err := newHTTPExecutor().Execute(context.Background(), paginatedNode(srv.URL, tt.paginate), nil)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := out.(map[string]interface{})
+		if got["pages"] != int(tt.pages) || len(got["items"].([]interface{})) != tt.items {
+			t.Errorf("%s: %v pages, %d items; want %v and %d", tt.name, got["pages"], len(got["items"].([]interface{})), tt.pages, tt.items)
+		}
+	}
+}
+
+func TestHTTPPaginationNextURL(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/first":
+			w.Write([]byte(`{"rows": ["a"], "next": "/second?x=1"}`))
+		case "/second":
+			w.Write([]byte(`{"rows": ["b"], "next": "` + srv.URL + `/third"}`))
+		default:
+			w.Write([]byte(`{"rows": ["c"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	out, err := newHTTPExecutor().Execute(context.Background(), paginatedNode(srv.URL+"/first", map[string]interface{}{"next": "next", "items": "rows"}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := out.(map[string]interface{})["items"]; !reflect.DeepEqual(items, []interface{}{"a", "b", "c"}) {
+		t.Fatalf("items = %v", items)
+	}
+}
+
+func TestHTTPPaginationCancelledBetweenPages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := &pagedAPI{onPage: func(n int) {
+		if n == 2 {
+			cancel()
+		}
+	}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	_, err := newHTTPExecutor().Execute(ctx, paginatedNode(srv.URL, map[string]interface{}{"next": "meta.next_cursor", "param": "cursor"}), nil)
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("err = %v, want cancellation", err)
+	}
+	if n := len(api.requested()); n > 2 {
+		t.Fatalf("requested %d pages after cancellation", n)
+	}
+}
+
+func TestHTTPPaginateProperty(t *testing.T) {
+	for name, paginate := range map[string]interface{}{
+		"missing next": map[string]interface{}{"param": "cursor"},
+		"bad JSON":     "{next:",
+		"wrong type":   map[string]interface{}{"next": "a", "maxPages": "many"},
+	} {
+		node := &Node{Properties: map[string]interface{}{"paginate": paginate}}
+		if _, err := paginateProperty(node); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	cfg, err := paginateProperty(&Node{Properties: map[string]interface{}{"paginate": `{"next": "cursor"}`}})
+	if err != nil || cfg.MaxPages != defaultMaxPages {
+		t.Fatalf("cfg = %+v, %v; want the default maxPages", cfg, err)
+	}
+}
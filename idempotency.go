@@ -0,0 +1,109 @@
+// idempotency.go - Idempotency keys for execute requests
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultIdempotencyTTL is how long an Idempotency-Key is remembered.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// ============================================
+// Idempotency Store
+// ============================================
+
+// IdempotencyStore remembers which execution an Idempotency-Key started,
+// per owner and workflow, so a retried execute request gets the original
+// outcome instead of running the workflow again.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	clock   Clock
+	ttl     time.Duration
+	entries map[idempotencyKey]*idempotentRun
+}
+
+type idempotencyKey struct {
+	owner, workflowID, key string
+}
+
+// idempotentRun is the execution started for a key. done closes once it
+// has finished, or been abandoned because the request failed to start it.
+type idempotentRun struct {
+	done        chan struct{}
+	executionID string
+	result      *ExecutionResult
+	expires     time.Time
+}
+
+func NewIdempotencyStore(clock Clock, ttl time.Duration) *IdempotencyStore {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &IdempotencyStore{
+		clock:   clock,
+		ttl:     ttl,
+		entries: make(map[idempotencyKey]*idempotentRun),
+	}
+}
+
+// Begin claims key for a new run, returning a nil run, or returns the
+// earlier run recorded for it once that has finished. A caller that claims
+// the key must call Finish or Abandon.
+func (is *IdempotencyStore) Begin(ctx context.Context, owner, workflowID, key string) (*idempotentRun, error) {
+	k := idempotencyKey{owner, workflowID, key}
+	for {
+		is.mu.Lock()
+		now := is.clock.Now()
+		for ek, run := range is.entries {
+			if !run.expires.IsZero() && now.After(run.expires) {
+				delete(is.entries, ek)
+			}
+		}
+		run, ok := is.entries[k]
+		if !ok {
+			is.entries[k] = &idempotentRun{done: make(chan struct{})}
+			is.mu.Unlock()
+			return nil, nil
+		}
+		is.mu.Unlock()
+
+		select {
+		case <-run.done:
+			if run.executionID != "" {
+				return run, nil
+			}
+			// Abandoned; try to claim the key again
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Finish records the execution started for a claimed key. result is nil
+// for asynchronous runs.
+func (is *IdempotencyStore) Finish(owner, workflowID, key, executionID string, result *ExecutionResult) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	run := is.entries[idempotencyKey{owner, workflowID, key}]
+	if run == nil {
+		return
+	}
+	run.executionID = executionID
+	run.result = result
+	run.expires = is.clock.Now().Add(is.ttl)
+	close(run.done)
+}
+
+// Abandon releases a claimed key after the request failed without
+// starting an execution, so a retry may run.
+func (is *IdempotencyStore) Abandon(owner, workflowID, key string) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	k := idempotencyKey{owner, workflowID, key}
+	if run := is.entries[k]; run != nil {
+		delete(is.entries, k)
+		close(run.done)
+	}
+}
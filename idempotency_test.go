@@ -0,0 +1,135 @@
+// idempotency_test.go - Idempotency-Key tests
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRepeatedIdempotencyKeyReturnsOriginalResult(t *testing.T) {
+	rec := &recorder{}
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: rec}})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, &Workflow{Name: "charge", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+	other := mustCreate(t, s.engine, ctx, &Workflow{Name: "other", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+	execute := func(id, key string) (*http.Response, ExecutionResult) {
+		t.Helper()
+		resp, data := doRequest(t, ts, "POST", "/api/workflows/"+id+"/execute", nil, "Idempotency-Key", key)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("execute: %d %s", resp.StatusCode, data)
+		}
+		var result ExecutionResult
+		decode(t, data, &result)
+		return resp, result
+	}
+
+	resp, first := execute(wf.ID, "retry-1")
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatal("first request marked as replayed")
+	}
+	resp, again := execute(wf.ID, "retry-1")
+	if resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatal("repeated request not marked as replayed")
+	}
+	if again.ID != first.ID || again.Status != first.Status {
+		t.Fatalf("replayed %s (%s), want the original %s (%s)", again.ID, again.Status, first.ID, first.Status)
+	}
+	if n := len(rec.calls()); n != 1 {
+		t.Fatalf("workflow ran %d times, want 1", n)
+	}
+
+	// Keys are scoped per workflow, and a new key runs again
+	if _, r := execute(other.ID, "retry-1"); r.ID == first.ID {
+		t.Fatal("key shared across workflows")
+	}
+	if _, r := execute(wf.ID, "retry-2"); r.ID == first.ID {
+		t.Fatal("new key replayed an old run")
+	}
+	if n := len(rec.calls()); n != 3 {
+		t.Fatalf("workflows ran %d times, want 3", n)
+	}
+}
+
+func TestConcurrentIdempotentRequestsRunOnce(t *testing.T) {
+	release := make(gate)
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeGate: release}})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{Name: "slow", Nodes: []Node{{ID: "g", Type: nodeGate}}})
+
+	const requests = 3
+	ids := make([]string, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := http.NewRequest("POST", ts.URL+"/api/workflows/"+wf.ID+"/execute", nil)
+			req.Header.Set("Idempotency-Key", "same")
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			var result ExecutionResult
+			json.NewDecoder(resp.Body).Decode(&result)
+			ids[i] = result.ID
+		}(i)
+	}
+	eventually(t, "the first run to start", func() bool {
+		active, _, _, _ := s.engine.pool.Stats()
+		return active == 1
+	})
+	time.Sleep(20 * time.Millisecond)
+	if active, queued, _, _ := s.engine.pool.Stats(); active != 1 || queued != 0 {
+		t.Fatalf("%d active and %d queued runs, want the duplicates to wait", active, queued)
+	}
+	close(release)
+	wg.Wait()
+
+	for _, id := range ids {
+		if id == "" || id != ids[0] {
+			t.Fatalf("execution IDs = %v, want one shared run", ids)
+		}
+	}
+}
+
+func TestIdempotencyStoreExpiryAndAbandon(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	is := NewIdempotencyStore(clock, time.Hour)
+	ctx := context.Background()
+
+	if run, err := is.Begin(ctx, "o", "wf", "k"); run != nil || err != nil {
+		t.Fatalf("first Begin = %v, %v; want a claim", run, err)
+	}
+	is.Finish("o", "wf", "k", "exec-1", &ExecutionResult{ID: "exec-1"})
+
+	clock.Advance(59 * time.Minute)
+	if run, _ := is.Begin(ctx, "o", "wf", "k"); run == nil || run.executionID != "exec-1" {
+		t.Fatalf("within the TTL: run = %+v, want exec-1", run)
+	}
+	if run, _ := is.Begin(ctx, "other-owner", "wf", "k"); run != nil {
+		t.Fatal("key shared across owners")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if run, _ := is.Begin(ctx, "o", "wf", "k"); run != nil {
+		t.Fatalf("expired key replayed %+v", run)
+	}
+
+	// A request that failed to start its run frees the key for a retry
+	is.Abandon("o", "wf", "k")
+	if run, _ := is.Begin(ctx, "o", "wf", "k"); run != nil {
+		t.Fatalf("abandoned key replayed %+v", run)
+	}
+
+	// A waiter gives up with its request
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := is.Begin(cancelled, "o", "wf", "k"); err != context.Canceled {
+		t.Fatalf("cancelled wait: err = %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// jsonquery.go - JMESPath query node
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// ============================================
+// JSON Query Node
+// ============================================
+
+// JSONQueryExecutor applies a JMESPath expression to the input and outputs
+// the result, e.g. "items[?price > `10`].name" or "data.user.{id: id,
+// email: contact.email}". An expression matching nothing outputs null.
+type JSONQueryExecutor struct{}
+
+func (e *JSONQueryExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	expression, _ := node.Properties["expression"].(string)
+	if expression = strings.TrimSpace(expression); expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	query, err := jmespath.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %v", err)
+	}
+	result, err := query.Search(genericJSON(input))
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	return result, nil
+}
+
+// checkJSONQueryNode reports an expression that does not compile.
+func checkJSONQueryNode(node *Node) []FieldError {
+	expression, _ := node.Properties["expression"].(string)
+	if strings.TrimSpace(expression) == "" || secretRefPattern.MatchString(expression) {
+		return nil
+	}
+	if _, err := jmespath.Compile(expression); err != nil {
+		return []FieldError{{NodeID: node.ID, Field: "expression", Message: err.Error()}}
+	}
+	return nil
+}
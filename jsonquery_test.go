@@ -0,0 +1,93 @@
+// jsonquery_test.go - JMESPath query node tests
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// apiResponse is a typical API payload to query.
+var apiResponse = map[string]interface{}{
+	"data": map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":      "u-7",
+			"contact": map[string]interface{}{"email": "ada@example.com"},
+		},
+	},
+	"items": []interface{}{
+		map[string]interface{}{"name": "lamp", "price": 25.0, "tags": []interface{}{"home"}},
+		map[string]interface{}{"name": "pen", "price": 2.5, "tags": []interface{}{"office"}},
+		map[string]interface{}{"name": "desk", "price": 180.0, "tags": []interface{}{"office", "home"}},
+	},
+}
+
+func TestJSONQuerySelectsAndFilters(t *testing.T) {
+	tests := []struct {
+		name, expression string
+		want             interface{}
+	}{
+		{"nested field", "data.user.contact.email", "ada@example.com"},
+		{"reshape", "data.user.{id: id, email: contact.email}", map[string]interface{}{"id": "u-7", "email": "ada@example.com"}},
+		{"filter array", "items[?price > `10`].name", []interface{}{"lamp", "desk"}},
+		{"filter on a list field", "items[?contains(tags, 'office')].{name: name, price: price}", []interface{}{
+			map[string]interface{}{"name": "pen", "price": 2.5},
+			map[string]interface{}{"name": "desk", "price": 180.0},
+		}},
+		{"function", "max_by(items, &price).name", "desk"},
+		{"missing field", "data.account.id", nil},
+	}
+	e := &JSONQueryExecutor{}
+	for _, tt := range tests {
+		got, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"expression": tt.expression}}, apiResponse)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %s = %#v, want %#v", tt.name, tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestJSONQueryInWorkflow(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "cheap office items",
+		Nodes: []Node{{ID: "q", Type: NodeJSONQuery, Properties: map[string]interface{}{
+			"expression": "items[?contains(tags, 'office') && price < `100`] | [0].name",
+		}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, apiResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted || result.Results["q"] != "pen" {
+		t.Fatalf("status %s, output %v (errors %v)", result.Status, result.Results["q"], result.Errors)
+	}
+}
+
+func TestJSONQueryRejectsBadExpressions(t *testing.T) {
+	we := newTestEngine(t)
+	err := we.CreateWorkflow(context.Background(), &Workflow{
+		Name:  "broken",
+		Nodes: []Node{{ID: "q", Type: NodeJSONQuery, Properties: map[string]interface{}{"expression": "items[?price >"}}},
+	})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || invalid.Fields[0].Field != "expression" {
+		t.Fatalf("err = %v, want a ValidationError on expression", err)
+	}
+
+	e := &JSONQueryExecutor{}
+	for name, expression := range map[string]string{
+		"blank":         " ",
+		"invalid":       "a.[",
+		"runtime error": "abs(data)",
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"expression": expression}}, apiResponse); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// jwt.go - JWT sign and verify node
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when a JWT fails verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// ============================================
+// JWT Node
+// ============================================
+
+// JWTExecutor signs and verifies JSON Web Tokens with HS256 or RS256.
+// Properties: operation (sign or verify), algorithm (default HS256),
+// secret for HS256, privateKey (PEM) to sign and publicKey (PEM key or
+// certificate) to verify with RS256, and:
+//   - sign: claims, an object whose string values are templates rendered
+//     against the input (blank = the input object), and expiresIn, which
+//     sets exp; iat is always set. Outputs {token}.
+//   - verify: token, a template (blank = the input itself, or its token
+//     field). Outputs {valid, header, claims}.
+//
+// Verification rejects tokens signed with a different algorithm, with a
+// bad signature, or outside their exp/nbf window; the node fails with
+// ErrInvalidToken.
+type JWTExecutor struct {
+	clock Clock
+}
+
+func (e *JWTExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	alg, _ := node.Properties["algorithm"].(string)
+	if alg = strings.ToUpper(strings.TrimSpace(alg)); alg == "" {
+		alg = "HS256"
+	}
+	if alg != "HS256" && alg != "RS256" {
+		return nil, fmt.Errorf("unsupported jwt algorithm: %q", alg)
+	}
+
+	switch operation, _ := node.Properties["operation"].(string); operation {
+	case "", "sign":
+		return e.sign(node, input, alg)
+	case "verify":
+		return e.verify(node, input, alg)
+	default:
+		return nil, fmt.Errorf("unsupported jwt operation: %q", operation)
+	}
+}
+
+// sign builds and signs a token from the claims.
+func (e *JWTExecutor) sign(node *Node, input interface{}, alg string) (interface{}, error) {
+	claims, err := objectProperty(node, "claims")
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		m, ok := genericJSON(input).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("claims is required when the input is not an object")
+		}
+		claims = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			claims[k] = v
+		}
+	} else if rendered, err := renderTemplates(claims, input); err != nil {
+		return nil, fmt.Errorf("claims: %v", err)
+	} else {
+		claims = rendered.(map[string]interface{})
+	}
+
+	now := e.clock.Now()
+	claims["iat"] = now.Unix()
+	if v := node.Properties["expiresIn"]; v != nil && v != "" {
+		d, err := parseDelay(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid expiresIn: %v", v)
+		}
+		claims["exp"] = now.Add(d).Unix()
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("encode claims: %v", err)
+	}
+	signingInput := jwtEncode(header) + "." + jwtEncode(payload)
+
+	var sig []byte
+	switch alg {
+	case "HS256":
+		secret, err := jwtSecret(node)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	case "RS256":
+		key, err := jwtPrivateKey(node)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			return nil, fmt.Errorf("sign token: %v", err)
+		}
+	}
+	return map[string]interface{}{"token": signingInput + "." + jwtEncode(sig)}, nil
+}
+
+// verify checks a token's signature and validity window.
+func (e *JWTExecutor) verify(node *Node, input interface{}, alg string) (interface{}, error) {
+	token, err := jwtToken(node, input)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header map[string]interface{}
+	if err := jwtDecode(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if got, _ := header["alg"].(string); got != alg {
+		return nil, fmt.Errorf("%w: signed with %q, expected %s", ErrInvalidToken, got, alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	signingInput := parts[0] + "." + parts[1]
+
+	switch alg {
+	case "HS256":
+		secret, err := jwtSecret(node)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	case "RS256":
+		key, err := jwtPublicKey(node)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+			return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	}
+
+	var claims map[string]interface{}
+	if err := jwtDecode(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	now := e.clock.Now()
+	if exp, ok := claims["exp"].(float64); ok && !now.Before(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("%w: expired at %s", ErrInvalidToken, time.Unix(int64(exp), 0).UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("%w: not valid before %s", ErrInvalidToken, time.Unix(int64(nbf), 0).UTC().Format(time.RFC3339))
+	}
+
+	return map[string]interface{}{
+		"valid":  true,
+		"header": header,
+		"claims": claims,
+	}, nil
+}
+
+// jwtToken reads the token to verify.
+func jwtToken(node *Node, input interface{}) (string, error) {
+	if tmpl, _ := node.Properties["token"].(string); strings.TrimSpace(tmpl) != "" {
+		rendered, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return "", fmt.Errorf("token: %v", err)
+		}
+		return strings.TrimSpace(exprString(rendered)), nil
+	}
+	switch v := genericJSON(input).(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case map[string]interface{}:
+		if s, ok := v["token"].(string); ok {
+			return strings.TrimSpace(s), nil
+		}
+	}
+	return "", fmt.Errorf("token is required when the input is not a token")
+}
+
+// jwtSecret reads the HS256 secret.
+func jwtSecret(node *Node) ([]byte, error) {
+	secret, _ := node.Properties["secret"].(string)
+	if secret == "" {
+		return nil, fmt.Errorf("secret is required for HS256")
+	}
+	return []byte(secret), nil
+}
+
+// jwtPrivateKey parses the PEM privateKey, PKCS#1 or PKCS#8.
+func jwtPrivateKey(node *Node) (*rsa.PrivateKey, error) {
+	data, _ := node.Properties["privateKey"].(string)
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("privateKey must be a PEM RSA private key for RS256")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid privateKey: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("privateKey is not an RSA key")
+	}
+	return key, nil
+}
+
+// jwtPublicKey parses the PEM publicKey: a PKIX or PKCS#1 key, or a
+// certificate.
+func jwtPublicKey(node *Node) (*rsa.PublicKey, error) {
+	data, _ := node.Properties["publicKey"].(string)
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("publicKey must be a PEM RSA public key or certificate for RS256")
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			parsed = cert.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid publicKey: %v", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("publicKey is not an RSA key")
+	}
+	return key, nil
+}
+
+// jwtEncode is base64url without padding, as JWTs use.
+func jwtEncode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// jwtDecode decodes one base64url JSON segment into out.
+func jwtDecode(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,330 @@
+// kafka.go - Kafka consumer trigger and produce node
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaReconnectDelay is how long a consumer waits before rejoining its
+// group after an error or a failed execution.
+const kafkaReconnectDelay = 5 * time.Second
+
+// WithKafkaClient replaces the client the Kafka nodes reach brokers
+// through, e.g. with a fake broker in tests.
+func WithKafkaClient(client KafkaClient) EngineOption {
+	return func(we *WorkflowEngine) { we.kafka = client }
+}
+
+// ============================================
+// Kafka Client
+// ============================================
+
+// KafkaMessage is a record consumed from or produced to a topic.
+type KafkaMessage struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Time      time.Time
+}
+
+// KafkaConsumer reads a topic as a member of a consumer group.
+type KafkaConsumer interface {
+	// Fetch blocks for the next message without committing its offset.
+	Fetch(ctx context.Context) (KafkaMessage, error)
+	// Commit marks msg and everything before it in its partition consumed.
+	Commit(ctx context.Context, msg KafkaMessage) error
+	Close() error
+}
+
+// KafkaClient connects the Kafka nodes to brokers.
+type KafkaClient interface {
+	Consumer(brokers []string, topic, group string) (KafkaConsumer, error)
+	Produce(ctx context.Context, brokers []string, msg KafkaMessage) error
+	Close() error
+}
+
+// kafkaGoClient is the default KafkaClient, sharing one writer per broker
+// list between produce nodes.
+type kafkaGoClient struct {
+	mu      sync.Mutex
+	writers map[string]*kafka.Writer
+}
+
+func NewKafkaClient() KafkaClient {
+	return &kafkaGoClient{
+		writers: make(map[string]*kafka.Writer),
+	}
+}
+
+func (c *kafkaGoClient) Consumer(brokers []string, topic, group string) (KafkaConsumer, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		Topic:   topic,
+		GroupID: group,
+	})
+	return &kafkaGoConsumer{reader: reader}, nil
+}
+
+func (c *kafkaGoClient) Produce(ctx context.Context, brokers []string, msg KafkaMessage) error {
+	c.mu.Lock()
+	key := strings.Join(brokers, ",")
+	w, ok := c.writers[key]
+	if !ok {
+		w = &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: 10 * time.Millisecond,
+			RequiredAcks: kafka.RequireAll,
+		}
+		c.writers[key] = w
+	}
+	c.mu.Unlock()
+
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return w.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+// Close closes every shared writer.
+func (c *kafkaGoClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, w := range c.writers {
+		w.Close()
+		delete(c.writers, key)
+	}
+	return nil
+}
+
+type kafkaGoConsumer struct {
+	reader *kafka.Reader
+}
+
+func (c *kafkaGoConsumer) Fetch(ctx context.Context) (KafkaMessage, error) {
+	m, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return KafkaMessage{}, err
+	}
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return KafkaMessage{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Value:     m.Value,
+		Headers:   headers,
+		Time:      m.Time,
+	}, nil
+}
+
+func (c *kafkaGoConsumer) Commit(ctx context.Context, msg KafkaMessage) error {
+	return c.reader.CommitMessages(ctx, kafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+	})
+}
+
+func (c *kafkaGoConsumer) Close() error {
+	return c.reader.Close()
+}
+
+// kafkaBrokers reads the node's comma-separated broker addresses.
+func kafkaBrokers(node *Node) ([]string, error) {
+	raw, _ := node.Properties["brokers"].(string)
+	var brokers []string
+	for _, b := range strings.Split(raw, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("brokers is required")
+	}
+	return brokers, nil
+}
+
+func kafkaTopic(node *Node) (string, error) {
+	topic, _ := node.Properties["topic"].(string)
+	if topic = strings.TrimSpace(topic); topic == "" {
+		return "", fmt.Errorf("topic is required")
+	}
+	return topic, nil
+}
+
+// ============================================
+// Kafka Consumer Trigger
+// ============================================
+
+// kafkaListener consumes the node's topic in its consumer group and runs
+// the workflow once per message. Offsets are committed only after a
+// successful execution; a failed one makes the consumer rejoin the group
+// after kafkaReconnectDelay and receive the message again (at-least-once).
+// Properties: brokers, topic, group.
+func kafkaListener(client KafkaClient) TriggerListener {
+	return func(ctx context.Context, node *Node, fire func(input interface{}) error) error {
+		brokers, err := kafkaBrokers(node)
+		if err != nil {
+			return err
+		}
+		topic, err := kafkaTopic(node)
+		if err != nil {
+			return err
+		}
+		group, _ := node.Properties["group"].(string)
+		if group = strings.TrimSpace(group); group == "" {
+			return fmt.Errorf("group is required")
+		}
+
+		logger := loggerFromContext(ctx)
+		for {
+			err := consumeTopic(ctx, client, brokers, topic, group, fire)
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Warn("kafka consumer stopped; rejoining", "topic", topic, "group", group, "error", err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(kafkaReconnectDelay):
+			}
+		}
+	}
+}
+
+// consumeTopic delivers messages until ctx ends, a fetch or commit fails,
+// or an execution fails, leaving that message uncommitted.
+func consumeTopic(ctx context.Context, client KafkaClient, brokers []string, topic, group string, fire func(input interface{}) error) error {
+	consumer, err := client.Consumer(brokers, topic, group)
+	if err != nil {
+		return fmt.Errorf("join group %s: %v", group, err)
+	}
+	defer consumer.Close()
+
+	for {
+		msg, err := consumer.Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch from %s: %v", topic, err)
+		}
+		if err := fire(kafkaInput(msg)); err != nil {
+			return fmt.Errorf("message at partition %d offset %d not committed: %v", msg.Partition, msg.Offset, err)
+		}
+		if err := consumer.Commit(ctx, msg); err != nil {
+			return fmt.Errorf("commit offset %d: %v", msg.Offset, err)
+		}
+	}
+}
+
+// kafkaInput is the trigger output for a message. JSON values are decoded;
+// anything else is passed through as a string.
+func kafkaInput(msg KafkaMessage) map[string]interface{} {
+	var value interface{} = string(msg.Value)
+	var decoded interface{}
+	if err := json.Unmarshal(msg.Value, &decoded); err == nil {
+		value = decoded
+	}
+
+	headers := make(map[string]interface{}, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+
+	input := map[string]interface{}{
+		"value":     value,
+		"key":       string(msg.Key),
+		"headers":   headers,
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+	}
+	if !msg.Time.IsZero() {
+		input["timestamp"] = msg.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return input
+}
+
+// KafkaTriggerExecutor is the consumer trigger node itself; the message
+// arrives as the execution input and is passed through.
+type KafkaTriggerExecutor struct{}
+
+func (e *KafkaTriggerExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	topic, _ := node.Properties["topic"].(string)
+	return map[string]interface{}{
+		"status":  "message_received",
+		"topic":   topic,
+		"message": input,
+	}, nil
+}
+
+// ============================================
+// Kafka Produce Node
+// ============================================
+
+// KafkaProduceExecutor produces a message. Properties: brokers, topic, key
+// (messages with the same key share a partition), message (blank = the
+// node input as JSON), headers (JSON object of strings).
+type KafkaProduceExecutor struct {
+	client KafkaClient
+}
+
+func (e *KafkaProduceExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	brokers, err := kafkaBrokers(node)
+	if err != nil {
+		return nil, err
+	}
+	topic, err := kafkaTopic(node)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := node.Properties["key"].(string)
+
+	var value []byte
+	if msg, _ := node.Properties["message"].(string); msg != "" {
+		value = []byte(msg)
+	} else if value, err = json.Marshal(input); err != nil {
+		return nil, fmt.Errorf("encode message: %v", err)
+	}
+
+	headers := make(map[string]string)
+	raw, err := objectProperty(node, "headers")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range raw {
+		headers[k] = exprString(v)
+	}
+
+	msg := KafkaMessage{Topic: topic, Key: []byte(key), Value: value, Headers: headers}
+	if err := e.client.Produce(ctx, brokers, msg); err != nil {
+		return nil, fmt.Errorf("produce to %s: %v", topic, err)
+	}
+
+	return map[string]interface{}{
+		"status": "produced",
+		"topic":  topic,
+		"key":    key,
+		"bytes":  len(value),
+	}, nil
+}
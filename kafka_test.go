@@ -0,0 +1,210 @@
+// kafka_test.go - Kafka trigger and produce node tests against a fake broker
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKafka is an in-memory KafkaClient: messages sent to its queue are
+// fetched by every consumer in turn; committed offsets and produced
+// messages are recorded.
+type fakeKafka struct {
+	queue chan KafkaMessage
+
+	mu        sync.Mutex
+	committed []int64
+	produced  []KafkaMessage
+	brokers   []string
+	groups    []string
+	closed    int
+}
+
+func newFakeKafka() *fakeKafka {
+	return &fakeKafka{queue: make(chan KafkaMessage, 16)}
+}
+
+func (f *fakeKafka) Consumer(brokers []string, topic, group string) (KafkaConsumer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.brokers, f.groups = brokers, append(f.groups, topic+"/"+group)
+	return fakeConsumer{f}, nil
+}
+
+func (f *fakeKafka) Produce(ctx context.Context, brokers []string, msg KafkaMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if msg.Topic == "unreachable" {
+		return errors.New("no brokers available")
+	}
+	f.produced = append(f.produced, msg)
+	return nil
+}
+
+func (f *fakeKafka) Close() error { return nil }
+
+// commits returns the offsets committed so far.
+func (f *fakeKafka) commits() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int64(nil), f.committed...)
+}
+
+type fakeConsumer struct{ f *fakeKafka }
+
+func (c fakeConsumer) Fetch(ctx context.Context) (KafkaMessage, error) {
+	select {
+	case msg := <-c.f.queue:
+		return msg, nil
+	case <-ctx.Done():
+		return KafkaMessage{}, ctx.Err()
+	}
+}
+
+func (c fakeConsumer) Commit(ctx context.Context, msg KafkaMessage) error {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.committed = append(c.f.committed, msg.Offset)
+	return nil
+}
+
+func (c fakeConsumer) Close() error {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.closed++
+	return nil
+}
+
+func TestKafkaTriggerRunsAndCommits(t *testing.T) {
+	broker, rec := newFakeKafka(), &recorder{}
+	we := newTestEngine(t, WithKafkaClient(broker), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "orders",
+		Nodes: []Node{
+			{ID: "consume", Type: NodeKafkaTrigger, Properties: map[string]interface{}{"brokers": "k1:9092, k2:9092", "topic": "orders", "group": "billing"}},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "consume", ToID: "r"}},
+	})
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	broker.queue <- KafkaMessage{Topic: "orders", Offset: 41, Key: []byte("o-1"), Value: []byte(`{"total": 12}`), Headers: map[string]string{"source": "shop"}}
+	broker.queue <- KafkaMessage{Topic: "orders", Offset: 42, Value: []byte("plain text")}
+	eventually(t, "both offsets committed", func() bool { return len(broker.commits()) == 2 })
+
+	calls := rec.calls()
+	if len(calls) != 2 {
+		t.Fatalf("recorded %d runs, want 2", len(calls))
+	}
+	first := calls[0].(map[string]interface{})["message"].(map[string]interface{})
+	if !reflect.DeepEqual(first["value"], map[string]interface{}{"total": 12.0}) || first["key"] != "o-1" ||
+		!reflect.DeepEqual(first["headers"], map[string]interface{}{"source": "shop"}) {
+		t.Fatalf("first message input = %v", first)
+	}
+	if second := calls[1].(map[string]interface{})["message"].(map[string]interface{}); second["value"] != "plain text" {
+		t.Fatalf("second message value = %v, want the raw string", second["value"])
+	}
+	if !reflect.DeepEqual(broker.commits(), []int64{41, 42}) {
+		t.Fatalf("committed %v, want [41 42]", broker.commits())
+	}
+	broker.mu.Lock()
+	if !reflect.DeepEqual(broker.brokers, []string{"k1:9092", "k2:9092"}) || broker.groups[0] != "orders/billing" {
+		t.Errorf("joined %v as %v", broker.brokers, broker.groups)
+	}
+	broker.mu.Unlock()
+}
+
+func TestKafkaFailedExecutionLeavesOffsetUncommitted(t *testing.T) {
+	broker := newFakeKafka()
+	broker.queue <- KafkaMessage{Offset: 1, Value: []byte(`"ok"`)}
+	broker.queue <- KafkaMessage{Offset: 2, Value: []byte(`"poison"`)}
+	broker.queue <- KafkaMessage{Offset: 3, Value: []byte(`"after"`)}
+
+	var seen []interface{}
+	fire := func(input interface{}) error {
+		value := input.(map[string]interface{})["value"]
+		seen = append(seen, value)
+		if value == "poison" {
+			return errors.New("execution failed")
+		}
+		return nil
+	}
+	err := consumeTopic(context.Background(), broker, []string{"k:9092"}, "t", "g", fire)
+	if err == nil || !strings.Contains(err.Error(), "offset 2 not committed") {
+		t.Fatalf("err = %v, want the failed message reported uncommitted", err)
+	}
+	// Consumption stops at the failure so the group redelivers from there
+	if !reflect.DeepEqual(seen, []interface{}{"ok", "poison"}) {
+		t.Fatalf("delivered %v, want to stop at the failure", seen)
+	}
+	if !reflect.DeepEqual(broker.commits(), []int64{1}) {
+		t.Fatalf("committed %v, want only [1]", broker.commits())
+	}
+	if broker.closed != 1 {
+		t.Fatalf("consumer closed %d times, want 1", broker.closed)
+	}
+}
+
+func TestKafkaProduce(t *testing.T) {
+	broker := newFakeKafka()
+	e := &KafkaProduceExecutor{client: broker}
+	ctx := context.Background()
+
+	out, err := e.Execute(ctx, &Node{Properties: map[string]interface{}{
+		"brokers": "k:9092",
+		"topic":   "events",
+		"key":     "user-7",
+		"headers": map[string]interface{}{"attempt": 2.0},
+	}}, map[string]interface{}{"event": "signup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{}); got["status"] != "produced" || got["topic"] != "events" {
+		t.Fatalf("output = %v", got)
+	}
+	if len(broker.produced) != 1 {
+		t.Fatalf("produced %d messages, want 1", len(broker.produced))
+	}
+	msg := broker.produced[0]
+	var value map[string]interface{}
+	json.Unmarshal(msg.Value, &value)
+	if string(msg.Key) != "user-7" || value["event"] != "signup" || msg.Headers["attempt"] != "2" {
+		t.Fatalf("message = key %q value %s headers %v", msg.Key, msg.Value, msg.Headers)
+	}
+
+	// An explicit message is sent as is
+	e.Execute(ctx, &Node{Properties: map[string]interface{}{"brokers": "k:9092", "topic": "events", "message": "hello"}}, nil)
+	if got := string(broker.produced[1].Value); got != "hello" {
+		t.Fatalf("explicit message = %q", got)
+	}
+
+	for name, props := range map[string]map[string]interface{}{
+		"no brokers":   {"topic": "events"},
+		"no topic":     {"brokers": "k:9092"},
+		"broker error": {"brokers": "k:9092", "topic": "unreachable"},
+	} {
+		if _, err := e.Execute(ctx, &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestKafkaInputTimestamp(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	input := kafkaInput(KafkaMessage{Topic: "t", Partition: 3, Offset: 9, Value: []byte("x"), Time: at})
+	if input["timestamp"] != "2024-05-01T10:00:00Z" || input["partition"] != 3 || input["offset"] != int64(9) {
+		t.Fatalf("input = %v", input)
+	}
+	if _, ok := kafkaInput(KafkaMessage{})["timestamp"]; ok {
+		t.Fatal("zero time reported as a timestamp")
+	}
+}
@@ -0,0 +1,167 @@
+// kubernetes.go - Kubernetes resource node
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// ============================================
+// Kubernetes Node
+// ============================================
+
+// KubeClientFactory builds a dynamic client and REST mapper for a kubeconfig
+// path. An empty path uses the default loading rules (KUBECONFIG,
+// ~/.kube/config, then in-cluster).
+type KubeClientFactory func(kubeconfig string) (dynamic.Interface, meta.RESTMapper, error)
+
+// KubernetesExecutor gets, applies, or deletes a resource and returns its
+// status. Properties: operation (get|apply|delete), kubeconfig, manifest
+// (apply) or apiVersion/kind/name/namespace (get, delete).
+type KubernetesExecutor struct {
+	newClient KubeClientFactory
+}
+
+func NewKubernetesExecutor() *KubernetesExecutor {
+	return &KubernetesExecutor{newClient: defaultKubeClient}
+}
+
+func defaultKubeClient(kubeconfig string) (dynamic.Interface, meta.RESTMapper, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load kubeconfig: %v", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	disco, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disco))
+	return client, mapper, nil
+}
+
+func (e *KubernetesExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	operation, _ := node.Properties["operation"].(string)
+	kubeconfig, _ := node.Properties["kubeconfig"].(string)
+
+	obj, err := kubeTarget(node, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	client, mapper, err := e.newClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %v", gvk.Kind, err)
+	}
+
+	var res dynamic.ResourceInterface = client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = metav1.NamespaceDefault
+		}
+		res = client.Resource(mapping.Resource).Namespace(ns)
+	}
+
+	switch operation {
+	case "get":
+		current, err := res.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return kubeResult("found", current), nil
+
+	case "apply":
+		applied, err := res.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: "goflow", Force: true})
+		if err != nil {
+			return nil, err
+		}
+		return kubeResult("applied", applied), nil
+
+	case "delete":
+		if err := res.Delete(ctx, obj.GetName(), metav1.DeleteOptions{}); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"status":    "deleted",
+			"kind":      gvk.Kind,
+			"name":      obj.GetName(),
+			"namespace": obj.GetNamespace(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported kubernetes operation: %q", operation)
+}
+
+// kubeTarget builds the object the operation acts on: the parsed manifest
+// for apply, or an identity-only object for get and delete.
+func kubeTarget(node *Node, operation string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+
+	if operation == "apply" {
+		manifest, _ := node.Properties["manifest"].(string)
+		if manifest == "" {
+			return nil, fmt.Errorf("manifest is required for apply")
+		}
+		data, err := yaml.YAMLToJSON([]byte(manifest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: %v", err)
+		}
+		if err := json.Unmarshal(data, &obj.Object); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %v", err)
+		}
+	} else {
+		apiVersion, _ := node.Properties["apiVersion"].(string)
+		kind, _ := node.Properties["kind"].(string)
+		name, _ := node.Properties["name"].(string)
+		namespace, _ := node.Properties["namespace"].(string)
+
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid apiVersion: %v", err)
+		}
+		obj.SetGroupVersionKind(gv.WithKind(kind))
+		obj.SetName(name)
+		obj.SetNamespace(namespace)
+	}
+
+	if obj.GetKind() == "" || obj.GetName() == "" {
+		return nil, fmt.Errorf("kind and name are required")
+	}
+	return obj, nil
+}
+
+func kubeResult(status string, obj *unstructured.Unstructured) map[string]interface{} {
+	return map[string]interface{}{
+		"status":          status,
+		"kind":            obj.GetKind(),
+		"name":            obj.GetName(),
+		"namespace":       obj.GetNamespace(),
+		"resourceVersion": obj.GetResourceVersion(),
+		"resource_status": obj.Object["status"],
+	}
+}
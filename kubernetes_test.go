@@ -0,0 +1,171 @@
+// kubernetes_test.go - Kubernetes node tests against a fake dynamic client
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	configMapGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+// fakeKube returns an executor backed by a fake dynamic client seeded with
+// objs. The fake tracker cannot server-side apply, so a reactor stores the
+// applied object as-is.
+func fakeKube(t *testing.T, objs ...runtime.Object) (*KubernetesExecutor, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapGVR:  "ConfigMapList",
+		deploymentGVR: "DeploymentList",
+	}, objs...)
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		tracker := client.Tracker()
+		ns := action.GetNamespace()
+		_, err := tracker.Get(action.GetResource(), ns, patch.GetName())
+		switch {
+		case apierrors.IsNotFound(err):
+			err = tracker.Create(action.GetResource(), obj, ns)
+		case err == nil:
+			err = tracker.Update(action.GetResource(), obj, ns)
+		}
+		return true, obj, err
+	})
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	return &KubernetesExecutor{newClient: func(string) (dynamic.Interface, meta.RESTMapper, error) {
+		return client, mapper, nil
+	}}, client
+}
+
+func TestKubernetesApplyThenGet(t *testing.T) {
+	e, client := fakeKube(t)
+	ctx := context.Background()
+
+	out, err := e.Execute(ctx, &Node{Properties: map[string]interface{}{
+		"operation": "apply",
+		"manifest": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: ops
+data:
+  mode: blue
+`,
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := out.(map[string]interface{})
+	if applied["status"] != "applied" || applied["kind"] != "ConfigMap" || applied["name"] != "settings" {
+		t.Fatalf("apply result = %v", applied)
+	}
+
+	stored, err := client.Resource(configMapGVR).Namespace("ops").Get(ctx, "settings", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("applied object not stored: %v", err)
+	}
+	if mode, _, _ := unstructured.NestedString(stored.Object, "data", "mode"); mode != "blue" {
+		t.Fatalf("stored data.mode = %q, want blue", mode)
+	}
+
+	out, err = e.Execute(ctx, &Node{Properties: map[string]interface{}{
+		"operation":  "get",
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"name":       "settings",
+		"namespace":  "ops",
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{}); got["status"] != "found" || got["namespace"] != "ops" {
+		t.Fatalf("get result = %v", got)
+	}
+}
+
+func TestKubernetesGetReturnsResourceStatus(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"status":     map[string]interface{}{"readyReplicas": int64(3)},
+	}}
+	e, _ := fakeKube(t, deployment)
+
+	// No namespace property: namespaced kinds default to "default".
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"operation":  "get",
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"name":       "web",
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.(map[string]interface{})["resource_status"]
+	if want := map[string]interface{}{"readyReplicas": int64(3)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("resource_status = %v, want %v", got, want)
+	}
+}
+
+func TestKubernetesDeleteAndMissing(t *testing.T) {
+	e, _ := fakeKube(t, &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "old", "namespace": "default"},
+	}})
+	props := map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "name": "old"}
+	ctx := context.Background()
+
+	props["operation"] = "delete"
+	out, err := e.Execute(ctx, &Node{Properties: props}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.(map[string]interface{})["status"] != "deleted" {
+		t.Fatalf("delete result = %v", out)
+	}
+
+	props["operation"] = "get"
+	if _, err := e.Execute(ctx, &Node{Properties: props}, nil); !apierrors.IsNotFound(err) {
+		t.Fatalf("get after delete: err = %v, want not found", err)
+	}
+}
+
+func TestKubernetesRejectsBadInput(t *testing.T) {
+	e, _ := fakeKube(t)
+	for name, props := range map[string]map[string]interface{}{
+		"apply without manifest": {"operation": "apply"},
+		"get without name":       {"operation": "get", "apiVersion": "v1", "kind": "ConfigMap"},
+		"unknown operation":      {"operation": "scale", "apiVersion": "v1", "kind": "ConfigMap", "name": "x"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
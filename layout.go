@@ -0,0 +1,209 @@
+// layout.go - Automatic canvas layout for workflows
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// Auto-layout geometry, matching the spacing of the bundled templates.
+const (
+	layoutOriginX     = 100
+	layoutOriginY     = 150
+	layoutColumnWidth = 250
+	layoutRowHeight   = 150
+)
+
+// NodePosition is a node's place on the canvas.
+type NodePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// AutoLayout reports a workflow's new node positions and the version the
+// layout was saved as.
+type AutoLayout struct {
+	WorkflowID string                  `json:"workflow_id"`
+	Version    int64                   `json:"version"`
+	Positions  map[string]NodePosition `json:"positions"`
+}
+
+// ============================================
+// Layout
+// ============================================
+
+// layoutRanks assigns each node, by index, its column: one past the
+// furthest of its upstream nodes, so every connection runs left to
+// right. A cycle is broken at its first node in workflow order.
+func layoutRanks(w *Workflow) []int {
+	index := make(map[string]int, len(w.Nodes))
+	for i, node := range w.Nodes {
+		index[node.ID] = i
+	}
+	indegree := make([]int, len(w.Nodes))
+	next := make([][]int, len(w.Nodes))
+	for _, c := range w.Connections {
+		from, ok := index[c.FromID]
+		if !ok {
+			continue
+		}
+		to, ok := index[c.ToID]
+		if !ok || to == from {
+			continue
+		}
+		next[from] = append(next[from], to)
+		indegree[to]++
+	}
+
+	rank := make([]int, len(w.Nodes))
+	done := make([]bool, len(w.Nodes))
+	place := func(i int) {
+		done[i] = true
+		for _, j := range next[i] {
+			if done[j] {
+				continue
+			}
+			if rank[i]+1 > rank[j] {
+				rank[j] = rank[i] + 1
+			}
+			indegree[j]--
+		}
+	}
+	for placed := 0; placed < len(w.Nodes); {
+		progressed := false
+		for i := range w.Nodes {
+			if !done[i] && indegree[i] <= 0 {
+				place(i)
+				placed++
+				progressed = true
+			}
+		}
+		if progressed {
+			continue
+		}
+		for i := range w.Nodes {
+			if !done[i] {
+				place(i)
+				placed++
+				break
+			}
+		}
+	}
+	return rank
+}
+
+// layoutPositions places nodes in columns by rank. Within a column nodes
+// are ordered by the mean row of their upstream nodes, to keep
+// connections short and uncrossed, then by workflow order; columns are
+// centred on the tallest one.
+func layoutPositions(w *Workflow) map[string]NodePosition {
+	rank := layoutRanks(w)
+	index := make(map[string]int, len(w.Nodes))
+	columns := [][]int{}
+	for i, node := range w.Nodes {
+		index[node.ID] = i
+		for len(columns) <= rank[i] {
+			columns = append(columns, nil)
+		}
+		columns[rank[i]] = append(columns[rank[i]], i)
+	}
+	prev := make([][]int, len(w.Nodes))
+	for _, c := range w.Connections {
+		from, okFrom := index[c.FromID]
+		to, okTo := index[c.ToID]
+		if okFrom && okTo && rank[from] < rank[to] {
+			prev[to] = append(prev[to], from)
+		}
+	}
+
+	row := make([]int, len(w.Nodes))
+	tallest := 0
+	for _, column := range columns {
+		key := make(map[int]float64, len(column))
+		for _, i := range column {
+			key[i] = math.Inf(1)
+			if len(prev[i]) > 0 {
+				sum := 0
+				for _, p := range prev[i] {
+					sum += row[p]
+				}
+				key[i] = float64(sum) / float64(len(prev[i]))
+			}
+		}
+		sort.SliceStable(column, func(a, b int) bool { return key[column[a]] < key[column[b]] })
+		for r, i := range column {
+			row[i] = r
+		}
+		if len(column) > tallest {
+			tallest = len(column)
+		}
+	}
+
+	positions := make(map[string]NodePosition, len(w.Nodes))
+	for c, column := range columns {
+		offset := float64(tallest-len(column)) / 2
+		for _, i := range column {
+			positions[w.Nodes[i].ID] = NodePosition{
+				X: layoutOriginX + float64(c)*layoutColumnWidth,
+				Y: layoutOriginY + (float64(row[i])+offset)*layoutRowHeight,
+			}
+		}
+	}
+	return positions
+}
+
+// AutoLayoutWorkflow lays the workflow's nodes out left to right by depth
+// and saves the result as an edit, so the previous positions stay in the
+// version history.
+func (we *WorkflowEngine) AutoLayoutWorkflow(ctx context.Context, id string) (*Workflow, map[string]NodePosition, error) {
+	existing, err := we.GetWorkflow(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	positions := layoutPositions(existing)
+
+	w := &Workflow{
+		ID:                id,
+		Name:              existing.Name,
+		Description:       existing.Description,
+		Nodes:             make([]Node, len(existing.Nodes)),
+		Connections:       existing.Connections,
+		RateLimit:         existing.RateLimit,
+		Tags:              existing.Tags,
+		ConcurrencyPolicy: existing.ConcurrencyPolicy,
+		Version:           existing.Version,
+	}
+	for i, node := range existing.Nodes {
+		node.X, node.Y = positions[node.ID].X, positions[node.ID].Y
+		w.Nodes[i] = node
+	}
+	if err := we.UpdateWorkflow(ctx, w); err != nil {
+		return nil, nil, err
+	}
+	return w, positions, nil
+}
+
+// ============================================
+// Layout Handlers
+// ============================================
+
+func (s *Server) handleAutoLayout(w http.ResponseWriter, r *http.Request) {
+	workflow, positions, err := s.engine.AutoLayoutWorkflow(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", workflowETag(workflow))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&AutoLayout{
+		WorkflowID: workflow.ID,
+		Version:    workflow.Version,
+		Positions:  positions,
+	})
+}
@@ -0,0 +1,116 @@
+// layout_test.go - Auto-layout tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// layoutFlow builds a workflow of record nodes with ids, listed in the
+// given order, and a connection for each pair in edges.
+func layoutFlow(ids []string, edges ...[2]string) *Workflow {
+	w := &Workflow{Name: "layout"}
+	for _, id := range ids {
+		w.Nodes = append(w.Nodes, Node{ID: id, Type: nodeRecord})
+	}
+	for _, e := range edges {
+		w.Connections = append(w.Connections, Connection{ID: e[0] + "-" + e[1], FromID: e[0], ToID: e[1]})
+	}
+	return w
+}
+
+// columnX is the X of layout column rank.
+func columnX(rank int) float64 {
+	return layoutOriginX + float64(rank)*layoutColumnWidth
+}
+
+func TestLayoutXIncreasesByDepth(t *testing.T) {
+	// A diamond with a shortcut from the start to the end; nodes listed
+	// out of order so the layout cannot lean on it
+	w := layoutFlow([]string{"end", "left", "start", "right", "tail"},
+		[2]string{"start", "left"}, [2]string{"start", "right"},
+		[2]string{"left", "end"}, [2]string{"right", "end"},
+		[2]string{"start", "end"}, [2]string{"end", "tail"})
+	positions := layoutPositions(w)
+
+	for _, c := range w.Connections {
+		if from, to := positions[c.FromID], positions[c.ToID]; from.X >= to.X {
+			t.Errorf("%s -> %s runs right to left: x %v -> %v", c.FromID, c.ToID, from.X, to.X)
+		}
+	}
+	for id, depth := range map[string]int{"start": 0, "left": 1, "right": 1, "end": 2, "tail": 3} {
+		if got := positions[id].X; got != columnX(depth) {
+			t.Errorf("%s: x = %v, want %v for depth %d", id, got, columnX(depth), depth)
+		}
+	}
+	if positions["left"].Y == positions["right"].Y {
+		t.Fatalf("left and right share a row: %v", positions)
+	}
+	// Single-node columns sit centred on the two-node one
+	if mid := (positions["left"].Y + positions["right"].Y) / 2; positions["start"].Y != mid || positions["end"].Y != mid {
+		t.Fatalf("start y %v, end y %v, want both centred at %v", positions["start"].Y, positions["end"].Y, mid)
+	}
+}
+
+func TestLayoutBreaksCyclesAndStacksLoneNodes(t *testing.T) {
+	w := layoutFlow([]string{"a", "b", "c", "lone"},
+		[2]string{"a", "b"}, [2]string{"b", "c"}, [2]string{"c", "a"})
+	positions := layoutPositions(w)
+
+	if len(positions) != 4 {
+		t.Fatalf("positions = %v, want all four nodes placed", positions)
+	}
+	// The cycle is broken at a, its first node in workflow order
+	for id, depth := range map[string]int{"a": 0, "b": 1, "c": 2, "lone": 0} {
+		if got := positions[id].X; got != columnX(depth) {
+			t.Errorf("%s: x = %v, want %v", id, got, columnX(depth))
+		}
+	}
+	if positions["a"].Y == positions["lone"].Y {
+		t.Fatalf("a and lone overlap at %v", positions["a"])
+	}
+}
+
+func TestAutoLayoutEndpointSavesPositions(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: &recorder{}},
+	})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, layoutFlow([]string{"second", "first"}, [2]string{"first", "second"}))
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/auto-layout", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("auto-layout: %d %s", resp.StatusCode, data)
+	}
+	var layout AutoLayout
+	decode(t, data, &layout)
+	if layout.WorkflowID != wf.ID || layout.Version != 2 {
+		t.Fatalf("layout = %+v, want version 2 of %s", layout, wf.ID)
+	}
+	if layout.Positions["first"].X != columnX(0) || layout.Positions["second"].X != columnX(1) {
+		t.Fatalf("positions = %v", layout.Positions)
+	}
+
+	saved, err := s.engine.GetWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range saved.Nodes {
+		if p := layout.Positions[node.ID]; node.X != p.X || node.Y != p.Y {
+			t.Fatalf("saved %s at (%v, %v), want (%v, %v)", node.ID, node.X, node.Y, p.X, p.Y)
+		}
+	}
+	if len(saved.Connections) != 1 {
+		t.Fatalf("layout changed the connections: %v", saved.Connections)
+	}
+	// The old positions stay in the history
+	if versions, _ := s.engine.store.Versions(wf.ID); len(versions) != 1 {
+		t.Fatalf("%d versions kept, want the pre-layout one", len(versions))
+	}
+
+	if resp, _ := doRequest(t, ts, "POST", "/api/workflows/missing/auto-layout", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown workflow: status %d, want 404", resp.StatusCode)
+	}
+}
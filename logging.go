@@ -0,0 +1,107 @@
+// logging.go - Structured logging and request correlation
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDKey contextKey = "requestID"
+	loggerKey    contextKey = "logger"
+)
+
+// ============================================
+// Loggers
+// ============================================
+
+// NewJSONLogger writes JSON lines to stdout at the named level
+// (debug, info, warn, error; default info).
+func NewJSONLogger(level string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: slogLevel(strings.ToLower(level))}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// loggerFromContext returns the logger carried by ctx, annotated with
+// whatever request/execution identity has been attached so far.
+func loggerFromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// ============================================
+// Request Middleware
+// ============================================
+
+// requestLogging tags each request with an ID (reusing an inbound
+// X-Request-ID), echoes it in the response, and logs the outcome.
+func (s *Server) requestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = uuid.New().String()
+		}
+		w.Header().Set("X-Request-ID", id)
+
+		logger := s.logger.With("request_id", id)
+		ctx := context.WithValue(r.Context(), requestIDKey, id)
+		ctx = withLogger(ctx, logger)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		logger.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Hijack lets WebSocket upgrades pass through the recorder.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
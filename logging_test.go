@@ -0,0 +1,126 @@
+// logging_test.go - Structured logging tests
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// nodeFail is a test-only node type that always fails.
+const nodeFail NodeType = "fail"
+
+type failing struct{}
+
+func (failing) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	return nil, errors.New("boom")
+}
+
+// logBuffer collects log output from concurrent writers.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines decodes every JSON log line written so far.
+func (b *logBuffer) lines(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var line map[string]interface{}
+		decode(t, scanner.Bytes(), &line)
+		out = append(out, line)
+	}
+	return out
+}
+
+// find returns the first line with the given level and message.
+func find(lines []map[string]interface{}, level, msg string) map[string]interface{} {
+	for _, l := range lines {
+		if l["level"] == level && l["msg"] == msg {
+			return l
+		}
+	}
+	return nil
+}
+
+func TestFailedExecutionLogsCarryIdentity(t *testing.T) {
+	logs := &logBuffer{}
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		Logger:        slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		NodeExecutors: map[NodeType]NodeExecutor{nodeFail: failing{}},
+	})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name:  "broken",
+		Nodes: []Node{{ID: "f", Type: nodeFail}},
+	})
+
+	resp, body := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil, "X-Request-ID", "req-42")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("execute: %d %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("X-Request-ID"); got != "req-42" {
+		t.Fatalf("X-Request-ID = %q, want req-42", got)
+	}
+	var result ExecutionResult
+	decode(t, body, &result)
+	if result.Status != StatusFailed {
+		t.Fatalf("status = %s, want failed", result.Status)
+	}
+	eventually(t, "request log line", func() bool { return find(logs.lines(t), "INFO", "request") != nil })
+	lines := logs.lines(t)
+
+	failure := find(lines, "ERROR", "boom")
+	if failure == nil {
+		t.Fatalf("no error line for the failed node in:\n%s", logs.buf.String())
+	}
+	want := map[string]interface{}{
+		"request_id":   "req-42",
+		"workflow_id":  wf.ID,
+		"execution_id": result.ID,
+		"node_id":      "f",
+		"node_type":    "fail",
+	}
+	for k, v := range want {
+		if failure[k] != v {
+			t.Errorf("error line %s = %v, want %v", k, failure[k], v)
+		}
+	}
+
+	// A failed node logs its error in place of "node finished"
+	started := find(lines, "DEBUG", "node started")
+	if started == nil || started["execution_id"] != result.ID || started["request_id"] != "req-42" {
+		t.Errorf("node started line = %v", started)
+	}
+	if finished := find(lines, "DEBUG", "node finished"); finished != nil {
+		t.Errorf("failed node logged %v", finished)
+	}
+
+	request := find(lines, "INFO", "request")
+	if request["request_id"] != "req-42" || request["status"] != 200.0 {
+		t.Errorf("request line = %v", request)
+	}
+}
+
+func TestRequestIDGeneratedWhenAbsent(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	resp, _ := doRequest(t, ts, "GET", "/api/workflows", nil)
+	if resp.Header.Get("X-Request-ID") == "" {
+		t.Fatal("response has no X-Request-ID")
+	}
+}
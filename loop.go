@@ -0,0 +1,143 @@
+// loop.go - Loop node with batching and parallel iterations
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxLoopParallel bounds how many iterations a loop node runs at once.
+const maxLoopParallel = 32
+
+// ============================================
+// Loop Node
+// ============================================
+
+// LoopExecutor iterates over the items of its input array, or over
+// iterations copies of a non-array input. Properties:
+//   - batchSize: items per iteration; above 1 each iteration gets an
+//     array of up to batchSize items (default 1, one item each)
+//   - workflowId: workflow run once per iteration with the item or batch
+//     as input; without it the node outputs the batches themselves
+//   - parallel: iterations run at once (default 1, sequential)
+//
+// The output lists each iteration's result in input order, whatever order
+// parallel iterations finish in. The first failing iteration fails the
+// node and cancels the rest.
+type LoopExecutor struct {
+	engine *WorkflowEngine
+}
+
+func (e *LoopExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	items, err := loopItems(node, input)
+	if err != nil {
+		return nil, err
+	}
+	batchSize, err := loopSetting(node, "batchSize", 1)
+	if err != nil {
+		return nil, err
+	}
+	parallel, err := loopSetting(node, "parallel", 1)
+	if err != nil {
+		return nil, err
+	}
+	if parallel > maxLoopParallel {
+		parallel = maxLoopParallel
+	}
+
+	iterations := make([]interface{}, 0, (len(items)+batchSize-1)/batchSize)
+	for start := 0; start < len(items); start += batchSize {
+		if batchSize == 1 {
+			iterations = append(iterations, items[start])
+			continue
+		}
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		iterations = append(iterations, items[start:end])
+	}
+
+	target, _ := node.Properties["workflowId"].(string)
+	if target = strings.TrimSpace(target); target == "" {
+		return iterations, nil
+	}
+	return e.run(ctx, target, iterations, parallel)
+}
+
+// run calls target once per iteration, at most parallel at a time,
+// collecting the results by index.
+func (e *LoopExecutor) run(ctx context.Context, target string, iterations []interface{}, parallel int) ([]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]interface{}, len(iterations))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, item := range iterations {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, item interface{}) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			out, err := callWorkflow(ctx, e.engine, target, item)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("iteration %d: %v", i, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = out
+		}(i, item)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// loopItems is the input array, or iterations copies of any other input.
+func loopItems(node *Node, input interface{}) ([]interface{}, error) {
+	if items, ok := genericJSON(input).([]interface{}); ok {
+		return items, nil
+	}
+	n, err := loopSetting(node, "iterations", 1)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = input
+	}
+	return items, nil
+}
+
+// loopSetting reads a positive integer property, def when unset.
+func loopSetting(node *Node, key string, def int) (int, error) {
+	v, ok := node.Properties[key]
+	if !ok || v == nil || v == "" {
+		return def, nil
+	}
+	n, err := toInt(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %v", key, v)
+	}
+	return n, nil
+}
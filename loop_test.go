@@ -0,0 +1,136 @@
+// loop_test.go - Loop node batching and parallel iteration tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jitter is a node executor that holds smaller numbers longer, so parallel
+// iterations finish out of order, and records the most it saw at once.
+type jitter struct {
+	mu           sync.Mutex
+	active, peak int
+}
+
+func (j *jitter) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	j.mu.Lock()
+	j.active++
+	if j.active > j.peak {
+		j.peak = j.active
+	}
+	j.mu.Unlock()
+	defer func() {
+		j.mu.Lock()
+		j.active--
+		j.mu.Unlock()
+	}()
+
+	n, _ := input.(float64)
+	time.Sleep(time.Duration(10-n) * 3 * time.Millisecond)
+	return n * 10, nil
+}
+
+const nodeJitter NodeType = "jitter"
+
+// loopOver builds a workflow whose loop node runs with props.
+func loopOver(props map[string]interface{}) *Workflow {
+	return &Workflow{Name: "loop", Nodes: []Node{{ID: "loop", Type: NodeLoop, Properties: props}}}
+}
+
+func TestParallelLoopPreservesOrder(t *testing.T) {
+	j := &jitter{}
+	we := newTestEngine(t, WithNodeExecutor(nodeJitter, j))
+	ctx := context.Background()
+	body := mustCreate(t, we, ctx, &Workflow{Name: "body", Nodes: []Node{{ID: "j", Type: nodeJitter}}})
+	wf := mustCreate(t, we, ctx, loopOver(map[string]interface{}{"workflowId": body.ID, "parallel": 4.0}))
+
+	items := []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0}
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status %s, errors %v", result.Status, result.Errors)
+	}
+	var want []interface{}
+	for _, item := range items {
+		want = append(want, map[string]interface{}{"j": item.(float64) * 10})
+	}
+	if got := result.Results["loop"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("loop output = %v, want %v in input order", got, want)
+	}
+	if j.peak < 2 || j.peak > 4 {
+		t.Fatalf("%d iterations ran at once, want between 2 and 4", j.peak)
+	}
+}
+
+func TestLoopBatchesTenIntoThrees(t *testing.T) {
+	items := make([]interface{}, 10)
+	for i := range items {
+		items[i] = float64(i)
+	}
+	want := []interface{}{
+		[]interface{}{0.0, 1.0, 2.0},
+		[]interface{}{3.0, 4.0, 5.0},
+		[]interface{}{6.0, 7.0, 8.0},
+		[]interface{}{9.0},
+	}
+
+	// Without a workflow the node outputs the batches themselves
+	out, err := (&LoopExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{"batchSize": 3.0}}, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("batches = %v, want %v", out, want)
+	}
+
+	// With one, each batch is one downstream invocation
+	rec := &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	body := mustCreate(t, we, ctx, &Workflow{Name: "body", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+	wf := mustCreate(t, we, ctx, loopOver(map[string]interface{}{"workflowId": body.ID, "batchSize": 3.0}))
+	if result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, items); err != nil || result.Status != StatusCompleted {
+		t.Fatalf("run: %v %+v", err, result)
+	}
+	if calls := rec.calls(); !reflect.DeepEqual(calls, want) {
+		t.Fatalf("invocations = %v, want %v", calls, want)
+	}
+}
+
+func TestLoopIterationsAndErrors(t *testing.T) {
+	we := newTestEngine(t, WithNodeExecutor(nodeFail, failing{}))
+	ctx := context.Background()
+
+	// A non-array input is repeated iterations times
+	out, err := (&LoopExecutor{}).Execute(ctx, &Node{Properties: map[string]interface{}{"iterations": 3.0}}, "x")
+	if err != nil || !reflect.DeepEqual(out, []interface{}{"x", "x", "x"}) {
+		t.Fatalf("iterations: %v %v", out, err)
+	}
+
+	for name, props := range map[string]map[string]interface{}{
+		"zero parallel":    {"parallel": 0},
+		"bad batch size":   {"batchSize": "lots"},
+		"negative repeats": {"iterations": -1},
+	} {
+		if _, err := (&LoopExecutor{}).Execute(ctx, &Node{Properties: props}, "x"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	body := mustCreate(t, we, ctx, &Workflow{Name: "broken", Nodes: []Node{{ID: "f", Type: nodeFail}}})
+	wf := mustCreate(t, we, ctx, loopOver(map[string]interface{}{"workflowId": body.ID, "parallel": 2.0}))
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, []interface{}{1.0, 2.0, 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed || !strings.Contains(result.Nodes["loop"].Error, "iteration") {
+		t.Fatalf("status %s, loop node %+v, want a failed iteration", result.Status, result.Nodes["loop"])
+	}
+}
@@ -0,0 +1,160 @@
+// main_test.go - Shared helpers for engine and API tests
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nodeRecord is a test-only node type run by a recorder.
+const nodeRecord NodeType = "record"
+
+// recorder is a node executor that records each input it receives and
+// passes it on unchanged.
+type recorder struct {
+	mu     sync.Mutex
+	inputs []interface{}
+}
+
+func (r *recorder) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inputs = append(r.inputs, input)
+	return input, nil
+}
+
+// calls returns the inputs recorded so far.
+func (r *recorder) calls() []interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]interface{}(nil), r.inputs...)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestEngine builds an engine that logs nowhere and is shut down when
+// the test ends.
+func newTestEngine(t *testing.T, opts ...EngineOption) *WorkflowEngine {
+	t.Helper()
+	we := NewWorkflowEngine(append([]EngineOption{WithLogger(discardLogger())}, opts...)...)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		we.Shutdown(ctx)
+	})
+	return we
+}
+
+// mustCreate stores w for the principal in ctx.
+func mustCreate(t *testing.T, we *WorkflowEngine, ctx context.Context, w *Workflow) *Workflow {
+	t.Helper()
+	if err := we.CreateWorkflow(ctx, w); err != nil {
+		t.Fatalf("creating workflow %q: %v", w.Name, err)
+	}
+	return w
+}
+
+// asPrincipal returns a context acting as principal.
+func asPrincipal(principal string) context.Context {
+	return context.WithValue(context.Background(), principalKey, principal)
+}
+
+// eventually fails the test unless cond holds within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// awaitWaiters blocks until at least n goroutines wait on clock, so
+// advancing it wakes them.
+func awaitWaiters(t *testing.T, clock *FakeClock, n int) {
+	t.Helper()
+	eventually(t, "clock waiters", func() bool { return clock.Waiters() >= n })
+}
+
+// newTestServer serves the API for config on a local listener.
+func newTestServer(t *testing.T, config ServerConfig) (*Server, *httptest.Server) {
+	t.Helper()
+	if config.Logger == nil {
+		config.Logger = discardLogger()
+	}
+	s := NewServer(config)
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.engine.Shutdown(ctx)
+	})
+	return s, ts
+}
+
+// doRequest sends body, JSON-encoded unless it is a string or nil, with
+// the given header name/value pairs, and returns the response and its
+// body.
+func doRequest(t *testing.T, ts *httptest.Server, method, path string, body interface{}, header ...string) (*http.Response, []byte) {
+	t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+// decode unmarshals data into v, failing the test if it cannot.
+func decode(t *testing.T, data []byte, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+}
+
+// apiError decodes the error envelope of an API error response.
+func apiError(t *testing.T, data []byte) APIError {
+	t.Helper()
+	var envelope struct {
+		Error APIError `json:"error"`
+	}
+	decode(t, data, &envelope)
+	return envelope.Error
+}
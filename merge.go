@@ -0,0 +1,82 @@
+// merge.go - Join converging branches
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ============================================
+// Merge Node
+// ============================================
+
+// MergeExecutor combines the outputs of the branches that converge on it.
+// Property mode:
+//   - combine (default): merge object outputs into one object, later
+//     connections winning on key clashes; non-object outputs are kept
+//     under their source node ID
+//   - append: collect outputs into one array in connection order,
+//     flattening array outputs
+//   - waitAll: require every upstream node to have delivered, then output
+//     an object of outputs keyed by source node ID
+//
+// Branches that were skipped or failed contribute nothing; waitAll treats
+// them as an error.
+type MergeExecutor struct{}
+
+func (e *MergeExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	up := upstreamFromContext(ctx)
+	mode, _ := node.Properties["mode"].(string)
+
+	switch mode {
+	case "", "combine":
+		merged := make(map[string]interface{})
+		for _, src := range up.Sources {
+			v, ok := up.Values[src]
+			if !ok {
+				continue
+			}
+			if m, ok := genericJSON(v).(map[string]interface{}); ok {
+				for k, val := range m {
+					merged[k] = val
+				}
+			} else {
+				merged[src] = v
+			}
+		}
+		return merged, nil
+
+	case "append":
+		items := []interface{}{}
+		for _, src := range up.Sources {
+			v, ok := up.Values[src]
+			if !ok {
+				continue
+			}
+			if list, ok := genericJSON(v).([]interface{}); ok {
+				items = append(items, list...)
+			} else {
+				items = append(items, v)
+			}
+		}
+		return items, nil
+
+	case "waitAll":
+		var missing []string
+		all := make(map[string]interface{}, len(up.Sources))
+		for _, src := range up.Sources {
+			v, ok := up.Values[src]
+			if !ok {
+				missing = append(missing, src)
+				continue
+			}
+			all[src] = v
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("upstream did not complete: %s", strings.Join(missing, ", "))
+		}
+		return all, nil
+	}
+	return nil, fmt.Errorf("unsupported merge mode: %q", mode)
+}
@@ -0,0 +1,108 @@
+// merge_test.go - Merge node tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// mergeFlow joins two set nodes, a and b, on a merge node in mode.
+func mergeFlow(mode string, a, b map[string]interface{}) *Workflow {
+	return &Workflow{
+		Name: "merge " + mode,
+		Nodes: []Node{
+			{ID: "a", Type: NodeSet, Properties: map[string]interface{}{"fields": a}},
+			{ID: "b", Type: NodeSet, Properties: map[string]interface{}{"fields": b}},
+			{ID: "join", Type: NodeMerge, Properties: map[string]interface{}{"mode": mode}},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "a", ToID: "join"},
+			{ID: "c2", FromID: "b", ToID: "join"},
+		},
+	}
+}
+
+func TestMergeModes(t *testing.T) {
+	a := map[string]interface{}{"x": "1", "shared": "from a"}
+	b := map[string]interface{}{"y": "2", "shared": "from b"}
+	tests := []struct {
+		mode string
+		want interface{}
+	}{
+		{"combine", map[string]interface{}{"x": "1", "y": "2", "shared": "from b"}},
+		{"", map[string]interface{}{"x": "1", "y": "2", "shared": "from b"}},
+		{"append", []interface{}{a, b}},
+		{"waitAll", map[string]interface{}{"a": a, "b": b}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			we := newTestEngine(t)
+			ctx := context.Background()
+			wf := mustCreate(t, we, ctx, mergeFlow(tt.mode, a, b))
+
+			result, err := we.ExecuteWorkflow(ctx, wf.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Status != StatusCompleted {
+				t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+			}
+			if got := genericJSON(result.Results["join"]); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("merged = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeAppendFlattensArrays(t *testing.T) {
+	e := &MergeExecutor{}
+	ctx := context.WithValue(context.Background(), upstreamKey, &Upstream{
+		Sources: []string{"a", "b"},
+		Values: map[string]interface{}{
+			"a": []interface{}{"one", "two"},
+			"b": "three",
+		},
+	})
+	out, err := e.Execute(ctx, &Node{Properties: map[string]interface{}{"mode": "append"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"one", "two", "three"}; !reflect.DeepEqual(out, want) {
+		t.Fatalf("appended = %v, want %v", out, want)
+	}
+}
+
+func TestMergeWaitAllFailsWhenABranchDoesNotDeliver(t *testing.T) {
+	we := newTestEngine(t, WithNodeExecutor(nodeFail, failing{}))
+	ctx := context.Background()
+	w := mergeFlow("waitAll", map[string]interface{}{"x": "1"}, nil)
+	w.Nodes[1] = Node{ID: "b", Type: nodeFail}
+	wf := mustCreate(t, we, ctx, w)
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Nodes["join"]; got == nil || got.Status != NodeStatusFailed {
+		t.Fatalf("join = %+v, want failed", got)
+	}
+
+	// combine still runs with the branch that delivered
+	w = mergeFlow("combine", map[string]interface{}{"x": "1"}, nil)
+	w.Nodes[1] = Node{ID: "b", Type: nodeFail}
+	wf = mustCreate(t, we, ctx, w)
+	result, err = we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := genericJSON(result.Results["join"]); !reflect.DeepEqual(got, map[string]interface{}{"x": "1"}) {
+		t.Fatalf("combined = %v", got)
+	}
+}
+
+func TestMergeRejectsUnknownMode(t *testing.T) {
+	if _, err := (&MergeExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{"mode": "zip"}}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,40 @@
+// metrics.go - Prometheus-style metrics endpoint
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ============================================
+// Metrics
+// ============================================
+
+// handleMetrics reports gauges in the Prometheus text exposition format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	workflows, err := s.engine.store.List("", false)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	executions, resultBytes := s.engine.executions.Stats()
+
+	writeGauge(w, "goflow_workflows", "Number of stored workflows.", len(workflows))
+	writeGauge(w, "goflow_executions_stored", "Number of executions held in the result store.", executions)
+	writeGauge(w, "goflow_executions_suspended", "Number of executions waiting on an approval.", len(s.engine.approvals.List("")))
+	writeGauge(w, "goflow_result_store_bytes", "Approximate encoded size of the result store.", resultBytes)
+
+	active, queued, workers, depth := s.engine.pool.Stats()
+	writeGauge(w, "goflow_workers_active", "Number of executions running on a worker.", active)
+	writeGauge(w, "goflow_workers_max", "Number of workers in the execution pool.", workers)
+	writeGauge(w, "goflow_execution_queue_depth", "Number of executions waiting for a worker.", queued)
+	writeGauge(w, "goflow_execution_queue_capacity", "Number of executions that may wait for a worker.", depth)
+	writeGauge(w, "goflow_circuits_open", "Number of external targets whose circuit is open.", s.engine.executor.breakers.Open())
+}
+
+func writeGauge(w io.Writer, name, help string, value interface{}) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+}
@@ -0,0 +1,71 @@
+// metrics_test.go - Metrics endpoint tests
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// gauges parses a /metrics body into values by metric name.
+func gauges(t *testing.T, body []byte) map[string]float64 {
+	t.Helper()
+	out := make(map[string]float64)
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, " ")
+		if !ok {
+			t.Fatalf("malformed metric line %q", line)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatalf("metric %s: %v", name, err)
+		}
+		out[name] = v
+	}
+	return out
+}
+
+func TestMetricsReflectWorkflowsAndExecutions(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+
+	_, body := doRequest(t, ts, "GET", "/metrics", nil)
+	before := gauges(t, body)
+	if before["goflow_workflows"] != 0 || before["goflow_executions_stored"] != 0 || before["goflow_result_store_bytes"] != 0 {
+		t.Fatalf("fresh server gauges = %v", before)
+	}
+
+	nodes := []Node{{ID: "t", Type: NodeTransform}}
+	wf := mustCreate(t, s.engine, ctx, &Workflow{Name: "one", Nodes: nodes})
+	mustCreate(t, s.engine, ctx, &Workflow{Name: "two", Nodes: nodes})
+	for i := 0; i < 3; i++ {
+		if _, err := s.engine.ExecuteWorkflow(ctx, wf.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, body := doRequest(t, ts, "GET", "/metrics", nil)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("content type = %q", ct)
+	}
+	after := gauges(t, body)
+	if after["goflow_workflows"] != 2 {
+		t.Errorf("goflow_workflows = %v, want 2", after["goflow_workflows"])
+	}
+	if after["goflow_executions_stored"] != 3 {
+		t.Errorf("goflow_executions_stored = %v, want 3", after["goflow_executions_stored"])
+	}
+	if after["goflow_executions_suspended"] != 0 {
+		t.Errorf("goflow_executions_suspended = %v, want 0", after["goflow_executions_suspended"])
+	}
+	if after["goflow_result_store_bytes"] <= 0 {
+		t.Errorf("goflow_result_store_bytes = %v, want positive", after["goflow_result_store_bytes"])
+	}
+	if !strings.Contains(string(body), "# TYPE goflow_workflows gauge") {
+		t.Errorf("missing TYPE line in:\n%s", body)
+	}
+}
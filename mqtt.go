@@ -0,0 +1,309 @@
+// mqtt.go - MQTT subscribe trigger and publish node
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+)
+
+const (
+	// mqttReconnectDelay is how long a subscriber waits before retrying a
+	// broker it could not reach.
+	mqttReconnectDelay = 5 * time.Second
+	// mqttTimeout bounds connecting, subscribing and publishing.
+	mqttTimeout = 10 * time.Second
+)
+
+// WithMQTTClient replaces the client the MQTT nodes reach brokers
+// through, e.g. with a fake broker in tests.
+func WithMQTTClient(client MQTTClient) EngineOption {
+	return func(we *WorkflowEngine) { we.mqtt = client }
+}
+
+// ============================================
+// MQTT Client
+// ============================================
+
+// MQTTMessage is a message received on or published to a topic.
+type MQTTMessage struct {
+	Topic    string
+	Payload  []byte
+	QoS      byte
+	Retained bool
+}
+
+// MQTTClient connects the MQTT nodes to brokers.
+type MQTTClient interface {
+	// Subscribe calls handle for each message on topics matching filter
+	// until stop is called.
+	Subscribe(broker, filter string, qos byte, handle func(MQTTMessage)) (stop func(), err error)
+	Publish(ctx context.Context, broker string, msg MQTTMessage) error
+	Close()
+}
+
+// pahoMQTTClient is the default MQTTClient. Each subscription gets its own
+// connection, resubscribing whenever it reconnects; publishers share one
+// connection per broker.
+type pahoMQTTClient struct {
+	mu         sync.Mutex
+	publishers map[string]mqtt.Client
+}
+
+func NewMQTTClient() MQTTClient {
+	return &pahoMQTTClient{
+		publishers: make(map[string]mqtt.Client),
+	}
+}
+
+// mqttOptions configures a connection to broker, taking credentials from
+// its URL.
+func mqttOptions(broker string) *mqtt.ClientOptions {
+	opts := mqtt.NewClientOptions().
+		SetClientID("goflow-" + uuid.NewString()[:8]).
+		SetAutoReconnect(true).
+		SetConnectTimeout(mqttTimeout)
+	if u, err := url.Parse(broker); err == nil && u.User != nil {
+		opts.SetUsername(u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			opts.SetPassword(password)
+		}
+		u.User = nil
+		broker = u.String()
+	}
+	return opts.AddBroker(broker)
+}
+
+// mqttWait waits for token, failing after mqttTimeout or when ctx ends.
+func mqttWait(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(mqttTimeout):
+		return fmt.Errorf("timed out after %s", mqttTimeout)
+	}
+}
+
+func (c *pahoMQTTClient) Subscribe(broker, filter string, qos byte, handle func(MQTTMessage)) (func(), error) {
+	subscribed := make(chan error, 1)
+	opts := mqttOptions(broker).SetOnConnectHandler(func(client mqtt.Client) {
+		token := client.Subscribe(filter, qos, func(_ mqtt.Client, m mqtt.Message) {
+			handle(MQTTMessage{Topic: m.Topic(), Payload: m.Payload(), QoS: m.Qos(), Retained: m.Retained()})
+		})
+		err := mqttWait(context.Background(), token)
+		select {
+		case subscribed <- err:
+		default:
+		}
+	})
+
+	client := mqtt.NewClient(opts)
+	if err := mqttWait(context.Background(), client.Connect()); err != nil {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("connect to broker: %v", err)
+	}
+	if err := <-subscribed; err != nil {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("subscribe %s: %v", filter, err)
+	}
+	return func() { client.Disconnect(250) }, nil
+}
+
+func (c *pahoMQTTClient) Publish(ctx context.Context, broker string, msg MQTTMessage) error {
+	c.mu.Lock()
+	client, ok := c.publishers[broker]
+	if !ok {
+		client = mqtt.NewClient(mqttOptions(broker))
+		c.publishers[broker] = client
+	}
+	c.mu.Unlock()
+
+	if !client.IsConnectionOpen() {
+		if err := mqttWait(ctx, client.Connect()); err != nil {
+			return fmt.Errorf("connect to broker: %v", err)
+		}
+	}
+	if err := mqttWait(ctx, client.Publish(msg.Topic, msg.QoS, msg.Retained, msg.Payload)); err != nil {
+		return fmt.Errorf("publish: %v", err)
+	}
+	return nil
+}
+
+// Close disconnects every shared publisher.
+func (c *pahoMQTTClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for broker, client := range c.publishers {
+		client.Disconnect(250)
+		delete(c.publishers, broker)
+	}
+}
+
+// mqttTopicMatches reports whether topic matches filter, where "+" matches
+// one level and a trailing "#" any number of levels, including none.
+// Wildcards at the first level do not match topics starting with "$".
+func mqttTopicMatches(filter, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	if strings.HasPrefix(topic, "$") && (f[0] == "+" || f[0] == "#") {
+		return false
+	}
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) || (level != "+" && level != t[i]) {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+// mqttSettings reads the node's broker url, topic and QoS level.
+func mqttSettings(node *Node) (broker, topic string, qos byte, err error) {
+	broker, _ = node.Properties["url"].(string)
+	if broker = strings.TrimSpace(broker); broker == "" {
+		return "", "", 0, fmt.Errorf("url is required")
+	}
+	topic, _ = node.Properties["topic"].(string)
+	if topic = strings.TrimSpace(topic); topic == "" {
+		return "", "", 0, fmt.Errorf("topic is required")
+	}
+	if v, ok := node.Properties["qos"]; ok && v != nil && v != "" {
+		n, err := toInt(v)
+		if err != nil || n < 0 || n > 2 {
+			return "", "", 0, fmt.Errorf("qos must be 0, 1 or 2, got %v", v)
+		}
+		qos = byte(n)
+	}
+	return broker, topic, qos, nil
+}
+
+// ============================================
+// MQTT Subscribe Trigger
+// ============================================
+
+// mqttListener subscribes to the node's topic filter and runs the workflow
+// once per matching message, in arrival order. Properties: url, topic (may
+// use + and # wildcards), qos (default 0).
+func mqttListener(client MQTTClient) TriggerListener {
+	return func(ctx context.Context, node *Node, fire func(input interface{}) error) error {
+		broker, filter, qos, err := mqttSettings(node)
+		if err != nil {
+			return err
+		}
+
+		messages := make(chan MQTTMessage, 64)
+		handle := func(m MQTTMessage) {
+			select {
+			case messages <- m:
+			case <-ctx.Done():
+			}
+		}
+
+		logger := loggerFromContext(ctx)
+		var stop func()
+		for {
+			if stop, err = client.Subscribe(broker, filter, qos, handle); err == nil {
+				break
+			}
+			logger.Warn("mqtt subscribe failed; retrying", "topic", filter, "error", err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(mqttReconnectDelay):
+			}
+		}
+		defer stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case m := <-messages:
+				if mqttTopicMatches(filter, m.Topic) {
+					fire(mqttInput(m))
+				}
+			}
+		}
+	}
+}
+
+// mqttInput is the trigger output for a message. JSON payloads are decoded;
+// anything else is passed through as a string.
+func mqttInput(m MQTTMessage) map[string]interface{} {
+	var payload interface{} = string(m.Payload)
+	var decoded interface{}
+	if err := json.Unmarshal(m.Payload, &decoded); err == nil {
+		payload = decoded
+	}
+	return map[string]interface{}{
+		"topic":    m.Topic,
+		"payload":  payload,
+		"qos":      int(m.QoS),
+		"retained": m.Retained,
+	}
+}
+
+// MQTTTriggerExecutor is the subscribe trigger node itself; the message
+// arrives as the execution input and is passed through.
+type MQTTTriggerExecutor struct{}
+
+func (e *MQTTTriggerExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	topic, _ := node.Properties["topic"].(string)
+	return map[string]interface{}{
+		"status":  "message_received",
+		"topic":   topic,
+		"message": input,
+	}, nil
+}
+
+// ============================================
+// MQTT Publish Node
+// ============================================
+
+// MQTTExecutor publishes a message. Properties: url, topic, qos (default
+// 0), retain, message (blank = the node input as JSON).
+type MQTTExecutor struct {
+	client MQTTClient
+}
+
+func (e *MQTTExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	broker, topic, qos, err := mqttSettings(node)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(topic, "+#") {
+		return nil, fmt.Errorf("cannot publish to wildcard topic %q", topic)
+	}
+
+	var payload []byte
+	if msg, _ := node.Properties["message"].(string); msg != "" {
+		payload = []byte(msg)
+	} else if payload, err = json.Marshal(input); err != nil {
+		return nil, fmt.Errorf("encode message: %v", err)
+	}
+
+	msg := MQTTMessage{Topic: topic, Payload: payload, QoS: qos, Retained: boolProperty(node, "retain")}
+	if err := e.client.Publish(ctx, broker, msg); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"status": "published",
+		"topic":  topic,
+		"qos":    int(qos),
+		"bytes":  len(payload),
+	}, nil
+}
@@ -0,0 +1,152 @@
+// mqtt_test.go - MQTT trigger and publish node tests against a fake client
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeMQTT is an in-memory MQTTClient. Like a broker that ignores filters,
+// it hands every message sent with send to every subscriber, so the
+// trigger's own topic matching decides what runs.
+type fakeMQTT struct {
+	mu          sync.Mutex
+	subscribers []func(MQTTMessage)
+	filters     []string
+	qos         []byte
+	published   []MQTTMessage
+}
+
+func (c *fakeMQTT) Subscribe(broker, filter string, qos byte, handle func(MQTTMessage)) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers = append(c.subscribers, handle)
+	c.filters = append(c.filters, filter)
+	c.qos = append(c.qos, qos)
+	return func() {}, nil
+}
+
+func (c *fakeMQTT) Publish(ctx context.Context, broker string, msg MQTTMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published = append(c.published, msg)
+	return nil
+}
+
+func (c *fakeMQTT) Close() {}
+
+func (c *fakeMQTT) subscribed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subscribers)
+}
+
+func (c *fakeMQTT) send(topic, payload string) {
+	c.mu.Lock()
+	subscribers := c.subscribers[:len(c.subscribers):len(c.subscribers)]
+	c.mu.Unlock()
+	for _, handle := range subscribers {
+		handle(MQTTMessage{Topic: topic, Payload: []byte(payload), QoS: 1})
+	}
+}
+
+func TestMQTTMatchingTopicTriggersWorkflow(t *testing.T) {
+	client, rec := &fakeMQTT{}, &recorder{}
+	we := newTestEngine(t, WithMQTTClient(client), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "temperatures",
+		Nodes: []Node{
+			{ID: "sub", Type: NodeMQTTTrigger, Properties: map[string]interface{}{
+				"url": "tcp://broker:1883", "topic": "sensors/+/temp", "qos": 1.0,
+			}},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "sub", ToID: "r"}},
+	})
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "subscription", func() bool { return client.subscribed() == 1 })
+	if client.filters[0] != "sensors/+/temp" || client.qos[0] != 1 {
+		t.Fatalf("subscribed to %q at qos %d", client.filters[0], client.qos[0])
+	}
+
+	// Messages are handled in arrival order, so by the time the last one
+	// has run the non-matching ones before it were already dropped.
+	client.send("sensors/kitchen/humidity", `{"rh": 40}`)
+	client.send("sensors/kitchen/temp/raw", `{"c": 0}`)
+	client.send("sensors/kitchen/temp", `{"c": 21.5}`)
+	client.send("alerts/kitchen/temp", `{"c": 99}`)
+	client.send("sensors/hall/temp", `cold`)
+	eventually(t, "two runs", func() bool { return len(rec.calls()) == 2 })
+
+	calls := rec.calls()
+	first := calls[0].(map[string]interface{})["message"].(map[string]interface{})
+	if first["topic"] != "sensors/kitchen/temp" || !reflect.DeepEqual(first["payload"], map[string]interface{}{"c": 21.5}) {
+		t.Fatalf("first run input = %v", first)
+	}
+	if second := calls[1].(map[string]interface{})["message"].(map[string]interface{}); second["payload"] != "cold" {
+		t.Fatalf("non-JSON payload = %v, want the raw string", second["payload"])
+	}
+	if n := len(we.executions.List("", wf.ID)); n != 2 {
+		t.Fatalf("%d executions, want 2", n)
+	}
+}
+
+func TestMQTTTopicMatches(t *testing.T) {
+	tests := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"home/kitchen/temp", "home/kitchen/temp", true},
+		{"home/kitchen/temp", "home/kitchen", false},
+		{"home/+/temp", "home/hall/temp", true},
+		{"home/+/temp", "home/hall/humidity", false},
+		{"home/+", "home/hall/temp", false},
+		{"home/#", "home/hall/temp", true},
+		{"home/#", "home", true},
+		{"#", "anything/at/all", true},
+		{"#", "$SYS/uptime", false},
+		{"+/uptime", "$SYS/uptime", false},
+		{"$SYS/#", "$SYS/uptime", true},
+	}
+	for _, tt := range tests {
+		if got := mqttTopicMatches(tt.filter, tt.topic); got != tt.want {
+			t.Errorf("mqttTopicMatches(%q, %q) = %v, want %v", tt.filter, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestMQTTPublish(t *testing.T) {
+	client := &fakeMQTT{}
+	e := &MQTTExecutor{client: client}
+	ctx := context.Background()
+
+	out, err := e.Execute(ctx, &Node{Properties: map[string]interface{}{
+		"url": "tcp://broker:1883", "topic": "lights/hall", "qos": 2.0, "retain": true,
+	}}, map[string]interface{}{"on": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{}); got["status"] != "published" || got["qos"] != 2 {
+		t.Fatalf("output = %v", got)
+	}
+	want := MQTTMessage{Topic: "lights/hall", Payload: []byte(`{"on":true}`), QoS: 2, Retained: true}
+	if !reflect.DeepEqual(client.published[0], want) {
+		t.Fatalf("published %+v, want %+v", client.published[0], want)
+	}
+
+	for name, props := range map[string]map[string]interface{}{
+		"no url":         {"topic": "lights/hall"},
+		"no topic":       {"url": "tcp://broker:1883"},
+		"bad qos":        {"url": "tcp://broker:1883", "topic": "lights/hall", "qos": 3.0},
+		"wildcard topic": {"url": "tcp://broker:1883", "topic": "lights/#"},
+	} {
+		if _, err := e.Execute(ctx, &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+// n8n.go - Conversion to and from n8n workflow JSON
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ============================================
+// n8n Model
+// ============================================
+
+type n8nWorkflow struct {
+	Name        string                              `json:"name"`
+	Nodes       []n8nNode                           `json:"nodes"`
+	Connections map[string]map[string][][]n8nTarget `json:"connections"`
+	Active      bool                                `json:"active"`
+	Settings    map[string]interface{}              `json:"settings"`
+}
+
+type n8nNode struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type"`
+	TypeVersion float64                `json:"typeVersion"`
+	Position    [2]float64             `json:"position"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	OnError     string                 `json:"onError,omitempty"`
+}
+
+type n8nTarget struct {
+	Node  string `json:"node"`
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+// n8n's per-node error settings
+const (
+	n8nErrorOutput   = "continueErrorOutput"   // failures leave via output 1
+	n8nContinueOnErr = "continueRegularOutput" // failures continue as success
+)
+
+// n8nType pairs one of our node types with its closest n8n equivalent and
+// the property names that differ between the two.
+type n8nType struct {
+	ours   NodeType
+	theirs string
+	params map[string]string // our property -> n8n parameter
+}
+
+var n8nTypes = []n8nType{
+	{NodeWebhook, "n8n-nodes-base.webhook", nil},
+	{NodeTimer, "n8n-nodes-base.scheduleTrigger", nil},
+	{NodeHTTP, "n8n-nodes-base.httpRequest", nil},
+	{NodeGraphQL, "n8n-nodes-base.graphql", map[string]string{"url": "endpoint"}},
+	{NodeEmail, "n8n-nodes-base.emailSend", map[string]string{"to": "toEmail", "body": "text"}},
+	{NodeDatabase, "n8n-nodes-base.postgres", nil},
+	{NodeCondition, "n8n-nodes-base.if", nil},
+	{NodeLoop, "n8n-nodes-base.splitInBatches", nil},
+	{NodeTransform, "n8n-nodes-base.code", map[string]string{"script": "jsCode"}},
+	{NodeSlack, "n8n-nodes-base.slack", nil},
+	{NodeSheets, "n8n-nodes-base.googleSheets", nil},
+	{NodeOpenAI, "n8n-nodes-base.openAi", nil},
+	{NodePGNotify, "n8n-nodes-base.postgresTrigger", nil},
+	{NodeRabbitMQTrigger, "n8n-nodes-base.rabbitmqTrigger", nil},
+	{NodeRabbitMQ, "n8n-nodes-base.rabbitmq", nil},
+	{NodeKafkaTrigger, "n8n-nodes-base.kafkaTrigger", nil},
+	{NodeKafkaProduce, "n8n-nodes-base.kafka", nil},
+	{NodeMQTTTrigger, "n8n-nodes-base.mqttTrigger", nil},
+	{NodeMQTT, "n8n-nodes-base.mqtt", nil},
+	{NodeRedis, "n8n-nodes-base.redis", nil},
+	{NodeSMS, "n8n-nodes-base.twilio", map[string]string{"body": "message"}},
+	{NodeDiscord, "n8n-nodes-base.discord", map[string]string{"webhook": "webhookUri", "content": "text"}},
+	{NodeNotion, "n8n-nodes-base.notion", nil},
+	{NodeGCalendar, "n8n-nodes-base.googleCalendar", nil},
+	{NodeJWT, "n8n-nodes-base.jwt", nil},
+	{NodeCrypto, "n8n-nodes-base.crypto", map[string]string{"operation": "action", "algorithm": "type"}},
+	{NodeDedupe, "n8n-nodes-base.removeDuplicates", nil},
+}
+
+func n8nTypeFor(t NodeType) (n8nType, bool) {
+	for _, m := range n8nTypes {
+		if m.ours == t {
+			return m, true
+		}
+	}
+	return n8nType{}, false
+}
+
+func n8nTypeFrom(theirs string) (n8nType, bool) {
+	for _, m := range n8nTypes {
+		if m.theirs == theirs {
+			return m, true
+		}
+	}
+	return n8nType{}, false
+}
+
+// UnmappableNodesError lists the nodes a conversion had no equivalent for.
+type UnmappableNodesError struct {
+	Nodes []string
+}
+
+func (e *UnmappableNodesError) Error() string {
+	return fmt.Sprintf("no equivalent for %d node(s): %s", len(e.Nodes), strings.Join(e.Nodes, ", "))
+}
+
+// ============================================
+// Conversion
+// ============================================
+
+// ToN8n converts a workflow to n8n's format. n8n links nodes by name, so
+// blank or repeated names are made unique. onError connections become the
+// error output of a node set to continueErrorOutput.
+func ToN8n(w *Workflow) (*n8nWorkflow, error) {
+	var unmappable []string
+	names := make(map[string]string, len(w.Nodes)) // node ID -> n8n name
+	used := make(map[string]bool, len(w.Nodes))
+	hasErrorEdge := make(map[string]bool)
+	for _, c := range w.Connections {
+		if c.Port == PortError {
+			hasErrorEdge[c.FromID] = true
+		}
+	}
+
+	out := &n8nWorkflow{
+		Name:        w.Name,
+		Nodes:       []n8nNode{},
+		Connections: make(map[string]map[string][][]n8nTarget),
+		Active:      w.Status == StatusActive,
+		Settings:    map[string]interface{}{},
+	}
+
+	for _, node := range w.Nodes {
+		m, ok := n8nTypeFor(node.Type)
+		if !ok {
+			unmappable = append(unmappable, fmt.Sprintf("%s (%s)", node.ID, node.Type))
+			continue
+		}
+
+		name := uniqueName(node.Name, string(node.Type), used)
+		names[node.ID] = name
+
+		params := make(map[string]interface{}, len(node.Properties))
+		for k, v := range node.Properties {
+			if k == "continueOnError" {
+				continue
+			}
+			if renamed, ok := m.params[k]; ok {
+				k = renamed
+			}
+			params[k] = v
+		}
+
+		n := n8nNode{
+			ID:          node.ID,
+			Name:        name,
+			Type:        m.theirs,
+			TypeVersion: 1,
+			Position:    [2]float64{node.X, node.Y},
+			Parameters:  params,
+		}
+		switch {
+		case hasErrorEdge[node.ID]:
+			n.OnError = n8nErrorOutput
+		case boolProperty(&node, "continueOnError"):
+			n.OnError = n8nContinueOnErr
+		}
+		out.Nodes = append(out.Nodes, n)
+	}
+	if len(unmappable) > 0 {
+		return nil, &UnmappableNodesError{Nodes: unmappable}
+	}
+
+	for _, c := range w.Connections {
+		from, ok := names[c.FromID]
+		if !ok {
+			continue
+		}
+		to, ok := names[c.ToID]
+		if !ok {
+			continue
+		}
+		output := 0
+		if c.Port == PortError {
+			output = 1
+		}
+
+		if out.Connections[from] == nil {
+			out.Connections[from] = map[string][][]n8nTarget{"main": {}}
+		}
+		main := out.Connections[from]["main"]
+		for len(main) <= output {
+			main = append(main, []n8nTarget{})
+		}
+		main[output] = append(main[output], n8nTarget{Node: to, Type: "main", Index: 0})
+		out.Connections[from]["main"] = main
+	}
+	return out, nil
+}
+
+// FromN8n converts an n8n workflow to ours. Only "main" connections are
+// read; output 1 of a continueErrorOutput node becomes an onError
+// connection and any other extra outputs follow the normal path.
+func FromN8n(in *n8nWorkflow) (*Workflow, error) {
+	var unmappable []string
+	ids := make(map[string]string, len(in.Nodes)) // n8n name -> node ID
+	errorOutputs := make(map[string]bool)
+
+	w := &Workflow{
+		Name:        in.Name,
+		Nodes:       []Node{},
+		Connections: []Connection{},
+	}
+
+	for _, n := range in.Nodes {
+		m, ok := n8nTypeFrom(n.Type)
+		if !ok {
+			unmappable = append(unmappable, fmt.Sprintf("%s (%s)", n.Name, n.Type))
+			continue
+		}
+
+		id := n.ID
+		if id == "" {
+			id = uuid.New().String()
+		}
+		ids[n.Name] = id
+
+		props := make(map[string]interface{}, len(n.Parameters))
+		for k, v := range n.Parameters {
+			for ours, theirs := range m.params {
+				if k == theirs {
+					k = ours
+					break
+				}
+			}
+			props[k] = v
+		}
+		switch n.OnError {
+		case n8nErrorOutput:
+			errorOutputs[n.Name] = true
+		case n8nContinueOnErr:
+			props["continueOnError"] = true
+		}
+
+		w.Nodes = append(w.Nodes, Node{
+			ID:         id,
+			Type:       m.ours,
+			Name:       n.Name,
+			X:          n.Position[0],
+			Y:          n.Position[1],
+			Properties: props,
+		})
+	}
+	if len(unmappable) > 0 {
+		return nil, &UnmappableNodesError{Nodes: unmappable}
+	}
+
+	// Walk sources in a fixed order so connection IDs are stable
+	sources := make([]string, 0, len(in.Connections))
+	for name := range in.Connections {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+
+	for _, from := range sources {
+		for output, targets := range in.Connections[from]["main"] {
+			for _, t := range targets {
+				fromID, ok := ids[from]
+				if !ok {
+					continue
+				}
+				toID, ok := ids[t.Node]
+				if !ok {
+					continue
+				}
+				c := Connection{
+					ID:     fmt.Sprintf("conn_%d", len(w.Connections)+1),
+					FromID: fromID,
+					ToID:   toID,
+				}
+				if output == 1 && errorOutputs[from] {
+					c.Port = PortError
+				}
+				w.Connections = append(w.Connections, c)
+			}
+		}
+	}
+	return w, nil
+}
+
+func uniqueName(name, fallback string, used map[string]bool) string {
+	if name == "" {
+		name = fallback
+	}
+	unique := name
+	for i := 1; used[unique]; i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// ============================================
+// Export / Import Handlers
+// ============================================
+
+func (s *Server) handleExportWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := s.engine.GetWorkflow(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	workflow = s.presentWorkflow(r, workflow)
+	var out interface{} = workflow
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+	case "yaml":
+		data, err := marshalYAML(workflow)
+		if err != nil {
+			writeEngineError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(data)
+		return
+	case "n8n":
+		if out, err = ToN8n(workflow); err != nil {
+			writeConversionError(w, err)
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, CodeUnsupportedFormat, fmt.Sprintf("unsupported export format: %q", format))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// handleImportWorkflow creates a workflow from an export, in JSON or YAML
+// by Content-Type; ?format=n8n reads n8n's workflow JSON.
+func (s *Server) handleImportWorkflow(w http.ResponseWriter, r *http.Request) {
+	var workflow *Workflow
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json", "yaml":
+		workflow = &Workflow{}
+		if err := decodeBody(r, workflow); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+			return
+		}
+	case "n8n":
+		var in n8nWorkflow
+		// n8n exports carry many fields we ignore, so unknown ones pass
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidBody, bodyError(err).Error())
+			return
+		}
+		var err error
+		if workflow, err = FromN8n(&in); err != nil {
+			writeConversionError(w, err)
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, CodeUnsupportedFormat, fmt.Sprintf("unsupported import format: %q", format))
+		return
+	}
+
+	workflow.ID = ""
+	if err := s.engine.CreateWorkflow(r.Context(), workflow); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.presentWorkflow(r, workflow))
+}
+
+// writeConversionError reports unmappable nodes as JSON so clients can
+// show which nodes blocked the conversion.
+func writeConversionError(w http.ResponseWriter, err error) {
+	unmappable, ok := err.(*UnmappableNodesError)
+	if !ok {
+		writeEngineError(w, err)
+		return
+	}
+	writeAPIError(w, http.StatusUnprocessableEntity, APIError{
+		Code:    CodeUnmappableNodes,
+		Message: unmappable.Error(),
+		Details: map[string]interface{}{"unmappable": unmappable.Nodes},
+	})
+}
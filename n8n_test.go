@@ -0,0 +1,144 @@
+// n8n_test.go - n8n import/export tests
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestN8nRoundTripMappableTypes(t *testing.T) {
+	w := &Workflow{Name: "migrated"}
+	for i, m := range n8nTypes {
+		props := map[string]interface{}{"note": fmt.Sprintf("node %d", i)}
+		for ours := range m.params {
+			props[ours] = "value of " + ours
+		}
+		w.Nodes = append(w.Nodes, Node{
+			ID:         fmt.Sprintf("n%d", i),
+			Type:       m.ours,
+			Name:       fmt.Sprintf("Step %d", i),
+			X:          float64(100 * i),
+			Y:          float64(50 * (i % 3)),
+			Properties: props,
+		})
+	}
+	w.Nodes[1].Properties["continueOnError"] = true
+	w.Connections = []Connection{
+		{FromID: "n0", ToID: "n1"},
+		{FromID: "n1", ToID: "n2"},
+		{FromID: "n2", ToID: "n3"},
+		{FromID: "n2", ToID: "n4", Port: PortError},
+	}
+
+	exported, err := ToN8n(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded n8nWorkflow
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	back, err := FromN8n(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if back.Name != w.Name || len(back.Nodes) != len(w.Nodes) {
+		t.Fatalf("got %q with %d nodes, want %q with %d", back.Name, len(back.Nodes), w.Name, len(w.Nodes))
+	}
+	for i, want := range w.Nodes {
+		if got := back.Nodes[i]; !reflect.DeepEqual(got, want) {
+			t.Errorf("node %s (%s) came back as %+v, want %+v", want.ID, want.Type, got, want)
+		}
+	}
+
+	type edge struct{ from, to, port string }
+	var got []edge
+	for _, c := range back.Connections {
+		got = append(got, edge{c.FromID, c.ToID, c.Port})
+	}
+	want := []edge{{"n0", "n1", ""}, {"n1", "n2", ""}, {"n2", "n3", ""}, {"n2", "n4", PortError}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("connections = %v, want %v", got, want)
+	}
+}
+
+func TestN8nExportRenamesParametersAndNames(t *testing.T) {
+	out, err := ToN8n(&Workflow{Nodes: []Node{
+		{ID: "a", Type: NodeTransform, Properties: map[string]interface{}{"script": "return 1"}},
+		{ID: "b", Type: NodeTransform},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Nodes[0].Type != "n8n-nodes-base.code" || out.Nodes[0].Parameters["jsCode"] != "return 1" {
+		t.Fatalf("node = %+v", out.Nodes[0])
+	}
+	// n8n links nodes by name, so blank names become unique
+	if out.Nodes[0].Name != "transform" || out.Nodes[1].Name != "transform 1" {
+		t.Fatalf("names = %q, %q", out.Nodes[0].Name, out.Nodes[1].Name)
+	}
+}
+
+func TestN8nUnmappableNodes(t *testing.T) {
+	_, err := ToN8n(&Workflow{Nodes: []Node{
+		{ID: "ok", Type: NodeHTTP},
+		{ID: "sw", Type: NodeSwitch},
+	}})
+	var unmappable *UnmappableNodesError
+	if !errors.As(err, &unmappable) || !reflect.DeepEqual(unmappable.Nodes, []string{"sw (switch)"}) {
+		t.Fatalf("export err = %v", err)
+	}
+
+	_, err = FromN8n(&n8nWorkflow{Nodes: []n8nNode{{Name: "Magic", Type: "n8n-nodes-base.magic"}}})
+	if !errors.As(err, &unmappable) || !reflect.DeepEqual(unmappable.Nodes, []string{"Magic (n8n-nodes-base.magic)"}) {
+		t.Fatalf("import err = %v", err)
+	}
+}
+
+func TestN8nExportImportEndpoints(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "api",
+		Nodes: []Node{
+			{ID: "hook", Type: NodeWebhook, Name: "Hook"},
+			{ID: "call", Type: NodeHTTP, Name: "Call", Properties: map[string]interface{}{"url": "https://example.com"}},
+		},
+		Connections: []Connection{{ID: "c", FromID: "hook", ToID: "call"}},
+	})
+
+	resp, body := doRequest(t, ts, "GET", "/api/workflows/"+wf.ID+"/export?format=n8n", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("export: %d %s", resp.StatusCode, body)
+	}
+	resp, body = doRequest(t, ts, "POST", "/api/workflows/import?format=n8n", string(body))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("import: %d %s", resp.StatusCode, body)
+	}
+	var imported Workflow
+	decode(t, body, &imported)
+	if imported.ID == wf.ID || len(imported.Nodes) != 2 || len(imported.Connections) != 1 {
+		t.Fatalf("imported = %+v", imported)
+	}
+	if imported.Nodes[1].Properties["url"] != "https://example.com" {
+		t.Fatalf("imported node properties = %v", imported.Nodes[1].Properties)
+	}
+
+	bad := mustCreate(t, s.engine, context.Background(), &Workflow{Name: "routing", Nodes: []Node{{ID: "sw", Type: NodeSwitch}}})
+	resp, body = doRequest(t, ts, "GET", "/api/workflows/"+bad.ID+"/export?format=n8n", nil)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("unmappable export: %d %s", resp.StatusCode, body)
+	}
+	if apiErr := apiError(t, body); apiErr.Code != CodeUnmappableNodes || apiErr.Details == nil {
+		t.Fatalf("error = %+v", apiErr)
+	}
+}
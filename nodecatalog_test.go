@@ -0,0 +1,63 @@
+// nodecatalog_test.go - Node type discovery endpoint tests
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNodeTypesListsEveryBuiltIn(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeShout: shout{}}})
+	resp, data := doRequest(t, ts, "GET", "/api/node-types", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("node types: %d %s", resp.StatusCode, data)
+	}
+	var types []NodeTypeInfo
+	decode(t, data, &types)
+	listed := make(map[NodeType]NodeTypeInfo, len(types))
+	for _, info := range types {
+		if _, dup := listed[info.Type]; dup {
+			t.Errorf("%s listed twice", info.Type)
+		}
+		listed[info.Type] = info
+	}
+
+	// Every executor the engine registers itself has catalog metadata
+	s.engine.executor.mu.RLock()
+	var builtIn []NodeType
+	for nodeType := range s.engine.executor.nodeExecutors {
+		if nodeType != nodeShout {
+			builtIn = append(builtIn, nodeType)
+		}
+	}
+	s.engine.executor.mu.RUnlock()
+	for _, nodeType := range builtIn {
+		info, ok := listed[nodeType]
+		switch {
+		case !ok:
+			t.Errorf("%s is not listed", nodeType)
+		case info.Category == "Custom" || info.Name == "" || info.Icon == "":
+			t.Errorf("%s has no catalog entry: %+v", nodeType, info)
+		}
+	}
+	for _, info := range nodeCatalog {
+		if _, ok := listed[info.Type]; !ok {
+			t.Errorf("catalog entry %s is not listed", info.Type)
+		}
+	}
+
+	// Property schemas come through for the editor
+	var url *PropertySpec
+	for i, p := range listed[NodeHTTP].Properties {
+		if p.Name == "url" {
+			url = &listed[NodeHTTP].Properties[i]
+		}
+	}
+	if url == nil || url.Label == "" || url.Type == "" {
+		t.Fatalf("http url property = %+v", url)
+	}
+
+	if custom := listed[nodeShout]; custom.Category != "Custom" || custom.Name != "shout" || custom.Properties == nil {
+		t.Fatalf("custom type = %+v", custom)
+	}
+}
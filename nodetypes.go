@@ -0,0 +1,129 @@
+// nodetypes.go - Node type metadata
+package main
+
+import "fmt"
+
+// ============================================
+// Port Types
+// ============================================
+
+// DataType describes the shape of data flowing through a node port.
+type DataType string
+
+const (
+	DataAny    DataType = "any"
+	DataObject DataType = "object"
+	DataArray  DataType = "array"
+	DataString DataType = "string"
+)
+
+// PortTypes declares what a node type accepts and produces.
+type PortTypes struct {
+	Input  DataType `json:"input"`
+	Output DataType `json:"output"`
+}
+
+var nodePortTypes = map[NodeType]PortTypes{
+	NodeWebhook:          {Input: DataAny, Output: DataObject},
+	NodeTimer:            {Input: DataAny, Output: DataObject},
+	NodeHTTP:             {Input: DataAny, Output: DataObject},
+	NodeEmail:            {Input: DataAny, Output: DataObject},
+	NodeDatabase:         {Input: DataAny, Output: DataArray},
+	NodeCondition:        {Input: DataAny, Output: DataObject},
+	NodeLoop:             {Input: DataAny, Output: DataArray},
+	NodeTransform:        {Input: DataAny, Output: DataAny},
+	NodeSlack:            {Input: DataAny, Output: DataObject},
+	NodeSheets:           {Input: DataAny, Output: DataArray},
+	NodeOpenAI:           {Input: DataAny, Output: DataObject},
+	NodeScheduleFollowUp: {Input: DataAny, Output: DataObject},
+	NodeKubernetes:       {Input: DataAny, Output: DataObject},
+	NodeFuzzy:            {Input: DataAny, Output: DataObject},
+	NodeApproval:         {Input: DataAny, Output: DataObject},
+	NodeBusinessDate:     {Input: DataAny, Output: DataObject},
+	NodePGNotify:         {Input: DataAny, Output: DataObject},
+	NodeRabbitMQTrigger:  {Input: DataAny, Output: DataObject},
+	NodeRabbitMQ:         {Input: DataAny, Output: DataObject},
+	NodeSubWorkflow:      {Input: DataAny, Output: DataObject},
+	NodeDelay:            {Input: DataAny, Output: DataAny},
+	NodeMerge:            {Input: DataAny, Output: DataAny},
+	NodeSwitch:           {Input: DataAny, Output: DataAny},
+	NodeFilter:           {Input: DataAny, Output: DataAny},
+	NodeSet:              {Input: DataAny, Output: DataObject},
+	NodeFileRead:         {Input: DataAny, Output: DataObject},
+	NodeFileWrite:        {Input: DataAny, Output: DataObject},
+	NodeCSVParse:         {Input: DataAny, Output: DataAny},
+	NodeCSVBuild:         {Input: DataAny, Output: DataString},
+	NodeConvert:          {Input: DataAny, Output: DataAny},
+	NodeExec:             {Input: DataAny, Output: DataObject},
+	NodePoll:             {Input: DataAny, Output: DataObject},
+	NodeKafkaTrigger:     {Input: DataAny, Output: DataObject},
+	NodeKafkaProduce:     {Input: DataAny, Output: DataObject},
+	NodeMQTTTrigger:      {Input: DataAny, Output: DataObject},
+	NodeMQTT:             {Input: DataAny, Output: DataObject},
+	NodeS3Upload:         {Input: DataAny, Output: DataObject},
+	NodeS3Download:       {Input: DataAny, Output: DataObject},
+	NodeRedis:            {Input: DataAny, Output: DataObject},
+	NodeGraphQL:          {Input: DataAny, Output: DataObject},
+	NodeGRPC:             {Input: DataAny, Output: DataObject},
+	NodeSMS:              {Input: DataAny, Output: DataObject},
+	NodeDiscord:          {Input: DataAny, Output: DataObject},
+	NodeNotion:           {Input: DataAny, Output: DataObject},
+	NodeGCalendar:        {Input: DataAny, Output: DataObject},
+	NodeJWT:              {Input: DataAny, Output: DataObject},
+	NodeCrypto:           {Input: DataAny, Output: DataObject},
+	NodeRegex:            {Input: DataAny, Output: DataObject},
+	NodeJSONQuery:        {Input: DataAny, Output: DataAny},
+	NodeHTMLExtract:      {Input: DataAny, Output: DataArray},
+	NodeSort:             {Input: DataAny, Output: DataArray},
+	NodeDedupe:           {Input: DataAny, Output: DataArray},
+	NodeDateTime:         {Input: DataAny, Output: DataObject},
+	NodeTextTemplate:     {Input: DataAny, Output: DataString},
+}
+
+// portTypesFor returns the declared port types, treating unknown node
+// types as accepting and producing anything.
+func portTypesFor(t NodeType) PortTypes {
+	if pt, ok := nodePortTypes[t]; ok {
+		return pt
+	}
+	return PortTypes{Input: DataAny, Output: DataAny}
+}
+
+func compatibleTypes(out, in DataType) bool {
+	return out == DataAny || in == DataAny || out == in
+}
+
+// ValidateConnections checks that every connection joins existing nodes
+// whose port types agree. Problems are returned as warnings rather than
+// errors so half-built workflows can still be saved.
+func ValidateConnections(w *Workflow) []string {
+	nodes := make(map[string]*Node, len(w.Nodes))
+	for i := range w.Nodes {
+		nodes[w.Nodes[i].ID] = &w.Nodes[i]
+	}
+
+	warnings := []string{}
+	for _, c := range w.Connections {
+		from, ok := nodes[c.FromID]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("connection %s: unknown source node %s", c.ID, c.FromID))
+			continue
+		}
+		to, ok := nodes[c.ToID]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("connection %s: unknown target node %s", c.ID, c.ToID))
+			continue
+		}
+
+		out := portTypesFor(from.Type).Output
+		if c.Port == PortError {
+			out = DataObject // error details
+		}
+		in := portTypesFor(to.Type).Input
+		if !compatibleTypes(out, in) {
+			warnings = append(warnings, fmt.Sprintf("connection %s: %s outputs %s but %s expects %s",
+				c.ID, from.ID, out, to.ID, in))
+		}
+	}
+	return warnings
+}
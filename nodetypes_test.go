@@ -0,0 +1,70 @@
+// nodetypes_test.go - Connection type checking tests
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// nodeArrayInput and nodeStringInput are node types that only accept
+// arrays or strings, as no built-in node type is that strict.
+const (
+	nodeArrayInput  NodeType = "array_input"
+	nodeStringInput NodeType = "string_input"
+)
+
+// withPortTypes declares port types for a test node type until t ends.
+func withPortTypes(t *testing.T, nodeType NodeType, pt PortTypes) {
+	nodePortTypes[nodeType] = pt
+	t.Cleanup(func() { delete(nodePortTypes, nodeType) })
+}
+
+func TestValidateConnections(t *testing.T) {
+	withPortTypes(t, nodeArrayInput, PortTypes{Input: DataArray, Output: DataArray})
+	withPortTypes(t, nodeStringInput, PortTypes{Input: DataString, Output: DataString})
+	tests := []struct {
+		name     string
+		from, to NodeType
+		port     string
+		warning  string
+	}{
+		{name: "array into loop", from: NodeDatabase, to: NodeLoop},
+		{name: "object into loop", from: NodeHTTP, to: NodeLoop},
+		{name: "error details into loop", from: NodeDatabase, to: NodeLoop, port: PortError},
+		{name: "array into array input", from: NodeDatabase, to: nodeArrayInput},
+		{name: "string into array input", from: NodeCSVBuild, to: nodeArrayInput, warning: "a outputs string but b expects array"},
+		{name: "error details into array input", from: NodeDatabase, to: nodeArrayInput, port: PortError, warning: "a outputs object but b expects array"},
+		{name: "array into string input", from: NodeDatabase, to: nodeStringInput, warning: "a outputs array but b expects string"},
+		// Parsed CSV is an array of rows, or an object with output "table"
+		{name: "parsed csv into string input", from: NodeCSVParse, to: nodeStringInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Workflow{
+				Nodes:       []Node{{ID: "a", Type: tt.from}, {ID: "b", Type: tt.to}},
+				Connections: []Connection{{ID: "c", FromID: "a", ToID: "b", Port: tt.port}},
+			}
+			warnings := ValidateConnections(w)
+			if tt.warning == "" {
+				if len(warnings) != 0 {
+					t.Fatalf("warnings = %v, want none", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], tt.warning) {
+				t.Fatalf("warnings = %v, want one containing %q", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestValidateConnectionsUnknownNode(t *testing.T) {
+	w := &Workflow{
+		Nodes:       []Node{{ID: "a", Type: NodeHTTP}},
+		Connections: []Connection{{ID: "c", FromID: "a", ToID: "missing"}},
+	}
+	warnings := ValidateConnections(w)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "unknown target node missing") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+}
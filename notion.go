@@ -0,0 +1,357 @@
+// notion.go - Notion database query and page creation node
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// notionAPIBase is the Notion API root.
+	notionAPIBase = "https://api.notion.com/v1"
+	// notionVersion is the API version requests are made against.
+	notionVersion = "2022-06-28"
+)
+
+// ============================================
+// Notion Node
+// ============================================
+
+// NotionExecutor queries a Notion database or adds a page to it, with an
+// integration token. Properties: token, databaseId, operation and:
+//   - query: filter and sorts (JSON, as the Notion API takes them) and
+//     maxPages (default 10), bounding how many result pages are fetched
+//   - create: properties, an object of property name to value (blank =
+//     the input object); string values are templates rendered against the
+//     input
+//
+// Page properties are simplified for the workflow: titles and text become
+// strings, selects their names, dates their start, and so on. Values given
+// to create are converted back using the database's property types;
+// objects are sent as they are, in Notion's own format.
+type NotionExecutor struct {
+	client  HTTPDoer
+	baseURL string
+}
+
+func (e *NotionExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	token, _ := node.Properties["token"].(string)
+	databaseID, _ := node.Properties["databaseId"].(string)
+	token, databaseID = strings.TrimSpace(token), strings.TrimSpace(databaseID)
+	if token == "" || databaseID == "" {
+		return nil, fmt.Errorf("token and databaseId are required")
+	}
+
+	switch operation, _ := node.Properties["operation"].(string); operation {
+	case "", "query":
+		return e.query(ctx, node, token, databaseID)
+	case "create":
+		return e.create(ctx, node, input, token, databaseID)
+	default:
+		return nil, fmt.Errorf("unsupported notion operation: %q", operation)
+	}
+}
+
+// query pages through the database's matching pages.
+func (e *NotionExecutor) query(ctx context.Context, node *Node, token, databaseID string) (interface{}, error) {
+	req := make(map[string]interface{})
+	if filter, err := objectProperty(node, "filter"); err != nil {
+		return nil, err
+	} else if filter != nil {
+		req["filter"] = filter
+	}
+	if sorts, ok := node.Properties["sorts"].(string); ok && strings.TrimSpace(sorts) != "" {
+		var parsed []interface{}
+		if err := json.Unmarshal([]byte(sorts), &parsed); err != nil {
+			return nil, fmt.Errorf("invalid sorts JSON: %v", err)
+		}
+		req["sorts"] = parsed
+	} else if sorts, ok := node.Properties["sorts"].([]interface{}); ok {
+		req["sorts"] = sorts
+	}
+	maxPages := defaultMaxPages
+	if v := node.Properties["maxPages"]; v != nil && v != "" {
+		n, err := toInt(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("maxPages must be a positive integer, got %v", v)
+		}
+		maxPages = n
+	}
+
+	results := []interface{}{}
+	pages := 0
+	hasMore := false
+	for pages < maxPages {
+		var resp struct {
+			Results    []notionPage `json:"results"`
+			HasMore    bool         `json:"has_more"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		path := "/databases/" + url.PathEscape(databaseID) + "/query"
+		if err := e.call(ctx, token, http.MethodPost, path, req, &resp); err != nil {
+			return nil, err
+		}
+		pages++
+		for _, p := range resp.Results {
+			results = append(results, p.simplify())
+		}
+		hasMore = resp.HasMore && resp.NextCursor != ""
+		if !hasMore {
+			break
+		}
+		req["start_cursor"] = resp.NextCursor
+	}
+
+	return map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+		"pages":   pages,
+		"hasMore": hasMore,
+	}, nil
+}
+
+// create adds a page to the database.
+func (e *NotionExecutor) create(ctx context.Context, node *Node, input interface{}, token, databaseID string) (interface{}, error) {
+	values, err := objectProperty(node, "properties")
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		m, ok := genericJSON(input).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("properties is required when the input is not an object")
+		}
+		values = m
+	} else if rendered, err := renderTemplates(values, input); err != nil {
+		return nil, fmt.Errorf("properties: %v", err)
+	} else {
+		values = rendered.(map[string]interface{})
+	}
+
+	var db struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := e.call(ctx, token, http.MethodGet, "/databases/"+url.PathEscape(databaseID), nil, &db); err != nil {
+		return nil, err
+	}
+	props := make(map[string]interface{}, len(values))
+	for name, v := range values {
+		schema, ok := db.Properties[name]
+		if !ok {
+			return nil, fmt.Errorf("database has no property %q", name)
+		}
+		if props[name], err = notionPropertyValue(schema.Type, v); err != nil {
+			return nil, fmt.Errorf("property %q: %v", name, err)
+		}
+	}
+
+	var page notionPage
+	req := map[string]interface{}{
+		"parent":     map[string]interface{}{"database_id": databaseID},
+		"properties": props,
+	}
+	if err := e.call(ctx, token, http.MethodPost, "/pages", req, &page); err != nil {
+		return nil, err
+	}
+	return page.simplify(), nil
+}
+
+// call sends one API request and decodes the response into out. Notion's
+// error responses become node errors carrying their code and message.
+func (e *NotionExecutor) call(ctx context.Context, token, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	base := e.baseURL
+	if base == "" {
+		base = notionAPIBase
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notion: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes+1))
+	if err != nil {
+		return fmt.Errorf("notion: %v", err)
+	}
+	if len(data) > maxHTTPResponseBytes {
+		return fmt.Errorf("notion: response exceeds %d bytes", maxHTTPResponseBytes)
+	}
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Code != "" {
+			return withStatus(resp.StatusCode, fmt.Errorf("notion %s: %s", apiErr.Code, apiErr.Message))
+		}
+		return withStatus(resp.StatusCode, fmt.Errorf("notion returned %s", resp.Status))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("notion: invalid response: %v", err)
+	}
+	return nil
+}
+
+// ============================================
+// Property Mapping
+// ============================================
+
+// notionPage is a page as the API returns it.
+type notionPage struct {
+	ID             string                            `json:"id"`
+	URL            string                            `json:"url"`
+	CreatedTime    string                            `json:"created_time"`
+	LastEditedTime string                            `json:"last_edited_time"`
+	Properties     map[string]map[string]interface{} `json:"properties"`
+}
+
+// simplify flattens the page for the workflow.
+func (p notionPage) simplify() map[string]interface{} {
+	props := make(map[string]interface{}, len(p.Properties))
+	for name, prop := range p.Properties {
+		props[name] = notionSimpleValue(prop)
+	}
+	return map[string]interface{}{
+		"id":             p.ID,
+		"url":            p.URL,
+		"createdTime":    p.CreatedTime,
+		"lastEditedTime": p.LastEditedTime,
+		"properties":     props,
+	}
+}
+
+// notionSimpleValue is a property's plain value: text for titles and rich
+// text, names for selects, the start of dates, IDs for relations and
+// people, and the value itself for everything else.
+func notionSimpleValue(prop map[string]interface{}) interface{} {
+	kind, _ := prop["type"].(string)
+	v := prop[kind]
+	switch kind {
+	case "title", "rich_text":
+		var sb strings.Builder
+		items, _ := v.([]interface{})
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				text, _ := m["plain_text"].(string)
+				sb.WriteString(text)
+			}
+		}
+		return sb.String()
+	case "select", "status":
+		if m, ok := v.(map[string]interface{}); ok {
+			return m["name"]
+		}
+		return nil
+	case "multi_select":
+		names := []interface{}{}
+		items, _ := v.([]interface{})
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				names = append(names, m["name"])
+			}
+		}
+		return names
+	case "date":
+		if m, ok := v.(map[string]interface{}); ok {
+			return m["start"]
+		}
+		return nil
+	case "relation", "people":
+		ids := []interface{}{}
+		items, _ := v.([]interface{})
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				ids = append(ids, m["id"])
+			}
+		}
+		return ids
+	case "formula":
+		if m, ok := v.(map[string]interface{}); ok {
+			t, _ := m["type"].(string)
+			return m[t]
+		}
+		return nil
+	}
+	return v
+}
+
+// notionPropertyValue converts a plain value to the API's format for a
+// property of type kind. Objects are taken to be in that format already.
+func notionPropertyValue(kind string, v interface{}) (interface{}, error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, nil
+	}
+	text := func(s string) []interface{} {
+		return []interface{}{map[string]interface{}{"text": map[string]interface{}{"content": s}}}
+	}
+	switch kind {
+	case "title", "rich_text":
+		return map[string]interface{}{kind: text(exprString(v))}, nil
+	case "number":
+		if _, ok := v.(float64); !ok && v != nil {
+			return nil, fmt.Errorf("expected a number, got %v", v)
+		}
+		return map[string]interface{}{kind: v}, nil
+	case "checkbox":
+		b, ok := v.(bool)
+		if !ok {
+			b = exprString(v) == "true"
+		}
+		return map[string]interface{}{kind: b}, nil
+	case "select", "status":
+		return map[string]interface{}{kind: map[string]interface{}{"name": exprString(v)}}, nil
+	case "multi_select":
+		var options []interface{}
+		items, ok := v.([]interface{})
+		if !ok {
+			items = nil
+			for _, s := range strings.Split(exprString(v), ",") {
+				items = append(items, strings.TrimSpace(s))
+			}
+		}
+		for _, item := range items {
+			options = append(options, map[string]interface{}{"name": exprString(item)})
+		}
+		return map[string]interface{}{kind: options}, nil
+	case "date":
+		return map[string]interface{}{kind: map[string]interface{}{"start": exprString(v)}}, nil
+	case "url", "email", "phone_number":
+		return map[string]interface{}{kind: exprString(v)}, nil
+	case "relation", "people":
+		var refs []interface{}
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		for _, item := range items {
+			refs = append(refs, map[string]interface{}{"id": exprString(item)})
+		}
+		return map[string]interface{}{kind: refs}, nil
+	}
+	return nil, fmt.Errorf("cannot set %s properties from a plain value", kind)
+}
@@ -0,0 +1,242 @@
+// notion_test.go - Notion node tests against a fake API
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// notionCall is one request the fake Notion API received.
+type notionCall struct {
+	method, path string
+	body         map[string]interface{}
+	header       http.Header
+}
+
+// fakeNotion is an HTTPDoer serving the Notion API from fixed responses:
+// query pages keyed by start cursor, a database schema and page creation,
+// which echoes the properties sent back in a new page.
+type fakeNotion struct {
+	pages  map[string]string
+	schema string
+	calls  []notionCall
+}
+
+func (f *fakeNotion) Do(req *http.Request) (*http.Response, error) {
+	call := notionCall{method: req.Method, path: req.URL.Path, header: req.Header}
+	if req.Body != nil {
+		json.NewDecoder(req.Body).Decode(&call.body)
+	}
+	f.calls = append(f.calls, call)
+
+	status, body := http.StatusOK, ""
+	switch {
+	case strings.HasSuffix(call.path, "/query"):
+		cursor, _ := call.body["start_cursor"].(string)
+		body = f.pages[cursor]
+	case call.method == http.MethodGet && strings.HasPrefix(call.path, "/v1/databases/"):
+		body = f.schema
+	case call.path == "/v1/pages":
+		data, _ := json.Marshal(notionEcho(call.body["properties"].(map[string]interface{})))
+		body = fmt.Sprintf(`{"id": "new-page", "url": "https://notion.so/new-page", "properties": %s}`, data)
+	}
+	if body == "" {
+		status, body = http.StatusNotFound, `{"object": "error", "code": "object_not_found", "message": "Could not find database."}`
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+// notionEcho turns the properties of a create request into a page's
+// properties, as Notion fills in each one's type and text's plain_text.
+func notionEcho(props map[string]interface{}) map[string]interface{} {
+	page := make(map[string]interface{}, len(props))
+	for name, v := range props {
+		for kind, value := range v.(map[string]interface{}) {
+			if items, ok := value.([]interface{}); ok && (kind == "title" || kind == "rich_text") {
+				var texts []interface{}
+				for _, item := range items {
+					content := item.(map[string]interface{})["text"].(map[string]interface{})["content"]
+					texts = append(texts, map[string]interface{}{"plain_text": content})
+				}
+				value = texts
+			}
+			page[name] = map[string]interface{}{"type": kind, kind: value}
+		}
+	}
+	return page
+}
+
+// notionTask is a page of a task database, as the API returns it.
+func notionTask(id, title, status string) string {
+	return fmt.Sprintf(`{"id": %q, "url": "https://notion.so/%s", "properties": {
+		"Name": {"type": "title", "title": [{"plain_text": %q}]},
+		"Status": {"type": "select", "select": {"name": %q}},
+		"Tags": {"type": "multi_select", "multi_select": [{"name": "ops"}, {"name": "urgent"}]},
+		"Due": {"type": "date", "date": {"start": "2024-03-01"}},
+		"Points": {"type": "number", "number": 3}
+	}}`, id, id, title, status)
+}
+
+func twoPagesOfTasks() *fakeNotion {
+	return &fakeNotion{pages: map[string]string{
+		"":   `{"results": [` + notionTask("t1", "Rotate keys", "Todo") + `,` + notionTask("t2", "Patch hosts", "Doing") + `], "has_more": true, "next_cursor": "c2"}`,
+		"c2": `{"results": [` + notionTask("t3", "Renew certs", "Todo") + `], "has_more": false, "next_cursor": null}`,
+	}}
+}
+
+func TestNotionQueryFollowsPages(t *testing.T) {
+	api := twoPagesOfTasks()
+	we := newTestEngine(t, WithNodeExecutor(NodeNotion, &NotionExecutor{client: api}))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "open tasks",
+		Nodes: []Node{{ID: "tasks", Type: NodeNotion, Properties: map[string]interface{}{
+			"token":      "secret_abc",
+			"databaseId": "db1",
+			"operation":  "query",
+			"filter":     `{"property": "Status", "select": {"does_not_equal": "Done"}}`,
+			"sorts":      `[{"property": "Due", "direction": "ascending"}]`,
+		}}},
+	})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := result.Results["tasks"].(map[string]interface{})
+	if out["count"] != 3 || out["pages"] != 2 || out["hasMore"] != false {
+		t.Fatalf("count %v, pages %v, hasMore %v", out["count"], out["pages"], out["hasMore"])
+	}
+	first := out["results"].([]interface{})[0].(map[string]interface{})
+	want := map[string]interface{}{
+		"Name":   "Rotate keys",
+		"Status": "Todo",
+		"Tags":   []interface{}{"ops", "urgent"},
+		"Due":    "2024-03-01",
+		"Points": 3.0,
+	}
+	if first["id"] != "t1" || !reflect.DeepEqual(first["properties"], want) {
+		t.Fatalf("first result = %v", first)
+	}
+
+	if len(api.calls) != 2 {
+		t.Fatalf("%d requests, want 2", len(api.calls))
+	}
+	for i, call := range api.calls {
+		if call.method != http.MethodPost || call.path != "/v1/databases/db1/query" {
+			t.Fatalf("request %d: %s %s", i, call.method, call.path)
+		}
+		if call.header.Get("Authorization") != "Bearer secret_abc" || call.header.Get("Notion-Version") != notionVersion {
+			t.Fatalf("request %d headers = %v", i, call.header)
+		}
+		if call.body["filter"] == nil || call.body["sorts"] == nil {
+			t.Fatalf("request %d lost the filter or sorts: %v", i, call.body)
+		}
+	}
+	if _, ok := api.calls[0].body["start_cursor"]; ok || api.calls[1].body["start_cursor"] != "c2" {
+		t.Fatalf("cursors = %v, %v", api.calls[0].body["start_cursor"], api.calls[1].body["start_cursor"])
+	}
+}
+
+func TestNotionQueryStopsAtMaxPages(t *testing.T) {
+	api := twoPagesOfTasks()
+	e := &NotionExecutor{client: api}
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"token": "secret_abc", "databaseId": "db1", "maxPages": 1.0,
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.(map[string]interface{})
+	if got["count"] != 2 || got["pages"] != 1 || got["hasMore"] != true || len(api.calls) != 1 {
+		t.Fatalf("output %v after %d requests", got, len(api.calls))
+	}
+}
+
+func TestNotionCreatePage(t *testing.T) {
+	api := &fakeNotion{schema: `{"properties": {
+		"Name": {"type": "title"}, "Status": {"type": "select"}, "Tags": {"type": "multi_select"},
+		"Points": {"type": "number"}, "Done": {"type": "checkbox"}, "Due": {"type": "date"}
+	}}`}
+	e := &NotionExecutor{client: api, baseURL: "https://notion.test/v1"}
+	out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"token":      "secret_abc",
+		"databaseId": "db1",
+		"operation":  "create",
+		"properties": map[string]interface{}{
+			"Name":   "Investigate {{ alert }}",
+			"Status": "Todo",
+			"Tags":   "ops, urgent",
+			"Points": 5.0,
+			"Done":   false,
+			"Due":    "{{ due }}",
+		},
+	}}, map[string]interface{}{"alert": "disk full", "due": "2024-03-02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.calls) != 2 || api.calls[0].path != "/v1/databases/db1" || api.calls[1].path != "/v1/pages" {
+		t.Fatalf("requests = %+v", api.calls)
+	}
+	sent := api.calls[1].body
+	if sent["parent"].(map[string]interface{})["database_id"] != "db1" {
+		t.Fatalf("parent = %v", sent["parent"])
+	}
+	props := sent["properties"].(map[string]interface{})
+	if want := map[string]interface{}{"select": map[string]interface{}{"name": "Todo"}}; !reflect.DeepEqual(props["Status"], want) {
+		t.Fatalf("Status = %v, want %v", props["Status"], want)
+	}
+	if want := map[string]interface{}{"checkbox": false}; !reflect.DeepEqual(props["Done"], want) {
+		t.Fatalf("Done = %v, want %v", props["Done"], want)
+	}
+
+	page := out.(map[string]interface{})
+	if page["id"] != "new-page" || page["url"] != "https://notion.so/new-page" {
+		t.Fatalf("output = %v", page)
+	}
+	want := map[string]interface{}{
+		"Name": "Investigate disk full", "Status": "Todo", "Tags": []interface{}{"ops", "urgent"},
+		"Points": 5.0, "Done": false, "Due": "2024-03-02",
+	}
+	if !reflect.DeepEqual(page["properties"], want) {
+		t.Fatalf("properties = %v, want %v", page["properties"], want)
+	}
+}
+
+func TestNotionErrors(t *testing.T) {
+	api := &fakeNotion{schema: `{"properties": {"Name": {"type": "title"}, "Files": {"type": "files"}}}`}
+	e := &NotionExecutor{client: api}
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		input interface{}
+		want  string
+	}{
+		{"missing database", map[string]interface{}{"databaseId": "gone"}, nil, "notion object_not_found: Could not find database."},
+		{"unknown property", map[string]interface{}{"operation": "create"}, map[string]interface{}{"Owner": "ada"}, `no property "Owner"`},
+		{"unsupported type", map[string]interface{}{"operation": "create"}, map[string]interface{}{"Files": "a.pdf"}, "cannot set files properties"},
+		{"input not an object", map[string]interface{}{"operation": "create"}, "text", "properties is required"},
+		{"bad maxPages", map[string]interface{}{"maxPages": 0.0}, nil, "maxPages must be a positive integer"},
+		{"bad operation", map[string]interface{}{"operation": "archive"}, nil, "unsupported notion operation"},
+		{"no token", map[string]interface{}{"token": ""}, nil, "token and databaseId are required"},
+	}
+	for _, tt := range tests {
+		props := map[string]interface{}{"token": "secret_abc", "databaseId": "db1"}
+		for k, v := range tt.props {
+			props[k] = v
+		}
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, tt.input); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
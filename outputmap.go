@@ -0,0 +1,118 @@
+// outputmap.go - Inline per-node output reshaping
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ============================================
+// Output Map
+// ============================================
+
+// applyOutputMap reshapes a node's output according to its outputMap
+// property, an object of {"newField": "path"} pairs given inline or as a
+// JSON string. Paths use dot/bracket notation with an optional "$." prefix
+// ("$.user.name", "items[0].id"); missing paths map to null. Nodes without
+// an outputMap keep their output unchanged.
+func applyOutputMap(node *Node, output interface{}) (interface{}, error) {
+	mapping, err := objectProperty(node, "outputMap")
+	if err != nil || mapping == nil {
+		return output, err
+	}
+
+	output = genericJSON(output)
+	shaped := make(map[string]interface{}, len(mapping))
+	for field, path := range mapping {
+		p, ok := path.(string)
+		if !ok {
+			return nil, fmt.Errorf("outputMap field %q: path must be a string", field)
+		}
+		value, _ := lookupPath(output, p)
+		shaped[field] = value
+	}
+	return shaped, nil
+}
+
+// objectProperty reads an object-valued property given inline or, from the
+// UI's text areas, as a JSON string. Blank or empty objects read as nil.
+func objectProperty(node *Node, key string) (map[string]interface{}, error) {
+	switch m := node.Properties[key].(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		if len(m) == 0 {
+			return nil, nil
+		}
+		return m, nil
+	case string:
+		if strings.TrimSpace(m) == "" {
+			return nil, nil
+		}
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(m), &parsed); err != nil {
+			return nil, fmt.Errorf("invalid %s JSON: %v", key, err)
+		}
+		return parsed, nil
+	}
+	return nil, fmt.Errorf("%s must be an object", key)
+}
+
+// genericJSON converts typed values (structs, typed maps) to the plain
+// map/slice form that lookupPath walks.
+func genericJSON(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, string, float64, bool:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// lookupPath walks value along a dot/bracket path and reports whether the
+// path resolved. An empty path (or "$") returns value itself.
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+
+	for _, seg := range splitPath(path) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[seg]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// splitPath turns "a.b[0].c" into ["a", "b", "0", "c"].
+func splitPath(path string) []string {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	var segs []string
+	for _, seg := range strings.Split(path, ".") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	return segs
+}
@@ -0,0 +1,77 @@
+// outputmap_test.go - Inline output map tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestOutputMapReshapesNodeOutput(t *testing.T) {
+	rec := &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "reshape",
+		Nodes: []Node{
+			{ID: "src", Type: nodeRecord, Properties: map[string]interface{}{
+				"outputMap": map[string]interface{}{
+					"name":    "$.user.name",
+					"firstId": "items[0].id",
+					"missing": "user.email",
+				},
+			}},
+			{ID: "dst", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "src", ToID: "dst"}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{
+		"user":  map[string]interface{}{"name": "Ada"},
+		"items": []interface{}{map[string]interface{}{"id": 7.0}, map[string]interface{}{"id": 8.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"name": "Ada", "firstId": 7.0, "missing": nil}
+	if got := result.Results["src"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("stored output = %v, want %v", got, want)
+	}
+	if calls := rec.calls(); len(calls) != 2 || !reflect.DeepEqual(calls[1], want) {
+		t.Fatalf("downstream input = %v, want %v", calls, want)
+	}
+}
+
+func TestApplyOutputMap(t *testing.T) {
+	output := map[string]interface{}{"status": "ok", "data": map[string]interface{}{"rows": []interface{}{"a", "b"}}}
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		want  interface{}
+	}{
+		{"no map", nil, output},
+		{"empty map", map[string]interface{}{"outputMap": map[string]interface{}{}}, output},
+		{"blank string", map[string]interface{}{"outputMap": "  "}, output},
+		{"JSON string", map[string]interface{}{"outputMap": `{"second": "data.rows[1]"}`}, map[string]interface{}{"second": "b"}},
+	}
+	for _, tt := range tests {
+		got, err := applyOutputMap(&Node{Properties: tt.props}, output)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for name, m := range map[string]interface{}{
+		"bad JSON":      "{nope",
+		"non-string":    map[string]interface{}{"x": 1.0},
+		"not an object": []interface{}{"x"},
+	} {
+		if _, err := applyOutputMap(&Node{Properties: map[string]interface{}{"outputMap": m}}, output); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// pgnotify.go - Postgres LISTEN/NOTIFY trigger
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ============================================
+// Postgres Notify Trigger
+// ============================================
+
+// listenPGNotify holds a LISTEN connection on the node's channel and fires
+// once per NOTIFY. Properties: connection (DSN), channel.
+func listenPGNotify(ctx context.Context, node *Node, fire func(input interface{}) error) error {
+	dsn, channel, err := pgNotifyTarget(node)
+	if err != nil {
+		return err
+	}
+
+	logger := loggerFromContext(ctx)
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Warn("pgnotify connection event", "channel", channel, "event", ev, "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return fmt.Errorf("listen %s: %v", channel, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// pq sends nil after a reconnect; notifications sent while
+			// disconnected are lost
+			if n == nil || ctx.Err() != nil {
+				continue
+			}
+			fire(notificationInput(n))
+		}
+	}
+}
+
+// notificationInput is the trigger output for a NOTIFY. JSON payloads are
+// decoded; anything else is passed through as a string.
+func notificationInput(n *pq.Notification) map[string]interface{} {
+	var payload interface{} = n.Extra
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(n.Extra), &decoded); err == nil {
+		payload = decoded
+	}
+	return map[string]interface{}{
+		"channel": n.Channel,
+		"payload": payload,
+		"pid":     n.BePid,
+	}
+}
+
+func pgNotifyTarget(node *Node) (dsn, channel string, err error) {
+	dsn, _ = node.Properties["connection"].(string)
+	channel, _ = node.Properties["channel"].(string)
+	dsn, channel = strings.TrimSpace(dsn), strings.TrimSpace(channel)
+	if dsn == "" {
+		return "", "", fmt.Errorf("connection is required")
+	}
+	if channel == "" {
+		return "", "", fmt.Errorf("channel is required")
+	}
+	return dsn, channel, nil
+}
+
+// ============================================
+// Postgres Notify Node
+// ============================================
+
+// PGNotifyExecutor is the trigger node itself; the notification arrives as
+// the execution input and is passed through.
+type PGNotifyExecutor struct{}
+
+func (e *PGNotifyExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	channel, _ := node.Properties["channel"].(string)
+	return map[string]interface{}{
+		"status":       "notification_received",
+		"channel":      channel,
+		"notification": input,
+	}, nil
+}
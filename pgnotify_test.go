@@ -0,0 +1,245 @@
+// pgnotify_test.go - Postgres LISTEN/NOTIFY trigger tests
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePostgres speaks just enough of the Postgres wire protocol for a pq
+// listener: it trusts any startup, answers every query as complete, and
+// reports each LISTEN channel on listens. notify sends a NOTIFY to the
+// most recent connection.
+type fakePostgres struct {
+	ln      net.Listener
+	listens chan string
+	conns   chan net.Conn
+}
+
+func newFakePostgres(t *testing.T) *fakePostgres {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg := &fakePostgres{ln: ln, listens: make(chan string, 8), conns: make(chan net.Conn, 8)}
+	t.Cleanup(func() { ln.Close() })
+	go pg.serve()
+	return pg
+}
+
+func (pg *fakePostgres) dsn() string {
+	return "postgres://test@" + pg.ln.Addr().String() + "/test?sslmode=disable"
+}
+
+func (pg *fakePostgres) serve() {
+	for {
+		conn, err := pg.ln.Accept()
+		if err != nil {
+			return
+		}
+		go pg.handle(conn)
+	}
+}
+
+func (pg *fakePostgres) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// Startup message: length, protocol version, parameters
+	var size int32
+	if binary.Read(r, binary.BigEndian, &size) != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(size-4)); err != nil {
+		return
+	}
+	writePGMessage(conn, 'R', []byte{0, 0, 0, 0}) // AuthenticationOk
+	writePGMessage(conn, 'Z', []byte{'I'})
+	pg.conns <- conn
+
+	for {
+		kind, err := r.ReadByte()
+		if err != nil || kind == 'X' {
+			return
+		}
+		if binary.Read(r, binary.BigEndian, &size) != nil {
+			return
+		}
+		body := make([]byte, size-4)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+		if kind != 'Q' {
+			continue
+		}
+		query := strings.TrimRight(string(body), "\x00")
+		tag := strings.ToUpper(strings.Fields(query + " X")[0])
+		writePGMessage(conn, 'C', append([]byte(tag), 0))
+		writePGMessage(conn, 'Z', []byte{'I'})
+		if tag == "LISTEN" {
+			pg.listens <- strings.Trim(strings.Fields(query)[1], `"`)
+		}
+	}
+}
+
+// notify sends a NotificationResponse on conn.
+func (pg *fakePostgres) notify(conn net.Conn, channel, payload string) {
+	body := binary.BigEndian.AppendUint32(nil, 4242)
+	body = append(append(body, channel...), 0)
+	body = append(append(body, payload...), 0)
+	writePGMessage(conn, 'A', body)
+}
+
+func writePGMessage(w io.Writer, kind byte, body []byte) {
+	msg := append([]byte{kind}, binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	w.Write(append(msg, body...))
+}
+
+func TestPGNotifyTriggersWorkflow(t *testing.T) {
+	pg := newFakePostgres(t)
+	rec := &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "on notify",
+		Nodes: []Node{
+			{ID: "pg", Type: NodePGNotify, Properties: map[string]interface{}{"connection": pg.dsn(), "channel": "orders"}},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "pg", ToID: "r"}},
+	})
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var conn net.Conn
+	select {
+	case conn = <-pg.conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener never connected")
+	}
+	select {
+	case channel := <-pg.listens:
+		if channel != "orders" {
+			t.Fatalf("LISTEN %q, want orders", channel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener never issued LISTEN")
+	}
+
+	// pq drops notifications that race with its LISTEN handshake, so
+	// repeat the first one until a run picks it up
+	deadline := time.After(5 * time.Second)
+	for len(rec.calls()) == 0 {
+		pg.notify(conn, "orders", `{"id": 7}`)
+		select {
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no notification triggered a run")
+		}
+	}
+	out := rec.calls()[0].(map[string]interface{})
+	want := map[string]interface{}{"channel": "orders", "payload": map[string]interface{}{"id": 7.0}, "pid": 4242}
+	if got := out["notification"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("notification = %v, want %v", got, want)
+	}
+
+	pg.notify(conn, "orders", "plain text")
+	eventually(t, "plain text run", func() bool {
+		calls := rec.calls()
+		last := calls[len(calls)-1].(map[string]interface{})["notification"].(map[string]interface{})
+		return last["payload"] == "plain text"
+	})
+}
+
+func TestPGNotifyConnectionFromSecret(t *testing.T) {
+	pg := newFakePostgres(t)
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, &recorder{}))
+	alice := asPrincipal("alice")
+	if err := we.secrets.Set("alice", "PG_DSN", pg.dsn()); err != nil {
+		t.Fatal(err)
+	}
+	listen := func(name string) *Workflow {
+		wf := mustCreate(t, we, alice, &Workflow{Name: "on notify", Nodes: []Node{
+			{ID: "pg", Type: NodePGNotify, Properties: map[string]interface{}{"connection": "{{secrets." + name + "}}", "channel": "orders"}},
+		}})
+		if _, err := we.ActivateWorkflow(alice, wf.ID); err != nil {
+			t.Fatal(err)
+		}
+		return wf
+	}
+
+	// An unknown secret keeps the listener from starting at all
+	listen("MISSING")
+	select {
+	case <-pg.conns:
+		t.Fatal("listener with an unresolved secret connected")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	listen("PG_DSN")
+	select {
+	case <-pg.conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener never connected with the DSN from the secret")
+	}
+}
+
+// TestPGNotifyRealPostgres runs against the server in PGNOTIFY_TEST_DSN,
+// when set.
+func TestPGNotifyRealPostgres(t *testing.T) {
+	dsn := os.Getenv("PGNOTIFY_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PGNOTIFY_TEST_DSN not set")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fired := make(chan interface{}, 1)
+	go listenPGNotify(ctx, &Node{Properties: map[string]interface{}{"connection": dsn, "channel": "goflow_test"}}, func(input interface{}) error {
+		fired <- input
+		return nil
+	})
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	deadline := time.After(10 * time.Second)
+	for {
+		if _, err := db.Exec(`NOTIFY goflow_test, '{"ok": true}'`); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case input := <-fired:
+			if got := input.(map[string]interface{})["payload"]; !reflect.DeepEqual(got, map[string]interface{}{"ok": true}) {
+				t.Fatalf("payload = %v", got)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no notification received")
+		}
+	}
+}
+
+func TestPGNotifyTargetRequiresConnectionAndChannel(t *testing.T) {
+	for _, props := range []map[string]interface{}{
+		{"channel": "orders"},
+		{"connection": "postgres://x", "channel": "  "},
+	} {
+		if _, _, err := pgNotifyTarget(&Node{Properties: props}); err == nil {
+			t.Errorf("pgNotifyTarget(%v): expected an error", props)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// poll.go - Polling trigger for sources without webhooks
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPollInterval is how often a poll node fetches unless set.
+	defaultPollInterval = time.Minute
+	// minPollInterval keeps a poll node from hammering its endpoint.
+	minPollInterval = time.Second
+	// maxPollSeen bounds how many item keys a poll cursor remembers.
+	maxPollSeen = 10000
+)
+
+// ============================================
+// Poll Cursors
+// ============================================
+
+// pollCursor remembers which items a poll node has already seen. It is
+// tied to the node's url and dedupe key; changing either starts afresh.
+type pollCursor struct {
+	mu     sync.Mutex
+	id     string
+	source string
+	seen   map[string]bool
+	order  []string
+}
+
+// advance records items and returns those not seen before, in order. The
+// first poll of a fresh cursor only records what is already there.
+// changed reports whether any item was recorded.
+func (c *pollCursor) advance(items []interface{}, key string) (fresh []interface{}, changed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	primed := c.seen != nil
+	if !primed {
+		c.seen = make(map[string]bool)
+	}
+	changed = !primed
+	for _, item := range items {
+		k := pollItemKey(item, key)
+		if c.seen[k] {
+			continue
+		}
+		changed = true
+		c.seen[k] = true
+		c.order = append(c.order, k)
+		if primed {
+			fresh = append(fresh, item)
+		}
+	}
+	if over := len(c.order) - maxPollSeen; over > 0 {
+		for _, k := range c.order[:over] {
+			delete(c.seen, k)
+		}
+		c.order = append([]string(nil), c.order[over:]...)
+	}
+	return fresh, changed
+}
+
+// state returns the cursor as saved in the store.
+func (c *pollCursor) state() *PollCursor {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &PollCursor{ID: c.id, Source: c.source, Seen: append([]string(nil), c.order...)}
+}
+
+// pollItemKey identifies an item by its key field, or by its whole JSON
+// encoding when key is empty or missing from the item.
+func pollItemKey(item interface{}, key string) string {
+	if key != "" {
+		if v, ok := lookupPath(item, key); ok && v != nil {
+			return exprString(v)
+		}
+	}
+	data, _ := json.Marshal(item)
+	return string(data)
+}
+
+// cursor returns the poll cursor of a workflow's node for source,
+// picking up the one saved in the store after a restart and resetting it
+// if the node now polls something else.
+func (s *Scheduler) cursor(workflowID, nodeID, source string) *pollCursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := workflowID + "/" + nodeID
+	c, ok := s.cursors[id]
+	if ok && c.source == source {
+		return c
+	}
+	c = &pollCursor{id: id, source: source}
+	if !ok {
+		saved, err := s.engine.store.PollCursor(id)
+		if err != nil {
+			s.engine.logger.Warn("loading poll cursor failed", "cursor", id, "error", err)
+		}
+		if saved != nil && saved.Source == source {
+			c.seen = make(map[string]bool, len(saved.Seen))
+			for _, k := range saved.Seen {
+				c.seen[k] = true
+			}
+			c.order = saved.Seen
+		}
+	}
+	s.cursors[id] = c
+	return c
+}
+
+// ForgetCursors drops the poll cursors of a purged workflow.
+func (s *Scheduler) ForgetCursors(workflowID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.cursors {
+		if strings.HasPrefix(id, workflowID+"/") {
+			delete(s.cursors, id)
+		}
+	}
+	if err := s.engine.store.DeletePollCursors(workflowID); err != nil {
+		s.engine.logger.Warn("deleting poll cursors failed", "workflow_id", workflowID, "error", err)
+	}
+}
+
+// ============================================
+// Polling
+// ============================================
+
+// poll fetches a poll node's endpoint every interval until ctx is
+// cancelled, running the workflow once for each new item. Properties: url,
+// headers, interval (duration or seconds), items (path to the array in the
+// response body; empty for the body itself) and dedupeKey (item field that
+// identifies it; empty compares whole items).
+func (s *Scheduler) poll(ctx context.Context, w *Workflow, node Node, interval time.Duration) {
+	logger := s.engine.logger.With("workflow_id", w.ID, "node_id", node.ID, "node_type", node.Type)
+	url, _ := node.Properties["url"].(string)
+	key, _ := node.Properties["dedupeKey"].(string)
+	cursor := s.cursor(w.ID, node.ID, url+"|"+key)
+
+	for {
+		items, err := s.engine.pollItems(ctx, w, &node)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("poll failed", "error", err)
+			}
+			items = nil
+		} else {
+			var changed bool
+			items, changed = cursor.advance(items, key)
+			if changed {
+				if err := s.engine.store.SavePollCursor(cursor.state()); err != nil {
+					logger.Warn("saving poll cursor failed", "error", err)
+				}
+			}
+		}
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return
+			}
+			_, err := s.engine.ExecuteWorkflowWithInput(context.Background(), w.ID, item)
+			if err != nil {
+				logger.Error("polled execution failed", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(interval):
+		}
+	}
+}
+
+// pollInterval reads a poll node's interval.
+func pollInterval(node *Node) (time.Duration, error) {
+	v := node.Properties["interval"]
+	if v == nil || v == "" {
+		return defaultPollInterval, nil
+	}
+	d, err := parseDelay(v)
+	if err != nil {
+		return 0, err
+	}
+	if d < minPollInterval {
+		return 0, fmt.Errorf("interval must be at least %s", minPollInterval)
+	}
+	return d, nil
+}
+
+// pollItems fetches a poll node's endpoint through the HTTP node, with the
+// workflow owner's secrets, and returns the items in the response. The
+// fetch is held to the workflow's outbound rate limit and circuit breaker
+// like an HTTP node in a run.
+func (we *WorkflowEngine) pollItems(ctx context.Context, w *Workflow, node *Node) ([]interface{}, error) {
+	resolved, err := resolveSecrets(node, we.secrets.Snapshot(w.OwnerID))
+	if err != nil {
+		return nil, err
+	}
+	exec, ok := we.executor.executorFor(NodeHTTP)
+	if !ok {
+		return nil, fmt.Errorf("no executor for node type: %s", NodeHTTP)
+	}
+	request := &Node{ID: node.ID, Type: NodeHTTP, Properties: map[string]interface{}{
+		"url":          resolved.Properties["url"],
+		"method":       "GET",
+		"headers":      resolved.Properties["headers"],
+		"failOnStatus": true,
+	}}
+
+	if w.OwnerID != "" {
+		ctx = context.WithValue(ctx, principalKey, w.OwnerID)
+	}
+	if limiter := we.limits.For(w); limiter != nil {
+		ctx = context.WithValue(ctx, outboundLimiterKey, limiter)
+	}
+	if err := we.executor.typeLimits.Wait(ctx, NodeHTTP); err != nil {
+		return nil, err
+	}
+	if err := waitOutbound(ctx, NodeHTTP); err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = we.executor.breakers.call(ctx, breakerTarget(ctx, request), func() (err error) {
+		out, err = exec.Execute(ctx, request, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, _ := lookupPath(genericJSON(out), "body")
+	if path, _ := node.Properties["items"].(string); strings.TrimSpace(path) != "" {
+		body, _ = lookupPath(body, strings.TrimSpace(path))
+	}
+	items, ok := body.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("response has no item array")
+	}
+	return items, nil
+}
+
+// ============================================
+// Poll Node
+// ============================================
+
+// PollExecutor is the entry point of a polled run; the new item arrives as
+// input.
+type PollExecutor struct{}
+
+func (e *PollExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	url, _ := node.Properties["url"].(string)
+	return map[string]interface{}{
+		"status": "item_received",
+		"url":    url,
+		"item":   input,
+	}, nil
+}
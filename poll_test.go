@@ -0,0 +1,167 @@
+// poll_test.go - Polling trigger tests
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// feed is a fake endpoint serving {"data": items} with the items set
+// last, counting polls.
+type feed struct {
+	mu    sync.Mutex
+	ids   []int
+	polls int
+}
+
+func (f *feed) set(ids ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = ids
+}
+
+func (f *feed) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.polls
+}
+
+func (f *feed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	items := make([]map[string]interface{}, len(f.ids))
+	for i, id := range f.ids {
+		items[i] = map[string]interface{}{"id": id, "title": "item"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": items})
+}
+
+// polledIDs lists the ids of the items that triggered runs so far.
+func polledIDs(rec *recorder) []float64 {
+	var ids []float64
+	for _, call := range rec.calls() {
+		item := call.(map[string]interface{})["item"].(map[string]interface{})
+		ids = append(ids, item["id"].(float64))
+	}
+	return ids
+}
+
+func TestPollTriggersOnlyNewItems(t *testing.T) {
+	f := &feed{}
+	f.set(1, 2)
+	api := httptest.NewServer(f)
+	defer api.Close()
+
+	store := NewMemoryStore()
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	rec := &recorder{}
+	we := newTestEngine(t, WithStore(store), WithClock(clock), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "polled",
+		Nodes: []Node{
+			{ID: "poll", Type: NodePoll, Properties: map[string]interface{}{
+				"url": api.URL, "interval": "10s", "items": "data", "dedupeKey": "id",
+			}},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "poll", ToID: "r"}},
+	})
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first poll only records what is already there
+	awaitWaiters(t, clock, 1)
+	if f.count() != 1 || len(rec.calls()) != 0 {
+		t.Fatalf("after the first poll: %d polls, %d runs", f.count(), len(rec.calls()))
+	}
+
+	steps := []struct {
+		ids  []int
+		want []float64
+	}{
+		{[]int{1, 2, 3}, []float64{3}},
+		{[]int{1, 2, 3}, []float64{3}},
+		{[]int{2, 3, 4, 5}, []float64{3, 4, 5}},
+	}
+	for i, step := range steps {
+		f.set(step.ids...)
+		clock.Advance(10 * time.Second)
+		eventually(t, "the next poll", func() bool { return f.count() == i+2 })
+		awaitWaiters(t, clock, 1)
+		if got := polledIDs(rec); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("poll %d: runs for %v, want %v", i+2, got, step.want)
+		}
+	}
+
+	// A restarted engine picks up the saved cursor instead of priming again
+	we.DeactivateWorkflow(ctx, wf.ID)
+	rec2 := &recorder{}
+	clock2 := NewFakeClock(clock.Now())
+	restarted := newTestEngine(t, WithStore(store), WithClock(clock2), WithNodeExecutor(nodeRecord, rec2))
+	f.set(4, 5, 6)
+	if _, err := restarted.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	awaitWaiters(t, clock2, 1)
+	if got := polledIDs(rec2); !reflect.DeepEqual(got, []float64{6}) {
+		t.Fatalf("after restart: runs for %v, want [6]", got)
+	}
+}
+
+func TestPollCursorAdvance(t *testing.T) {
+	c := &pollCursor{}
+	if fresh, changed := c.advance([]interface{}{"a", "b"}, ""); len(fresh) != 0 || !changed {
+		t.Fatalf("priming: fresh %v, changed %v", fresh, changed)
+	}
+	if fresh, changed := c.advance([]interface{}{"a", "b"}, ""); len(fresh) != 0 || changed {
+		t.Fatalf("nothing new: fresh %v, changed %v", fresh, changed)
+	}
+	fresh, changed := c.advance([]interface{}{"c", "a", "d"}, "")
+	if !reflect.DeepEqual(fresh, []interface{}{"c", "d"}) || !changed {
+		t.Fatalf("new items: fresh %v, changed %v", fresh, changed)
+	}
+	if got := c.state().Seen; len(got) != 4 {
+		t.Fatalf("saved %d keys, want 4", len(got))
+	}
+}
+
+func TestPollItemKeyAndInterval(t *testing.T) {
+	item := map[string]interface{}{"id": 7.0, "meta": map[string]interface{}{"uid": "x"}}
+	for key, want := range map[string]string{
+		"id":       "7",
+		"meta.uid": "x",
+		"":         `{"id":7,"meta":{"uid":"x"}}`,
+		"missing":  `{"id":7,"meta":{"uid":"x"}}`,
+	} {
+		if got := pollItemKey(item, key); got != want {
+			t.Errorf("pollItemKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	tests := []struct {
+		interval interface{}
+		want     time.Duration
+		fails    bool
+	}{
+		{nil, defaultPollInterval, false},
+		{"30s", 30 * time.Second, false},
+		{"10ms", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := pollInterval(&Node{Properties: map[string]interface{}{"interval": tt.interval}})
+		if (err != nil) != tt.fails || got != tt.want {
+			t.Errorf("pollInterval(%v) = %v, %v", tt.interval, got, err)
+		}
+	}
+}
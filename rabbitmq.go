@@ -0,0 +1,284 @@
+// rabbitmq.go - RabbitMQ consumer trigger and publish node
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// WithAMQPBroker replaces the broker the RabbitMQ nodes reach through,
+// e.g. with a fake broker in tests.
+func WithAMQPBroker(broker AMQPBroker) EngineOption {
+	return func(we *WorkflowEngine) { we.amqp = broker }
+}
+
+// ============================================
+// AMQP Connections
+// ============================================
+
+// AMQPBroker connects the RabbitMQ nodes to brokers. Deliveries are acked
+// and nacked through their Acknowledger, so a fake can observe both.
+type AMQPBroker interface {
+	// Consume subscribes to queue with prefetch unacknowledged deliveries
+	// in flight. stop ends the subscription.
+	Consume(url, queue string, prefetch int) (deliveries <-chan amqp.Delivery, stop func(), err error)
+	Publish(ctx context.Context, url, exchange, routingKey string, msg amqp.Publishing) error
+	Close()
+}
+
+// AMQPPool is the default AMQPBroker. It shares one connection per broker
+// URL between consumers and publishers, redialing when a connection has
+// closed.
+type AMQPPool struct {
+	mu    sync.Mutex
+	conns map[string]*amqp.Connection
+}
+
+func NewAMQPPool() *AMQPPool {
+	return &AMQPPool{
+		conns: make(map[string]*amqp.Connection),
+	}
+}
+
+// Channel opens a channel on the shared connection for url. Callers close
+// the channel; the connection stays open for reuse.
+func (p *AMQPPool) Channel(url string) (*amqp.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, ok := p.conns[url]
+	if !ok || conn.IsClosed() {
+		var err error
+		if conn, err = amqp.Dial(url); err != nil {
+			return nil, fmt.Errorf("connect to broker: %v", err)
+		}
+		p.conns[url] = conn
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("open channel: %v", err)
+	}
+	return ch, nil
+}
+
+// Consume opens a channel for a queue subscription.
+func (p *AMQPPool) Consume(url, queue string, prefetch int) (<-chan amqp.Delivery, func(), error) {
+	ch, err := p.Channel(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("set prefetch: %v", err)
+	}
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("consume %s: %v", queue, err)
+	}
+	return deliveries, func() { ch.Close() }, nil
+}
+
+// Publish sends msg on a short-lived channel.
+func (p *AMQPPool) Publish(ctx context.Context, url, exchange, routingKey string, msg amqp.Publishing) error {
+	ch, err := p.Channel(url)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := ch.PublishWithContext(ctx, exchange, routingKey, false, false, msg); err != nil {
+		return fmt.Errorf("publish: %v", err)
+	}
+	return nil
+}
+
+// Close closes every pooled connection.
+func (p *AMQPPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for url, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, url)
+	}
+}
+
+func amqpURL(node *Node) (string, error) {
+	url, _ := node.Properties["url"].(string)
+	if url = strings.TrimSpace(url); url == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	return url, nil
+}
+
+// ============================================
+// RabbitMQ Consumer Trigger
+// ============================================
+
+// rabbitMQReconnectDelay is how long a consumer waits before resubscribing
+// after its connection drops.
+const rabbitMQReconnectDelay = 5 * time.Second
+
+// rabbitMQListener consumes the node's queue and runs the workflow once per
+// message, acking on success and nacking on failure. Properties: url,
+// queue, prefetch (default 1), requeue (requeue rejected messages; default
+// false so a poison message cannot loop forever).
+func rabbitMQListener(broker AMQPBroker) TriggerListener {
+	return func(ctx context.Context, node *Node, fire func(input interface{}) error) error {
+		url, err := amqpURL(node)
+		if err != nil {
+			return err
+		}
+		queue, _ := node.Properties["queue"].(string)
+		if queue = strings.TrimSpace(queue); queue == "" {
+			return fmt.Errorf("queue is required")
+		}
+		prefetch := 1
+		if v, ok := node.Properties["prefetch"]; ok && v != "" && v != nil {
+			if prefetch, err = toInt(v); err != nil || prefetch < 1 {
+				return fmt.Errorf("invalid prefetch: %v", v)
+			}
+		}
+		requeue := boolProperty(node, "requeue")
+
+		logger := loggerFromContext(ctx)
+		for {
+			err := consumeQueue(ctx, broker, url, queue, prefetch, requeue, fire)
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Warn("rabbitmq consumer disconnected; retrying", "queue", queue, "error", err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(rabbitMQReconnectDelay):
+			}
+		}
+	}
+}
+
+// consumeQueue delivers messages until ctx ends or the channel closes.
+func consumeQueue(ctx context.Context, broker AMQPBroker, url, queue string, prefetch int, requeue bool, fire func(input interface{}) error) error {
+	deliveries, stop, err := broker.Consume(url, queue, prefetch)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("delivery channel closed")
+			}
+			if err := fire(deliveryInput(d)); err != nil {
+				d.Nack(false, requeue)
+				continue
+			}
+			d.Ack(false)
+		}
+	}
+}
+
+// deliveryInput is the trigger output for a message. JSON bodies are
+// decoded; anything else is passed through as a string.
+func deliveryInput(d amqp.Delivery) map[string]interface{} {
+	var body interface{} = string(d.Body)
+	var decoded interface{}
+	if err := json.Unmarshal(d.Body, &decoded); err == nil {
+		body = decoded
+	}
+
+	headers := make(map[string]interface{}, len(d.Headers))
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+
+	return map[string]interface{}{
+		"body":          body,
+		"exchange":      d.Exchange,
+		"routingKey":    d.RoutingKey,
+		"messageId":     d.MessageId,
+		"correlationId": d.CorrelationId,
+		"contentType":   d.ContentType,
+		"headers":       headers,
+		"redelivered":   d.Redelivered,
+	}
+}
+
+// RabbitMQTriggerExecutor is the consumer trigger node itself; the message
+// arrives as the execution input and is passed through.
+type RabbitMQTriggerExecutor struct{}
+
+func (e *RabbitMQTriggerExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	queue, _ := node.Properties["queue"].(string)
+	return map[string]interface{}{
+		"status":  "message_received",
+		"queue":   queue,
+		"message": input,
+	}, nil
+}
+
+// ============================================
+// RabbitMQ Publish Node
+// ============================================
+
+// RabbitMQExecutor publishes a message. Properties: url, exchange (blank =
+// default exchange), routingKey, message (blank = the node input as JSON),
+// contentType.
+type RabbitMQExecutor struct {
+	broker AMQPBroker
+}
+
+func (e *RabbitMQExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	url, err := amqpURL(node)
+	if err != nil {
+		return nil, err
+	}
+	exchange, _ := node.Properties["exchange"].(string)
+	routingKey, _ := node.Properties["routingKey"].(string)
+	contentType, _ := node.Properties["contentType"].(string)
+
+	var body []byte
+	if msg, _ := node.Properties["message"].(string); msg != "" {
+		body = []byte(msg)
+	} else {
+		if body, err = json.Marshal(input); err != nil {
+			return nil, fmt.Errorf("encode message: %v", err)
+		}
+		if contentType == "" {
+			contentType = "application/json"
+		}
+	}
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	err = e.broker.Publish(ctx, url, exchange, routingKey, amqp.Publishing{
+		ContentType:  contentType,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"status":     "published",
+		"exchange":   exchange,
+		"routingKey": routingKey,
+		"bytes":      len(body),
+	}, nil
+}
@@ -0,0 +1,233 @@
+// rabbitmq_test.go - RabbitMQ trigger and publish tests with a fake broker
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeAMQP is an in-memory AMQPBroker. Messages sent with deliver go to
+// the queue's consumer; acks and nacks are recorded by delivery tag.
+type fakeAMQP struct {
+	mu        sync.Mutex
+	queues    map[string]chan amqp.Delivery
+	acked     []uint64
+	nacked    []uint64
+	requeued  []bool
+	published []amqp.Publishing
+	routes    []string
+	prefetch  int
+	stopped   int
+}
+
+func newFakeAMQP() *fakeAMQP {
+	return &fakeAMQP{queues: make(map[string]chan amqp.Delivery)}
+}
+
+func (b *fakeAMQP) queue(name string) chan amqp.Delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, ok := b.queues[name]
+	if !ok {
+		q = make(chan amqp.Delivery, 16)
+		b.queues[name] = q
+	}
+	return q
+}
+
+func (b *fakeAMQP) Consume(url, queue string, prefetch int) (<-chan amqp.Delivery, func(), error) {
+	q := b.queue(queue)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prefetch = prefetch
+	return q, func() { b.mu.Lock(); b.stopped++; b.mu.Unlock() }, nil
+}
+
+func (b *fakeAMQP) Publish(ctx context.Context, url, exchange, routingKey string, msg amqp.Publishing) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = append(b.published, msg)
+	b.routes = append(b.routes, exchange+"/"+routingKey)
+	return nil
+}
+
+func (b *fakeAMQP) Close() {}
+
+func (b *fakeAMQP) deliver(queue string, tag uint64, body string) {
+	b.queue(queue) <- amqp.Delivery{Acknowledger: b, DeliveryTag: tag, Body: []byte(body), RoutingKey: queue}
+}
+
+func (b *fakeAMQP) Ack(tag uint64, multiple bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.acked = append(b.acked, tag)
+	return nil
+}
+
+func (b *fakeAMQP) Nack(tag uint64, multiple, requeue bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nacked = append(b.nacked, tag)
+	b.requeued = append(b.requeued, requeue)
+	return nil
+}
+
+func (b *fakeAMQP) Reject(tag uint64, requeue bool) error {
+	return b.Nack(tag, false, requeue)
+}
+
+func (b *fakeAMQP) settled() (acked, nacked []uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]uint64(nil), b.acked...), append([]uint64(nil), b.nacked...)
+}
+
+// consumerFlow is a RabbitMQ trigger on queue followed by a node of next's
+// type.
+func consumerFlow(queue string, next NodeType, props map[string]interface{}) *Workflow {
+	trigger := map[string]interface{}{"url": "amqp://fake", "queue": queue}
+	for k, v := range props {
+		trigger[k] = v
+	}
+	return &Workflow{
+		Name: "consume " + queue,
+		Nodes: []Node{
+			{ID: "mq", Type: NodeRabbitMQTrigger, Properties: trigger},
+			{ID: "next", Type: next},
+		},
+		Connections: []Connection{{ID: "c", FromID: "mq", ToID: "next"}},
+	}
+}
+
+func TestRabbitMQMessageTriggersRunAndAcks(t *testing.T) {
+	broker := newFakeAMQP()
+	rec := &recorder{}
+	we := newTestEngine(t, WithAMQPBroker(broker), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, consumerFlow("orders", nodeRecord, nil))
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	broker.deliver("orders", 1, `{"order": 7}`)
+	eventually(t, "ack", func() bool { acked, _ := broker.settled(); return len(acked) == 1 })
+
+	acked, nacked := broker.settled()
+	if acked[0] != 1 || len(nacked) != 0 {
+		t.Fatalf("acked %v nacked %v, want only 1 acked", acked, nacked)
+	}
+	msg := rec.calls()[0].(map[string]interface{})["message"].(map[string]interface{})
+	if !reflect.DeepEqual(msg["body"], map[string]interface{}{"order": 7.0}) || msg["routingKey"] != "orders" {
+		t.Fatalf("message = %v", msg)
+	}
+}
+
+func TestRabbitMQFailedRunNacks(t *testing.T) {
+	for _, requeue := range []bool{false, true} {
+		broker := newFakeAMQP()
+		we := newTestEngine(t, WithAMQPBroker(broker), WithNodeExecutor(nodeFail, failing{}))
+		ctx := context.Background()
+		wf := mustCreate(t, we, ctx, consumerFlow("jobs", nodeFail, map[string]interface{}{"requeue": requeue}))
+		if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		broker.deliver("jobs", 9, "not json")
+		eventually(t, "nack", func() bool { _, nacked := broker.settled(); return len(nacked) == 1 })
+		acked, nacked := broker.settled()
+		if len(acked) != 0 || nacked[0] != 9 || broker.requeued[0] != requeue {
+			t.Fatalf("requeue=%v: acked %v nacked %v requeued %v", requeue, acked, nacked, broker.requeued)
+		}
+	}
+}
+
+func TestRabbitMQPublish(t *testing.T) {
+	broker := newFakeAMQP()
+	we := newTestEngine(t, WithAMQPBroker(broker))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "publish",
+		Nodes: []Node{{ID: "pub", Type: NodeRabbitMQ, Properties: map[string]interface{}{
+			"url":        "amqp://fake",
+			"exchange":   "events",
+			"routingKey": "order.created",
+		}}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{"order": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if len(broker.published) != 1 || broker.routes[0] != "events/order.created" {
+		t.Fatalf("published %d to %v", len(broker.published), broker.routes)
+	}
+	msg := broker.published[0]
+	if string(msg.Body) != `{"order":7}` || msg.ContentType != "application/json" || msg.DeliveryMode != amqp.Persistent {
+		t.Fatalf("published %q as %s, mode %d", msg.Body, msg.ContentType, msg.DeliveryMode)
+	}
+}
+
+func TestConsumeQueueSettlesEachDelivery(t *testing.T) {
+	broker := newFakeAMQP()
+	broker.deliver("jobs", 1, `{"ok": true}`)
+	broker.deliver("jobs", 2, `{"ok": false}`)
+	broker.deliver("jobs", 3, `{"ok": true}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fire := func(input interface{}) error {
+		body := input.(map[string]interface{})["body"].(map[string]interface{})
+		if body["ok"] != true {
+			return errors.New("execution failed")
+		}
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- consumeQueue(ctx, broker, "amqp://fake", "jobs", 3, true, fire) }()
+
+	eventually(t, "all settled", func() bool { acked, nacked := broker.settled(); return len(acked)+len(nacked) == 3 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("consumeQueue after cancel = %v, want nil", err)
+	}
+
+	acked, nacked := broker.settled()
+	if !reflect.DeepEqual(acked, []uint64{1, 3}) || !reflect.DeepEqual(nacked, []uint64{2}) || !broker.requeued[0] {
+		t.Fatalf("acked %v nacked %v requeued %v", acked, nacked, broker.requeued)
+	}
+	if broker.prefetch != 3 || broker.stopped != 1 {
+		t.Fatalf("prefetch %d, stopped %d times", broker.prefetch, broker.stopped)
+	}
+}
+
+func TestConsumeQueueEndsWhenChannelCloses(t *testing.T) {
+	broker := newFakeAMQP()
+	close(broker.queue("jobs"))
+	err := consumeQueue(context.Background(), broker, "amqp://fake", "jobs", 1, false, func(interface{}) error { return nil })
+	if err == nil {
+		t.Fatal("expected an error when the delivery channel closes")
+	}
+	if broker.stopped != 1 {
+		t.Fatalf("stopped %d times, want 1", broker.stopped)
+	}
+}
+
+func TestRabbitMQListenerRejectsBadSettings(t *testing.T) {
+	fire := func(interface{}) error { return nil }
+	for name, props := range map[string]map[string]interface{}{
+		"no url":        {"queue": "q"},
+		"no queue":      {"url": "amqp://fake"},
+		"zero prefetch": {"url": "amqp://fake", "queue": "q", "prefetch": 0.0},
+	} {
+		if err := rabbitMQListener(newFakeAMQP())(context.Background(), &Node{Properties: props}, fire); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// ratelimit.go - Workflow-wide limits on outbound calls
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const outboundLimiterKey contextKey = "outboundLimiter"
+
+// outboundNodeTypes are the node types that call third-party services and
+// so draw from their workflow's outbound rate limit.
+var outboundNodeTypes = map[NodeType]bool{
+	NodeHTTP:         true,
+	NodeEmail:        true,
+	NodeSlack:        true,
+	NodeSheets:       true,
+	NodeOpenAI:       true,
+	NodeRabbitMQ:     true,
+	NodeKafkaProduce: true,
+	NodeMQTT:         true,
+	NodeS3Upload:     true,
+	NodeS3Download:   true,
+	NodeRedis:        true,
+	NodeGraphQL:      true,
+	NodeGRPC:         true,
+	NodeSMS:          true,
+	NodeDiscord:      true,
+	NodeNotion:       true,
+	NodeGCalendar:    true,
+}
+
+// ============================================
+// Outbound Limits
+// ============================================
+
+// OutboundLimits holds one limiter per rate-limited workflow. A limiter is
+// shared by every node and every concurrent run of its workflow, so the
+// workflow's RateLimit caps its total outbound calls per second.
+type OutboundLimits struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func NewOutboundLimits() *OutboundLimits {
+	return &OutboundLimits{
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// For returns the limiter for w, adjusting it to the current RateLimit, or
+// nil when the workflow is unlimited.
+func (ol *OutboundLimits) For(w *Workflow) *rate.Limiter {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	if w.RateLimit <= 0 {
+		delete(ol.limiters, w.ID)
+		return nil
+	}
+
+	limit := rate.Limit(w.RateLimit)
+	burst := int(math.Max(1, math.Floor(w.RateLimit)))
+	l, ok := ol.limiters[w.ID]
+	if !ok {
+		l = rate.NewLimiter(limit, burst)
+		ol.limiters[w.ID] = l
+	} else if l.Limit() != limit {
+		l.SetLimit(limit)
+		l.SetBurst(burst)
+	}
+	return l
+}
+
+// Remove drops the limiter of a deleted workflow.
+func (ol *OutboundLimits) Remove(workflowID string) {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+	delete(ol.limiters, workflowID)
+}
+
+// waitOutbound blocks an outbound node until its workflow's limiter allows
+// another call, or ctx ends.
+func waitOutbound(ctx context.Context, nodeType NodeType) error {
+	if !outboundNodeTypes[nodeType] {
+		return nil
+	}
+	l, ok := ctx.Value(outboundLimiterKey).(*rate.Limiter)
+	if !ok {
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
+// ============================================
+// Node Type Limits
+// ============================================
+
+// WithNodeTypeRateLimits caps calls per second for each listed node type,
+// across all workflows, e.g. {"openai": 10}.
+func WithNodeTypeRateLimits(limits map[NodeType]float64) EngineOption {
+	return func(we *WorkflowEngine) { we.typeRates = limits }
+}
+
+// NodeTypeLimits holds a token bucket per rate-limited node type. Nodes of
+// a limited type wait for a token before they run.
+type NodeTypeLimits struct {
+	limiters map[NodeType]*rate.Limiter
+}
+
+func NewNodeTypeLimits(limits map[NodeType]float64) *NodeTypeLimits {
+	ntl := &NodeTypeLimits{limiters: make(map[NodeType]*rate.Limiter)}
+	for nodeType, perSecond := range limits {
+		if perSecond > 0 {
+			burst := int(math.Max(1, math.Floor(perSecond)))
+			ntl.limiters[nodeType] = rate.NewLimiter(rate.Limit(perSecond), burst)
+		}
+	}
+	return ntl
+}
+
+// Wait blocks until a node of nodeType may run, or ctx ends.
+func (ntl *NodeTypeLimits) Wait(ctx context.Context, nodeType NodeType) error {
+	if ntl == nil {
+		return nil
+	}
+	l, ok := ntl.limiters[nodeType]
+	if !ok {
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
+// parseNodeTypeRateLimits reads "type=perSecond" pairs separated by
+// commas, e.g. "openai=10,slack=1".
+func parseNodeTypeRateLimits(spec string) (map[NodeType]float64, error) {
+	limits := make(map[NodeType]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		nodeType, value, found := strings.Cut(entry, "=")
+		perSecond, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !found || err != nil || perSecond <= 0 {
+			return nil, fmt.Errorf("invalid node rate limit %q", entry)
+		}
+		limits[NodeType(strings.TrimSpace(nodeType))] = perSecond
+	}
+	return limits, nil
+}
@@ -0,0 +1,157 @@
+// ratelimit_test.go - Outbound rate limit tests
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestMixedOutboundCallsShareWorkflowLimit(t *testing.T) {
+	var hits atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	slack := &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(NodeSlack, slack))
+	ctx := context.Background()
+
+	// 15 Slack and 15 HTTP nodes at 20 calls/s: the first 20 use the burst,
+	// the other 10 wait about half a second between them
+	w := &Workflow{Name: "chatty", RateLimit: 20}
+	for i := 0; i < 15; i++ {
+		w.Nodes = append(w.Nodes,
+			Node{ID: fmt.Sprintf("slack%d", i), Type: NodeSlack},
+			Node{ID: fmt.Sprintf("http%d", i), Type: NodeHTTP, Properties: map[string]interface{}{"url": api.URL, "method": "GET"}},
+		)
+	}
+	wf := mustCreate(t, we, ctx, w)
+
+	start := time.Now()
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	if len(slack.calls()) != 15 || hits.Load() != 15 {
+		t.Fatalf("slack calls %d, http hits %d; want 15 each", len(slack.calls()), hits.Load())
+	}
+	if elapsed < 400*time.Millisecond {
+		t.Fatalf("30 calls at 20/s took %v; the limit was not shared", elapsed)
+	}
+}
+
+func TestOutboundLimitsFor(t *testing.T) {
+	ol := NewOutboundLimits()
+	w := &Workflow{ID: "wf", RateLimit: 2.5}
+
+	l := ol.For(w)
+	if l == nil || l.Limit() != rate.Limit(2.5) || l.Burst() != 2 {
+		t.Fatalf("limiter = %v", l)
+	}
+	if again := ol.For(w); again != l {
+		t.Fatal("runs of one workflow got different limiters")
+	}
+
+	w.RateLimit = 0.5
+	if adjusted := ol.For(w); adjusted != l || l.Limit() != rate.Limit(0.5) || l.Burst() != 1 {
+		t.Fatalf("adjusted limiter: limit %v burst %d", l.Limit(), l.Burst())
+	}
+
+	w.RateLimit = 0
+	if ol.For(w) != nil {
+		t.Fatal("unlimited workflow has a limiter")
+	}
+	w.RateLimit = 1
+	if ol.For(w) == l {
+		t.Fatal("limiter survived the limit being removed")
+	}
+}
+
+func TestNodeTypeLimitCapsHTTPBurst(t *testing.T) {
+	var hits atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	we := newTestEngine(t, WithNodeTypeRateLimits(map[NodeType]float64{NodeHTTP: 20}))
+	ctx := context.Background()
+
+	// 30 parallel http nodes at 20/s: 20 use the burst, the other 10 are
+	// spaced 50ms apart
+	w := &Workflow{Name: "burst"}
+	for i := 0; i < 30; i++ {
+		w.Nodes = append(w.Nodes, Node{ID: fmt.Sprintf("http%d", i), Type: NodeHTTP, Properties: map[string]interface{}{"url": api.URL, "method": "GET"}})
+	}
+	wf := mustCreate(t, we, ctx, w)
+
+	start := time.Now()
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if result.Status != StatusCompleted || hits.Load() != 30 {
+		t.Fatalf("status = %s with %d hits, errors %v", result.Status, hits.Load(), result.Errors)
+	}
+	if elapsed < 400*time.Millisecond {
+		t.Fatalf("30 http calls at 20/s took %v; throughput was not capped", elapsed)
+	}
+}
+
+func TestNodeTypeLimitsWaitRespectsContext(t *testing.T) {
+	ntl := NewNodeTypeLimits(map[NodeType]float64{NodeHTTP: 1, NodeSlack: 0})
+	ctx := context.Background()
+
+	if err := ntl.Wait(ctx, NodeHTTP); err != nil {
+		t.Fatalf("first call should use the burst: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := ntl.Wait(ctx, NodeSlack); err != nil {
+			t.Fatalf("unlimited type waited: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := ntl.Wait(ctx, NodeHTTP); err == nil {
+		t.Fatal("second call within a second did not wait")
+	}
+
+	cancelled, stop := context.WithCancel(context.Background())
+	stop()
+	if err := ntl.Wait(cancelled, NodeHTTP); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled wait: err = %v", err)
+	}
+}
+
+func TestParseNodeTypeRateLimits(t *testing.T) {
+	got, err := parseNodeTypeRateLimits(" openai=10, slack = 0.5,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[NodeType]float64{"openai": 10, "slack": 0.5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("limits = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"openai", "openai=fast", "openai=0", "slack=-1"} {
+		if _, err := parseNodeTypeRateLimits(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
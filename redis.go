@@ -0,0 +1,251 @@
+// redis.go - Redis get/set/incr/publish node
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WithRedisClient replaces the client the Redis node reaches servers
+// through, e.g. with an in-memory server in tests.
+func WithRedisClient(client RedisClient) EngineOption {
+	return func(we *WorkflowEngine) { we.redis = client }
+}
+
+// ============================================
+// Redis Client
+// ============================================
+
+// RedisClient connects the Redis node to servers, each named by a
+// redis:// URL.
+type RedisClient interface {
+	// Get returns the value of key, with found false when it is unset.
+	Get(ctx context.Context, url, key string) (value string, found bool, err error)
+	// Set stores value under key, expiring after ttl unless it is zero.
+	Set(ctx context.Context, url, key, value string, ttl time.Duration) error
+	Incr(ctx context.Context, url, key string, by int64) (int64, error)
+	// Publish sends message on channel, returning how many subscribers
+	// received it.
+	Publish(ctx context.Context, url, channel, message string) (int64, error)
+	Close()
+}
+
+// goRedisClient is the default RedisClient, sharing one connection pool
+// per server URL.
+type goRedisClient struct {
+	mu      sync.Mutex
+	clients map[string]*redis.Client
+}
+
+func NewRedisClient() RedisClient {
+	return &goRedisClient{
+		clients: make(map[string]*redis.Client),
+	}
+}
+
+func (c *goRedisClient) client(url string) (*redis.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[url]; ok {
+		return client, nil
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	client := redis.NewClient(opts)
+	c.clients[url] = client
+	return client, nil
+}
+
+func (c *goRedisClient) Get(ctx context.Context, url, key string) (string, bool, error) {
+	client, err := c.client(url)
+	if err != nil {
+		return "", false, err
+	}
+	value, err := client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+func (c *goRedisClient) Set(ctx context.Context, url, key, value string, ttl time.Duration) error {
+	client, err := c.client(url)
+	if err != nil {
+		return err
+	}
+	return client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *goRedisClient) Incr(ctx context.Context, url, key string, by int64) (int64, error) {
+	client, err := c.client(url)
+	if err != nil {
+		return 0, err
+	}
+	return client.IncrBy(ctx, key, by).Result()
+}
+
+func (c *goRedisClient) Publish(ctx context.Context, url, channel, message string) (int64, error) {
+	client, err := c.client(url)
+	if err != nil {
+		return 0, err
+	}
+	return client.Publish(ctx, channel, message).Result()
+}
+
+// Close closes every connection pool.
+func (c *goRedisClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for url, client := range c.clients {
+		client.Close()
+		delete(c.clients, url)
+	}
+}
+
+// ============================================
+// Redis Node
+// ============================================
+
+// RedisExecutor runs one Redis command. Properties: url, operation (get,
+// set, incr or publish), key, value, ttl (set; duration or seconds, blank
+// = no expiry), by (incr; default 1) and channel (publish). key, value and
+// channel are templates rendered against the input; a blank value stores
+// or publishes the input itself. Non-string values are stored as JSON, and
+// get decodes JSON values back.
+type RedisExecutor struct {
+	client RedisClient
+}
+
+func (e *RedisExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	url, _ := node.Properties["url"].(string)
+	if url = strings.TrimSpace(url); url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	operation, _ := node.Properties["operation"].(string)
+
+	render := func(name string) (string, error) {
+		tmpl, _ := node.Properties[name].(string)
+		if strings.TrimSpace(tmpl) == "" {
+			return "", nil
+		}
+		v, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", name, err)
+		}
+		return redisString(v)
+	}
+	required := func(name string) (string, error) {
+		v, err := render(name)
+		if err == nil && v == "" {
+			err = fmt.Errorf("%s is required", name)
+		}
+		return v, err
+	}
+	value := func() (string, error) {
+		v, err := render("value")
+		if err != nil || v != "" {
+			return v, err
+		}
+		return redisString(input)
+	}
+
+	switch operation {
+	case "get":
+		key, err := required("key")
+		if err != nil {
+			return nil, err
+		}
+		raw, found, err := e.client.Get(ctx, url, key)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %v", key, err)
+		}
+		var v interface{}
+		if found {
+			v = raw
+			var decoded interface{}
+			if json.Unmarshal([]byte(raw), &decoded) == nil {
+				v = decoded
+			}
+		}
+		return map[string]interface{}{"key": key, "found": found, "value": v}, nil
+
+	case "set":
+		key, err := required("key")
+		if err != nil {
+			return nil, err
+		}
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		var ttl time.Duration
+		if raw := node.Properties["ttl"]; raw != nil && raw != "" {
+			if ttl, err = parseDelay(raw); err != nil || ttl < 0 {
+				return nil, fmt.Errorf("invalid ttl: %v", raw)
+			}
+		}
+		if err := e.client.Set(ctx, url, key, v, ttl); err != nil {
+			return nil, fmt.Errorf("set %s: %v", key, err)
+		}
+		return map[string]interface{}{"key": key, "status": "set", "ttlSeconds": ttl.Seconds()}, nil
+
+	case "incr":
+		key, err := required("key")
+		if err != nil {
+			return nil, err
+		}
+		by := int64(1)
+		if raw := node.Properties["by"]; raw != nil && raw != "" {
+			n, err := toInt(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid by: %v", raw)
+			}
+			by = int64(n)
+		}
+		n, err := e.client.Incr(ctx, url, key, by)
+		if err != nil {
+			return nil, fmt.Errorf("incr %s: %v", key, err)
+		}
+		return map[string]interface{}{"key": key, "value": n}, nil
+
+	case "publish":
+		channel, err := required("channel")
+		if err != nil {
+			return nil, err
+		}
+		msg, err := value()
+		if err != nil {
+			return nil, err
+		}
+		receivers, err := e.client.Publish(ctx, url, channel, msg)
+		if err != nil {
+			return nil, fmt.Errorf("publish to %s: %v", channel, err)
+		}
+		return map[string]interface{}{"channel": channel, "status": "published", "receivers": receivers}, nil
+	}
+	return nil, fmt.Errorf("unsupported redis operation: %q", operation)
+}
+
+// redisString is v as Redis stores it: strings as they are, anything else
+// as JSON.
+func redisString(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode value: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,166 @@
+// redis_test.go - Redis node tests against an in-memory miniredis server
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// redisNode runs one Redis operation against the server at url.
+func redisNode(id, url, operation string, props map[string]interface{}) Node {
+	p := map[string]interface{}{"url": url, "operation": operation}
+	for k, v := range props {
+		p[k] = v
+	}
+	return Node{ID: id, Type: NodeRedis, Properties: p}
+}
+
+func TestRedisSetThenGet(t *testing.T) {
+	mr := miniredis.RunT(t)
+	url := "redis://" + mr.Addr()
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "cache user",
+		Nodes: []Node{
+			redisNode("set", url, "set", map[string]interface{}{"key": "user:{{ id }}", "ttl": "10m"}),
+			redisNode("get", url, "get", map[string]interface{}{"key": "{{ key }}"}),
+		},
+		Connections: []Connection{{ID: "c", FromID: "set", ToID: "get"}},
+	})
+
+	user := map[string]interface{}{"id": 7.0, "name": "Ada"}
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+
+	set := result.Results["set"].(map[string]interface{})
+	if set["key"] != "user:7" || set["ttlSeconds"] != 600.0 {
+		t.Fatalf("set output = %v", set)
+	}
+	if ttl := mr.TTL("user:7"); ttl != 10*time.Minute {
+		t.Fatalf("ttl = %s, want 10m", ttl)
+	}
+	got := result.Results["get"].(map[string]interface{})
+	if got["found"] != true || !reflect.DeepEqual(got["value"], user) {
+		t.Fatalf("get output = %v, want the stored user", got)
+	}
+
+	// Once the key expires, get reports it missing
+	mr.FastForward(11 * time.Minute)
+	node := redisNode("", url, "get", map[string]interface{}{"key": "user:7"})
+	out, err := (&RedisExecutor{client: we.redis}).Execute(ctx, &node, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{}); got["found"] != false || got["value"] != nil {
+		t.Fatalf("expired get = %v", got)
+	}
+}
+
+func TestRedisSetTemplatedValue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	e := &RedisExecutor{client: NewRedisClient()}
+	defer e.client.Close()
+	node := redisNode("", "redis://"+mr.Addr(), "set", map[string]interface{}{
+		"key": "greeting", "value": "hello {{ name }}", "ttl": 30.0,
+	})
+	if _, err := e.Execute(context.Background(), &node, map[string]interface{}{"name": "Ada"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := mr.Get("greeting"); v != "hello Ada" {
+		t.Fatalf("stored %q", v)
+	}
+	if ttl := mr.TTL("greeting"); ttl != 30*time.Second {
+		t.Fatalf("ttl = %s, want 30s", ttl)
+	}
+}
+
+func TestRedisIncr(t *testing.T) {
+	mr := miniredis.RunT(t)
+	url := "redis://" + mr.Addr()
+	e := &RedisExecutor{client: NewRedisClient()}
+	defer e.client.Close()
+	ctx := context.Background()
+
+	var last interface{}
+	for _, by := range []interface{}{nil, nil, 5.0, "-2"} {
+		node := redisNode("", url, "incr", map[string]interface{}{"key": "hits", "by": by})
+		out, err := e.Execute(ctx, &node, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = out.(map[string]interface{})["value"]
+	}
+	if last != int64(5) {
+		t.Fatalf("counter = %v, want 5", last)
+	}
+	if v, _ := mr.Get("hits"); v != "5" {
+		t.Fatalf("stored %q, want 5", v)
+	}
+
+	mr.Set("name", "ada")
+	node := redisNode("", url, "incr", map[string]interface{}{"key": "name"})
+	if _, err := e.Execute(ctx, &node, nil); err == nil {
+		t.Fatal("incr of a non-integer value: expected an error")
+	}
+}
+
+func TestRedisPublish(t *testing.T) {
+	mr := miniredis.RunT(t)
+	sub := mr.NewSubscriber()
+	defer sub.Close()
+	sub.Subscribe("orders")
+	// miniredis delivers synchronously, so drain while publishing
+	received := make(chan string, 1)
+	go func() {
+		if msg, ok := <-sub.Messages(); ok {
+			received <- msg.Message
+		}
+	}()
+
+	e := &RedisExecutor{client: NewRedisClient()}
+	defer e.client.Close()
+	node := redisNode("", "redis://"+mr.Addr(), "publish", map[string]interface{}{"channel": "{{ kind }}s"})
+	out, err := e.Execute(context.Background(), &node, map[string]interface{}{"kind": "order", "id": 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(map[string]interface{}); got["channel"] != "orders" || got["receivers"] != int64(1) {
+		t.Fatalf("publish output = %v", got)
+	}
+	select {
+	case msg := <-received:
+		if msg != `{"id":3,"kind":"order"}` {
+			t.Fatalf("received %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestRedisRejectsBadInput(t *testing.T) {
+	e := &RedisExecutor{client: NewRedisClient()}
+	defer e.client.Close()
+	for name, props := range map[string]map[string]interface{}{
+		"no url":            {"operation": "get", "key": "k"},
+		"unknown operation": {"url": "redis://localhost", "operation": "del", "key": "k"},
+		"no key":            {"url": "redis://localhost", "operation": "get"},
+		"no channel":        {"url": "redis://localhost", "operation": "publish"},
+		"bad ttl":           {"url": "redis://localhost", "operation": "set", "key": "k", "ttl": "soon"},
+		"bad by":            {"url": "redis://localhost", "operation": "incr", "key": "k", "by": "lots"},
+		"bad template":      {"url": "redis://localhost", "operation": "get", "key": "{{ unclosed"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// regex.go - Regular expression extract and replace node
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ============================================
+// Regex Node
+// ============================================
+
+// RegexExecutor matches a regular expression (Go RE2 syntax; flags such as
+// (?i) go inline) against text. Properties: pattern, operation (extract or
+// replace), text, a template rendered against the input (blank = the input
+// string), global ("true" for every match rather than the first) and:
+//   - extract: outputs {matched, match, groups, named} for the first
+//     match, or {matched, count, matches} of those objects with global
+//   - replace: replacement, a template rendered against the input that may
+//     refer to groups as $1 or ${name}; outputs {result, count}
+type RegexExecutor struct{}
+
+func (e *RegexExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	re, err := regexProperty(node)
+	if err != nil {
+		return nil, err
+	}
+	text, err := regexText(node, input)
+	if err != nil {
+		return nil, err
+	}
+	global := boolProperty(node, "global")
+
+	switch operation, _ := node.Properties["operation"].(string); operation {
+	case "", "extract":
+		if !global {
+			m := re.FindStringSubmatch(text)
+			if m == nil {
+				return map[string]interface{}{"matched": false, "match": nil, "groups": []interface{}{}, "named": map[string]interface{}{}}, nil
+			}
+			out := regexMatch(re, m)
+			out["matched"] = true
+			return out, nil
+		}
+		matches := []interface{}{}
+		for _, m := range re.FindAllStringSubmatch(text, -1) {
+			matches = append(matches, regexMatch(re, m))
+		}
+		return map[string]interface{}{
+			"matched": len(matches) > 0,
+			"count":   len(matches),
+			"matches": matches,
+		}, nil
+
+	case "replace":
+		tmpl, _ := node.Properties["replacement"].(string)
+		rendered, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return nil, fmt.Errorf("replacement: %v", err)
+		}
+		replacement := exprString(rendered)
+		if global {
+			count := len(re.FindAllStringIndex(text, -1))
+			return map[string]interface{}{
+				"result": re.ReplaceAllString(text, replacement),
+				"count":  count,
+			}, nil
+		}
+		loc := re.FindStringSubmatchIndex(text)
+		if loc == nil {
+			return map[string]interface{}{"result": text, "count": 0}, nil
+		}
+		var sb strings.Builder
+		sb.WriteString(text[:loc[0]])
+		sb.Write(re.ExpandString(nil, replacement, text, loc))
+		sb.WriteString(text[loc[1]:])
+		return map[string]interface{}{"result": sb.String(), "count": 1}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported regex operation: %q", operation)
+	}
+}
+
+// regexMatch describes one match: the whole match, its positional groups
+// and its named groups.
+func regexMatch(re *regexp.Regexp, m []string) map[string]interface{} {
+	groups := make([]interface{}, 0, len(m)-1)
+	for _, g := range m[1:] {
+		groups = append(groups, g)
+	}
+	named := make(map[string]interface{})
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			named[name] = m[i]
+		}
+	}
+	return map[string]interface{}{
+		"match":  m[0],
+		"groups": groups,
+		"named":  named,
+	}
+}
+
+// regexText is the text to match against.
+func regexText(node *Node, input interface{}) (string, error) {
+	if tmpl, _ := node.Properties["text"].(string); tmpl != "" {
+		rendered, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return "", fmt.Errorf("text: %v", err)
+		}
+		return exprString(rendered), nil
+	}
+	s, ok := genericJSON(input).(string)
+	if !ok {
+		return "", fmt.Errorf("text is required when the input is not a string")
+	}
+	return s, nil
+}
+
+// regexProperty compiles the node's pattern.
+func regexProperty(node *Node) (*regexp.Regexp, error) {
+	pattern, _ := node.Properties["pattern"].(string)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	return re, nil
+}
+
+// checkRegexNode reports a pattern that does not compile, so it is caught
+// when the workflow is saved rather than when it runs.
+func checkRegexNode(node *Node) []FieldError {
+	pattern, _ := node.Properties["pattern"].(string)
+	if pattern == "" || secretRefPattern.MatchString(pattern) {
+		return nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return []FieldError{{NodeID: node.ID, Field: "pattern", Message: err.Error()}}
+	}
+	return nil
+}
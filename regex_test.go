@@ -0,0 +1,141 @@
+// regex_test.go - Regex extract and replace node tests
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// runRegex executes a regex node with props against input.
+func runRegex(t *testing.T, props map[string]interface{}, input interface{}) map[string]interface{} {
+	t.Helper()
+	out, err := (&RegexExecutor{}).Execute(context.Background(), &Node{Properties: props}, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.(map[string]interface{})
+}
+
+func TestRegexExtractNamedGroups(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "parse order email",
+		Nodes: []Node{{ID: "order", Type: NodeRegex, Properties: map[string]interface{}{
+			"pattern": `(?i)order #(?P<id>\d+) for (?P<amount>\$[\d.]+)`,
+			"text":    "{{ email.subject }}",
+		}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{
+		"email": map[string]interface{}{"subject": "Re: ORDER #1042 for $19.99 shipped"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	want := map[string]interface{}{
+		"matched": true,
+		"match":   "ORDER #1042 for $19.99",
+		"groups":  []interface{}{"1042", "$19.99"},
+		"named":   map[string]interface{}{"id": "1042", "amount": "$19.99"},
+	}
+	if got := result.Results["order"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+}
+
+func TestRegexExtractAllMatches(t *testing.T) {
+	out := runRegex(t, map[string]interface{}{
+		"pattern": `(?P<key>\w+)=(?P<value>\w*)`,
+		"global":  true,
+	}, "a=1 b= c=three")
+	if out["matched"] != true || out["count"] != 3 {
+		t.Fatalf("output = %v", out)
+	}
+	second := out["matches"].([]interface{})[1].(map[string]interface{})
+	if want := map[string]interface{}{"key": "b", "value": ""}; !reflect.DeepEqual(second["named"], want) {
+		t.Fatalf("second match named = %v, want %v", second["named"], want)
+	}
+
+	none := runRegex(t, map[string]interface{}{"pattern": `\d+`}, "no digits")
+	if none["matched"] != false || none["match"] != nil || len(none["groups"].([]interface{})) != 0 {
+		t.Fatalf("no match = %v", none)
+	}
+}
+
+func TestRegexReplace(t *testing.T) {
+	tests := []struct {
+		name   string
+		props  map[string]interface{}
+		input  interface{}
+		result string
+		count  int
+	}{
+		{
+			"global with groups",
+			map[string]interface{}{"pattern": `(\d{4})-(\d{2})-(\d{2})`, "replacement": "$3/$2/$1", "global": true},
+			"from 2024-03-04 to 2024-03-10", "from 04/03/2024 to 10/03/2024", 2,
+		},
+		{
+			"first only",
+			map[string]interface{}{"pattern": `\s+`, "replacement": "_"},
+			"a  b   c", "a_b   c", 1,
+		},
+		{
+			"named group and templated replacement",
+			map[string]interface{}{"pattern": `(?P<user>\w+)@example\.com`, "replacement": "${user}@{{ domain }}", "text": "{{ from }}", "global": "true"},
+			map[string]interface{}{"from": "ada@example.com, grace@example.com", "domain": "corp.test"},
+			"ada@corp.test, grace@corp.test", 2,
+		},
+		{
+			"no match",
+			map[string]interface{}{"pattern": `x`, "replacement": "y"},
+			"abc", "abc", 0,
+		},
+	}
+	for _, tt := range tests {
+		tt.props["operation"] = "replace"
+		out := runRegex(t, tt.props, tt.input)
+		if out["result"] != tt.result || out["count"] != tt.count {
+			t.Errorf("%s: got %q (%v), want %q (%d)", tt.name, out["result"], out["count"], tt.result, tt.count)
+		}
+	}
+}
+
+func TestRegexInvalidPatternRejectedOnSave(t *testing.T) {
+	we := newTestEngine(t)
+	err := we.CreateWorkflow(context.Background(), &Workflow{
+		Name:  "broken",
+		Nodes: []Node{{ID: "re", Type: NodeRegex, Properties: map[string]interface{}{"pattern": `(?P<id\d+)`}}},
+	})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || invalid.Fields[0].Field != "pattern" {
+		t.Fatalf("err = %v, want a ValidationError on pattern", err)
+	}
+
+	// A pattern from a secret is only known at run time
+	ok := &Node{ID: "re", Type: NodeRegex, Properties: map[string]interface{}{"pattern": "{{ secrets.PATTERN }}"}}
+	if fields := checkRegexNode(ok); fields != nil {
+		t.Fatalf("secret pattern rejected: %v", fields)
+	}
+}
+
+func TestRegexRejectsBadInput(t *testing.T) {
+	for name, props := range map[string]map[string]interface{}{
+		"no pattern":        {},
+		"invalid pattern":   {"pattern": "[a-"},
+		"unknown operation": {"pattern": "a", "operation": "split"},
+		"bad text template": {"pattern": "a", "text": "{{ unclosed"},
+	} {
+		if _, err := (&RegexExecutor{}).Execute(context.Background(), &Node{Properties: props}, "abc"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := (&RegexExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{"pattern": "a"}}, 42.0); err == nil {
+		t.Error("non-string input without text: expected an error")
+	}
+}
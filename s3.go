@@ -0,0 +1,297 @@
+// s3.go - S3-compatible object storage upload and download nodes
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// defaultS3Endpoint is used when a node sets no endpoint.
+const defaultS3Endpoint = "https://s3.amazonaws.com"
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// WithObjectStore replaces the store the S3 nodes reach buckets through,
+// e.g. with a fake store in tests.
+func WithObjectStore(store ObjectStore) EngineOption {
+	return func(we *WorkflowEngine) { we.objects = store }
+}
+
+// ============================================
+// Object Storage
+// ============================================
+
+// S3Conn identifies an S3-compatible service and the credentials to use.
+type S3Conn struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	URL         string
+	Size        int64
+	ContentType string
+	ETag        string
+}
+
+// ObjectStore connects the S3 nodes to object storage. Get fails with
+// ErrObjectNotFound for a missing bucket or key.
+type ObjectStore interface {
+	// Put stores body under key; size is -1 when unknown.
+	Put(ctx context.Context, conn S3Conn, bucket, key string, body io.Reader, size int64, contentType string) (ObjectInfo, error)
+	Get(ctx context.Context, conn S3Conn, bucket, key string) (io.ReadCloser, ObjectInfo, error)
+}
+
+// minioObjectStore is the default ObjectStore, sharing one client per
+// endpoint and credentials.
+type minioObjectStore struct {
+	mu      sync.Mutex
+	clients map[S3Conn]*minio.Client
+}
+
+func NewObjectStore() ObjectStore {
+	return &minioObjectStore{
+		clients: make(map[S3Conn]*minio.Client),
+	}
+}
+
+func (s *minioObjectStore) client(conn S3Conn) (*minio.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.clients[conn]; ok {
+		return c, nil
+	}
+	u, err := url.Parse(conn.Endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint: %q", conn.Endpoint)
+	}
+	opts := &minio.Options{Secure: u.Scheme == "https", Region: conn.Region}
+	if conn.AccessKey != "" {
+		opts.Creds = credentials.NewStaticV4(conn.AccessKey, conn.SecretKey, "")
+	}
+	c, err := minio.New(u.Host, opts)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %v", conn.Endpoint, err)
+	}
+	s.clients[conn] = c
+	return c, nil
+}
+
+func (s *minioObjectStore) Put(ctx context.Context, conn S3Conn, bucket, key string, body io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	c, err := s.client(conn)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := c.PutObject(ctx, bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return ObjectInfo{}, objectError(bucket, key, err)
+	}
+	return ObjectInfo{
+		URL:         c.EndpointURL().JoinPath(bucket, key).String(),
+		Size:        info.Size,
+		ContentType: contentType,
+		ETag:        info.ETag,
+	}, nil
+}
+
+func (s *minioObjectStore) Get(ctx context.Context, conn S3Conn, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	c, err := s.client(conn)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	obj, err := c.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, objectError(bucket, key, err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, ObjectInfo{}, objectError(bucket, key, err)
+	}
+	return obj, ObjectInfo{
+		URL:         c.EndpointURL().JoinPath(bucket, key).String(),
+		Size:        stat.Size,
+		ContentType: stat.ContentType,
+		ETag:        stat.ETag,
+	}, nil
+}
+
+// objectError maps a missing bucket or key to ErrObjectNotFound.
+func objectError(bucket, key string, err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return fmt.Errorf("%w: s3://%s/%s", ErrObjectNotFound, bucket, key)
+	}
+	return fmt.Errorf("s3://%s/%s: %v", bucket, key, err)
+}
+
+// s3Settings reads the node's connection, bucket and key.
+func s3Settings(node *Node) (conn S3Conn, bucket, key string, err error) {
+	str := func(name string) string {
+		v, _ := node.Properties[name].(string)
+		return strings.TrimSpace(v)
+	}
+	conn = S3Conn{
+		Endpoint:  str("endpoint"),
+		Region:    str("region"),
+		AccessKey: str("accessKey"),
+		SecretKey: str("secretKey"),
+	}
+	if conn.Endpoint == "" {
+		conn.Endpoint = defaultS3Endpoint
+	}
+	if bucket = str("bucket"); bucket == "" {
+		return S3Conn{}, "", "", fmt.Errorf("bucket is required")
+	}
+	if key = strings.TrimPrefix(str("key"), "/"); key == "" {
+		return S3Conn{}, "", "", fmt.Errorf("key is required")
+	}
+	return conn, bucket, key, nil
+}
+
+// ============================================
+// S3 Upload Node
+// ============================================
+
+// S3UploadExecutor stores its input as an object. Properties: endpoint
+// (default AWS), region, accessKey, secretKey, bucket, key, field (path
+// to the value to store; blank = the whole input), encoding and
+// contentType. Strings are stored as they are, or decoded first with
+// encoding "base64"; anything else is streamed as JSON.
+type S3UploadExecutor struct {
+	store ObjectStore
+}
+
+func (e *S3UploadExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	conn, bucket, key, err := s3Settings(node)
+	if err != nil {
+		return nil, err
+	}
+	value := input
+	if field, _ := node.Properties["field"].(string); strings.TrimSpace(field) != "" {
+		if value, _ = lookupPath(genericJSON(input), strings.TrimSpace(field)); value == nil {
+			return nil, fmt.Errorf("field %q not found in input", field)
+		}
+	}
+	encoding, _ := node.Properties["encoding"].(string)
+	contentType, _ := node.Properties["contentType"].(string)
+
+	var info ObjectInfo
+	switch v := value.(type) {
+	case string:
+		data := []byte(v)
+		switch encoding {
+		case "", "text":
+		case "base64":
+			if data, err = base64.StdEncoding.DecodeString(v); err != nil {
+				return nil, fmt.Errorf("invalid base64 content: %v", err)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported encoding: %q", encoding)
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		info, err = e.store.Put(ctx, conn, bucket, key, bytes.NewReader(data), int64(len(data)), contentType)
+	default:
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(json.NewEncoder(pw).Encode(value))
+		}()
+		info, err = e.store.Put(ctx, conn, bucket, key, pr, -1, contentType)
+		pr.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"status":      "uploaded",
+		"bucket":      bucket,
+		"key":         key,
+		"url":         info.URL,
+		"size":        info.Size,
+		"contentType": contentType,
+		"etag":        info.ETag,
+	}, nil
+}
+
+// ============================================
+// S3 Download Node
+// ============================================
+
+// S3DownloadExecutor reads an object. Properties: endpoint, region,
+// accessKey, secretKey, bucket, key and encoding: "text", "base64", or
+// "auto" (the default), which decodes JSON objects and reads anything else
+// as text. Objects over the node data limit are refused.
+type S3DownloadExecutor struct {
+	store    ObjectStore
+	maxBytes int64
+}
+
+func (e *S3DownloadExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	conn, bucket, key, err := s3Settings(node)
+	if err != nil {
+		return nil, err
+	}
+	encoding, _ := node.Properties["encoding"].(string)
+	switch encoding {
+	case "":
+		encoding = "auto"
+	case "auto", "text", "base64":
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %q", encoding)
+	}
+
+	body, info, err := e.store.Get(ctx, conn, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, e.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("s3://%s/%s: %v", bucket, key, err)
+	}
+	if int64(len(data)) > e.maxBytes {
+		return nil, fmt.Errorf("%w: s3://%s/%s is over %d bytes", ErrDataTooLarge, bucket, key, e.maxBytes)
+	}
+
+	var content interface{} = string(data)
+	switch encoding {
+	case "base64":
+		content = base64.StdEncoding.EncodeToString(data)
+	case "auto":
+		var decoded interface{}
+		if strings.Contains(info.ContentType, "json") && json.Unmarshal(data, &decoded) == nil {
+			content = decoded
+		}
+	}
+
+	return map[string]interface{}{
+		"bucket":      bucket,
+		"key":         key,
+		"url":         info.URL,
+		"size":        len(data),
+		"contentType": info.ContentType,
+		"etag":        info.ETag,
+		"content":     content,
+	}, nil
+}
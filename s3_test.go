@@ -0,0 +1,206 @@
+// s3_test.go - S3 upload and download node tests against a fake object store
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeObjects is an in-memory ObjectStore recording every put and get.
+type fakeObjects struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	puts    []string
+	gets    []string
+	conns   []S3Conn
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	size        int64 // as passed to Put
+}
+
+func newFakeObjects() *fakeObjects {
+	return &fakeObjects{objects: make(map[string]fakeObject)}
+}
+
+func (s *fakeObjects) Put(ctx context.Context, conn S3Conn, bucket, key string, body io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := bucket + "/" + key
+	s.objects[path] = fakeObject{data, contentType, size}
+	s.puts = append(s.puts, path)
+	s.conns = append(s.conns, conn)
+	return ObjectInfo{URL: conn.Endpoint + "/" + path, Size: int64(len(data)), ContentType: contentType, ETag: fmt.Sprintf("etag-%d", len(s.puts))}, nil
+}
+
+func (s *fakeObjects) Get(ctx context.Context, conn S3Conn, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := bucket + "/" + key
+	s.gets = append(s.gets, path)
+	obj, ok := s.objects[path]
+	if !ok {
+		return nil, ObjectInfo{}, fmt.Errorf("%w: s3://%s", ErrObjectNotFound, path)
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), ObjectInfo{URL: conn.Endpoint + "/" + path, ContentType: obj.contentType}, nil
+}
+
+// s3Props is the connection for a node storing bucket/key, plus extra.
+func s3Props(key string, extra map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{
+		"endpoint": "https://minio.local", "region": "eu-west-1",
+		"accessKey": "AKID", "secretKey": "s3cret",
+		"bucket": "artifacts", "key": key,
+	}
+	for k, v := range extra {
+		props[k] = v
+	}
+	return props
+}
+
+func TestS3UploadThenDownload(t *testing.T) {
+	store := newFakeObjects()
+	we := newTestEngine(t, WithObjectStore(store))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "stash report",
+		Nodes: []Node{
+			{ID: "up", Type: NodeS3Upload, Properties: s3Props("reports/daily.json", nil)},
+			{ID: "down", Type: NodeS3Download, Properties: s3Props("reports/daily.json", nil)},
+		},
+		Connections: []Connection{{ID: "c", FromID: "up", ToID: "down"}},
+	})
+
+	report := map[string]interface{}{"total": 42.0, "items": []interface{}{"a", "b"}}
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+
+	up := result.Results["up"].(map[string]interface{})
+	if up["url"] != "https://minio.local/artifacts/reports/daily.json" || up["contentType"] != "application/json" {
+		t.Fatalf("upload output = %v", up)
+	}
+	if !reflect.DeepEqual(store.puts, []string{"artifacts/reports/daily.json"}) || !reflect.DeepEqual(store.gets, store.puts) {
+		t.Fatalf("puts %v gets %v", store.puts, store.gets)
+	}
+	// JSON input is streamed, so its size is not known up front
+	if obj := store.objects["artifacts/reports/daily.json"]; obj.size != -1 {
+		t.Fatalf("put size = %d, want -1", obj.size)
+	}
+	want := S3Conn{Endpoint: "https://minio.local", Region: "eu-west-1", AccessKey: "AKID", SecretKey: "s3cret"}
+	if store.conns[0] != want {
+		t.Fatalf("connection = %+v", store.conns[0])
+	}
+
+	down := result.Results["down"].(map[string]interface{})
+	if !reflect.DeepEqual(down["content"], report) {
+		t.Fatalf("downloaded %v, want %v", down["content"], report)
+	}
+}
+
+func TestS3UploadStrings(t *testing.T) {
+	store := newFakeObjects()
+	e := &S3UploadExecutor{store: store}
+	input := map[string]interface{}{
+		"note": "hello",
+		"file": map[string]interface{}{"content": "iVBORw0K"}, // PNG magic, base64
+	}
+
+	tests := []struct {
+		name      string
+		props     map[string]interface{}
+		data      string
+		mediaType string
+	}{
+		{"text field", map[string]interface{}{"field": "note", "contentType": "text/plain"}, "hello", "text/plain"},
+		{"base64 field", map[string]interface{}{"field": "file.content", "encoding": "base64"}, "\x89PNG\r\n", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if _, err := e.Execute(context.Background(), &Node{Properties: s3Props(tt.name, tt.props)}, input); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		obj := store.objects["artifacts/"+tt.name]
+		if string(obj.data) != tt.data || obj.contentType != tt.mediaType || obj.size != int64(len(tt.data)) {
+			t.Errorf("%s: stored %q as %s (size %d)", tt.name, obj.data, obj.contentType, obj.size)
+		}
+	}
+
+	for name, props := range map[string]map[string]interface{}{
+		"missing field":        {"field": "nope"},
+		"bad base64":           {"field": "note", "encoding": "base64"},
+		"unsupported encoding": {"field": "note", "encoding": "hex"},
+		"no bucket":            {"bucket": ""},
+		"no key":               {"key": "/"},
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: s3Props("bad", props)}, input); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if len(store.puts) != 2 {
+		t.Fatalf("%d puts, want only the 2 valid uploads", len(store.puts))
+	}
+}
+
+func TestS3DownloadEncodingsAndErrors(t *testing.T) {
+	store := newFakeObjects()
+	store.objects["artifacts/logo.png"] = fakeObject{data: []byte("\x89PNG"), contentType: "image/png"}
+	store.objects["artifacts/data.json"] = fakeObject{data: []byte(`{"a":1}`), contentType: "application/json"}
+	e := &S3DownloadExecutor{store: store, maxBytes: 16}
+	ctx := context.Background()
+
+	tests := []struct {
+		key, encoding string
+		want          interface{}
+	}{
+		{"logo.png", "base64", "iVBORw=="},
+		{"data.json", "", map[string]interface{}{"a": 1.0}},
+		{"data.json", "text", `{"a":1}`},
+		{"/data.json", "auto", map[string]interface{}{"a": 1.0}},
+	}
+	for _, tt := range tests {
+		out, err := e.Execute(ctx, &Node{Properties: s3Props(tt.key, map[string]interface{}{"encoding": tt.encoding})}, nil)
+		if err != nil {
+			t.Fatalf("%s as %q: %v", tt.key, tt.encoding, err)
+		}
+		if got := out.(map[string]interface{})["content"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s as %q = %#v, want %#v", tt.key, tt.encoding, got, tt.want)
+		}
+	}
+
+	if _, err := e.Execute(ctx, &Node{Properties: s3Props("missing.txt", nil)}, nil); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("missing key: err = %v, want ErrObjectNotFound", err)
+	}
+	store.objects["artifacts/big.bin"] = fakeObject{data: make([]byte, 17)}
+	if _, err := e.Execute(ctx, &Node{Properties: s3Props("big.bin", nil)}, nil); !errors.Is(err, ErrDataTooLarge) {
+		t.Errorf("oversized object: err = %v, want ErrDataTooLarge", err)
+	}
+	if _, err := e.Execute(ctx, &Node{Properties: s3Props("data.json", map[string]interface{}{"encoding": "hex"})}, nil); err == nil {
+		t.Error("unsupported encoding: expected an error")
+	}
+}
+
+func TestS3SettingsDefaultEndpoint(t *testing.T) {
+	conn, bucket, key, err := s3Settings(&Node{Properties: map[string]interface{}{"bucket": " b ", "key": "/k/v.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Endpoint != defaultS3Endpoint || bucket != "b" || key != "k/v.txt" {
+		t.Fatalf("got %+v %q %q", conn, bucket, key)
+	}
+}
@@ -0,0 +1,291 @@
+// scheduler.go - Deferred and recurring workflow executions
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+// ============================================
+// Scheduler
+// ============================================
+
+type Scheduler struct {
+	engine    *WorkflowEngine
+	clock     Clock
+	mu        sync.Mutex
+	jobs      map[string]*scheduledJob
+	recurring map[string]context.CancelFunc // workflowID -> stop
+	cursors   map[string]*pollCursor        // workflowID/nodeID -> seen items
+}
+
+type scheduledJob struct {
+	id         string
+	workflowID string
+	owner      string
+	runAt      time.Time
+	input      interface{}
+}
+
+func NewScheduler(engine *WorkflowEngine, clock Clock) *Scheduler {
+	return &Scheduler{
+		engine:    engine,
+		clock:     clock,
+		jobs:      make(map[string]*scheduledJob),
+		recurring: make(map[string]context.CancelFunc),
+		cursors:   make(map[string]*pollCursor),
+	}
+}
+
+// ScheduleOnce queues a single execution of a workflow after delay and
+// returns the job ID. The run is scoped to the principal in ctx.
+func (s *Scheduler) ScheduleOnce(ctx context.Context, workflowID string, delay time.Duration, input interface{}) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := &scheduledJob{
+		id:         uuid.New().String(),
+		workflowID: workflowID,
+		owner:      principalFromContext(ctx),
+		runAt:      s.clock.Now().Add(delay),
+		input:      input,
+	}
+	s.jobs[job.id] = job
+
+	fire := s.clock.After(delay)
+	go func() {
+		<-fire
+		s.fire(job)
+	}()
+	return job.id
+}
+
+// Pending returns the number of one-shot jobs that have not fired yet.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// ScheduleWorkflow starts a recurring run for every timer node in w, and a
+// poller for every poll node, replacing any schedule the workflow already
+// had, so edits re-parse their cron expressions and intervals. It returns
+// the number of timers and pollers started.
+func (s *Scheduler) ScheduleWorkflow(w *Workflow) int {
+	s.UnscheduleWorkflow(w.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := 0
+	for _, node := range w.Nodes {
+		if node.Type == NodePoll {
+			interval, err := pollInterval(&node)
+			if err != nil {
+				s.engine.logger.Warn("poll node not scheduled", "workflow_id", w.ID, "node_id", node.ID, "error", err)
+				continue
+			}
+			go s.poll(ctx, w, node, interval)
+			started++
+			continue
+		}
+		if node.Type != NodeTimer {
+			continue
+		}
+		schedule, err := timerSchedule(&node)
+		if err != nil {
+			s.engine.logger.Warn("timer node not scheduled", "workflow_id", w.ID, "node_id", node.ID, "error", err)
+			continue
+		}
+		go s.run(ctx, w.ID, schedule)
+		started++
+	}
+
+	if started == 0 {
+		cancel()
+		return 0
+	}
+
+	s.mu.Lock()
+	s.recurring[w.ID] = cancel
+	s.mu.Unlock()
+	return started
+}
+
+// UnscheduleWorkflow stops the recurring runs of a workflow. A tick that is
+// already executing finishes, but no further runs are queued.
+func (s *Scheduler) UnscheduleWorkflow(workflowID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, ok := s.recurring[workflowID]; ok {
+		cancel()
+		delete(s.recurring, workflowID)
+	}
+}
+
+// Stop cancels every recurring schedule and every pending one-shot job.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, cancel := range s.recurring {
+		cancel()
+		delete(s.recurring, id)
+	}
+	for id := range s.jobs {
+		delete(s.jobs, id)
+	}
+}
+
+// IsScheduled reports whether a workflow has recurring runs.
+func (s *Scheduler) IsScheduled(workflowID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.recurring[workflowID]
+	return ok
+}
+
+func (s *Scheduler) run(ctx context.Context, workflowID string, schedule cron.Schedule) {
+	for {
+		now := s.clock.Now()
+		tick := s.clock.After(schedule.Next(now).Sub(now))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			// A tick may race with cancellation; never run once stopped
+			if ctx.Err() != nil {
+				return
+			}
+			_, err := s.engine.ExecuteWorkflowWithInput(context.Background(), workflowID, nil)
+			switch {
+			case errors.Is(err, ErrWorkflowBusy):
+				s.engine.logger.Info("scheduled execution skipped: workflow still running", "workflow_id", workflowID)
+			case err != nil:
+				s.engine.logger.Error("scheduled execution failed", "workflow_id", workflowID, "error", err)
+			}
+		}
+	}
+}
+
+// timerSchedule reads a timer node's schedule. The cron property takes
+// precedence and accepts standard 5-field expressions as well as
+// descriptors such as "@every 5m" and "@hourly"; otherwise interval is a
+// period in seconds.
+func timerSchedule(node *Node) (cron.Schedule, error) {
+	if spec, _ := node.Properties["cron"].(string); strings.TrimSpace(spec) != "" {
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+		}
+		return schedule, nil
+	}
+
+	interval, err := parseDelay(node.Properties["interval"])
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	return cron.Every(interval), nil
+}
+
+func (s *Scheduler) fire(job *scheduledJob) {
+	s.mu.Lock()
+	_, pending := s.jobs[job.id]
+	delete(s.jobs, job.id)
+	s.mu.Unlock()
+
+	// Jobs dropped by Stop still wake up; skip them
+	if !pending {
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), principalKey, job.owner)
+	if _, err := s.engine.ExecuteWorkflowWithInput(ctx, job.workflowID, job.input); err != nil {
+		s.engine.logger.Error("follow-up execution failed", "workflow_id", job.workflowID, "job_id", job.id, "error", err)
+	}
+}
+
+// ============================================
+// Schedule Follow-up Node
+// ============================================
+
+// ScheduleFollowUpExecutor registers a one-shot future execution of a
+// workflow. Without a workflowId property the current workflow re-runs.
+type ScheduleFollowUpExecutor struct {
+	scheduler *Scheduler
+}
+
+func (e *ScheduleFollowUpExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	workflowID, _ := node.Properties["workflowId"].(string)
+	if workflowID == "" {
+		workflowID = workflowIDFromContext(ctx)
+	}
+	if workflowID == "" {
+		return nil, fmt.Errorf("no workflow to schedule")
+	}
+
+	delay, err := parseDelay(node.Properties["delay"])
+	if err != nil {
+		return nil, err
+	}
+	if delay < 0 {
+		return nil, fmt.Errorf("delay must not be negative")
+	}
+
+	payload := input
+	switch v := node.Properties["input"].(type) {
+	case string:
+		if v != "" {
+			if err := json.Unmarshal([]byte(v), &payload); err != nil {
+				return nil, fmt.Errorf("invalid input JSON: %v", err)
+			}
+		}
+	case nil:
+	default:
+		payload = v
+	}
+
+	jobID := e.scheduler.ScheduleOnce(ctx, workflowID, delay, payload)
+	runAt := e.scheduler.clock.Now().Add(delay)
+
+	return map[string]interface{}{
+		"status":      "followup_scheduled",
+		"job_id":      jobID,
+		"workflow_id": workflowID,
+		"run_at":      runAt,
+	}, nil
+}
+
+// parseDelay accepts a Go duration string ("90s", "1h") or a number of
+// seconds.
+func parseDelay(v interface{}) (time.Duration, error) {
+	switch d := v.(type) {
+	case float64:
+		return time.Duration(d * float64(time.Second)), nil
+	case string:
+		if dur, err := time.ParseDuration(d); err == nil {
+			return dur, nil
+		}
+		secs, err := strconv.ParseFloat(d, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid delay: %q", d)
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	case nil:
+		return 0, fmt.Errorf("delay is required")
+	default:
+		return 0, fmt.Errorf("invalid delay: %v", v)
+	}
+}
@@ -0,0 +1,287 @@
+// scheduler_test.go - Schedule, follow-up and delay tests
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleFollowUpFiresLater(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	rec := &recorder{}
+	we := newTestEngine(t, WithClock(clock), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+
+	target := mustCreate(t, we, ctx, &Workflow{Name: "target", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+	source := mustCreate(t, we, ctx, &Workflow{Name: "source", Nodes: []Node{{
+		ID:   "f",
+		Type: NodeScheduleFollowUp,
+		Properties: map[string]interface{}{
+			"workflowId": target.ID,
+			"delay":      "1h",
+			"input":      `{"ticket": 42}`,
+		},
+	}}})
+
+	result, err := we.ExecuteWorkflow(ctx, source.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	out := result.Results["f"].(map[string]interface{})
+	if out["status"] != "followup_scheduled" || out["workflow_id"] != target.ID {
+		t.Fatalf("output = %v", out)
+	}
+	if n := we.scheduler.Pending(); n != 1 {
+		t.Fatalf("pending = %d, want 1", n)
+	}
+
+	clock.Advance(59 * time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	if calls := rec.calls(); len(calls) != 0 {
+		t.Fatalf("follow-up fired early with %v", calls)
+	}
+
+	clock.Advance(time.Minute)
+	eventually(t, "follow-up run", func() bool { return len(rec.calls()) == 1 })
+	if got, want := rec.calls()[0], map[string]interface{}{"ticket": 42.0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("follow-up input = %v, want %v", got, want)
+	}
+	if n := we.scheduler.Pending(); n != 0 {
+		t.Fatalf("pending after firing = %d, want 0", n)
+	}
+}
+
+func TestScheduleFollowUpRejectsNegativeDelay(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "source", Nodes: []Node{{
+		ID:         "f",
+		Type:       NodeScheduleFollowUp,
+		Properties: map[string]interface{}{"delay": "-5m"},
+	}}})
+
+	result, err := we.ExecuteWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed || len(result.Errors) == 0 || !strings.Contains(result.Errors[0], "negative") {
+		t.Fatalf("status = %s, errors %v; want a negative delay failure", result.Status, result.Errors)
+	}
+	if n := we.scheduler.Pending(); n != 0 {
+		t.Fatalf("pending = %d, want 0", n)
+	}
+}
+
+func TestActivateSchedulesTimerWorkflow(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	rec := &recorder{}
+	we := newTestEngine(t, WithClock(clock), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "every minute",
+		Nodes: []Node{
+			{ID: "timer", Type: NodeTimer, Properties: map[string]interface{}{"interval": 60.0}},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "timer", ToID: "r"}},
+	})
+	if we.scheduler.IsScheduled(wf.ID) {
+		t.Fatal("inactive workflow is scheduled")
+	}
+
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !we.scheduler.IsScheduled(wf.ID) {
+		t.Fatal("activated workflow is not scheduled")
+	}
+	awaitWaiters(t, clock, 1)
+	clock.Advance(time.Minute)
+	eventually(t, "scheduled run", func() bool { return len(rec.calls()) == 1 })
+
+	if err := we.DeactivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if we.scheduler.IsScheduled(wf.ID) {
+		t.Fatal("deactivated workflow is still scheduled")
+	}
+	clock.Advance(5 * time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	if n := len(rec.calls()); n != 1 {
+		t.Fatalf("deactivated workflow ran %d times, want 1", n)
+	}
+	if stored, _ := we.GetWorkflow(ctx, wf.ID); stored.Status != StatusInactive {
+		t.Fatalf("status = %s, want inactive", stored.Status)
+	}
+}
+
+func TestTimerSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 2, 30, 0, time.UTC)
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		next  time.Time
+	}{
+		{"five-field cron", map[string]interface{}{"cron": "*/5 * * * *"}, time.Date(2024, 1, 1, 9, 5, 0, 0, time.UTC)},
+		{"every descriptor", map[string]interface{}{"cron": "@every 30s"}, now.Add(30 * time.Second)},
+		{"hourly descriptor", map[string]interface{}{"cron": "@hourly"}, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"cron wins over interval", map[string]interface{}{"cron": "0 12 * * *", "interval": 10.0}, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"interval seconds", map[string]interface{}{"interval": 90.0}, now.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		schedule, err := timerSchedule(&Node{Properties: tt.props})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := schedule.Next(now); !got.Equal(tt.next) {
+			t.Errorf("%s: next = %v, want %v", tt.name, got, tt.next)
+		}
+	}
+
+	for _, props := range []map[string]interface{}{
+		{"cron": "61 * * * *"},
+		{"cron": "* * *"},
+		{"interval": 0.0},
+		{},
+	} {
+		if _, err := timerSchedule(&Node{Properties: props}); err == nil {
+			t.Errorf("timerSchedule(%v): expected an error", props)
+		}
+	}
+}
+
+func TestCronScheduleFollowsUpdatesAndDeletion(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	rec := &recorder{}
+	we := newTestEngine(t, WithClock(clock), WithNodeExecutor(nodeRecord, rec))
+	ctx := context.Background()
+	cronWorkflow := func(spec string) *Workflow {
+		return &Workflow{
+			Name: "cron",
+			Nodes: []Node{
+				{ID: "timer", Type: NodeTimer, Properties: map[string]interface{}{"cron": spec}},
+				{ID: "r", Type: nodeRecord},
+			},
+			Connections: []Connection{{ID: "c", FromID: "timer", ToID: "r"}},
+		}
+	}
+	wf := mustCreate(t, we, ctx, cronWorkflow("*/5 * * * *"))
+	if _, err := we.ActivateWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	awaitWaiters(t, clock, 1)
+	clock.Advance(5 * time.Minute)
+	eventually(t, "first cron run", func() bool { return len(rec.calls()) == 1 })
+
+	// Switching to hourly must drop the five-minute schedule
+	edited := cronWorkflow("0 * * * *")
+	edited.ID = wf.ID
+	if err := we.UpdateWorkflow(ctx, edited); err != nil {
+		t.Fatal(err)
+	}
+	awaitWaiters(t, clock, 2)
+	clock.Advance(5 * time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	if n := len(rec.calls()); n != 1 {
+		t.Fatalf("old schedule still fired: %d runs, want 1", n)
+	}
+	clock.Advance(50 * time.Minute)
+	eventually(t, "hourly run", func() bool { return len(rec.calls()) == 2 })
+
+	if err := we.DeleteWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if we.scheduler.IsScheduled(wf.ID) {
+		t.Fatal("deleted workflow is still scheduled")
+	}
+	clock.Advance(2 * time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	if n := len(rec.calls()); n != 2 {
+		t.Fatalf("deleted workflow ran %d times, want 2", n)
+	}
+}
+
+func TestParseDelay(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want time.Duration
+	}{
+		{"5s", 5 * time.Second},
+		{"2m", 2 * time.Minute},
+		{"1h", time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"1.5", 1500 * time.Millisecond},
+		{30.0, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := parseDelay(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseDelay(%v) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []interface{}{nil, "soon", "5 minutes", true} {
+		if _, err := parseDelay(bad); err == nil {
+			t.Errorf("parseDelay(%v): expected an error", bad)
+		}
+	}
+}
+
+func TestDelayPassesInputThroughAfterWaiting(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := &DelayExecutor{clock: clock}
+
+	type outcome struct {
+		out interface{}
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		out, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"duration": "2m"}}, "payload")
+		done <- outcome{out, err}
+	}()
+
+	awaitWaiters(t, clock, 1)
+	clock.Advance(time.Minute)
+	select {
+	case <-done:
+		t.Fatal("delay finished early")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(time.Minute)
+	if got := <-done; got.err != nil || got.out != "payload" {
+		t.Fatalf("delay returned %v, %v; want the input", got.out, got.err)
+	}
+}
+
+func TestDelayCancelledMidWait(t *testing.T) {
+	e := &DelayExecutor{clock: NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.Execute(ctx, &Node{Properties: map[string]interface{}{"duration": "1h"}}, nil)
+		done <- err
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delay ignored cancellation")
+	}
+
+	if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"duration": "-1s"}}, nil); err == nil {
+		t.Fatal("negative duration accepted")
+	}
+}
@@ -0,0 +1,204 @@
+// schema.go - Node output shapes for expression autocomplete
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+var ErrNodeNotFound = errors.New("node not found")
+
+// maxShapeDepth bounds how deep outputShape descends into nested values.
+const maxShapeDepth = 4
+
+// OutputDescriber is implemented by executors that can list their output
+// fields before the node has run.
+type OutputDescriber interface {
+	OutputFields(node *Node) []string
+}
+
+// Field sources, from most to least reliable.
+const (
+	FieldsFromLastRun   = "lastRun"
+	FieldsFromOutputMap = "outputMap"
+	FieldsFromDeclared  = "declared"
+	FieldsFromExecutor  = "executor"
+)
+
+// UpstreamFields lists the output fields of one node feeding another.
+type UpstreamFields struct {
+	NodeID string   `json:"nodeId"`
+	Type   NodeType `json:"type"`
+	Source string   `json:"source,omitempty"`
+	Fields []string `json:"fields"`
+}
+
+// NodeFields is what a node's expressions can reference: each upstream
+// node's fields, and the resulting paths in the node's own input, where
+// fields are prefixed by their node ID when several nodes feed it.
+type NodeFields struct {
+	NodeID   string           `json:"nodeId"`
+	Upstream []UpstreamFields `json:"upstream"`
+	Paths    []string         `json:"paths"`
+}
+
+// ============================================
+// Output Shapes
+// ============================================
+
+// outputShape lists the field paths in a sample value, e.g. "body",
+// "body.id", "items[]", "items[].name". Arrays are described by their
+// first element.
+func outputShape(v interface{}) []string {
+	var paths []string
+	var walk func(v interface{}, prefix string, depth int)
+	walk = func(v interface{}, prefix string, depth int) {
+		if depth > maxShapeDepth {
+			return
+		}
+		switch x := v.(type) {
+		case map[string]interface{}:
+			for k, item := range x {
+				path := k
+				if prefix != "" {
+					path = prefix + "." + k
+				}
+				paths = append(paths, path)
+				walk(item, path, depth+1)
+			}
+		case []interface{}:
+			if len(x) > 0 && prefix != "" {
+				paths = append(paths, prefix+"[]")
+				walk(x[0], prefix+"[]", depth+1)
+			}
+		}
+	}
+	walk(genericJSON(v), "", 0)
+	sort.Strings(paths)
+	return paths
+}
+
+// declaredFields lists a node's output fields without running it: the
+// keys of its outputMap, the shape of a sample given as its outputSchema
+// property, or what its executor describes.
+func (we *WorkflowExecutor) declaredFields(node *Node) ([]string, string) {
+	if mapping, err := objectProperty(node, "outputMap"); err == nil && mapping != nil {
+		fields := make([]string, 0, len(mapping))
+		for k := range mapping {
+			fields = append(fields, k)
+		}
+		sort.Strings(fields)
+		return fields, FieldsFromOutputMap
+	}
+	if sample, err := objectProperty(node, "outputSchema"); err == nil && sample != nil {
+		return outputShape(sample), FieldsFromDeclared
+	}
+	if exec, ok := we.executorFor(node.Type); ok {
+		if d, ok := exec.(OutputDescriber); ok {
+			fields := d.OutputFields(node)
+			sort.Strings(fields)
+			return fields, FieldsFromExecutor
+		}
+	}
+	return []string{}, ""
+}
+
+// nodeFields describes what reaches nodeID in workflow w, preferring each
+// upstream node's output in the latest run that produced one.
+func (we *WorkflowEngine) nodeFields(w *Workflow, nodeID string) (*NodeFields, error) {
+	nodes := make(map[string]*Node, len(w.Nodes))
+	for i := range w.Nodes {
+		nodes[w.Nodes[i].ID] = &w.Nodes[i]
+	}
+	if nodes[nodeID] == nil {
+		return nil, ErrNodeNotFound
+	}
+
+	runs := we.executions.List(w.OwnerID, w.ID)
+	out := &NodeFields{NodeID: nodeID, Upstream: []UpstreamFields{}, Paths: []string{}}
+	seen := make(map[string]bool)
+	for _, c := range w.Connections {
+		src := nodes[c.FromID]
+		if c.ToID != nodeID || src == nil || seen[src.ID] {
+			continue
+		}
+		seen[src.ID] = true
+
+		uf := UpstreamFields{NodeID: src.ID, Type: src.Type}
+		for _, run := range runs {
+			if output, ok := run.Results[src.ID]; ok {
+				uf.Fields, uf.Source = outputShape(output), FieldsFromLastRun
+				break
+			}
+		}
+		if uf.Source == "" {
+			uf.Fields, uf.Source = we.executor.declaredFields(src)
+		}
+		out.Upstream = append(out.Upstream, uf)
+	}
+
+	for _, uf := range out.Upstream {
+		if len(out.Upstream) == 1 {
+			out.Paths = append(out.Paths, uf.Fields...)
+			continue
+		}
+		out.Paths = append(out.Paths, uf.NodeID)
+		for _, f := range uf.Fields {
+			out.Paths = append(out.Paths, uf.NodeID+"."+f)
+		}
+	}
+	return out, nil
+}
+
+// ============================================
+// Declared Outputs
+// ============================================
+
+func (e *HTTPExecutor) OutputFields(node *Node) []string {
+	if p, _ := paginateProperty(node); p != nil {
+		return []string{"status", "pages", "items", "items[]"}
+	}
+	return []string{"status", "headers", "body"}
+}
+
+func (e *WebhookExecutor) OutputFields(node *Node) []string {
+	return []string{"status", "path", "body"}
+}
+
+func (e *ConditionExecutor) OutputFields(node *Node) []string {
+	return []string{"status", "condition", "result"}
+}
+
+func (e *EmailExecutor) OutputFields(node *Node) []string {
+	return []string{"status", "to", "subject"}
+}
+
+func (e *ExecExecutor) OutputFields(node *Node) []string {
+	return []string{"command", "stdout", "stderr", "exitCode"}
+}
+
+// ============================================
+// Fields Handler
+// ============================================
+
+// handleNodeFields lists the fields a node's expressions can reference,
+// for editor autocomplete.
+func (s *Server) handleNodeFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workflow, err := s.engine.GetWorkflow(r.Context(), vars["id"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	fields, err := s.engine.nodeFields(workflow, vars["nodeId"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fields)
+}
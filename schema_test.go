@@ -0,0 +1,123 @@
+// schema_test.go - Node output shape and upstream field tests
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOutputShape(t *testing.T) {
+	got := outputShape(map[string]interface{}{
+		"id":    1,
+		"items": []interface{}{map[string]interface{}{"name": "a"}},
+		"empty": []interface{}{},
+		"deep":  map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{"d": map[string]interface{}{"e": 1}}}}},
+	})
+	want := []string{"deep", "deep.a", "deep.a.b", "deep.a.b.c", "deep.a.b.c.d", "empty", "id", "items", "items[]", "items[].name"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("outputShape = %v, want %v", got, want)
+	}
+	if got := outputShape("scalar"); len(got) != 0 {
+		t.Fatalf("scalar shape = %v, want none", got)
+	}
+}
+
+func TestUpstreamFieldsForTransformFedByHTTP(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 7, "tags": [{"name": "vip"}]}`))
+	}))
+	defer api.Close()
+
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, &Workflow{
+		Name: "fetch and shape",
+		Nodes: []Node{
+			{ID: "http", Type: NodeHTTP, Properties: map[string]interface{}{"url": api.URL, "method": "GET"}},
+			{ID: "shape", Type: NodeTransform},
+		},
+		Connections: []Connection{{ID: "c1", FromID: "http", ToID: "shape"}},
+	})
+	fields := func() NodeFields {
+		t.Helper()
+		resp, data := doRequest(t, ts, "GET", "/api/workflows/"+wf.ID+"/nodes/shape/fields", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("fields: %d %s", resp.StatusCode, data)
+		}
+		var nf NodeFields
+		decode(t, data, &nf)
+		return nf
+	}
+
+	// Before any run the HTTP executor describes its output
+	before := fields()
+	if len(before.Upstream) != 1 || before.Upstream[0].NodeID != "http" || before.Upstream[0].Source != FieldsFromExecutor {
+		t.Fatalf("before run = %+v", before)
+	}
+	if want := []string{"body", "headers", "status"}; !reflect.DeepEqual(before.Paths, want) {
+		t.Fatalf("before run paths = %v, want %v", before.Paths, want)
+	}
+
+	if result, err := s.engine.ExecuteWorkflow(ctx, wf.ID); err != nil || result.Status != StatusCompleted {
+		t.Fatalf("run: %v %+v", err, result)
+	}
+	after := fields()
+	if after.Upstream[0].Source != FieldsFromLastRun {
+		t.Fatalf("after run source = %q, want %q", after.Upstream[0].Source, FieldsFromLastRun)
+	}
+	for _, want := range []string{"body.id", "body.tags[]", "body.tags[].name", "status"} {
+		found := false
+		for _, p := range after.Paths {
+			found = found || p == want
+		}
+		if !found {
+			t.Errorf("after run paths %v lack %q", after.Paths, want)
+		}
+	}
+
+	if resp, _ := doRequest(t, ts, "GET", "/api/workflows/"+wf.ID+"/nodes/nope/fields", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown node: %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestDeclaredFieldsAndSeveralUpstreams(t *testing.T) {
+	we := newTestEngine(t)
+	w := &Workflow{
+		ID: "w",
+		Nodes: []Node{
+			{ID: "mapped", Type: NodeTransform, Properties: map[string]interface{}{"outputMap": map[string]interface{}{"total": "a", "count": "b"}}},
+			{ID: "sampled", Type: NodeTransform, Properties: map[string]interface{}{"outputSchema": map[string]interface{}{"user": map[string]interface{}{"email": "x"}}}},
+			{ID: "merge", Type: NodeMerge},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "mapped", ToID: "merge"},
+			{ID: "c2", FromID: "sampled", ToID: "merge"},
+		},
+	}
+
+	nf, err := we.nodeFields(w, "merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UpstreamFields{
+		{NodeID: "mapped", Type: NodeTransform, Source: FieldsFromOutputMap, Fields: []string{"count", "total"}},
+		{NodeID: "sampled", Type: NodeTransform, Source: FieldsFromDeclared, Fields: []string{"user", "user.email"}},
+	}
+	if !reflect.DeepEqual(nf.Upstream, want) {
+		t.Fatalf("upstream = %+v, want %+v", nf.Upstream, want)
+	}
+	// Several inputs are keyed by node ID
+	wantPaths := []string{"mapped", "mapped.count", "mapped.total", "sampled", "sampled.user", "sampled.user.email"}
+	if !reflect.DeepEqual(nf.Paths, wantPaths) {
+		t.Fatalf("paths = %v, want %v", nf.Paths, wantPaths)
+	}
+
+	if _, err := we.nodeFields(w, "missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown node: err = %v, want ErrNodeNotFound", err)
+	}
+}
@@ -0,0 +1,289 @@
+// secrets.go - Per-owner secrets referenced from node properties
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// redactedValue replaces secret values in results and logs.
+const redactedValue = "[REDACTED]"
+
+const secretsKey contextKey = "secrets"
+
+var (
+	secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// secretRefPattern matches {{secrets.NAME}} in node properties
+	secretRefPattern = regexp.MustCompile(`\{\{\s*secrets\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// ============================================
+// Secret Store
+// ============================================
+
+// SecretStore keeps secret values in memory, scoped by owner. Values are
+// only read by the executor; the API lists names.
+type SecretStore struct {
+	mu      sync.RWMutex
+	secrets map[string]map[string]string
+}
+
+func NewSecretStore() *SecretStore {
+	return &SecretStore{
+		secrets: make(map[string]map[string]string),
+	}
+}
+
+func (ss *SecretStore) Set(owner, name, value string) error {
+	if !secretNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits and underscores", name)
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.secrets[owner] == nil {
+		ss.secrets[owner] = make(map[string]string)
+	}
+	ss.secrets[owner][name] = value
+	return nil
+}
+
+func (ss *SecretStore) Delete(owner, name string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.secrets[owner][name]; !ok {
+		return ErrSecretNotFound
+	}
+	delete(ss.secrets[owner], name)
+	return nil
+}
+
+// Names lists owner's secret names, sorted.
+func (ss *SecretStore) Names(owner string) []string {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	names := make([]string, 0, len(ss.secrets[owner]))
+	for name := range ss.secrets[owner] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot copies owner's secrets for one execution.
+func (ss *SecretStore) Snapshot(owner string) map[string]string {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	values := make(map[string]string, len(ss.secrets[owner]))
+	for name, value := range ss.secrets[owner] {
+		values[name] = value
+	}
+	return values
+}
+
+// ============================================
+// Resolution and Redaction
+// ============================================
+
+// resolveSecrets returns a copy of node whose properties have every
+// {{secrets.NAME}} reference replaced by its value. Nodes without
+// references are returned as they are.
+func resolveSecrets(node *Node, values map[string]string) (*Node, error) {
+	var missing []string
+	var resolve func(v interface{}) interface{}
+	resolve = func(v interface{}) interface{} {
+		switch x := v.(type) {
+		case string:
+			return secretRefPattern.ReplaceAllStringFunc(x, func(ref string) string {
+				name := secretRefPattern.FindStringSubmatch(ref)[1]
+				value, ok := values[name]
+				if !ok {
+					missing = append(missing, name)
+				}
+				return value
+			})
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(x))
+			for k, item := range x {
+				out[k] = resolve(item)
+			}
+			return out
+		case []interface{}:
+			out := make([]interface{}, len(x))
+			for i, item := range x {
+				out[i] = resolve(item)
+			}
+			return out
+		}
+		return v
+	}
+
+	if !hasSecretRefs(node.Properties) {
+		return node, nil
+	}
+	resolved := *node
+	resolved.Properties = resolve(node.Properties).(map[string]interface{})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unknown secret: %s", strings.Join(missing, ", "))
+	}
+	return &resolved, nil
+}
+
+func hasSecretRefs(v interface{}) bool {
+	switch x := v.(type) {
+	case string:
+		return secretRefPattern.MatchString(x)
+	case map[string]interface{}:
+		for _, item := range x {
+			if hasSecretRefs(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range x {
+			if hasSecretRefs(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newRedactor masks every secret value, longest first so a secret that
+// contains another is masked whole. It returns nil when there is nothing
+// to mask.
+func newRedactor(values map[string]string) *strings.Replacer {
+	secrets := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, s := range secrets {
+		pairs = append(pairs, s, redactedValue)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// executionSecrets are the secrets one execution may use.
+type executionSecrets struct {
+	values   map[string]string
+	redactor *strings.Replacer
+}
+
+func withSecrets(ctx context.Context, values map[string]string) context.Context {
+	return context.WithValue(ctx, secretsKey, &executionSecrets{
+		values:   values,
+		redactor: newRedactor(values),
+	})
+}
+
+func secretsFromContext(ctx context.Context) map[string]string {
+	if es, ok := ctx.Value(secretsKey).(*executionSecrets); ok {
+		return es.values
+	}
+	return nil
+}
+
+func redactorFromContext(ctx context.Context) *strings.Replacer {
+	if es, ok := ctx.Value(secretsKey).(*executionSecrets); ok {
+		return es.redactor
+	}
+	return nil
+}
+
+// redactString masks secrets known to ctx's execution in s.
+func redactString(ctx context.Context, s string) string {
+	if r := redactorFromContext(ctx); r != nil {
+		return r.Replace(s)
+	}
+	return s
+}
+
+// redactError masks secrets in err's message, keeping err itself when
+// there is nothing to mask so errors.Is still works.
+func redactError(ctx context.Context, err error) error {
+	if msg := redactString(ctx, err.Error()); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
+
+// redactValue masks secrets in every string inside v.
+func redactValue(ctx context.Context, v interface{}) interface{} {
+	if redactorFromContext(ctx) == nil {
+		return v
+	}
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch x := v.(type) {
+		case string:
+			return redactString(ctx, x)
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(x))
+			for k, item := range x {
+				out[k] = walk(item)
+			}
+			return out
+		case []interface{}:
+			out := make([]interface{}, len(x))
+			for i, item := range x {
+				out[i] = walk(item)
+			}
+			return out
+		}
+		return v
+	}
+	return walk(genericJSON(v))
+}
+
+// ============================================
+// Secret Handlers
+// ============================================
+
+// handleListSecrets lists the caller's secret names; values are never
+// returned.
+func (s *Server) handleListSecrets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.engine.secrets.Names(principalFromContext(r.Context())))
+}
+
+func (s *Server) handleSetSecret(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := decodeJSON(r.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+	name := mux.Vars(r)["name"]
+	if err := s.engine.secrets.Set(principalFromContext(r.Context()), name, body.Value); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if err := s.engine.secrets.Delete(principalFromContext(r.Context()), name); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
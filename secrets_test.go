@@ -0,0 +1,138 @@
+// secrets_test.go - Secret resolution and redaction tests
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testSecret = "tok-9f8e7d6c5b4a"
+
+func TestSecretResolvesAndIsRedacted(t *testing.T) {
+	var mu sync.Mutex
+	var sent string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = r.Header.Get("Authorization")
+		mu.Unlock()
+		// echo the credential back, as a careless API might
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"you_sent": "` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer api.Close()
+
+	_, ts := newTestServer(t, ServerConfig{APIKeys: map[string]string{"k-alice": "alice", "k-bob": "bob"}})
+	alice := []string{"Authorization", "Bearer k-alice"}
+	bob := []string{"Authorization", "Bearer k-bob"}
+
+	if resp, data := doRequest(t, ts, "PUT", "/api/secrets/API_TOKEN", map[string]string{"value": testSecret}, alice...); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("set secret: %d %s", resp.StatusCode, data)
+	}
+	_, data := doRequest(t, ts, "GET", "/api/secrets", nil, alice...)
+	if strings.TrimSpace(string(data)) != `["API_TOKEN"]` {
+		t.Fatalf("list = %s, want names only", data)
+	}
+
+	flow := map[string]interface{}{
+		"name": "call api",
+		"nodes": []interface{}{map[string]interface{}{
+			"id":   "call",
+			"type": "http",
+			"properties": map[string]interface{}{
+				"url":     api.URL,
+				"method":  "GET",
+				"headers": map[string]interface{}{"Authorization": "Bearer {{secrets.API_TOKEN}}"},
+			},
+		}},
+	}
+	_, data = doRequest(t, ts, "POST", "/api/workflows", flow, alice...)
+	var wf Workflow
+	decode(t, data, &wf)
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil, alice...)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("execute: %d %s", resp.StatusCode, data)
+	}
+	mu.Lock()
+	received := sent
+	mu.Unlock()
+	if received != "Bearer "+testSecret {
+		t.Fatalf("API received %q; the secret was not resolved", received)
+	}
+	if bytes.Contains(data, []byte(testSecret)) {
+		t.Fatalf("execution result leaks the secret: %s", data)
+	}
+	var result ExecutionResult
+	decode(t, data, &result)
+	body := result.Results["call"].(map[string]interface{})["body"].(map[string]interface{})
+	if body["you_sent"] != "Bearer "+redactedValue {
+		t.Fatalf("echoed credential = %v, want it redacted", body["you_sent"])
+	}
+
+	for _, path := range []string{"/api/workflows/" + wf.ID, "/api/executions/" + result.ID, "/api/executions/" + result.ID + "/logs"} {
+		_, data := doRequest(t, ts, "GET", path, nil, alice...)
+		if bytes.Contains(data, []byte(testSecret)) {
+			t.Errorf("GET %s leaks the secret: %s", path, data)
+		}
+	}
+
+	// Secrets belong to their owner: bob neither sees nor resolves them
+	_, data = doRequest(t, ts, "GET", "/api/secrets", nil, bob...)
+	if strings.TrimSpace(string(data)) != `[]` {
+		t.Fatalf("bob's list = %s", data)
+	}
+	_, data = doRequest(t, ts, "POST", "/api/workflows", flow, bob...)
+	decode(t, data, &wf)
+	_, data = doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil, bob...)
+	decode(t, data, &result)
+	if result.Status != StatusFailed || len(result.Errors) == 0 || !strings.Contains(result.Errors[0], "unknown secret: API_TOKEN") {
+		t.Fatalf("bob's run = %s %v, want an unknown secret error", result.Status, result.Errors)
+	}
+}
+
+func TestSecretRedactedFromErrorsAndLogs(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	doRequest(t, ts, "PUT", "/api/secrets/API_TOKEN", map[string]string{"value": testSecret})
+
+	// The failing request's error names the URL, secret and all
+	_, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{
+		"name": "leaky url",
+		"nodes": []interface{}{map[string]interface{}{
+			"id":         "call",
+			"type":       "http",
+			"properties": map[string]interface{}{"url": "http://127.0.0.1:1/{{secrets.API_TOKEN}}", "method": "GET"},
+		}},
+	})
+	var wf Workflow
+	decode(t, data, &wf)
+
+	_, data = doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil)
+	var result ExecutionResult
+	decode(t, data, &result)
+	if result.Status != StatusFailed || len(result.Errors) == 0 {
+		t.Fatalf("run = %s %v, want a failed request", result.Status, result.Errors)
+	}
+	if !strings.Contains(result.Errors[0], redactedValue) || bytes.Contains(data, []byte(testSecret)) {
+		t.Fatalf("errors = %v, want the secret redacted", result.Errors)
+	}
+	_, logs := doRequest(t, ts, "GET", "/api/executions/"+result.ID+"/logs", nil)
+	if bytes.Contains(logs, []byte(testSecret)) || !bytes.Contains(logs, []byte(redactedValue)) {
+		t.Fatalf("logs = %s, want the secret redacted", logs)
+	}
+}
+
+func TestSecretNamesAreValidated(t *testing.T) {
+	ss := NewSecretStore()
+	for _, name := range []string{"1ABC", "with-dash", "sp ace", ""} {
+		if err := ss.Set("o", name, "v"); err == nil {
+			t.Errorf("Set(%q): expected an error", name)
+		}
+	}
+	if err := ss.Delete("o", "MISSING"); err != ErrSecretNotFound {
+		t.Fatalf("Delete missing: err = %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+// setnode.go - Build objects from templated fields
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// ============================================
+// Set Node
+// ============================================
+
+// SetExecutor builds an object from its fields property, a map of output
+// field to value given inline or as JSON. String values are templates
+// rendered against the input ("{{ user.first }} {{ user.last }}"); other
+// values are copied as they are. With mode "merge" the fields are laid
+// over the input object; the default "replace" outputs only the fields.
+type SetExecutor struct{}
+
+func (e *SetExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	fields, err := objectProperty(node, "fields")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, len(fields))
+	switch mode, _ := node.Properties["mode"].(string); mode {
+	case "", "replace":
+	case "merge":
+		if m, ok := genericJSON(input).(map[string]interface{}); ok {
+			for k, v := range m {
+				out[k] = v
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported set mode: %q", mode)
+	}
+
+	for field, v := range fields {
+		tmpl, ok := v.(string)
+		if !ok {
+			out[field] = v
+			continue
+		}
+		rendered, err := renderTemplate(tmpl, input)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", field, err)
+		}
+		out[field] = rendered
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+// setnode_test.go - Set node tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSetBuildsObjects(t *testing.T) {
+	input := map[string]interface{}{
+		"user":  map[string]interface{}{"first": "Ada", "last": "Lovelace", "age": 36.0},
+		"tags":  []interface{}{"math", "engines"},
+		"extra": "kept on merge",
+	}
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{
+			name:  "rename fields",
+			props: map[string]interface{}{"fields": map[string]interface{}{"firstName": "{{ user.first }}", "years": "{{ user.age }}"}},
+			want:  map[string]interface{}{"firstName": "Ada", "years": 36.0},
+		},
+		{
+			name:  "static values",
+			props: map[string]interface{}{"fields": map[string]interface{}{"source": "crm", "priority": 2.0, "active": true}},
+			want:  map[string]interface{}{"source": "crm", "priority": 2.0, "active": true},
+		},
+		{
+			name:  "templated values",
+			props: map[string]interface{}{"fields": `{"fullName": "{{ user.first }} {{ user.last }}", "tags": "{{ tags }}", "adult": "{{ user.age >= 18 }}"}`},
+			want:  map[string]interface{}{"fullName": "Ada Lovelace", "tags": []interface{}{"math", "engines"}, "adult": true},
+		},
+		{
+			name:  "merge with input",
+			props: map[string]interface{}{"mode": "merge", "fields": map[string]interface{}{"user": "{{ user.first }}", "source": "crm"}},
+			want:  map[string]interface{}{"user": "Ada", "tags": []interface{}{"math", "engines"}, "extra": "kept on merge", "source": "crm"},
+		},
+	}
+	for _, tt := range tests {
+		out, err := (&SetExecutor{}).Execute(context.Background(), &Node{Properties: tt.props}, input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(out, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, out, tt.want)
+		}
+	}
+}
+
+func TestSetRejectsBadProperties(t *testing.T) {
+	for name, props := range map[string]map[string]interface{}{
+		"unknown mode":   {"mode": "patch", "fields": map[string]interface{}{"a": "b"}},
+		"invalid JSON":   {"fields": "{not json"},
+		"bad template":   {"fields": map[string]interface{}{"a": "{{ user.first"}},
+		"fields not map": {"fields": 42.0},
+	} {
+		if _, err := (&SetExecutor{}).Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// shutdown_test.go - Graceful shutdown tests
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// slowFlow is a single gate node, so its runs last until the gate opens
+// or they are cancelled.
+func slowFlow(t *testing.T, we *WorkflowEngine) *Workflow {
+	return mustCreate(t, we, context.Background(), &Workflow{Name: "slow", Nodes: []Node{{ID: "g", Type: nodeGate}}})
+}
+
+// startRun executes wf in the background and waits until it is running.
+func startRun(t *testing.T, we *WorkflowEngine, wf *Workflow) <-chan *ExecutionResult {
+	t.Helper()
+	done := make(chan *ExecutionResult, 1)
+	go func() {
+		result, _ := we.ExecuteWorkflow(context.Background(), wf.ID)
+		done <- result
+	}()
+	eventually(t, "run to start", func() bool {
+		active, _, _, _ := we.pool.Stats()
+		return active == 1
+	})
+	return done
+}
+
+func TestShutdownDrainsInFlightRun(t *testing.T) {
+	release := make(gate)
+	we := newTestEngine(t, WithNodeExecutor(nodeGate, release))
+	wf := slowFlow(t, we)
+	done := startRun(t, we, wf)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- we.Shutdown(ctx)
+	}()
+
+	// Draining refuses new runs while the old one finishes
+	eventually(t, "draining", func() bool {
+		we.runMu.Lock()
+		defer we.runMu.Unlock()
+		return we.draining
+	})
+	if _, err := we.ExecuteWorkflow(context.Background(), wf.ID); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("execute while draining: err = %v, want ErrShuttingDown", err)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned %v with a run in flight", err)
+	default:
+	}
+
+	close(release)
+	if result := <-done; result.Status != StatusCompleted {
+		t.Fatalf("drained run status = %s, want completed", result.Status)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestShutdownCancelsRunsPastDeadline(t *testing.T) {
+	we := newTestEngine(t, WithNodeExecutor(nodeGate, make(gate)))
+	done := startRun(t, we, slowFlow(t, we))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := we.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("shutdown: err = %v, want deadline exceeded", err)
+	}
+	select {
+	case result := <-done:
+		if result.Status == StatusCompleted || result.Status == StatusRunning {
+			t.Fatalf("cancelled run status = %s", result.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run still going after shutdown returned")
+	}
+}
+
+func TestServerShutdownRefusesNewExecutions(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{Name: "quick", Nodes: []Node{{ID: "t", Type: NodeTransform}}})
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	resp, body := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("execute after shutdown: %d %s", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,175 @@
+// signing_test.go - Webhook signature tests
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hmacHex computes the HMAC of payload independently of signPayload.
+func hmacHex(newHash func() hash.Hash, secret string, payload []byte) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHTTPNodeSignsOutgoingBody(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Clone(), body}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		algorithm, header string
+		newHash           func() hash.Hash
+		wantHeader        string
+		prefix            string
+	}{
+		{"", "", sha256.New, "X-Signature", "sha256="},
+		{"sha1", "", sha1.New, "X-Signature", "sha1="},
+		{"SHA512", "X-Hub-Signature", sha512.New, "X-Hub-Signature", "sha512="},
+	}
+	for _, tt := range tests {
+		out, err := newHTTPExecutor().Execute(context.Background(), &Node{Properties: map[string]interface{}{
+			"url":                srv.URL,
+			"method":             "POST",
+			"body":               `{"event": "order.paid", "amount": 42}`,
+			"signingSecret":      "whsec_test",
+			"signatureHeader":    tt.header,
+			"signatureAlgorithm": tt.algorithm,
+		}}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.algorithm, err)
+		}
+		if out.(map[string]interface{})["status"] != http.StatusOK {
+			t.Fatalf("%s: output %v", tt.algorithm, out)
+		}
+		r := <-got
+		if len(r.body) == 0 {
+			t.Fatalf("%s: no body sent", tt.algorithm)
+		}
+		want := tt.prefix + hmacHex(tt.newHash, "whsec_test", r.body)
+		if sig := r.header.Get(tt.wantHeader); sig != want {
+			t.Errorf("%s: %s = %q, want %q", tt.algorithm, tt.wantHeader, sig, want)
+		}
+	}
+}
+
+func TestHTTPNodeSigningErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Signature") != "" {
+			t.Error("unsigned request carried a signature")
+		}
+	}))
+	defer srv.Close()
+
+	_, err := newHTTPExecutor().Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"url": srv.URL, "method": "POST", "body": `{}`, "signingSecret": "s", "signatureAlgorithm": "md5",
+	}}, nil)
+	if err == nil || !strings.Contains(err.Error(), `unsupported signature algorithm "md5"`) {
+		t.Fatalf("err = %v", err)
+	}
+
+	if _, err := newHTTPExecutor().Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"url": srv.URL, "method": "POST", "body": `{}`,
+	}}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInboundWebhookSignatures(t *testing.T) {
+	rec := &recorder{}
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: rec}})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "stripe-style",
+		Nodes: []Node{
+			{ID: "hook", Type: NodeWebhook, Properties: map[string]interface{}{
+				"signingSecret":      "whsec_in",
+				"signatureHeader":    "Stripe-Signature",
+				"signatureAlgorithm": "sha512",
+				"timestampHeader":    "X-Timestamp",
+				"timestampTolerance": "2m",
+			}},
+			{ID: "r", Type: nodeRecord},
+		},
+		Connections: []Connection{{ID: "c", FromID: "hook", ToID: "r"}},
+	})
+	if resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/activate", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("activate: %d %s", resp.StatusCode, data)
+	}
+	hook := "/hooks/" + wf.ID + "/hook"
+	body := `{"type": "invoice.paid"}`
+	now := time.Now()
+	sign := func(at time.Time, secret string) (string, string) {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		return ts, "sha512=" + hmacHex(sha512.New, secret, []byte(ts+"."+body))
+	}
+
+	validTS, validSig := sign(now, "whsec_in")
+	staleTS, staleSig := sign(now.Add(-10*time.Minute), "whsec_in")
+	futureTS, futureSig := sign(now.Add(10*time.Minute), "whsec_in")
+	_, wrongSecret := sign(now, "whsec_other")
+	tests := []struct {
+		name      string
+		ts, sig   string
+		status    int
+		errSubstr string
+	}{
+		{"valid", validTS, validSig, http.StatusOK, ""},
+		{"valid without prefix", validTS, strings.TrimPrefix(validSig, "sha512="), http.StatusOK, ""},
+		{"wrong secret", validTS, wrongSecret, http.StatusUnauthorized, "signature mismatch"},
+		{"tampered timestamp", strconv.FormatInt(now.Unix()-1, 10), validSig, http.StatusUnauthorized, "signature mismatch"},
+		{"expired", staleTS, staleSig, http.StatusUnauthorized, "timestamp outside tolerance"},
+		{"from the future", futureTS, futureSig, http.StatusUnauthorized, "timestamp outside tolerance"},
+		{"missing signature", validTS, "", http.StatusUnauthorized, "missing Stripe-Signature header"},
+		{"missing timestamp", "", validSig, http.StatusUnauthorized, "missing or invalid X-Timestamp header"},
+	}
+	runs := 0
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, "POST", hook, body, "X-Timestamp", tt.ts, "Stripe-Signature", tt.sig)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, resp.StatusCode, tt.status, data)
+			continue
+		}
+		if tt.status == http.StatusOK {
+			runs++
+			continue
+		}
+		if e := apiError(t, data); e.Code != CodeInvalidSignature || !strings.Contains(e.Message, tt.errSubstr) {
+			t.Errorf("%s: error = %+v, want %q", tt.name, e, tt.errSubstr)
+		}
+	}
+	if got := len(rec.calls()); got != runs {
+		t.Fatalf("%d runs started, want %d; rejected requests must not run", got, runs)
+	}
+}
+
+func TestVerifySignatureWithoutSecretAcceptsAll(t *testing.T) {
+	if err := verifySignature(&Node{Properties: map[string]interface{}{}}, http.Header{}, []byte("x"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	node := &Node{Properties: map[string]interface{}{"signingSecret": "s"}}
+	header := http.Header{}
+	header.Set("X-Signature", "sha256="+hmacHex(sha256.New, "s", []byte("x")))
+	if err := verifySignature(node, header, []byte("x"), time.Now()); err != nil {
+		t.Fatalf("default header and algorithm: %v", err)
+	}
+}
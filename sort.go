@@ -0,0 +1,135 @@
+// sort.go - Sort array items by a field
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================
+// Sort Node
+// ============================================
+
+// SortExecutor sorts the input array (or the array at the optional items
+// path) by the value at field, a path such as "price" or "user.name";
+// blank sorts by the items themselves. order is asc (default) or desc.
+//
+// The sort is stable. Numbers, and strings that parse as numbers, compare
+// numerically; other strings compare lexically; numbers sort before
+// strings, and strings before booleans. Items missing the field
+// always sort last.
+type SortExecutor struct{}
+
+func (e *SortExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	value := genericJSON(input)
+	if path, _ := node.Properties["items"].(string); strings.TrimSpace(path) != "" {
+		value, _ = lookupPath(value, path)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sort needs an array input, got %T", value)
+	}
+	field, _ := node.Properties["field"].(string)
+	field = strings.TrimSpace(field)
+	desc := false
+	switch order, _ := node.Properties["order"].(string); order {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		return nil, fmt.Errorf("order must be asc or desc, got %q", order)
+	}
+
+	keys := make([]interface{}, len(items))
+	for i, item := range items {
+		if field == "" {
+			keys[i] = item
+		} else {
+			keys[i], _ = lookupPath(item, field)
+		}
+	}
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		ka, kb := keys[idx[a]], keys[idx[b]]
+		if ka == nil || kb == nil {
+			return ka != nil
+		}
+		c := compareSortKeys(ka, kb)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	sorted := make([]interface{}, len(items))
+	for i, j := range idx {
+		sorted[i] = items[j]
+	}
+	return sorted, nil
+}
+
+// sortRank orders values of different kinds.
+func sortRank(v interface{}) int {
+	switch v.(type) {
+	case float64:
+		return 0
+	case string:
+		return 1
+	case bool:
+		return 2
+	}
+	return 3
+}
+
+// numericKey turns a string that parses as a number into that number, so
+// it ranks and compares as one. NaN stays a string, as it has no order.
+func numericKey(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && !math.IsNaN(f) {
+			return f
+		}
+	}
+	return v
+}
+
+// compareSortKeys returns -1, 0 or 1 as a sorts before, with or after b.
+// Keys compare by kind first, so the order is consistent however numeric
+// and other strings are mixed.
+func compareSortKeys(a, b interface{}) int {
+	a, b = numericKey(a), numericKey(b)
+	ra, rb := sortRank(a), sortRank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	switch x := a.(type) {
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case string:
+		return strings.Compare(x, b.(string))
+	case bool:
+		y := b.(bool)
+		if x != y {
+			if !x {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+// sort_test.go - Sort node tests
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// itemNames lists each item's name field, in order.
+func itemNames(t *testing.T, items interface{}) []string {
+	t.Helper()
+	var out []string
+	for _, item := range items.([]interface{}) {
+		name, _ := item.(map[string]interface{})["name"].(string)
+		out = append(out, name)
+	}
+	return out
+}
+
+func products() []interface{} {
+	return []interface{}{
+		map[string]interface{}{"name": "pen", "price": 2.5, "maker": map[string]interface{}{"name": "Zed"}},
+		map[string]interface{}{"name": "desk", "price": 180.0, "maker": map[string]interface{}{"name": "acme"}},
+		map[string]interface{}{"name": "lamp", "price": "25", "maker": map[string]interface{}{"name": "Acme"}},
+		map[string]interface{}{"name": "mug", "price": 9.0},
+		map[string]interface{}{"name": "chair", "price": 180.0, "maker": map[string]interface{}{"name": "Brio"}},
+	}
+}
+
+func TestSortByNumberDescending(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name:  "priciest first",
+		Nodes: []Node{{ID: "s", Type: NodeSort, Properties: map[string]interface{}{"field": "price", "order": "desc"}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, products())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	// "25" compares as a number; equal prices keep their input order
+	want := []string{"desk", "chair", "lamp", "mug", "pen"}
+	if got := itemNames(t, result.Results["s"]); !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+}
+
+func TestSortByStringAscending(t *testing.T) {
+	got, err := (&SortExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"field": "maker.name",
+	}}, products())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lexical, so upper case sorts first; mug has no maker and sorts last
+	want := []string{"lamp", "chair", "pen", "desk", "mug"}
+	if order := itemNames(t, got); !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+}
+
+func TestSortItemsPathAndMixedKinds(t *testing.T) {
+	input := map[string]interface{}{"data": map[string]interface{}{
+		"values": []interface{}{true, "b", 10.0, nil, "2", "a", 1.0, false},
+	}}
+	tests := []struct {
+		order string
+		want  []interface{}
+	}{
+		{"asc", []interface{}{1.0, "2", 10.0, "a", "b", false, true, nil}},
+		{"desc", []interface{}{true, false, "b", "a", 10.0, "2", 1.0, nil}},
+	}
+	for _, tt := range tests {
+		got, err := (&SortExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{
+			"items": "data.values", "order": tt.order,
+		}}, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortRejectsBadInput(t *testing.T) {
+	e := &SortExecutor{}
+	if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{}}, map[string]interface{}{"a": 1.0}); err == nil {
+		t.Error("object input: expected an error")
+	}
+	if _, err := e.Execute(context.Background(), &Node{Properties: map[string]interface{}{"order": "random"}}, []interface{}{}); err == nil {
+		t.Error("unknown order: expected an error")
+	}
+}
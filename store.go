@@ -0,0 +1,340 @@
+// store.go - Workflow persistence
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	ErrWorkflowExists   = errors.New("workflow id already exists")
+)
+
+// ============================================
+// Store
+// ============================================
+
+// Store persists workflows. Implementations must be safe for concurrent use.
+// Create refuses an ID already in use, deleted or not, with
+// ErrWorkflowExists.
+// Get and List filter by owner; an empty ownerID matches every workflow, and
+// a workflow owned by someone else is reported as ErrWorkflowNotFound.
+// Get returns soft-deleted workflows (DeletedAt set) so they can be restored;
+// List includes them only when asked. Delete removes a workflow for good,
+// along with its versions.
+//
+// SaveVersion records a snapshot of a workflow's definition before it is
+// edited; Versions returns a workflow's snapshots newest first.
+// Implementations may drop the oldest snapshots beyond a retention limit.
+//
+// SaveCheckpoint records an execution's progress, replacing its previous
+// checkpoint, and UpdateCheckpoint adds one step to it, reporting
+// ErrCheckpointNotFound when there is none; Checkpoint filters by owner
+// like Get and reports
+// ErrCheckpointNotFound. Checkpoints lists every saved checkpoint, so a
+// restarted engine can resume interrupted executions.
+//
+// SaveApproval records an execution waiting at an approval node, so a
+// resumed run keeps waiting under the same resume token; Approvals lists
+// them and DeleteApproval drops one once decided.
+//
+// SavePollCursor records which items a poll node has seen, so a restart
+// does not trigger runs for them again; DeletePollCursors drops a purged
+// workflow's cursors.
+type Store interface {
+	Create(w *Workflow) error
+	Get(ownerID, id string) (*Workflow, error)
+	Update(w *Workflow) error
+	Delete(id string) error
+	List(ownerID string, includeDeleted bool) ([]*Workflow, error)
+	SaveVersion(w *Workflow) error
+	Versions(id string) ([]*Workflow, error)
+	SaveCheckpoint(cp *Checkpoint) error
+	UpdateCheckpoint(u CheckpointUpdate) error
+	Checkpoint(ownerID, executionID string) (*Checkpoint, error)
+	Checkpoints() ([]*Checkpoint, error)
+	DeleteCheckpoint(executionID string) error
+	SaveApproval(p *PendingApproval) error
+	Approvals() ([]*PendingApproval, error)
+	DeleteApproval(id string) error
+	SavePollCursor(c *PollCursor) error
+	PollCursor(id string) (*PollCursor, error)
+	DeletePollCursors(workflowID string) error
+}
+
+// PollCursor is the saved state of a poll node, identified by
+// "workflowID/nodeID": the source it polls and the item keys it has seen,
+// oldest first.
+type PollCursor struct {
+	ID     string   `json:"id"`
+	Source string   `json:"source"`
+	Seen   []string `json:"seen"`
+}
+
+// defaultVersionRetention is how many versions of each workflow
+// MemoryStore keeps.
+const defaultVersionRetention = 50
+
+// MemoryStore keeps workflows in a map; contents are lost on restart.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	workflows map[string]*Workflow
+	// versions holds each workflow's snapshots, oldest first
+	versions    map[string][]*Workflow
+	maxVersions int
+	checkpoints map[string]*Checkpoint
+	approvals   map[string]*PendingApproval
+	cursors     map[string]*PollCursor
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		workflows:   make(map[string]*Workflow),
+		versions:    make(map[string][]*Workflow),
+		maxVersions: defaultVersionRetention,
+		checkpoints: make(map[string]*Checkpoint),
+		approvals:   make(map[string]*PendingApproval),
+		cursors:     make(map[string]*PollCursor),
+	}
+}
+
+func (ms *MemoryStore) Create(w *Workflow) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.workflows[w.ID]; exists {
+		return ErrWorkflowExists
+	}
+	ms.workflows[w.ID] = w
+	return nil
+}
+
+func (ms *MemoryStore) Get(ownerID, id string) (*Workflow, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	w, exists := ms.workflows[id]
+	if !exists || !ownedBy(w, ownerID) {
+		return nil, ErrWorkflowNotFound
+	}
+	return w, nil
+}
+
+func (ms *MemoryStore) Update(w *Workflow) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.workflows[w.ID]; !exists {
+		return ErrWorkflowNotFound
+	}
+	ms.workflows[w.ID] = w
+	return nil
+}
+
+func (ms *MemoryStore) Delete(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.workflows[id]; !exists {
+		return ErrWorkflowNotFound
+	}
+	delete(ms.workflows, id)
+	delete(ms.versions, id)
+	return nil
+}
+
+func (ms *MemoryStore) List(ownerID string, includeDeleted bool) ([]*Workflow, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	workflows := make([]*Workflow, 0, len(ms.workflows))
+	for _, w := range ms.workflows {
+		if ownedBy(w, ownerID) && (includeDeleted || w.DeletedAt == nil) {
+			workflows = append(workflows, w)
+		}
+	}
+	return workflows, nil
+}
+
+func (ms *MemoryStore) SaveVersion(w *Workflow) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.workflows[w.ID]; !exists {
+		return ErrWorkflowNotFound
+	}
+	history := append(ms.versions[w.ID], w)
+	if len(history) > ms.maxVersions {
+		history = history[len(history)-ms.maxVersions:]
+	}
+	ms.versions[w.ID] = history
+	return nil
+}
+
+func (ms *MemoryStore) Versions(id string) ([]*Workflow, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if _, exists := ms.workflows[id]; !exists {
+		return nil, ErrWorkflowNotFound
+	}
+	history := ms.versions[id]
+	versions := make([]*Workflow, len(history))
+	for i, w := range history {
+		versions[len(history)-1-i] = w
+	}
+	return versions, nil
+}
+
+// SaveCheckpoint keeps a copy of cp; the executor goes on adding to the
+// original as the run progresses.
+func (ms *MemoryStore) SaveCheckpoint(cp *Checkpoint) error {
+	saved := cp.clone()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.checkpoints[cp.ExecutionID] = saved
+	return nil
+}
+
+func (ms *MemoryStore) UpdateCheckpoint(u CheckpointUpdate) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	cp, ok := ms.checkpoints[u.ExecutionID]
+	if !ok {
+		return ErrCheckpointNotFound
+	}
+	cp.Running, cp.UpdatedAt = u.Running, u.UpdatedAt
+	if u.Node != nil {
+		cp.Nodes[u.NodeID] = u.Node
+	}
+	return nil
+}
+
+func (ms *MemoryStore) Checkpoint(ownerID, executionID string) (*Checkpoint, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cp, ok := ms.checkpoints[executionID]
+	if !ok || (ownerID != "" && cp.OwnerID != ownerID) {
+		return nil, ErrCheckpointNotFound
+	}
+	return cp.clone(), nil
+}
+
+func (ms *MemoryStore) Checkpoints() ([]*Checkpoint, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	list := make([]*Checkpoint, 0, len(ms.checkpoints))
+	for _, cp := range ms.checkpoints {
+		list = append(list, cp.clone())
+	}
+	return list, nil
+}
+
+func (ms *MemoryStore) DeleteCheckpoint(executionID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.checkpoints, executionID)
+	return nil
+}
+
+// SaveApproval keeps a copy of p without its decision channel, which
+// belongs to the run waiting on it.
+func (ms *MemoryStore) SaveApproval(p *PendingApproval) error {
+	saved := *p
+	saved.decision = nil
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.approvals[p.ID] = &saved
+	return nil
+}
+
+func (ms *MemoryStore) Approvals() ([]*PendingApproval, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	list := make([]*PendingApproval, 0, len(ms.approvals))
+	for _, p := range ms.approvals {
+		saved := *p
+		list = append(list, &saved)
+	}
+	return list, nil
+}
+
+func (ms *MemoryStore) DeleteApproval(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.approvals, id)
+	return nil
+}
+
+func (ms *MemoryStore) SavePollCursor(c *PollCursor) error {
+	saved := &PollCursor{ID: c.ID, Source: c.Source, Seen: append([]string(nil), c.Seen...)}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.cursors[c.ID] = saved
+	return nil
+}
+
+// PollCursor returns a saved cursor, or nil when there is none.
+func (ms *MemoryStore) PollCursor(id string) (*PollCursor, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	c, ok := ms.cursors[id]
+	if !ok {
+		return nil, nil
+	}
+	return &PollCursor{ID: c.ID, Source: c.Source, Seen: append([]string(nil), c.Seen...)}, nil
+}
+
+func (ms *MemoryStore) DeletePollCursors(workflowID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for id := range ms.cursors {
+		if strings.HasPrefix(id, workflowID+"/") {
+			delete(ms.cursors, id)
+		}
+	}
+	return nil
+}
+
+func ownedBy(w *Workflow, ownerID string) bool {
+	return ownerID == "" || w.OwnerID == ownerID
+}
+
+// ============================================
+// Versions
+// ============================================
+
+// ErrVersionConflict reports an update based on a stale workflow version.
+var ErrVersionConflict = errors.New("workflow was modified since it was read")
+
+// workflowETag is the strong ETag for a workflow's current version.
+func workflowETag(w *Workflow) string {
+	return `"` + strconv.FormatInt(w.Version, 10) + `"`
+}
+
+// parseIfMatch reads the version from an If-Match header; "*" matches any
+// version and yields 0.
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, nil
+	}
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match header: %s", header)
+	}
+	return version, nil
+}
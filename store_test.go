@@ -0,0 +1,358 @@
+// store_test.go - Workflow store, tenant scoping and versioning tests
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenantCannotReachAnotherTenantsWorkflow(t *testing.T) {
+	we := newTestEngine(t)
+	alice, bob := asPrincipal("alice"), asPrincipal("bob")
+
+	wf := mustCreate(t, we, alice, &Workflow{Name: "alice's", Nodes: []Node{{ID: "t", Type: NodeTransform}}})
+	if wf.OwnerID != "alice" {
+		t.Fatalf("owner = %q, want alice", wf.OwnerID)
+	}
+
+	if _, err := we.GetWorkflow(bob, wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("get: err = %v, want not found", err)
+	}
+	edit := &Workflow{ID: wf.ID, Name: "hijacked", Nodes: wf.Nodes}
+	if err := we.UpdateWorkflow(bob, edit); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("update: err = %v, want not found", err)
+	}
+	if _, err := we.ExecuteWorkflow(bob, wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("execute: err = %v, want not found", err)
+	}
+	if _, err := we.ActivateWorkflow(bob, wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("activate: err = %v, want not found", err)
+	}
+	if err := we.DeleteWorkflow(bob, wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("delete: err = %v, want not found", err)
+	}
+	if list, err := we.ListWorkflows(bob, ListOptions{}); err != nil || len(list) != 0 {
+		t.Errorf("list: %d workflows, err %v; want none", len(list), err)
+	}
+
+	got, err := we.GetWorkflow(alice, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "alice's" || got.Version != 1 {
+		t.Fatalf("owner's workflow changed: name %q version %d", got.Name, got.Version)
+	}
+	if list, _ := we.ListWorkflows(alice, ListOptions{}); len(list) != 1 {
+		t.Fatalf("owner lists %d workflows, want 1", len(list))
+	}
+}
+
+func TestCreateRefusesTakenID(t *testing.T) {
+	we := newTestEngine(t)
+	nodes := []Node{{ID: "t", Type: NodeTransform}}
+	mustCreate(t, we, asPrincipal("alice"), &Workflow{ID: "shared", Name: "first", Nodes: nodes})
+
+	// The ID is taken whoever asks, without revealing the owner
+	for _, who := range []string{"alice", "bob"} {
+		err := we.CreateWorkflow(asPrincipal(who), &Workflow{ID: "shared", Name: "second", Nodes: nodes})
+		if !errors.Is(err, ErrWorkflowExists) {
+			t.Errorf("%s: err = %v, want ErrWorkflowExists", who, err)
+		}
+	}
+	if got, _ := we.GetWorkflow(asPrincipal("alice"), "shared"); got.Name != "first" {
+		t.Fatalf("original overwritten: name %q", got.Name)
+	}
+}
+
+func TestStaleWriteIsRejected(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": "shared"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, data)
+	}
+	var wf Workflow
+	decode(t, data, &wf)
+	path := "/api/workflows/" + wf.ID
+
+	// Both editors read version 1
+	resp, _ = doRequest(t, ts, "GET", path, nil)
+	etag := resp.Header.Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag = %q, want \"1\"", etag)
+	}
+
+	resp, data = doRequest(t, ts, "PUT", path, map[string]interface{}{"name": "first edit"}, "If-Match", etag)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("first write: %d ETag %q %s", resp.StatusCode, resp.Header.Get("ETag"), data)
+	}
+
+	resp, data = doRequest(t, ts, "PUT", path, map[string]interface{}{"name": "second edit"}, "If-Match", etag)
+	if resp.StatusCode != http.StatusConflict || apiError(t, data).Code != CodeVersionConflict {
+		t.Fatalf("stale write: %d %s, want 409 version_conflict", resp.StatusCode, data)
+	}
+	resp, data = doRequest(t, ts, "PUT", path, map[string]interface{}{"name": "second edit", "version": 1})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("stale body version: %d %s, want 409", resp.StatusCode, data)
+	}
+
+	resp, data = doRequest(t, ts, "GET", path, nil)
+	decode(t, data, &wf)
+	if wf.Name != "first edit" || wf.Version != 2 {
+		t.Fatalf("stored %q at version %d; the stale write clobbered it", wf.Name, wf.Version)
+	}
+
+	resp, _ = doRequest(t, ts, "PUT", path, map[string]interface{}{"name": "forced"}, "If-Match", "*")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"3"` {
+		t.Fatalf("If-Match *: %d ETag %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	resp, _ = doRequest(t, ts, "PUT", path, map[string]interface{}{"name": "x"}, "If-Match", "latest")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad If-Match: %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestConcurrentUpdatesFromOneVersion(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "race"})
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- we.UpdateWorkflow(ctx, &Workflow{ID: wf.ID, Name: "edit", Version: 1})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	won := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, ErrVersionConflict):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("%d writers updated version 1, want exactly 1", won)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{`"3"`, 3},
+		{`W/"7"`, 7},
+		{" 12 ", 12},
+		{"*", 0},
+	}
+	for _, tt := range tests {
+		if got, err := parseIfMatch(tt.header); err != nil || got != tt.want {
+			t.Errorf("parseIfMatch(%q) = %d, %v; want %d", tt.header, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{`"abc"`, `"0"`, `"-1"`, ""} {
+		if _, err := parseIfMatch(bad); err == nil {
+			t.Errorf("parseIfMatch(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestUpdateKeepsServerManagedFields(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	s.engine.clock = clock
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": "keep me"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, data)
+	}
+	var created Workflow
+	decode(t, data, &created)
+	if resp, data := doRequest(t, ts, "POST", "/api/workflows/"+created.ID+"/activate", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("activate: %d %s", resp.StatusCode, data)
+	}
+
+	clock.Advance(time.Hour)
+	path := "/api/workflows/" + created.ID
+	resp, data = doRequest(t, ts, "PUT", path, map[string]interface{}{
+		"name":        "renamed",
+		"description": "now described",
+		"status":      StatusInactive,
+		"created_at":  "2001-01-01T00:00:00Z",
+		"owner_id":    "mallory",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update: %d %s", resp.StatusCode, data)
+	}
+
+	resp, data = doRequest(t, ts, "GET", path, nil)
+	var got Workflow
+	decode(t, data, &got)
+	if got.Name != "renamed" || got.Description != "now described" {
+		t.Errorf("client fields not applied: %q %q", got.Name, got.Description)
+	}
+	if !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("created_at = %v, want %v", got.CreatedAt, created.CreatedAt)
+	}
+	if got.Status != StatusActive {
+		t.Errorf("status = %q, want it to stay active", got.Status)
+	}
+	if got.OwnerID != created.OwnerID || got.ID != created.ID {
+		t.Errorf("owner/id changed to %q/%q", got.OwnerID, got.ID)
+	}
+	if !got.UpdatedAt.Equal(clock.Now()) {
+		t.Errorf("updated_at = %v, want %v", got.UpdatedAt, clock.Now())
+	}
+}
+
+func TestUpdateTakesIDFromURL(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ids := make([]string, 2)
+	for i, name := range []string{"target", "bystander"} {
+		resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": name})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("create: %d %s", resp.StatusCode, data)
+		}
+		var wf Workflow
+		decode(t, data, &wf)
+		ids[i] = wf.ID
+	}
+	target, bystander := ids[0], ids[1]
+
+	tests := []struct {
+		name   string
+		bodyID string
+		status int
+	}{
+		{"matching", target, http.StatusOK},
+		{"empty", "", http.StatusOK},
+		{"mismatched", bystander, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, "PUT", "/api/workflows/"+target, map[string]interface{}{"id": tt.bodyID, "name": tt.name})
+		if resp.StatusCode != tt.status {
+			t.Fatalf("%s body id: status = %d, want %d (%s)", tt.name, resp.StatusCode, tt.status, data)
+		}
+		if tt.status == http.StatusOK {
+			var wf Workflow
+			decode(t, data, &wf)
+			if wf.ID != target || wf.Name != tt.name {
+				t.Fatalf("%s body id: updated %q to %q", tt.name, wf.ID, wf.Name)
+			}
+		} else if apiError(t, data).Code != CodeInvalidBody {
+			t.Fatalf("%s body id: %s", tt.name, data)
+		}
+	}
+
+	_, data := doRequest(t, ts, "GET", "/api/workflows/"+bystander, nil)
+	var wf Workflow
+	decode(t, data, &wf)
+	if wf.Name != "bystander" || wf.Version != 1 {
+		t.Fatalf("the body's workflow was modified: %q version %d", wf.Name, wf.Version)
+	}
+
+	resp, _ := doRequest(t, ts, "PUT", "/api/workflows/missing", map[string]interface{}{"id": target, "name": "x"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown URL id with a body id: %d, want 400", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, ts, "PUT", "/api/workflows/missing", map[string]interface{}{"name": "x"})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown URL id: %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestDeleteThenRestore(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := asPrincipal("alice")
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "keep me", Nodes: []Node{{ID: "t", Type: NodeTransform}}})
+
+	if err := we.DeleteWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := we.GetWorkflow(ctx, wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Fatalf("get after delete: err = %v, want not found", err)
+	}
+	if err := we.DeleteWorkflow(ctx, wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Fatalf("second delete: err = %v, want not found", err)
+	}
+
+	restored, err := we.RestoreWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt != nil || restored.Status != StatusInactive || restored.Name != "keep me" {
+		t.Fatalf("restored = %+v", restored)
+	}
+	if _, err := we.GetWorkflow(ctx, wf.ID); err != nil {
+		t.Fatalf("get after restore: %v", err)
+	}
+	if _, err := we.RestoreWorkflow(asPrincipal("bob"), wf.ID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Fatalf("restore by another tenant: err = %v, want not found", err)
+	}
+}
+
+func TestListFiltersDeleted(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	live := mustCreate(t, s.engine, ctx, &Workflow{Name: "live"})
+	gone := mustCreate(t, s.engine, ctx, &Workflow{Name: "gone"})
+
+	if resp, data := doRequest(t, ts, "DELETE", "/api/workflows/"+gone.ID, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", resp.StatusCode, data)
+	}
+	if resp, _ := doRequest(t, ts, "GET", "/api/workflows/"+gone.ID, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get deleted: %d, want 404", resp.StatusCode)
+	}
+
+	list := func(query string) map[string]*Workflow {
+		t.Helper()
+		resp, data := doRequest(t, ts, "GET", "/api/workflows"+query, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("list%s: %d %s", query, resp.StatusCode, data)
+		}
+		var workflows []*Workflow
+		decode(t, data, &workflows)
+		byID := make(map[string]*Workflow)
+		for _, w := range workflows {
+			byID[w.ID] = w
+		}
+		return byID
+	}
+	if got := list(""); len(got) != 1 || got[live.ID] == nil {
+		t.Fatalf("default list = %v, want only the live workflow", got)
+	}
+	got := list("?includeDeleted=true")
+	if len(got) != 2 || got[gone.ID] == nil || got[gone.ID].DeletedAt == nil {
+		t.Fatalf("includeDeleted list = %v, want both with deleted_at on the deleted one", got)
+	}
+
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+gone.ID+"/restore", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("restore: %d %s", resp.StatusCode, data)
+	}
+	if got := list(""); len(got) != 2 {
+		t.Fatalf("list after restore has %d workflows, want 2", len(got))
+	}
+
+	if resp, data := doRequest(t, ts, "POST", "/api/workflows/"+gone.ID+"/purge", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("purge: %d %s", resp.StatusCode, data)
+	}
+	if got := list("?includeDeleted=true"); len(got) != 1 || got[gone.ID] != nil {
+		t.Fatalf("list after purge = %v, want only the live workflow", got)
+	}
+	if resp, _ := doRequest(t, ts, "POST", "/api/workflows/"+gone.ID+"/restore", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("restore after purge: %d, want 404", resp.StatusCode)
+	}
+}
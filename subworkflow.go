@@ -0,0 +1,70 @@
+// subworkflow.go - Call another workflow as a node
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const callStackKey contextKey = "callStack"
+
+// maxSubworkflowDepth bounds how deeply workflows may call each other.
+const maxSubworkflowDepth = 10
+
+// ============================================
+// Sub-workflow Node
+// ============================================
+
+// SubWorkflowExecutor runs another workflow with the node's input and
+// returns that run's per-node results. Properties: workflowId. A workflow
+// may not call itself, directly or through others.
+type SubWorkflowExecutor struct {
+	engine *WorkflowEngine
+}
+
+func (e *SubWorkflowExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	target, _ := node.Properties["workflowId"].(string)
+	if target = strings.TrimSpace(target); target == "" {
+		return nil, fmt.Errorf("workflowId is required")
+	}
+	return callWorkflow(ctx, e.engine, target, input)
+}
+
+// callWorkflow runs target as a sub-workflow of the current run and
+// returns its per-node results; failed or cancelled runs are errors.
+func callWorkflow(ctx context.Context, engine *WorkflowEngine, target string, input interface{}) (map[string]interface{}, error) {
+	stack := callStackFromContext(ctx)
+	if current := workflowIDFromContext(ctx); current != "" {
+		stack = append(stack, current)
+	}
+	for _, id := range stack {
+		if id == target {
+			return nil, fmt.Errorf("recursive call to workflow %s via %s", target, strings.Join(stack, " -> "))
+		}
+	}
+	if len(stack) >= maxSubworkflowDepth {
+		return nil, fmt.Errorf("sub-workflow depth limit of %d reached", maxSubworkflowDepth)
+	}
+
+	ctx = context.WithValue(ctx, callStackKey, stack)
+	result, err := engine.ExecuteWorkflowWithInput(ctx, target, input)
+	if err != nil {
+		return nil, fmt.Errorf("workflow %s: %v", target, err)
+	}
+	if result.Status == StatusCancelled {
+		return nil, fmt.Errorf("workflow %s cancelled", target)
+	}
+	if result.Status == StatusFailed {
+		return nil, fmt.Errorf("workflow %s failed: %s", target, strings.Join(result.Errors, "; "))
+	}
+	return result.Results, nil
+}
+
+// callStackFromContext lists the workflows that called into the current
+// run, outermost first.
+func callStackFromContext(ctx context.Context) []string {
+	stack, _ := ctx.Value(callStackKey).([]string)
+	// Copy so sibling calls never share a backing array
+	return append([]string(nil), stack...)
+}
@@ -0,0 +1,105 @@
+// subworkflow_test.go - Sub-workflow call tests
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// caller is a workflow whose only node calls target.
+func caller(name, target string) *Workflow {
+	return &Workflow{
+		Name:  name,
+		Nodes: []Node{{ID: "call", Type: NodeSubWorkflow, Properties: map[string]interface{}{"workflowId": target}}},
+	}
+}
+
+func TestSubWorkflowTwoLevels(t *testing.T) {
+	rec := &recorder{}
+	we := newTestEngine(t, WithNodeExecutor(nodeRecord, rec))
+	ctx := asPrincipal("alice")
+
+	leaf := mustCreate(t, we, ctx, &Workflow{Name: "leaf", Nodes: []Node{{ID: "r", Type: nodeRecord}}})
+	middle := mustCreate(t, we, ctx, caller("middle", leaf.ID))
+	top := mustCreate(t, we, ctx, caller("top", middle.ID))
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, top.ID, map[string]interface{}{"n": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	in := map[string]interface{}{"n": 1.0}
+	if calls := rec.calls(); len(calls) != 1 || !reflect.DeepEqual(calls[0], in) {
+		t.Fatalf("leaf received %v, want the top-level input", calls)
+	}
+	want := map[string]interface{}{"call": map[string]interface{}{"r": in}}
+	if got := result.Results["call"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("nested output = %v, want %v", got, want)
+	}
+}
+
+func TestSubWorkflowRecursionGuard(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+
+	self := mustCreate(t, we, ctx, &Workflow{Name: "self", Nodes: []Node{{ID: "call", Type: NodeSubWorkflow}}})
+	self.Nodes[0].Properties = map[string]interface{}{"workflowId": self.ID}
+	if err := we.UpdateWorkflow(ctx, self); err != nil {
+		t.Fatal(err)
+	}
+
+	a := mustCreate(t, we, ctx, &Workflow{Name: "a", Nodes: []Node{{ID: "call", Type: NodeSubWorkflow}}})
+	b := mustCreate(t, we, ctx, caller("b", a.ID))
+	a.Nodes[0].Properties = map[string]interface{}{"workflowId": b.ID}
+	if err := we.UpdateWorkflow(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, id := range map[string]string{"direct": self.ID, "transitive": a.ID} {
+		result, err := we.ExecuteWorkflow(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != StatusFailed || !strings.Contains(strings.Join(result.Errors, " "), "recursive call") {
+			t.Errorf("%s: status %s, errors %v", name, result.Status, result.Errors)
+		}
+	}
+}
+
+func TestSubWorkflowDepthLimit(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+
+	// A chain one call longer than the limit allows
+	next := mustCreate(t, we, ctx, &Workflow{Name: "end", Nodes: []Node{{ID: "t", Type: NodeTransform}}})
+	for i := 0; i <= maxSubworkflowDepth; i++ {
+		next = mustCreate(t, we, ctx, caller(fmt.Sprintf("level %d", i), next.ID))
+	}
+
+	result, err := we.ExecuteWorkflow(ctx, next.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed || !strings.Contains(strings.Join(result.Errors, " "), "depth limit") {
+		t.Fatalf("status %s, errors %v", result.Status, result.Errors)
+	}
+}
+
+func TestSubWorkflowIsScopedToOwner(t *testing.T) {
+	we := newTestEngine(t)
+	theirs := mustCreate(t, we, asPrincipal("bob"), &Workflow{Name: "bob's", Nodes: []Node{{ID: "t", Type: NodeTransform}}})
+	mine := mustCreate(t, we, asPrincipal("alice"), caller("mine", theirs.ID))
+
+	result, err := we.ExecuteWorkflow(asPrincipal("alice"), mine.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusFailed || !strings.Contains(strings.Join(result.Errors, " "), ErrWorkflowNotFound.Error()) {
+		t.Fatalf("status %s, errors %v", result.Status, result.Errors)
+	}
+}
@@ -0,0 +1,44 @@
+// switch.go - Multi-way routing node
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// PortDefault is the switch output taken when no case matches.
+const PortDefault = "default"
+
+// ============================================
+// Switch Node
+// ============================================
+
+// SwitchExecutor routes its input, unchanged, to the output port named
+// after the first case equal to the value at field (a path into the
+// input, e.g. "body.status"), or to "default". Properties: field, cases
+// (comma- or newline-separated). Values compare by their text, so the case
+// "2" matches the number 2.
+type SwitchExecutor struct{}
+
+func (e *SwitchExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	field, _ := node.Properties["field"].(string)
+	cases := stringList(node.Properties["cases"])
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("at least one case is required")
+	}
+
+	value, found := lookupPath(genericJSON(input), field)
+	port := PortDefault
+	if found && value != nil {
+		text := fmt.Sprint(value)
+		for _, c := range cases {
+			if c == text {
+				port = c
+				break
+			}
+		}
+	}
+
+	logf(ctx, "info", "routing to %q", port)
+	return &Routed{Port: port, Output: input}, nil
+}
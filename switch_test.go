@@ -0,0 +1,66 @@
+// switch_test.go - Switch node routing tests
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSwitchRoutesToMatchingBranch(t *testing.T) {
+	branches := []string{"new", "paid", "shipped", PortDefault}
+	w := &Workflow{
+		Name:  "route orders",
+		Nodes: []Node{{ID: "route", Type: NodeSwitch, Properties: map[string]interface{}{"field": "order.status", "cases": "new, paid\nshipped"}}},
+	}
+	for _, b := range branches {
+		w.Nodes = append(w.Nodes, Node{ID: b, Type: NodeSet, Properties: map[string]interface{}{"fields": map[string]interface{}{"branch": b}}})
+		w.Connections = append(w.Connections, Connection{ID: "to-" + b, FromID: "route", ToID: b, Port: b})
+	}
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, w)
+
+	tests := []struct {
+		status interface{}
+		want   string
+	}{
+		{"new", "new"},
+		{"paid", "paid"},
+		{"shipped", "shipped"},
+		{"refunded", PortDefault},
+		{nil, PortDefault},
+	}
+	for _, tt := range tests {
+		input := map[string]interface{}{"order": map[string]interface{}{"status": tt.status}}
+		result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != StatusCompleted {
+			t.Fatalf("%v: status = %s, errors %v", tt.status, result.Status, result.Errors)
+		}
+		for _, b := range branches {
+			ran := result.Nodes[b].Status != NodeStatusSkipped
+			if ran != (b == tt.want) {
+				t.Errorf("status %v: branch %q ran = %v, want only %q", tt.status, b, ran, tt.want)
+			}
+		}
+	}
+}
+
+func TestSwitchMatchesNumbersByText(t *testing.T) {
+	out, err := (&SwitchExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{
+		"field": "code",
+		"cases": "1,2,3",
+	}}, map[string]interface{}{"code": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := out.(*Routed); !ok || r.Port != "2" {
+		t.Fatalf("routed = %+v, want port 2", out)
+	}
+
+	if _, err := (&SwitchExecutor{}).Execute(context.Background(), &Node{Properties: map[string]interface{}{"field": "code"}}, nil); err == nil {
+		t.Fatal("switch without cases accepted")
+	}
+}
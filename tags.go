@@ -0,0 +1,76 @@
+// tags.go - Workflow tags
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TagCount is a tag and how many workflows carry it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// ============================================
+// Tags
+// ============================================
+
+// normalizeTags trims tags and drops blanks and duplicates, keeping order.
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// hasTags reports whether w carries every one of tags.
+func hasTags(w *Workflow, tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, tag := range w.Tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// handleListTags lists the distinct tags on the caller's workflows with
+// their counts, sorted by tag.
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	workflows, err := s.engine.ListWorkflows(r.Context(), ListOptions{})
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, wf := range workflows {
+		for _, tag := range wf.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
@@ -0,0 +1,86 @@
+// tags_test.go - Workflow tag and tag filtering tests
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMultiTagFiltering(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	for name, tags := range map[string][]string{
+		"invoices": {"billing", "finance"},
+		"refunds":  {"billing", "support", " billing "},
+		"payroll":  {"finance"},
+		"untagged": nil,
+	} {
+		resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{"name": name, "tags": tags})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("create %s: %d %s", name, resp.StatusCode, data)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"invoices", "payroll", "refunds", "untagged"}},
+		{"?tag=billing", []string{"invoices", "refunds"}},
+		{"?tag=finance", []string{"invoices", "payroll"}},
+		{"?tag=billing&tag=finance", []string{"invoices"}},
+		{"?tag=billing&tag=support&tag=finance", nil},
+		{"?tag=unknown", nil},
+		{"?tag=", []string{"invoices", "payroll", "refunds", "untagged"}},
+	}
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, "GET", "/api/workflows"+tt.query, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("list%s: %d %s", tt.query, resp.StatusCode, data)
+		}
+		var workflows []*Workflow
+		decode(t, data, &workflows)
+		var names []string
+		for _, w := range workflows {
+			names = append(names, w.Name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("list%s = %v, want %v", tt.query, names, tt.want)
+		}
+	}
+
+	resp, data := doRequest(t, ts, "GET", "/api/tags", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("tags: %d %s", resp.StatusCode, data)
+	}
+	var counts []TagCount
+	decode(t, data, &counts)
+	want := []TagCount{{"billing", 2}, {"finance", 2}, {"support", 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("tags = %v, want %v", counts, want)
+	}
+}
+
+func TestUpdateReplacesTags(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := asPrincipal("alice")
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "tagged", Tags: []string{"a", "", "a", " b"}})
+	if !reflect.DeepEqual(wf.Tags, []string{"a", "b"}) {
+		t.Fatalf("created tags = %q, want [a b]", wf.Tags)
+	}
+
+	if err := we.UpdateWorkflow(ctx, &Workflow{ID: wf.ID, Name: "tagged", Tags: []string{"c"}}); err != nil {
+		t.Fatal(err)
+	}
+	for tag, want := range map[string]int{"a": 0, "c": 1} {
+		got, err := we.ListWorkflows(ctx, ListOptions{Tags: []string{tag}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != want {
+			t.Errorf("workflows tagged %q = %d, want %d", tag, len(got), want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// template.go - {{ }} templates over node input
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================
+// Templates
+// ============================================
+
+// renderTemplate fills each {{ expr }} in tmpl with the expression's value
+// against scope (see expr.go), e.g. "Hello {{ user.name }}". A template
+// that is a single placeholder keeps the value's type, so "{{ items }}"
+// yields the array itself rather than its text.
+func renderTemplate(tmpl string, scope interface{}) (interface{}, error) {
+	trimmed := strings.TrimSpace(tmpl)
+	if strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") &&
+		strings.Count(trimmed, "{{") == 1 {
+		return evalExpr(trimmed[2:len(trimmed)-2], scope)
+	}
+
+	var sb strings.Builder
+	rest := tmpl
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			sb.WriteString(rest)
+			return sb.String(), nil
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed {{ in template")
+		}
+		end += start
+
+		v, err := evalExpr(rest[start+2:end], scope)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(rest[:start])
+		sb.WriteString(exprString(v))
+		rest = rest[end+2:]
+	}
+}
+
+// renderTemplates renders every string within v, a value decoded from
+// JSON, as a template against scope, leaving other values as they are.
+func renderTemplates(v interface{}, scope interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		return renderTemplate(t, scope)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			rendered, err := renderTemplates(item, scope)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			rendered, err := renderTemplates(item, scope)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", i, err)
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,130 @@
+// templates.go - Prebuilt workflow templates
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+//go:embed templates/*.json
+var templateFS embed.FS
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// WorkflowTemplate is a prebuilt workflow offered to new users.
+type WorkflowTemplate struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Workflow    Workflow `json:"workflow"`
+}
+
+// ============================================
+// Template Gallery
+// ============================================
+
+// loadTemplates reads the embedded templates, sorted by name.
+func loadTemplates() ([]*WorkflowTemplate, error) {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]*WorkflowTemplate, 0, len(entries))
+	for _, entry := range entries {
+		data, err := templateFS.ReadFile(path.Join("templates", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var t WorkflowTemplate
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("template %s: %v", entry.Name(), err)
+		}
+		templates = append(templates, &t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+func findTemplate(name string) (*WorkflowTemplate, error) {
+	templates, err := loadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, ErrTemplateNotFound
+}
+
+// instantiate copies the template's workflow with fresh node and
+// connection IDs, rewiring connections to match.
+func (t *WorkflowTemplate) instantiate() *Workflow {
+	w := &Workflow{
+		Name:        t.Workflow.Name,
+		Description: t.Workflow.Description,
+		Nodes:       make([]Node, len(t.Workflow.Nodes)),
+		Connections: make([]Connection, len(t.Workflow.Connections)),
+	}
+
+	ids := make(map[string]string, len(t.Workflow.Nodes))
+	for i, node := range t.Workflow.Nodes {
+		ids[node.ID] = "node_" + uuid.New().String()[:8]
+		node.ID = ids[node.ID]
+		props := make(map[string]interface{}, len(node.Properties))
+		for k, v := range node.Properties {
+			props[k] = v
+		}
+		node.Properties = props
+		w.Nodes[i] = node
+	}
+	for i, c := range t.Workflow.Connections {
+		c.ID = "conn_" + uuid.New().String()[:8]
+		c.FromID = ids[c.FromID]
+		c.ToID = ids[c.ToID]
+		w.Connections[i] = c
+	}
+	return w
+}
+
+// ============================================
+// Template Handlers
+// ============================================
+
+func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := loadTemplates()
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// handleInstantiateTemplate creates a new workflow from a template.
+func (s *Server) handleInstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := findTemplate(mux.Vars(r)["name"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	workflow := t.instantiate()
+	if err := s.engine.CreateWorkflow(r.Context(), workflow); err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.presentWorkflow(r, workflow))
+}
@@ -0,0 +1,96 @@
+// templates_test.go - Template gallery tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// edges describes w's connections by node name, so topology compares
+// across fresh IDs.
+func edges(w *Workflow) []string {
+	names := make(map[string]string, len(w.Nodes))
+	for _, n := range w.Nodes {
+		names[n.ID] = n.Name
+	}
+	var out []string
+	for _, c := range w.Connections {
+		out = append(out, names[c.FromID]+" -"+c.Port+"-> "+names[c.ToID])
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestTemplatesAreValid(t *testing.T) {
+	templates, err := loadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) < 3 {
+		t.Fatalf("%d templates, want the curated set", len(templates))
+	}
+	for _, tmpl := range templates {
+		if tmpl.Name == "" || tmpl.Title == "" || len(tmpl.Workflow.Nodes) == 0 {
+			t.Errorf("%s: incomplete template %+v", tmpl.Name, tmpl)
+		}
+		if err := tmpl.Workflow.Validate(); err != nil {
+			t.Errorf("%s: %v", tmpl.Name, err)
+		}
+		if got := edges(&tmpl.Workflow); len(got) != len(tmpl.Workflow.Connections) {
+			t.Errorf("%s: edges = %v", tmpl.Name, got)
+		}
+		for _, e := range edges(&tmpl.Workflow) {
+			if e[0] == ' ' || e[len(e)-1] == ' ' {
+				t.Errorf("%s: connection to an unknown node: %q", tmpl.Name, e)
+			}
+		}
+	}
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+
+	resp, data := doRequest(t, ts, "GET", "/api/templates", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: %d %s", resp.StatusCode, data)
+	}
+	var listed []WorkflowTemplate
+	decode(t, data, &listed)
+
+	for _, tmpl := range listed {
+		seen := make(map[string]bool)
+		for i := 0; i < 2; i++ {
+			resp, data := doRequest(t, ts, "POST", "/api/templates/"+tmpl.Name+"/instantiate", nil)
+			if resp.StatusCode != http.StatusCreated {
+				t.Fatalf("%s: instantiate: %d %s", tmpl.Name, resp.StatusCode, data)
+			}
+			var wf Workflow
+			decode(t, data, &wf)
+
+			if len(wf.Nodes) != len(tmpl.Workflow.Nodes) || len(wf.Connections) != len(tmpl.Workflow.Connections) {
+				t.Fatalf("%s: %d nodes and %d connections, want %d and %d", tmpl.Name,
+					len(wf.Nodes), len(wf.Connections), len(tmpl.Workflow.Nodes), len(tmpl.Workflow.Connections))
+			}
+			if got, want := edges(&wf), edges(&tmpl.Workflow); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s: topology %v, want %v", tmpl.Name, got, want)
+			}
+			for j, n := range wf.Nodes {
+				if n.ID == tmpl.Workflow.Nodes[j].ID || seen[n.ID] {
+					t.Fatalf("%s: node ID %q was reused", tmpl.Name, n.ID)
+				}
+				seen[n.ID] = true
+			}
+			if _, err := s.engine.GetWorkflow(context.Background(), wf.ID); err != nil {
+				t.Fatalf("%s: instantiated workflow not stored: %v", tmpl.Name, err)
+			}
+		}
+	}
+
+	resp, data = doRequest(t, ts, "POST", "/api/templates/no-such-template/instantiate", nil)
+	if resp.StatusCode != http.StatusNotFound || apiError(t, data).Code != CodeTemplateNotFound {
+		t.Fatalf("unknown template: %d %s", resp.StatusCode, data)
+	}
+}
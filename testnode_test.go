@@ -0,0 +1,115 @@
+// testnode_test.go - Single node test endpoint tests
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTestHTTPNodeAgainstMockServer(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sent interface{}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"method": r.Method, "path": r.URL.Path, "sent": sent})
+	}))
+	defer api.Close()
+
+	upstream := &recorder{}
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: upstream}})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "lookup",
+		Nodes: []Node{
+			{ID: "source", Type: nodeRecord},
+			{ID: "fetch", Type: NodeHTTP, Properties: map[string]interface{}{
+				"url":    api.URL + "/users",
+				"method": "POST",
+				"body":   map[string]interface{}{"name": "ada"},
+			}},
+			{ID: "greet", Type: NodeSet, Properties: map[string]interface{}{"fields": map[string]interface{}{"greeting": "hi {{ user.first }}"}}},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "source", ToID: "fetch"},
+			{ID: "c2", FromID: "fetch", ToID: "greet"},
+		},
+	})
+	test := func(nodeID string, input interface{}) NodeResult {
+		t.Helper()
+		resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/nodes/"+nodeID+"/test", input)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("test %s: %d %s", nodeID, resp.StatusCode, data)
+		}
+		var result NodeResult
+		decode(t, data, &result)
+		if result.Status != NodeStatusCompleted || result.Attempts != 1 {
+			t.Fatalf("test %s: result = %+v", nodeID, result)
+		}
+		return result
+	}
+
+	result := test("fetch", map[string]interface{}{"id": 42})
+	output, _ := result.Output.(map[string]interface{})
+	want := map[string]interface{}{"method": "POST", "path": "/users", "sent": map[string]interface{}{"name": "ada"}}
+	if output["status"] != 200.0 || !reflect.DeepEqual(output["body"], want) {
+		t.Fatalf("output = %v, want status 200 and body %v", result.Output, want)
+	}
+
+	// Templates see the posted input as if it came from upstream
+	result = test("greet", map[string]interface{}{"user": map[string]interface{}{"first": "Grace"}})
+	if want := map[string]interface{}{"greeting": "hi Grace"}; !reflect.DeepEqual(result.Output, want) {
+		t.Fatalf("greet output = %v, want %v", result.Output, want)
+	}
+
+	// Only the tested nodes ran, and the tests left no execution behind
+	if calls := upstream.calls(); len(calls) != 0 {
+		t.Errorf("upstream node ran with %v", calls)
+	}
+	if runs := s.engine.executions.List("", wf.ID); len(runs) != 0 {
+		t.Errorf("node tests recorded %d executions", len(runs))
+	}
+}
+
+func TestTestNodeOutcomes(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeFail: failing{}}})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name: "outcomes",
+		Nodes: []Node{
+			{ID: "broken", Type: nodeFail},
+			{ID: "gated", Type: NodeTransform, Properties: map[string]interface{}{"runIf": "go == true"}},
+		},
+	})
+	base := "/api/workflows/" + wf.ID + "/nodes/"
+
+	tests := []struct {
+		node, body string
+		status     int
+		want       string
+	}{
+		{"broken", `{}`, http.StatusOK, NodeStatusFailed},
+		{"gated", `{"go": false}`, http.StatusOK, NodeStatusSkipped},
+		{"missing", `{}`, http.StatusNotFound, ""},
+		{"gated", `{"go":`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, "POST", base+tt.node+"/test", tt.body)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: %d %s, want %d", tt.node, tt.body, resp.StatusCode, data, tt.status)
+			continue
+		}
+		if tt.want == "" {
+			continue
+		}
+		var result NodeResult
+		decode(t, data, &result)
+		if result.Status != tt.want {
+			t.Errorf("%s: status %s, want %s", tt.node, result.Status, tt.want)
+		}
+		if tt.want == NodeStatusFailed && result.Error != "boom" {
+			t.Errorf("%s: error %q, want boom", tt.node, result.Error)
+		}
+	}
+}
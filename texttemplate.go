@@ -0,0 +1,114 @@
+// texttemplate.go - Go text/template rendering node
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateNoValue is what text/template prints for a missing map key; the
+// node renders it as empty instead.
+const templateNoValue = "<no value>"
+
+// templateFuncs are the functions available to template nodes. Functions
+// taking the string last read naturally in pipelines:
+// {{ .name | trim | replace " " "-" | lower }}.
+var templateFuncs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join": func(sep string, items []interface{}) string {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = exprString(item)
+		}
+		return strings.Join(parts, sep)
+	},
+	"default": func(def, v interface{}) interface{} {
+		if templateEmpty(v) {
+			return def
+		}
+		return v
+	},
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec": func(s string) (string, error) {
+		data, err := base64.StdEncoding.DecodeString(s)
+		return string(data), err
+	},
+}
+
+// templateEmpty reports whether v is a missing or zero value for default.
+func templateEmpty(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case bool:
+		return !x
+	case float64:
+		return x == 0
+	case int:
+		return x == 0
+	case []interface{}:
+		return len(x) == 0
+	case map[string]interface{}:
+		return len(x) == 0
+	}
+	return false
+}
+
+// ============================================
+// Template Node
+// ============================================
+
+// TextTemplateExecutor renders its template property, a Go text/template,
+// with the input as dot and outputs the rendered string. Besides the
+// built-in functions, templateFuncs offers upper, lower, trim, replace,
+// default, json, b64enc, b64dec and a few more. Missing fields render as
+// empty.
+type TextTemplateExecutor struct{}
+
+func (e *TextTemplateExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	text, _ := node.Properties["template"].(string)
+	if text == "" {
+		return nil, fmt.Errorf("template is required")
+	}
+	tmpl, err := template.New(node.ID).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, genericJSON(input)); err != nil {
+		return nil, fmt.Errorf("render template: %v", err)
+	}
+	return strings.ReplaceAll(sb.String(), templateNoValue, ""), nil
+}
+
+// checkTextTemplateNode reports a template that does not parse. Secret
+// references are resolved before the node runs, so they are left out.
+func checkTextTemplateNode(node *Node) []FieldError {
+	text, _ := node.Properties["template"].(string)
+	if text = secretRefPattern.ReplaceAllString(text, ""); text == "" {
+		return nil
+	}
+	if _, err := template.New(node.ID).Funcs(templateFuncs).Parse(text); err != nil {
+		return []FieldError{{NodeID: node.ID, Field: "template", Message: err.Error()}}
+	}
+	return nil
+}
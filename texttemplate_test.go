@@ -0,0 +1,98 @@
+// texttemplate_test.go - Go text/template node tests
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTextTemplateFunctions(t *testing.T) {
+	input := map[string]interface{}{
+		"name":    "  Ada Lovelace ",
+		"title":   "",
+		"tags":    []interface{}{"math", "engines", 1843.0},
+		"user":    map[string]interface{}{"id": 7.0, "admin": true},
+		"payload": "aGVsbG8gd29ybGQ=",
+		"path":    "/v1/users/",
+	}
+	tests := []struct {
+		name, template, want string
+	}{
+		{"upper", `{{ .name | trim | upper }}`, "ADA LOVELACE"},
+		{"lower and replace", `{{ .name | trim | replace " " "-" | lower }}`, "ada-lovelace"},
+		{"default for blank", `{{ .title | default "Untitled" }}`, "Untitled"},
+		{"default for missing", `{{ .nickname | default "n/a" }}`, "n/a"},
+		{"default keeps value", `{{ .user.id | default 0 }}`, "7"},
+		{"json", `{{ json .user }}`, `{"admin":true,"id":7}`},
+		{"b64dec", `{{ b64dec .payload }}`, "hello world"},
+		{"b64enc", `{{ "hello world" | b64enc }}`, "aGVsbG8gd29ybGQ="},
+		{"join", `{{ join ", " .tags }}`, "math, engines, 1843"},
+		{"split and range", `{{ range split "," "a,b,c" }}[{{ . }}]{{ end }}`, "[a][b][c]"},
+		{"trim affixes", `{{ .path | trimPrefix "/" | trimSuffix "/" }}`, "v1/users"},
+		{"conditions", `{{ if and .user.admin (contains "eng" (join " " .tags)) }}yes{{ else }}no{{ end }}`, "yes"},
+		{"prefix test", `{{ if hasPrefix "/v1" .path }}v1{{ end }}{{ if hasSuffix "x" .path }}x{{ end }}`, "v1"},
+		{"missing renders empty", `Hi {{ .nobody }}!`, "Hi !"},
+	}
+	e := &TextTemplateExecutor{}
+	for _, tt := range tests {
+		out, err := e.Execute(context.Background(), &Node{ID: "t", Properties: map[string]interface{}{"template": tt.template}}, input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if out != tt.want {
+			t.Errorf("%s: rendered %q, want %q", tt.name, out, tt.want)
+		}
+	}
+}
+
+func TestTextTemplateInWorkflow(t *testing.T) {
+	we := newTestEngine(t)
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "digest",
+		Nodes: []Node{{ID: "msg", Type: NodeTextTemplate, Properties: map[string]interface{}{
+			"template": "{{ len .orders }} orders:{{ range .orders }}\n- {{ .id | upper }} ({{ .status | default \"new\" }}){{ end }}",
+		}}},
+	})
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{
+		"orders": []interface{}{
+			map[string]interface{}{"id": "a1", "status": "shipped"},
+			map[string]interface{}{"id": "b2"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2 orders:\n- A1 (shipped)\n- B2 (new)"
+	if result.Status != StatusCompleted || result.Results["msg"] != want {
+		t.Fatalf("status %s, rendered %q, want %q (errors %v)", result.Status, result.Results["msg"], want, result.Errors)
+	}
+}
+
+func TestTextTemplateErrors(t *testing.T) {
+	we := newTestEngine(t)
+	err := we.CreateWorkflow(context.Background(), &Workflow{
+		Name:  "broken",
+		Nodes: []Node{{ID: "t", Type: NodeTextTemplate, Properties: map[string]interface{}{"template": "{{ .name | shout }}"}}},
+	})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || invalid.Fields[0].Field != "template" {
+		t.Fatalf("err = %v, want a ValidationError on template", err)
+	}
+	if fields := checkTextTemplateNode(&Node{ID: "t", Properties: map[string]interface{}{"template": "Bearer {{secrets.TOKEN}}"}}); fields != nil {
+		t.Fatalf("secret reference rejected: %v", fields)
+	}
+
+	e := &TextTemplateExecutor{}
+	for name, tmpl := range map[string]string{
+		"blank":       "",
+		"unparseable": "{{ if }}",
+		"bad base64":  "{{ b64dec .x }}",
+	} {
+		if _, err := e.Execute(context.Background(), &Node{ID: "t", Properties: map[string]interface{}{"template": tmpl}}, map[string]interface{}{"x": "%%"}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// tracing.go - OpenTelemetry tracing
+package main
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "goflow"
+
+// ============================================
+// Tracing
+// ============================================
+
+// WithTracerProvider traces executions with tp; by default spans go to a
+// no-op provider.
+func WithTracerProvider(tp trace.TracerProvider) EngineOption {
+	return func(we *WorkflowEngine) { we.tracer = tp.Tracer(tracerName) }
+}
+
+var tracePropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// traceContext continues a trace started by the caller, so workflow spans
+// nest under the caller's span when a traceparent header is sent.
+func traceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracePropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,95 @@
+// tracing_test.go - Execution tracing tests
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttr(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestNodeSpansNestUnderExecution(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	we := newTestEngine(t, WithTracerProvider(tp))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "traced",
+		Nodes: []Node{
+			{ID: "first", Type: NodeTransform},
+			{ID: "second", Type: NodeTransform},
+		},
+		Connections: []Connection{{ID: "c", FromID: "first", ToID: "second"}},
+	})
+	if _, err := we.ExecuteWorkflow(ctx, wf.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans().Snapshots()
+	var root sdktrace.ReadOnlySpan
+	nodes := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		switch s.Name() {
+		case "workflow.execute":
+			root = s
+		case "node.execute":
+			nodes[spanAttr(s, "node.id")] = s
+		}
+	}
+	if root == nil || len(nodes) != 2 {
+		t.Fatalf("got %d spans, want a workflow span and two node spans", len(spans))
+	}
+	if root.Parent().IsValid() {
+		t.Error("workflow span has a parent")
+	}
+	for id, s := range nodes {
+		if s.Parent().SpanID() != root.SpanContext().SpanID() || s.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("node %s span is not a child of the workflow span", id)
+		}
+		if spanAttr(s, "node.type") != "transform" || spanAttr(s, "workflow.id") != wf.ID {
+			t.Errorf("node %s span attributes = %v", id, s.Attributes())
+		}
+	}
+}
+
+func TestExecutionContinuesCallerTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:   true,
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+	})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{
+		Name:  "traced",
+		Nodes: []Node{{ID: "t", Type: NodeTransform}},
+	})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	resp, body := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil, "traceparent", traceparent)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("execute: %d %s", resp.StatusCode, body)
+	}
+
+	wantTrace, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	wantParent, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	for _, span := range exporter.GetSpans().Snapshots() {
+		if span.SpanContext().TraceID() != wantTrace {
+			t.Errorf("span %s has trace %s, want the caller's", span.Name(), span.SpanContext().TraceID())
+		}
+		if span.Name() == "workflow.execute" && span.Parent().SpanID() != wantParent {
+			t.Errorf("workflow span parent = %s, want the caller's span", span.Parent().SpanID())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// triggers.go - Long-lived subscription triggers
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ============================================
+// Listener Triggers
+// ============================================
+
+// TriggerListener holds a subscription open for one trigger node until ctx
+// is cancelled, calling fire for every event it receives. fire reports
+// whether the execution succeeded so brokers can acknowledge or reject the
+// message.
+type TriggerListener func(ctx context.Context, node *Node, fire func(input interface{}) error) error
+
+// ListenerTriggers runs a listener per trigger node of each active
+// workflow, for node types that subscribe to an external source (database
+// notifications, message queues) rather than being called or scheduled.
+type ListenerTriggers struct {
+	engine    *WorkflowEngine
+	listeners map[NodeType]TriggerListener
+	mu        sync.Mutex
+	active    map[string]context.CancelFunc // workflowID -> stop listeners
+}
+
+func NewListenerTriggers(engine *WorkflowEngine) *ListenerTriggers {
+	return &ListenerTriggers{
+		engine:    engine,
+		listeners: make(map[NodeType]TriggerListener),
+		active:    make(map[string]context.CancelFunc),
+	}
+}
+
+// Handle registers the listener for a trigger node type.
+func (t *ListenerTriggers) Handle(nodeType NodeType, listener TriggerListener) {
+	t.listeners[nodeType] = listener
+}
+
+// Start replaces the listeners of a workflow with one per listening trigger
+// node and returns how many were started.
+func (t *ListenerTriggers) Start(w *Workflow) int {
+	t.Stop(w.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := 0
+	for i := range w.Nodes {
+		node := w.Nodes[i]
+		listener, ok := t.listeners[node.Type]
+		if !ok {
+			continue
+		}
+		go t.run(ctx, w, &node, listener)
+		started++
+	}
+
+	if started == 0 {
+		cancel()
+		return 0
+	}
+
+	t.mu.Lock()
+	t.active[w.ID] = cancel
+	t.mu.Unlock()
+	return started
+}
+
+// Stop closes every listener belonging to a workflow.
+func (t *ListenerTriggers) Stop(workflowID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if cancel, ok := t.active[workflowID]; ok {
+		cancel()
+		delete(t.active, workflowID)
+	}
+}
+
+// StopAll closes every listener.
+func (t *ListenerTriggers) StopAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, cancel := range t.active {
+		cancel()
+		delete(t.active, id)
+	}
+}
+
+// run holds one listener open with the node's secrets resolved for the
+// workflow's owner. A node whose secrets cannot be resolved is not started.
+func (t *ListenerTriggers) run(ctx context.Context, w *Workflow, node *Node, listener TriggerListener) {
+	logger := t.engine.logger.With("workflow_id", w.ID, "node_id", node.ID, "node_type", node.Type)
+
+	resolved, err := resolveSecrets(node, t.engine.secrets.Snapshot(w.OwnerID))
+	if err != nil {
+		logger.Error("trigger listener not started", "error", err)
+		return
+	}
+
+	fire := func(input interface{}) error {
+		result, err := t.engine.ExecuteWorkflowWithInput(context.Background(), w.ID, input)
+		if err != nil {
+			logger.Error("triggered execution failed", "error", err)
+			return err
+		}
+		if result.Status == StatusFailed || result.Status == StatusCancelled {
+			return fmt.Errorf("execution %s %s", result.ID, result.Status)
+		}
+		return nil
+	}
+
+	if err := listener(withLogger(ctx, logger), resolved, fire); err != nil && ctx.Err() == nil {
+		logger.Error("trigger listener stopped", "error", err)
+	}
+}
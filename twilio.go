@@ -0,0 +1,135 @@
+// twilio.go - SMS node sending through Twilio
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// twilioAPIBase is Twilio's REST API root.
+const twilioAPIBase = "https://api.twilio.com"
+
+// HTTPDoer sends HTTP requests; *http.Client is one, and tests substitute
+// their own.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// twilioErrors explains the Twilio error codes a misconfigured SMS node
+// most often runs into.
+var twilioErrors = map[int]string{
+	20003: "authentication failed; check accountSid and authToken",
+	21211: "invalid 'to' phone number",
+	21212: "invalid 'from' phone number",
+	21408: "sending to this region is not enabled on the account",
+	21606: "the 'from' number cannot send SMS from this account",
+	21608: "trial accounts can only send to verified numbers",
+	21610: "the recipient has unsubscribed (replied STOP)",
+	21614: "the 'to' number is not a mobile number",
+	21617: "the message body is too long",
+}
+
+// ============================================
+// SMS Node
+// ============================================
+
+// SMSExecutor sends a text message through Twilio's Messages API and
+// outputs its SID and status. Properties: accountSid, authToken, from (or
+// messagingServiceSid), to and body; to and body are templates rendered
+// against the input.
+type SMSExecutor struct {
+	client  HTTPDoer
+	baseURL string
+}
+
+func (e *SMSExecutor) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	str := func(name string) string {
+		v, _ := node.Properties[name].(string)
+		return strings.TrimSpace(v)
+	}
+	render := func(name string) (string, error) {
+		v, err := renderTemplate(str(name), input)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", name, err)
+		}
+		return exprString(v), nil
+	}
+
+	sid, token := str("accountSid"), str("authToken")
+	if sid == "" || token == "" {
+		return nil, fmt.Errorf("accountSid and authToken are required")
+	}
+	to, err := render("to")
+	if err != nil {
+		return nil, err
+	}
+	body, err := render("body")
+	if err != nil {
+		return nil, err
+	}
+	if to == "" || body == "" {
+		return nil, fmt.Errorf("to and body are required")
+	}
+
+	form := url.Values{"To": {to}, "Body": {body}}
+	if service := str("messagingServiceSid"); service != "" {
+		form.Set("MessagingServiceSid", service)
+	} else if from := str("from"); from != "" {
+		form.Set("From", from)
+	} else {
+		return nil, fmt.Errorf("from or messagingServiceSid is required")
+	}
+
+	base := e.baseURL
+	if base == "" {
+		base = twilioAPIBase
+	}
+	endpoint := base + "/2010-04-01/Accounts/" + url.PathEscape(sid) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(sid, token)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("twilio: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("twilio: %v", err)
+	}
+
+	var result struct {
+		SID     string `json:"sid"`
+		Status  string `json:"status"`
+		To      string `json:"to"`
+		From    string `json:"from"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(data, &result)
+	if resp.StatusCode >= 400 {
+		if result.Code == 0 {
+			return nil, withStatus(resp.StatusCode, fmt.Errorf("twilio returned %s", resp.Status))
+		}
+		if reason, ok := twilioErrors[result.Code]; ok {
+			return nil, withStatus(resp.StatusCode, fmt.Errorf("twilio error %d: %s (%s)", result.Code, reason, result.Message))
+		}
+		return nil, withStatus(resp.StatusCode, fmt.Errorf("twilio error %d: %s", result.Code, result.Message))
+	}
+
+	return map[string]interface{}{
+		"sid":    result.SID,
+		"status": result.Status,
+		"to":     result.To,
+		"from":   result.From,
+	}, nil
+}
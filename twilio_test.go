@@ -0,0 +1,151 @@
+// twilio_test.go - SMS node tests against a fake Twilio API
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeTwilio is an HTTPDoer answering every request with status and body,
+// keeping the requests and their decoded forms.
+type fakeTwilio struct {
+	status   int
+	body     string
+	requests []*http.Request
+	forms    []url.Values
+}
+
+func (f *fakeTwilio) Do(req *http.Request) (*http.Response, error) {
+	data, _ := io.ReadAll(req.Body)
+	form, _ := url.ParseQuery(string(data))
+	f.requests = append(f.requests, req)
+	f.forms = append(f.forms, form)
+	return &http.Response{
+		StatusCode: f.status,
+		Status:     http.StatusText(f.status),
+		Body:       io.NopCloser(strings.NewReader(f.body)),
+	}, nil
+}
+
+// smsProps is an SMS node's account settings plus extra.
+func smsProps(extra map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{"accountSid": "AC123", "authToken": "secret", "from": "+15550001111"}
+	for k, v := range extra {
+		props[k] = v
+	}
+	return props
+}
+
+func TestSMSSendsFormEncodedRequest(t *testing.T) {
+	api := &fakeTwilio{status: http.StatusCreated, body: `{"sid": "SM42", "status": "queued", "to": "+15552223333", "from": "+15550001111"}`}
+	we := newTestEngine(t, WithNodeExecutor(NodeSMS, &SMSExecutor{client: api}))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{
+		Name: "notify",
+		Nodes: []Node{{ID: "text", Type: NodeSMS, Properties: smsProps(map[string]interface{}{
+			"to":   "{{ customer.phone }}",
+			"body": "Hi {{ customer.name }}, order {{ order }} has shipped",
+		})}},
+	})
+
+	result, err := we.ExecuteWorkflowWithInput(ctx, wf.ID, map[string]interface{}{
+		"customer": map[string]interface{}{"name": "Ada", "phone": "+15552223333"},
+		"order":    1042.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors %v", result.Status, result.Errors)
+	}
+	want := map[string]interface{}{"sid": "SM42", "status": "queued", "to": "+15552223333", "from": "+15550001111"}
+	if got := result.Results["text"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+
+	req := api.requests[0]
+	if req.Method != http.MethodPost || req.URL.String() != "https://api.twilio.com/2010-04-01/Accounts/AC123/Messages.json" {
+		t.Fatalf("sent %s %s", req.Method, req.URL)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "AC123" || pass != "secret" {
+		t.Fatalf("basic auth = %q, %q", user, pass)
+	}
+	form := url.Values{
+		"To":   {"+15552223333"},
+		"From": {"+15550001111"},
+		"Body": {"Hi Ada, order 1042 has shipped"},
+	}
+	if !reflect.DeepEqual(api.forms[0], form) {
+		t.Fatalf("form = %v, want %v", api.forms[0], form)
+	}
+}
+
+func TestSMSMessagingServiceReplacesFrom(t *testing.T) {
+	api := &fakeTwilio{status: http.StatusCreated, body: `{"sid": "SM1", "status": "accepted"}`}
+	e := &SMSExecutor{client: api, baseURL: "http://twilio.test"}
+	props := smsProps(map[string]interface{}{"messagingServiceSid": "MG9", "to": "+15552223333", "body": "hi"})
+	if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if form := api.forms[0]; form.Get("MessagingServiceSid") != "MG9" || form.Has("From") {
+		t.Fatalf("form = %v, want MessagingServiceSid without From", form)
+	}
+	if host := api.requests[0].URL.Host; host != "twilio.test" {
+		t.Fatalf("sent to %s, want the configured base URL", host)
+	}
+}
+
+func TestSMSMapsTwilioErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"invalid number", http.StatusBadRequest, `{"code": 21211, "message": "The 'To' number 123 is not a valid phone number."}`, "twilio error 21211: invalid 'to' phone number"},
+		{"bad credentials", http.StatusUnauthorized, `{"code": 20003, "message": "Authenticate"}`, "authentication failed"},
+		{"unlisted code", http.StatusBadRequest, `{"code": 30001, "message": "Queue overflow"}`, "twilio error 30001: Queue overflow"},
+		{"no error body", http.StatusServiceUnavailable, `upstream down`, "twilio returned"},
+	}
+	for _, tt := range tests {
+		e := &SMSExecutor{client: &fakeTwilio{status: tt.status, body: tt.body}}
+		_, err := e.Execute(context.Background(), &Node{Properties: smsProps(map[string]interface{}{"to": "123", "body": "hi"})}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+			continue
+		}
+		// The status lets the breaker tell a bad request from an outage
+		var se *statusError
+		if !errors.As(err, &se) || se.HTTPStatus() != tt.status {
+			t.Errorf("%s: err %v is not tagged with status %d", tt.name, err, tt.status)
+		}
+	}
+}
+
+func TestSMSRejectsBadSettings(t *testing.T) {
+	api := &fakeTwilio{status: http.StatusCreated}
+	e := &SMSExecutor{client: api}
+	for name, props := range map[string]map[string]interface{}{
+		"no credentials": {"from": "+1555", "to": "+1555", "body": "hi"},
+		"no to":          smsProps(map[string]interface{}{"body": "hi"}),
+		"no body":        smsProps(map[string]interface{}{"to": "+1555"}),
+		"no sender":      smsProps(map[string]interface{}{"from": "", "to": "+1555", "body": "hi"}),
+		"bad template":   smsProps(map[string]interface{}{"to": "+1555", "body": "{{ unclosed"}),
+	} {
+		if _, err := e.Execute(context.Background(), &Node{Properties: props}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if len(api.requests) != 0 {
+		t.Fatalf("sent %d requests for invalid nodes", len(api.requests))
+	}
+}
@@ -0,0 +1,157 @@
+// uploads.go - Multipart form and file uploads to webhooks
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+)
+
+// defaultMaxUploadBytes caps each file uploaded to a webhook unless the
+// node sets maxFileSize. The request body limit applies as well.
+const defaultMaxUploadBytes = 10 << 20
+
+// ============================================
+// Multipart Uploads
+// ============================================
+
+// UploadedFile describes a file posted to a webhook. Data holds its
+// contents in base64, or Path a temporary copy, by the node's fileMode.
+type UploadedFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Data        string `json:"data,omitempty"`
+	Path        string `json:"path,omitempty"`
+}
+
+// isMultipart reports whether contentType is multipart/form-data,
+// returning its boundary.
+func isMultipart(contentType string) (string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		return "", false
+	}
+	return params["boundary"], params["boundary"] != ""
+}
+
+// parseMultipart reads a multipart/form-data body into a webhook input:
+// {"fields": {name: value or [values]}, "files": [UploadedFile]}. With the
+// node's fileMode set to "tempfile", files are written to temporary paths
+// under baseDir's uploads directory, where the file nodes can read them,
+// and the caller removes them with cleanup once the run is over; otherwise
+// (the default, "base64") they are inlined.
+func parseMultipart(node *Node, body []byte, boundary, baseDir string) (input map[string]interface{}, cleanup func(), err error) {
+	mode, _ := node.Properties["fileMode"].(string)
+	switch mode {
+	case "":
+		mode = "base64"
+	case "base64", "tempfile":
+	default:
+		return nil, nil, fmt.Errorf("unsupported fileMode: %q", mode)
+	}
+	if mode == "tempfile" && baseDir == "" {
+		return nil, nil, fmt.Errorf("fileMode tempfile needs file access; set FILES_DIR to allow it")
+	}
+	maxFile := int64(defaultMaxUploadBytes)
+	if v := node.Properties["maxFileSize"]; v != nil && v != "" {
+		n, err := toInt(v)
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("invalid maxFileSize: %v", v)
+		}
+		maxFile = int64(n)
+	}
+
+	var paths []string
+	cleanup = func() {
+		for _, p := range paths {
+			os.Remove(p)
+		}
+	}
+	fields := make(map[string]interface{})
+	files := []UploadedFile{}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("malformed multipart body: %v", err)
+		}
+		data, err := io.ReadAll(io.LimitReader(part, maxFile+1))
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("malformed multipart body: %v", err)
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			switch prev := fields[name].(type) {
+			case nil:
+				fields[name] = string(data)
+			case []interface{}:
+				fields[name] = append(prev, string(data))
+			default:
+				fields[name] = []interface{}{prev, string(data)}
+			}
+			continue
+		}
+
+		if int64(len(data)) > maxFile {
+			cleanup()
+			return nil, nil, fmt.Errorf("file %q exceeds %d bytes", part.FileName(), maxFile)
+		}
+		file := UploadedFile{
+			Field:       name,
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        int64(len(data)),
+		}
+		if mode == "base64" {
+			file.Data = base64.StdEncoding.EncodeToString(data)
+		} else {
+			if file.Path, err = writeTempUpload(baseDir, data); err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			paths = append(paths, file.Path)
+		}
+		files = append(files, file)
+	}
+
+	input = map[string]interface{}{"fields": fields, "files": genericJSON(files)}
+	return input, cleanup, nil
+}
+
+// writeTempUpload saves an upload under base/uploads.
+func writeTempUpload(base string, data []byte) (string, error) {
+	dir, err := resolveFilePath(base, "uploads")
+	if err != nil {
+		return "", fmt.Errorf("saving upload: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("saving upload: %v", err)
+	}
+	f, err := os.CreateTemp(dir, "goflow-upload-*")
+	if err != nil {
+		return "", fmt.Errorf("saving upload: %v", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("saving upload: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("saving upload: %v", err)
+	}
+	return f.Name(), nil
+}
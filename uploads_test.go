@@ -0,0 +1,169 @@
+// uploads_test.go - Multipart webhook upload tests
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// multipartForm encodes fields and one file as multipart/form-data,
+// returning the body and its Content-Type.
+func multipartForm(t *testing.T, fields [][2]string, filename, content string) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, f := range fields {
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="doc"; filename="`+filename+`"`)
+	header.Set("Content-Type", "text/plain")
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String(), mw.FormDataContentType()
+}
+
+// uploadFlow is a webhook workflow passing what it receives to node r.
+func uploadFlow(hookProps map[string]interface{}, r NodeType) *Workflow {
+	return &Workflow{
+		Name:        "uploads",
+		Nodes:       []Node{{ID: "hook", Type: NodeWebhook, Properties: hookProps}, {ID: "r", Type: r}},
+		Connections: []Connection{{ID: "c", FromID: "hook", ToID: "r"}},
+	}
+}
+
+func TestWebhookMultipartUpload(t *testing.T) {
+	rec := &recorder{}
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true, NodeExecutors: map[NodeType]NodeExecutor{nodeRecord: rec}})
+	wf := mustCreate(t, s.engine, context.Background(), uploadFlow(nil, nodeRecord))
+	if resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/activate", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("activate: %d %s", resp.StatusCode, data)
+	}
+
+	body, contentType := multipartForm(t, [][2]string{{"title", "report"}, {"tag", "a"}, {"tag", "b"}}, "notes.txt", "hello")
+	resp, data := doRequest(t, ts, "POST", "/hooks/"+wf.ID+"/hook", body, "Content-Type", contentType)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("hook: %d %s", resp.StatusCode, data)
+	}
+
+	calls := rec.calls()
+	if len(calls) != 1 {
+		t.Fatalf("recorded %d runs, want 1", len(calls))
+	}
+	got := calls[0].(map[string]interface{})["body"]
+	want := map[string]interface{}{
+		"fields": map[string]interface{}{"title": "report", "tag": []interface{}{"a", "b"}},
+		"files": []interface{}{map[string]interface{}{
+			"field":       "doc",
+			"filename":    "notes.txt",
+			"contentType": "text/plain",
+			"size":        5.0,
+			"data":        base64.StdEncoding.EncodeToString([]byte("hello")),
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("workflow input = %v, want %v", got, want)
+	}
+}
+
+// uploadReader is a node executor that reads the first uploaded file's
+// temporary path while the run is in progress.
+type uploadReader struct{ paths *[]string }
+
+func (u uploadReader) Execute(ctx context.Context, node *Node, input interface{}) (interface{}, error) {
+	files := input.(map[string]interface{})["body"].(map[string]interface{})["files"].([]interface{})
+	path := files[0].(map[string]interface{})["path"].(string)
+	*u.paths = append(*u.paths, path)
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+const nodeUploadReader NodeType = "upload_reader"
+
+func TestWebhookUploadTempFilesRemoved(t *testing.T) {
+	var paths []string
+	dir := t.TempDir()
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:  true,
+		FileBaseDir:   dir,
+		NodeExecutors: map[NodeType]NodeExecutor{nodeUploadReader: uploadReader{&paths}},
+	})
+	wf := mustCreate(t, s.engine, context.Background(), uploadFlow(map[string]interface{}{"fileMode": "tempfile"}, nodeUploadReader))
+	doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/activate", nil)
+
+	body, contentType := multipartForm(t, nil, "notes.txt", "on disk")
+	resp, data := doRequest(t, ts, "POST", "/hooks/"+wf.ID+"/hook", body, "Content-Type", contentType)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("hook: %d %s", resp.StatusCode, data)
+	}
+	var result ExecutionResult
+	decode(t, data, &result)
+	if result.Results["r"] != "on disk" {
+		t.Fatalf("node read %v, want the uploaded contents", result.Results["r"])
+	}
+	if len(paths) != 1 || !strings.HasPrefix(paths[0], dir) {
+		t.Fatalf("upload paths = %v, want one under %s", paths, dir)
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Fatalf("temporary upload still exists after the run: %v", err)
+	}
+}
+
+func TestWebhookUploadRejects(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+	}{
+		{"file over maxFileSize", map[string]interface{}{"maxFileSize": 4.0}},
+		{"tempfile without file access", map[string]interface{}{"fileMode": "tempfile"}},
+	}
+	for _, tt := range tests {
+		wf := mustCreate(t, s.engine, context.Background(), uploadFlow(tt.props, NodeTransform))
+		doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/activate", nil)
+
+		body, contentType := multipartForm(t, nil, "big.txt", "too long")
+		resp, data := doRequest(t, ts, "POST", "/hooks/"+wf.ID+"/hook", body, "Content-Type", contentType)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", tt.name, resp.StatusCode, data)
+		}
+		if runs := s.engine.executions.List("", wf.ID); len(runs) != 0 {
+			t.Errorf("%s: rejected upload started a run", tt.name)
+		}
+	}
+}
+
+func TestIsMultipart(t *testing.T) {
+	tests := []struct {
+		contentType, boundary string
+		ok                    bool
+	}{
+		{"multipart/form-data; boundary=xyz", "xyz", true},
+		{"multipart/form-data", "", false},
+		{"multipart/mixed; boundary=xyz", "", false},
+		{"application/json", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		boundary, ok := isMultipart(tt.contentType)
+		if boundary != tt.boundary || ok != tt.ok {
+			t.Errorf("isMultipart(%q) = %q, %v, want %q, %v", tt.contentType, boundary, ok, tt.boundary, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// validate.go - Workflow validation against the node catalog
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// commonProperties are accepted by every node type.
+var commonProperties = []PropertySpec{
+	{Name: "continueOnError", Label: "Continue On Error", Type: PropSelect, Options: []string{"false", "true"}, Default: "false"},
+	{Name: "runIf", Label: "Run If", Type: PropText, Default: ""},
+	{Name: "outputMap", Label: "Output Map", Type: PropTextarea, Default: ""},
+	{Name: "outputSchema", Label: "Output Sample", Type: PropTextarea, Default: ""},
+}
+
+// nodeChecks are node-type specific checks run beyond the catalog schema.
+var nodeChecks = map[NodeType]func(*Node) []FieldError{
+	NodeRegex:        checkRegexNode,
+	NodeJSONQuery:    checkJSONQueryNode,
+	NodeHTMLExtract:  checkHTMLExtractNode,
+	NodeTextTemplate: checkTextTemplateNode,
+}
+
+// FieldError is one problem with a workflow, located by node and field.
+type FieldError struct {
+	NodeID  string `json:"nodeId,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in a workflow.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		if f.NodeID != "" {
+			msgs[i] = fmt.Sprintf("node %s: %s: %s", f.NodeID, f.Field, f.Message)
+		} else {
+			msgs[i] = fmt.Sprintf("%s: %s", f.Field, f.Message)
+		}
+	}
+	return "invalid workflow: " + strings.Join(msgs, "; ")
+}
+
+// ============================================
+// Validation
+// ============================================
+
+// Validate checks node IDs and checks each node's properties against its
+// type's schema in the catalog: numbers must be numeric and selects must
+// hold one of their options. Blank values and secret references are
+// accepted anywhere; node types without a catalog entry are not checked.
+// Node types with an entry in nodeChecks are checked further.
+func (w *Workflow) Validate() error {
+	var errs []FieldError
+	seen := make(map[string]bool, len(w.Nodes))
+	for i, node := range w.Nodes {
+		switch {
+		case node.ID == "":
+			errs = append(errs, FieldError{Field: fmt.Sprintf("nodes[%d].id", i), Message: "is required"})
+		case seen[node.ID]:
+			errs = append(errs, FieldError{Field: fmt.Sprintf("nodes[%d].id", i), Message: fmt.Sprintf("duplicate node id %q", node.ID)})
+		}
+		seen[node.ID] = true
+		if check, ok := nodeChecks[node.Type]; ok {
+			errs = append(errs, check(&w.Nodes[i])...)
+		}
+
+		info, ok := catalogEntry(node.Type)
+		if !ok {
+			continue
+		}
+		for _, spec := range append(info.Properties, commonProperties...) {
+			if msg := checkProperty(spec, node.Properties[spec.Name]); msg != "" {
+				errs = append(errs, FieldError{NodeID: node.ID, Field: spec.Name, Message: msg})
+			}
+		}
+	}
+	if !validConcurrencyPolicy(w.ConcurrencyPolicy) {
+		errs = append(errs, FieldError{Field: "concurrency_policy", Message: "must be allow, skip or queue"})
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// catalogEntry returns the catalog entry for t.
+func catalogEntry(t NodeType) (NodeTypeInfo, bool) {
+	for _, info := range nodeCatalog {
+		if info.Type == t {
+			return info, true
+		}
+	}
+	return NodeTypeInfo{}, false
+}
+
+// checkProperty returns why v is not a valid value for spec, or "".
+func checkProperty(spec PropertySpec, v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok && (strings.TrimSpace(s) == "" || secretRefPattern.MatchString(s)) {
+		return ""
+	}
+
+	switch spec.Type {
+	case PropNumber:
+		switch x := v.(type) {
+		case float64:
+			return ""
+		case string:
+			if _, err := strconv.ParseFloat(strings.TrimSpace(x), 64); err == nil {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be a number, got %v", v)
+
+	case PropSelect:
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = x
+		case bool, float64:
+			s = exprString(x)
+		default:
+			return fmt.Sprintf("must be one of %s", strings.Join(spec.Options, ", "))
+		}
+		for _, opt := range spec.Options {
+			// HTTP methods are case-insensitive
+			if s == opt || (spec.Name == "method" && strings.EqualFold(s, opt)) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s, got %q", strings.Join(spec.Options, ", "), s)
+	}
+	return ""
+}
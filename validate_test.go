@@ -0,0 +1,82 @@
+// validate_test.go - Node property schema validation tests
+package main
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestValidateRejectsInvalidProperties(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  Node
+		field string
+	}{
+		{"timer interval not numeric", Node{ID: "n", Type: NodeTimer, Properties: map[string]interface{}{"interval": "every minute"}}, "interval"},
+		{"timer interval wrong type", Node{ID: "n", Type: NodeTimer, Properties: map[string]interface{}{"interval": true}}, "interval"},
+		{"http unknown method", Node{ID: "n", Type: NodeHTTP, Properties: map[string]interface{}{"url": "http://x", "method": "FETCH"}}, "method"},
+		{"http method not a string", Node{ID: "n", Type: NodeHTTP, Properties: map[string]interface{}{"method": []interface{}{"GET"}}}, "method"},
+		{"common select", Node{ID: "n", Type: NodeTransform, Properties: map[string]interface{}{"continueOnError": "maybe"}}, "continueOnError"},
+		{"bad regex", Node{ID: "n", Type: NodeRegex, Properties: map[string]interface{}{"pattern": "(unclosed"}}, "pattern"},
+	}
+	for _, tt := range tests {
+		w := &Workflow{Nodes: []Node{tt.node}}
+		var invalid *ValidationError
+		if err := w.Validate(); !errors.As(err, &invalid) {
+			t.Errorf("%s: err = %v, want a ValidationError", tt.name, err)
+			continue
+		}
+		if len(invalid.Fields) != 1 || invalid.Fields[0].NodeID != "n" || invalid.Fields[0].Field != tt.field {
+			t.Errorf("%s: fields = %+v, want one error on n.%s", tt.name, invalid.Fields, tt.field)
+		}
+	}
+}
+
+func TestValidateAcceptsValidProperties(t *testing.T) {
+	w := &Workflow{Nodes: []Node{
+		{ID: "a", Type: NodeTimer, Properties: map[string]interface{}{"interval": 30.0}},
+		{ID: "b", Type: NodeTimer, Properties: map[string]interface{}{"interval": " 15 "}},
+		{ID: "c", Type: NodeHTTP, Properties: map[string]interface{}{"method": "post"}},
+		{ID: "d", Type: NodeHTTP, Properties: map[string]interface{}{"method": "{{secrets.METHOD}}"}},
+		{ID: "e", Type: NodeHTTP, Properties: map[string]interface{}{"method": ""}},
+		{ID: "f", Type: NodeTransform, Properties: map[string]interface{}{"continueOnError": true}},
+		{ID: "g", Type: "custom", Properties: map[string]interface{}{"anything": []interface{}{1.0}}},
+	}}
+	if err := w.Validate(); err != nil {
+		t.Fatalf("valid workflow rejected: %v", err)
+	}
+}
+
+func TestValidateReportsEveryField(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	resp, data := doRequest(t, ts, "POST", "/api/workflows", map[string]interface{}{
+		"name": "broken",
+		"nodes": []interface{}{
+			map[string]interface{}{"id": "tick", "type": "timer", "properties": map[string]interface{}{"interval": "soon"}},
+			map[string]interface{}{"id": "call", "type": "http", "properties": map[string]interface{}{"method": "FETCH"}},
+			map[string]interface{}{"id": "", "type": "transform"},
+		},
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("create: %d %s, want 400", resp.StatusCode, data)
+	}
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				Fields []FieldError `json:"fields"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	decode(t, data, &body)
+	want := []FieldError{
+		{NodeID: "tick", Field: "interval", Message: "must be a number, got soon"},
+		{NodeID: "call", Field: "method", Message: `must be one of GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS, got "FETCH"`},
+		{Field: "nodes[2].id", Message: "is required"},
+	}
+	if body.Error.Code != CodeValidationFailed || !reflect.DeepEqual(body.Error.Details.Fields, want) {
+		t.Fatalf("error = %+v\nwant fields %+v", body.Error, want)
+	}
+}
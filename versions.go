@@ -0,0 +1,101 @@
+// versions.go - Workflow version history and rollback
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+var ErrVersionNotFound = errors.New("workflow version not found")
+
+// ============================================
+// Version History
+// ============================================
+
+// WorkflowVersions lists the earlier versions of a workflow, newest first.
+// Each is the workflow as it stood before an edit replaced it.
+func (we *WorkflowEngine) WorkflowVersions(ctx context.Context, id string) ([]*Workflow, error) {
+	if _, err := we.liveWorkflow(ctx, id); err != nil {
+		return nil, err
+	}
+	return we.store.Versions(id)
+}
+
+// RollbackWorkflow restores the definition a workflow had at version. The
+// rollback is itself an edit: it gets a new version number and the current
+// definition joins the history, so it can be undone in turn.
+func (we *WorkflowEngine) RollbackWorkflow(ctx context.Context, id string, version int64) (*Workflow, error) {
+	versions, err := we.WorkflowVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var target *Workflow
+	for _, v := range versions {
+		if v.Version == version {
+			target = v
+			break
+		}
+	}
+	if target == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	w := &Workflow{
+		ID:                id,
+		Name:              target.Name,
+		Description:       target.Description,
+		Nodes:             target.Nodes,
+		Connections:       target.Connections,
+		RateLimit:         target.RateLimit,
+		Tags:              target.Tags,
+		ConcurrencyPolicy: target.ConcurrencyPolicy,
+	}
+	if err := we.UpdateWorkflow(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// ============================================
+// Version Handlers
+// ============================================
+
+func (s *Server) handleListVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := s.engine.WorkflowVersions(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	presented := make([]*Workflow, len(versions))
+	for i, wf := range versions {
+		presented[i] = s.presentWorkflow(r, wf)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(presented)
+}
+
+func (s *Server) handleRollbackWorkflow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	version, err := strconv.ParseInt(vars["version"], 10, 64)
+	if err != nil || version <= 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, "invalid version: "+vars["version"])
+		return
+	}
+
+	workflow, err := s.engine.RollbackWorkflow(r.Context(), vars["id"], version)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", workflowETag(workflow))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.presentWorkflow(r, workflow))
+}
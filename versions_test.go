@@ -0,0 +1,115 @@
+// versions_test.go - Workflow version history and rollback tests
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestVersionsAndRollback(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, &Workflow{Name: "v1"})
+	for _, name := range []string{"v2", "v3", "v4"} {
+		if err := s.engine.UpdateWorkflow(ctx, &Workflow{ID: wf.ID, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, data := doRequest(t, ts, "GET", "/api/workflows/"+wf.ID+"/versions", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("versions: %d %s", resp.StatusCode, data)
+	}
+	var versions []*Workflow
+	decode(t, data, &versions)
+	var names []string
+	for _, v := range versions {
+		names = append(names, v.Name)
+	}
+	if fmt.Sprint(names) != "[v3 v2 v1]" {
+		t.Fatalf("history = %v, want [v3 v2 v1], newest first", names)
+	}
+	first := versions[2]
+
+	path := fmt.Sprintf("/api/workflows/%s/versions/%d/rollback", wf.ID, first.Version)
+	resp, data = doRequest(t, ts, "POST", path, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("rollback: %d %s", resp.StatusCode, data)
+	}
+	if resp.Header.Get("ETag") == "" {
+		t.Error("rollback response has no ETag")
+	}
+	current, err := s.engine.GetWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Name != "v1" || current.Version <= versions[0].Version {
+		t.Fatalf("after rollback: name %q version %d, want v1 under a new version", current.Name, current.Version)
+	}
+
+	// The rollback is an edit of its own, so v4 can be brought back
+	versions, err = s.engine.WorkflowVersions(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 4 || versions[0].Name != "v4" {
+		t.Fatalf("history after rollback starts with %q (%d versions), want v4 of 4", versions[0].Name, len(versions))
+	}
+	if _, err := s.engine.RollbackWorkflow(ctx, wf.ID, versions[0].Version); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := s.engine.GetWorkflow(ctx, wf.ID); current.Name != "v4" {
+		t.Fatalf("undoing the rollback left %q, want v4", current.Name)
+	}
+}
+
+func TestRollbackRejectsUnknownVersion(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	wf := mustCreate(t, s.engine, context.Background(), &Workflow{Name: "only"})
+
+	tests := []struct {
+		version string
+		status  int
+	}{
+		{"99", http.StatusNotFound},
+		{"0", http.StatusBadRequest},
+		{"latest", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/versions/"+tt.version+"/rollback", nil)
+		if resp.StatusCode != tt.status {
+			t.Errorf("version %s: %d %s, want %d", tt.version, resp.StatusCode, data, tt.status)
+		}
+	}
+	if _, err := s.engine.RollbackWorkflow(asPrincipal("bob"), wf.ID, 1); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Fatalf("another tenant: err = %v, want not found", err)
+	}
+}
+
+func TestVersionRetention(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxVersions = 3
+	we := newTestEngine(t, WithStore(store))
+	ctx := context.Background()
+	wf := mustCreate(t, we, ctx, &Workflow{Name: "edit 0"})
+	for i := 1; i <= 5; i++ {
+		if err := we.UpdateWorkflow(ctx, &Workflow{ID: wf.ID, Name: fmt.Sprintf("edit %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := we.WorkflowVersions(ctx, wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range versions {
+		names = append(names, v.Name)
+	}
+	if fmt.Sprint(names) != "[edit 4 edit 3 edit 2]" {
+		t.Fatalf("retained = %v, want the three newest", names)
+	}
+}
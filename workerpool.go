@@ -0,0 +1,137 @@
+// workerpool.go - Bounded pool all executions run through
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// defaultMaxWorkers is how many executions run at once by default.
+	defaultMaxWorkers = 100
+	// defaultQueueDepth is how many executions may wait for a worker.
+	defaultQueueDepth = 1000
+)
+
+// ErrQueueFull reports an execution refused because every worker is busy
+// and the queue is full.
+var ErrQueueFull = errors.New("execution queue is full")
+
+// WithWorkerPool caps concurrent executions at workers, with up to
+// queueDepth more waiting for a worker. Beyond that, new executions are
+// refused with ErrQueueFull if reject is set, or wait for room otherwise.
+// Zero values use defaultMaxWorkers and defaultQueueDepth.
+func WithWorkerPool(workers, queueDepth int, reject bool) EngineOption {
+	return func(we *WorkflowEngine) { we.pool = NewWorkerPool(workers, queueDepth, reject) }
+}
+
+// ============================================
+// Worker Pool
+// ============================================
+
+// WorkerPool admits executions into a bounded queue and hands each a
+// worker in turn. An execution calls Enqueue, then Start once it is ready
+// to run, and Finish when done; one that gives up before starting calls
+// Dequeue instead.
+type WorkerPool struct {
+	// admitted holds a token per queued or running execution
+	admitted chan struct{}
+	// workers holds a token per running execution
+	workers chan struct{}
+	reject  bool
+}
+
+func NewWorkerPool(workers, queueDepth int, reject bool) *WorkerPool {
+	if workers <= 0 {
+		workers = defaultMaxWorkers
+	}
+	if queueDepth <= 0 {
+		queueDepth = defaultQueueDepth
+	}
+	return &WorkerPool{
+		admitted: make(chan struct{}, workers+queueDepth),
+		workers:  make(chan struct{}, workers),
+		reject:   reject,
+	}
+}
+
+// Enqueue admits an execution, failing with ErrQueueFull when the pool
+// rejects and is full, or waiting for room until ctx is done otherwise.
+func (p *WorkerPool) Enqueue(ctx context.Context) error {
+	select {
+	case p.admitted <- struct{}{}:
+		return nil
+	default:
+	}
+	if p.reject {
+		return ErrQueueFull
+	}
+	select {
+	case p.admitted <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dequeue gives up an admitted execution's place without running it.
+func (p *WorkerPool) Dequeue() {
+	<-p.admitted
+}
+
+// Start waits for a free worker until ctx is done. On error the execution
+// is still queued and must be dequeued.
+func (p *WorkerPool) Start(ctx context.Context) error {
+	select {
+	case p.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Finish frees a started execution's worker and place.
+func (p *WorkerPool) Finish() {
+	<-p.workers
+	<-p.admitted
+}
+
+// Stats reports the running executions, those waiting for a worker, and
+// the worker and queue capacity.
+func (p *WorkerPool) Stats() (active, queued, workers, queueDepth int) {
+	active = len(p.workers)
+	queued = len(p.admitted) - active
+	if queued < 0 {
+		queued = 0
+	}
+	return active, queued, cap(p.workers), cap(p.admitted) - cap(p.workers)
+}
+
+// enqueue admits a new run into the pool. Sub-workflow calls run inside
+// their caller's worker and bypass the pool, so a saturated pool cannot
+// deadlock on nested runs. The returned start waits for a worker and
+// returns the function that frees it; abandon gives up the run's place if
+// it will not start.
+func (we *WorkflowEngine) enqueue(ctx context.Context) (start func(context.Context) (func(), error), abandon func(), err error) {
+	if len(callStackFromContext(ctx)) > 0 {
+		return func(context.Context) (func(), error) { return func() {}, nil }, func() {}, nil
+	}
+	if err := we.pool.Enqueue(ctx); err != nil {
+		return nil, nil, err
+	}
+	start = func(ctx context.Context) (func(), error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(we.stopping, cancel)
+		defer stop()
+		if err := we.pool.Start(ctx); err != nil {
+			we.pool.Dequeue()
+			if we.stopping.Err() != nil {
+				return nil, ErrShuttingDown
+			}
+			return nil, err
+		}
+		return we.pool.Finish, nil
+	}
+	return start, we.pool.Dequeue, nil
+}
@@ -0,0 +1,122 @@
+// workerpool_test.go - Execution worker pool tests
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolCapsConcurrency(t *testing.T) {
+	probe := &concurrencyProbe{}
+	we := newTestEngine(t, WithWorkerPool(2, 10, false), WithNodeExecutor(nodeBusy, probe))
+	wf := mustCreate(t, we, context.Background(), &Workflow{Name: "busy", Nodes: []Node{{ID: "b", Type: nodeBusy}}})
+
+	for i, err := range triggerAll(we, wf.ID, 8) {
+		if err != nil {
+			t.Errorf("run %d: %v", i, err)
+		}
+	}
+	if probe.peak != 2 {
+		t.Fatalf("%d runs at once, want the pool's 2 workers", probe.peak)
+	}
+	if active, queued, _, _ := we.pool.Stats(); active != 0 || queued != 0 {
+		t.Fatalf("after the runs: %d active, %d queued", active, queued)
+	}
+}
+
+func TestWorkerPoolRejectsWhenFull(t *testing.T) {
+	release := make(gate)
+	s, ts := newTestServer(t, ServerConfig{
+		AuthDisabled:   true,
+		MaxWorkers:     1,
+		QueueDepth:     1,
+		RejectWhenFull: true,
+		NodeExecutors:  map[NodeType]NodeExecutor{nodeGate: release},
+	})
+	ctx := context.Background()
+	wf := mustCreate(t, s.engine, ctx, &Workflow{Name: "held", Nodes: []Node{{ID: "g", Type: nodeGate}}})
+
+	// One run takes the worker and a second waits in the queue
+	var ids []string
+	for i := 0; i < 2; i++ {
+		id, err := s.engine.StartWorkflow(ctx, wf.ID, nil)
+		if err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		ids = append(ids, id)
+	}
+	eventually(t, "one running and one queued", func() bool {
+		active, queued, _, _ := s.engine.pool.Stats()
+		return active == 1 && queued == 1
+	})
+
+	if _, err := s.engine.ExecuteWorkflow(ctx, wf.ID); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("engine: err = %v, want ErrQueueFull", err)
+	}
+	resp, data := doRequest(t, ts, "POST", "/api/workflows/"+wf.ID+"/execute", nil)
+	if resp.StatusCode != http.StatusTooManyRequests || apiError(t, data).Code != CodeQueueFull {
+		t.Fatalf("API: %d %s, want 429 %s", resp.StatusCode, data, CodeQueueFull)
+	}
+
+	_, data = doRequest(t, ts, "GET", "/metrics", nil)
+	for _, line := range []string{"goflow_workers_active 1", "goflow_workers_max 1", "goflow_execution_queue_depth 1", "goflow_execution_queue_capacity 1"} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("metrics lack %q", line)
+		}
+	}
+
+	close(release)
+	for _, id := range ids {
+		eventually(t, "run "+id+" to finish", func() bool {
+			result, err := s.engine.executions.Get("", id)
+			return err == nil && result.Status == StatusCompleted
+		})
+	}
+	if _, err := s.engine.ExecuteWorkflow(ctx, wf.ID); err != nil {
+		t.Fatalf("run once the pool drained: %v", err)
+	}
+}
+
+func TestWorkerPoolBlocksWhenFull(t *testing.T) {
+	release := make(gate)
+	we := newTestEngine(t, WithWorkerPool(1, 1, false), WithNodeExecutor(nodeGate, release))
+	wf := mustCreate(t, we, context.Background(), &Workflow{Name: "held", Nodes: []Node{{ID: "g", Type: nodeGate}}})
+	for i := 0; i < 2; i++ {
+		if _, err := we.StartWorkflow(context.Background(), wf.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the pool to fill", func() bool {
+		active, queued, _, _ := we.pool.Stats()
+		return active == 1 && queued == 1
+	})
+
+	// Without reject a caller waits for room, here until it gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := we.ExecuteWorkflow(ctx, wf.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the caller's deadline", err)
+	}
+	close(release)
+}
+
+func TestWorkerPoolSubWorkflowsBypassPool(t *testing.T) {
+	we := newTestEngine(t, WithWorkerPool(1, 1, true))
+	ctx := context.Background()
+	leaf := mustCreate(t, we, ctx, &Workflow{Name: "leaf", Nodes: []Node{{ID: "t", Type: NodeTransform}}})
+	top := mustCreate(t, we, ctx, caller("top", leaf.ID))
+
+	// With a single worker held by the caller, a pooled sub-workflow
+	// call could never start
+	result, err := we.ExecuteWorkflow(ctx, top.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted {
+		t.Fatalf("status %s, errors %v", result.Status, result.Errors)
+	}
+}
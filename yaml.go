@@ -0,0 +1,76 @@
+// yaml.go - YAML encoding of workflows
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================
+// YAML
+// ============================================
+
+// isYAML reports whether a request body is YAML by its Content-Type.
+func isYAML(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+	return false
+}
+
+// decodeBody reads a JSON body, or a YAML one when the Content-Type says
+// so, rejecting unknown fields. YAML is converted to JSON first so the
+// json struct tags apply to both; it is read as YAML 1.2, so keys like "y"
+// stay strings.
+func decodeBody(r *http.Request, v interface{}) error {
+	if !isYAML(r) {
+		return decodeJSON(r.Body, v)
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return bodyError(err)
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid YAML: %v", err)
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return fmt.Errorf("invalid YAML: %v", err)
+	}
+	return decodeJSON(bytes.NewReader(data), v)
+}
+
+// marshalYAML renders v as block-style YAML with keys in the order its
+// JSON encoding uses, so struct fields keep their declared order and
+// exports diff cleanly.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML; decoding into a node tree keeps key order
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	blockStyle(&doc)
+	return yaml.Marshal(&doc)
+}
+
+// blockStyle clears the flow style the JSON input left on every node.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,102 @@
+// yaml_test.go - YAML workflow encoding tests
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkflowYAMLRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	want := Workflow{
+		ID:          "wf-1",
+		OwnerID:     "alice",
+		Name:        "nightly report",
+		Description: "multi-line\ndescription",
+		Nodes: []Node{
+			{ID: "timer", Type: NodeTimer, Name: "Every night", X: 10, Y: 20, Properties: map[string]interface{}{"cron": "0 2 * * *"}},
+			{ID: "fetch", Type: NodeHTTP, Name: "Fetch", X: 200, Y: 20, Properties: map[string]interface{}{
+				"url":     "https://example.com/report",
+				"headers": map[string]interface{}{"Accept": "application/json"},
+				"retries": 3.0,
+				"y":       "stays a string key",
+			}},
+			{ID: "notify", Type: NodeSlack, Name: "Notify", X: 400, Y: 20, Properties: map[string]interface{}{"channel": "#ops", "enabled": true}},
+		},
+		Connections: []Connection{
+			{ID: "c1", FromID: "timer", ToID: "fetch"},
+			{ID: "c2", FromID: "fetch", ToID: "notify"},
+			{ID: "c3", FromID: "fetch", ToID: "notify", Port: PortError},
+		},
+		CreatedAt: created,
+		UpdatedAt: created.Add(time.Hour),
+		Status:    StatusActive,
+		Version:   4,
+		Tags:      []string{"reports"},
+	}
+
+	data, err := marshalYAML(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	if strings.Contains(text, "{") || strings.Index(text, "id:") > strings.Index(text, "name:") {
+		t.Fatalf("YAML is not block style in field order:\n%s", text)
+	}
+
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/yaml; charset=utf-8")
+	var got Workflow
+	if err := decodeBody(req, &got); err != nil {
+		t.Fatalf("decoding:\n%s\n%v", text, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip changed the workflow:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeBodyYAMLRejectsUnknownFields(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader("name: x\nnodez: []\n"))
+	req.Header.Set("Content-Type", "text/yaml")
+	if err := decodeBody(req, &Workflow{}); err == nil {
+		t.Fatal("expected an unknown field error")
+	}
+}
+
+func TestYAMLCreateAndExport(t *testing.T) {
+	s, ts := newTestServer(t, ServerConfig{AuthDisabled: true})
+	yamlBody := `name: from yaml
+nodes:
+  - id: t
+    type: transform
+    properties:
+      script: return input
+`
+	resp, body := doRequest(t, ts, "POST", "/api/workflows", yamlBody, "Content-Type", "application/yaml")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create: %d %s", resp.StatusCode, body)
+	}
+	var created Workflow
+	decode(t, body, &created)
+
+	resp, body = doRequest(t, ts, "GET", "/api/workflows/"+created.ID+"/export?format=yaml", nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/yaml" {
+		t.Fatalf("export: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/yaml")
+	var exported Workflow
+	if err := decodeBody(req, &exported); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := s.engine.GetWorkflow(context.Background(), created.ID)
+	if !reflect.DeepEqual(exported.Nodes, stored.Nodes) || exported.Name != "from yaml" {
+		t.Fatalf("exported %+v, stored %+v", exported, stored)
+	}
+}